@@ -2,10 +2,53 @@ package lifxlan
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 )
 
+// DefaultAckWindow is the default window used by WaitForAcksWithin.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var DefaultAckWindow = time.Millisecond * 200
+
+// WaitForAcksWithin is the best effort version of WaitForAcks,
+// sitting between sending without acks and requiring acks.
+//
+// It waits for the acks for up to window,
+// or DefaultAckWindow if window <= 0.
+// acked is true if all the acks arrived within the window.
+// If the window passed without all the acks,
+// it returns false with nil error instead of failing.
+//
+// It still returns an error when ctx is cancelled (or its deadline passed)
+// before the window,
+// or when the device replied StateUnhandled.
+func WaitForAcksWithin(
+	ctx context.Context,
+	conn net.Conn,
+	source uint32,
+	window time.Duration,
+	sequences ...uint8,
+) (acked bool, err error) {
+	if window <= 0 {
+		window = DefaultAckWindow
+	}
+	windowCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	err = WaitForAcks(windowCtx, conn, source, sequences...)
+	if err == nil {
+		return true, nil
+	}
+	if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return false, nil
+	}
+	return false, err
+}
+
 // WaitForAcks helps device API implementations to wait for acks.
 //
 // It blocks until acks for all sequences are received,
@@ -17,6 +60,11 @@ import (
 // Therefore, there shouldn't be more than one WaitForAcks functions running for
 // the same connection at the same time,
 // and this function should only be used when no other responses are expected.
+// Use SyncConn if the connection is shared by multiple goroutines.
+//
+// If ctx doesn't have a deadline,
+// the default timeout of the device with the source is applied (see
+// Device.SetTimeout and ApplyPolicy).
 //
 // If this function returns an error,
 // the error would be of type *WaitForAcksError.
@@ -26,36 +74,76 @@ func WaitForAcks(
 	source uint32,
 	sequences ...uint8,
 ) error {
+	ctx, cancel := ApplyPolicy(ctx, source)
+	defer cancel()
+
 	e := &WaitForAcksError{
 		Received: make([]uint8, 0, len(sequences)),
 		Total:    make([]uint8, len(sequences)),
 	}
 	copy(e.Total, sequences)
 
-	if ctx.Err() != nil {
-		e.Cause = ctx.Err()
+	seqMap := make(map[uint8]bool)
+	for _, seq := range sequences {
+		seqMap[seq] = true
+	}
+	fail := func(err error) error {
+		e.Cause = err
+		e.setMissing(seqMap)
 		return e
 	}
 
+	if ctx.Err() != nil {
+		return fail(ctx.Err())
+	}
+
 	if len(sequences) == 0 {
 		return nil
 	}
 
-	seqMap := make(map[uint8]bool)
-	for _, seq := range sequences {
-		seqMap[seq] = true
-	}
+	// The source to match the acks against.
+	match := SourceFromContext(ctx, source)
 
 	for {
-		resp, err := ReadNextResponse(ctx, conn)
+		resps, err := ReadNextResponses(ctx, conn)
 		if err != nil {
-			e.Cause = err
-			return e
-		}
-		if resp.Source != source || resp.Message != Acknowledgement {
-			continue
+			if m := MetricsRecorder; m != nil && ctx.Err() != nil {
+				m.IncAckTimeout()
+			}
+			return fail(err)
 		}
-		if seqMap[resp.Sequence] {
+		for _, resp := range resps {
+			if resp.Source == match && resp.Message == StateUnhandled && seqMap[resp.Sequence] {
+				releaseSequence(source, resp.Sequence)
+				return fail(parseUnhandled(resp))
+			}
+			if resp.Source == match && !seqMap[resp.Sequence] {
+				if err := checkSourceCollision("WaitForAcks", match, resp); err != nil {
+					return fail(err)
+				}
+			}
+			if resp.Source != match || resp.Message != Acknowledgement {
+				debugf(
+					"WaitForAcks: dropped %v from %v: source=%d sequence=%d",
+					resp.Message,
+					resp.Target,
+					resp.Source,
+					resp.Sequence,
+				)
+				continue
+			}
+			if !seqMap[resp.Sequence] {
+				debugf(
+					"WaitForAcks: dropped ack from %v: unexpected sequence=%d",
+					resp.Target,
+					resp.Sequence,
+				)
+				continue
+			}
+			if m := MetricsRecorder; m != nil {
+				m.IncAck()
+			}
+			releaseSequence(source, resp.Sequence)
 			e.Received = append(e.Received, resp.Sequence)
 			delete(seqMap, resp.Sequence)
 			if len(seqMap) == 0 {
@@ -71,6 +159,10 @@ type WaitForAcksError struct {
 	Received []uint8
 	Total    []uint8
 	Cause    error
+
+	// The sequences in Total that never acked, in the order of Total,
+	// so they can be resent without resending the whole batch.
+	Missing []uint8
 }
 
 var _ error = (*WaitForAcksError)(nil)
@@ -84,6 +176,18 @@ func (e *WaitForAcksError) Error() string {
 	)
 }
 
+// setMissing sets Missing to the sequences in Total that are still in seqMap.
+func (e *WaitForAcksError) setMissing(seqMap map[uint8]bool) {
+	e.Missing = make([]uint8, 0, len(seqMap))
+	added := make(map[uint8]bool, len(seqMap))
+	for _, seq := range e.Total {
+		if seqMap[seq] && !added[seq] {
+			added[seq] = true
+			e.Missing = append(e.Missing, seq)
+		}
+	}
+}
+
 // Unwrap returns the underlying error.
 func (e *WaitForAcksError) Unwrap() error {
 	return e.Cause
@@ -0,0 +1,7 @@
+// Package auto provides helpers to wrap lifxlan.Device into the most specific
+// device type from other subpackages,
+// based on the capabilities of the product,
+// and to discover devices bucketed by their device types (see DiscoverSet).
+//
+// Please refer to its parent package for more background/context.
+package auto // import "go.yhsif.com/lifxlan/auto"
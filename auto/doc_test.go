@@ -0,0 +1,49 @@
+package auto_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/auto"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/relay"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// This example demonstrates how to handle discovered devices by their types.
+func Example() {
+	// Need proper initialization on real code.
+	var (
+		device lifxlan.Device
+		// Important to set timeout to context when wrapping devices.
+		timeout time.Duration
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	wrapped, err := auto.Wrap(ctx, nil, device)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Note that the more specific types must come before light.Device,
+	// as they also implement light.Device.
+	switch d := wrapped.(type) {
+	default:
+		log.Printf("Unknown device %v", d)
+	case tile.Device:
+		log.Printf("Tile device %v with %d tiles", d, len(d.Tiles()))
+	case multizone.Device:
+		log.Printf("Multizone device %v", d)
+	case hev.Device:
+		log.Printf("HEV device %v", d)
+	case relay.Device:
+		log.Printf("Relay device %v", d)
+	case light.Device:
+		log.Printf("Light device %v", d)
+	}
+}
@@ -0,0 +1,78 @@
+package auto
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// StopEffect stops the firmware effect running on dev (if any), with ack,
+// so it's back to static colors.
+//
+// If dev is not already a tile.Device or multizone.Device,
+// it will be wrapped via Wrap first to find out its capabilities.
+// It sends SetTileEffect with tile.TileEffectOff to tile devices,
+// and SetMultiZoneEffect with multizone.MultiZoneEffectOff to multizone
+// devices.
+// For devices without firmware effect capability,
+// including the ones that reply StateUnhandled,
+// it's a no-op and returns nil error.
+//
+// It's safe to call it on devices not running any effects.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func StopEffect(ctx context.Context, conn net.Conn, dev lifxlan.Device) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	switch dev.(type) {
+	case tile.Device, multizone.Device:
+	default:
+		wrapped, err := Wrap(ctx, conn, dev)
+		if err != nil {
+			return err
+		}
+		dev = wrapped
+	}
+
+	var err error
+	switch d := dev.(type) {
+	default:
+		return nil
+	case tile.Device:
+		err = d.SetTileEffect(ctx, conn, tile.TileEffectOff, tile.TileEffectParams{}, true)
+	case multizone.Device:
+		err = d.SetMultiZoneEffect(
+			ctx,
+			conn,
+			multizone.MultiZoneEffect{
+				Type: multizone.MultiZoneEffectOff,
+			},
+			true,
+		)
+	}
+	var unhandled *lifxlan.UnhandledMessageError
+	if errors.As(err, &unhandled) {
+		return nil
+	}
+	return err
+}
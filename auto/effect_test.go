@@ -0,0 +1,140 @@
+package auto_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/auto"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestStopEffect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label    string
+		product  uint32
+		expected []lifxlan.MessageType
+	}{
+		{
+			label:   "Light",
+			product: 1, // LIFX Original 1000
+		},
+		{
+			label:    "Tile",
+			product:  55, // LIFX Tile
+			expected: []lifxlan.MessageType{tile.SetTileEffect},
+		},
+		{
+			label:    "Multizone",
+			product:  32, // LIFX Z
+			expected: []lifxlan.MessageType{multizone.SetMultiZoneEffect},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service := &mock.Service{
+					TB:              t,
+					Handlers:        make(map[lifxlan.MessageType]mock.HandlerFunc),
+					HandleAcks:      true,
+					RawStatePayload: &light.RawStatePayload{},
+					RawStateHostFirmwarePayload: &lifxlan.RawStateHostFirmwarePayload{
+						VersionMajor: 3,
+						VersionMinor: 70,
+					},
+					RawStateVersionPayload: &lifxlan.RawStateVersionPayload{
+						Version: lifxlan.HardwareVersion{
+							VendorID:  1,
+							ProductID: c.product,
+						},
+					},
+				}
+				rawChain := &tile.RawStateDeviceChainPayload{
+					TotalCount: 1,
+				}
+				rawChain.TileDevices[0] = tile.RawTileDevice{
+					Width:           8,
+					Height:          8,
+					HardwareVersion: service.RawStateVersionPayload.Version,
+				}
+				service.RawStateDeviceChainPayload = rawChain
+				service.Handlers[multizone.GetColorZones] = mock.ReplyHandler(
+					multizone.StateZone,
+					&multizone.RawStateZonePayload{
+						ZonesCount: 8,
+					},
+				)
+
+				var lock sync.Mutex
+				var received []lifxlan.MessageType
+				var effectType uint8
+				record := func(
+					_ *mock.Service,
+					_ net.PacketConn,
+					_ net.Addr,
+					orig *lifxlan.Response,
+				) {
+					lock.Lock()
+					defer lock.Unlock()
+					received = append(received, orig.Message)
+					switch orig.Message {
+					case tile.SetTileEffect:
+						var raw tile.RawSetTileEffectPayload
+						if err := binary.Read(bytes.NewReader(orig.Payload), binary.LittleEndian, &raw); err != nil {
+							t.Error(err)
+							return
+						}
+						effectType = uint8(raw.Settings.Type)
+					case multizone.SetMultiZoneEffect:
+						var raw multizone.RawMultiZoneEffectSettings
+						if err := binary.Read(bytes.NewReader(orig.Payload), binary.LittleEndian, &raw); err != nil {
+							t.Error(err)
+							return
+						}
+						effectType = uint8(raw.Type)
+					}
+				}
+				service.Handlers[tile.SetTileEffect] = record
+				service.Handlers[multizone.SetMultiZoneEffect] = record
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := auto.StopEffect(ctx, nil, device); err != nil {
+					t.Fatal(err)
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if len(received) != len(c.expected) {
+					t.Fatalf("Expected messages %v, got %v", c.expected, received)
+				}
+				for i := range received {
+					if received[i] != c.expected[i] {
+						t.Errorf("Expected messages %v, got %v", c.expected, received)
+					}
+				}
+				if effectType != 0 {
+					t.Errorf("Expected effect type off, got %d", effectType)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,158 @@
+package auto
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/relay"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// DeviceSet is a set of devices bucketed by their most specific device type
+// (see Wrap).
+//
+// Every device is only in one of the buckets,
+// e.g. a tile.Device is only in Tiles but not in Lights.
+// The devices in every bucket keep their original order.
+type DeviceSet struct {
+	// Light devices that are not tile, multizone or HEV devices.
+	Lights    []light.Device
+	Tiles     []tile.Device
+	Multizone []multizone.Device
+	HEV       []hev.Device
+	Relays    []relay.Device
+
+	// Devices with unknown products or none of the capabilities above,
+	// and devices failed to be probed.
+	Unknown []lifxlan.Device
+
+	// The errors of the devices in Unknown that failed to be probed
+	// (e.g. unreachable devices),
+	// keyed by their targets.
+	Errors map[lifxlan.Target]error
+}
+
+// Len returns the total number of devices in the set.
+func (s *DeviceSet) Len() int {
+	return len(s.Lights) +
+		len(s.Tiles) +
+		len(s.Multizone) +
+		len(s.HEV) +
+		len(s.Relays) +
+		len(s.Unknown)
+}
+
+func (s *DeviceSet) add(d lifxlan.Device) {
+	switch d := d.(type) {
+	default:
+		s.Unknown = append(s.Unknown, d)
+	case tile.Device:
+		s.Tiles = append(s.Tiles, d)
+	case multizone.Device:
+		s.Multizone = append(s.Multizone, d)
+	case hev.Device:
+		s.HEV = append(s.HEV, d)
+	case relay.Device:
+		s.Relays = append(s.Relays, d)
+	case light.Device:
+		s.Lights = append(s.Lights, d)
+	}
+}
+
+// Classify wraps all devices via Wrap in parallel,
+// with at most concurrency devices probed at the same time
+// (see lifxlan.ForEachDevice),
+// and returns them bucketed by their device types.
+//
+// Devices failed to be probed are put into Unknown as-is,
+// with their errors in Errors.
+//
+// Devices listed more than once in devices are only probed and put into the
+// set once.
+//
+// ctx should have a deadline,
+// otherwise unreachable devices without a default timeout (see
+// lifxlan.Device.SetTimeout) could block forever.
+func Classify(ctx context.Context, devices []lifxlan.Device, concurrency int) *DeviceSet {
+	// Dedupe devices,
+	// so the same device is never probed by multiple goroutines at the same
+	// time.
+	indices := make(map[lifxlan.Device]int, len(devices))
+	unique := make([]lifxlan.Device, 0, len(devices))
+	for _, d := range devices {
+		if _, ok := indices[d]; ok {
+			continue
+		}
+		indices[d] = len(unique)
+		unique = append(unique, d)
+	}
+	wrapped := make([]lifxlan.Device, len(unique))
+	errs := lifxlan.ForEachDevice(
+		ctx,
+		unique,
+		concurrency,
+		func(ctx context.Context, d lifxlan.Device, conn net.Conn) error {
+			w, err := Wrap(ctx, conn, d)
+			if err != nil {
+				return err
+			}
+			// Every call writes to a different index.
+			wrapped[indices[d]] = w
+			return nil
+		},
+	)
+
+	set := new(DeviceSet)
+	for i, d := range unique {
+		if err := errs[i]; err != nil {
+			if set.Errors == nil {
+				set.Errors = make(map[lifxlan.Target]error)
+			}
+			set.Errors[d.Target()] = err
+			set.Unknown = append(set.Unknown, d)
+			continue
+		}
+		set.add(wrapped[i])
+	}
+	return set
+}
+
+// DiscoverSet discovers the devices in the lan via lifxlan.DiscoverAll for
+// timeout,
+// then probes all of them in parallel via Classify,
+// and returns them bucketed by their device types.
+//
+// Probing is also limited to timeout,
+// devices not probed in time are put into Unknown with their errors.
+//
+// It returns an error if discovery fails or ctx is cancelled,
+// in which case the devices discovered before that are not probed.
+//
+// E.g. to bootstrap an application:
+//
+//     set, err := auto.DiscoverSet(ctx, time.Second)
+//     if err != nil {
+//       // handle error
+//     }
+//     for _, td := range set.Tiles {
+//       // Do something with td
+//     }
+func DiscoverSet(ctx context.Context, timeout time.Duration) (*DeviceSet, error) {
+	devices, err := lifxlan.DiscoverAll(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	set := Classify(probeCtx, devices, 0)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return set, nil
+}
@@ -0,0 +1,100 @@
+package auto_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/auto"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+func TestClassify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	start := func(t *testing.T, product uint32) (*mock.Service, lifxlan.Device) {
+		t.Helper()
+		service := &mock.Service{
+			TB:         t,
+			HandleAcks: true,
+			Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+				multizone.GetColorZones: mock.ReplyHandler(
+					multizone.StateZone,
+					&multizone.RawStateZonePayload{
+						ZonesCount: 8,
+					},
+				),
+			},
+			RawStatePayload: &light.RawStatePayload{},
+			RawStateVersionPayload: &lifxlan.RawStateVersionPayload{
+				Version: lifxlan.HardwareVersion{
+					VendorID:  1,
+					ProductID: product,
+				},
+			},
+			RawStateHostFirmwarePayload: &lifxlan.RawStateHostFirmwarePayload{
+				VersionMajor: 3,
+				VersionMinor: 70,
+			},
+		}
+		return service, service.Start()
+	}
+
+	lightService, lightDevice := start(t, 1) // LIFX Original 1000
+	defer lightService.Stop()
+	zService, zDevice := start(t, 32) // LIFX Z
+	defer zService.Stop()
+	unknownService, unknownDevice := start(t, 0)
+	defer unknownService.Stop()
+	goneService, goneDevice := start(t, 1)
+	goneService.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	set := auto.Classify(
+		ctx,
+		// lightDevice is listed twice and should only be classified once.
+		[]lifxlan.Device{goneDevice, zDevice, lightDevice, unknownDevice, lightDevice},
+		0, // concurrency
+	)
+
+	if n := set.Len(); n != 4 {
+		t.Errorf("Len expected 4, got %d", n)
+	}
+	if len(set.Lights) != 1 || set.Lights[0].Target() != lightDevice.Target() {
+		t.Errorf("Lights expected [%v], got %v", lightDevice, set.Lights)
+	}
+	if len(set.Multizone) != 1 || set.Multizone[0].Target() != zDevice.Target() {
+		t.Errorf("Multizone expected [%v], got %v", zDevice, set.Multizone)
+	}
+	if len(set.Tiles) != 0 || len(set.HEV) != 0 || len(set.Relays) != 0 {
+		t.Errorf("Unexpected devices: %v, %v, %v", set.Tiles, set.HEV, set.Relays)
+	}
+	if len(set.Unknown) != 2 ||
+		set.Unknown[0].Target() != goneDevice.Target() ||
+		set.Unknown[1].Target() != unknownDevice.Target() {
+		t.Errorf("Unknown expected [%v %v], got %v", goneDevice, unknownDevice, set.Unknown)
+	}
+	if len(set.Errors) != 1 || set.Errors[goneDevice.Target()] == nil {
+		t.Errorf("Expected only the error of %v, got %v", goneDevice, set.Errors)
+	} else {
+		t.Logf("Got error for %v: %v", goneDevice, set.Errors[goneDevice.Target()])
+	}
+}
+
+func TestDiscoverSetCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	set, err := auto.DiscoverSet(ctx, time.Second)
+	if err == nil {
+		t.Errorf("Expected error, got %+v", set)
+	}
+}
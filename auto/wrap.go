@@ -0,0 +1,87 @@
+package auto
+
+import (
+	"context"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/relay"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// Wrap wraps d into the most specific device type based on the capabilities
+// of its product, as looked up from lifxlan.ProductMap.
+//
+// If d's HardwareVersion is not cached yet, GetHardwareVersion will be called
+// first, using conn.
+// Similarly if d's Firmware is not cached yet, GetHostFirmware will be called,
+// so the capabilities from firmware upgrades
+// (e.g. extended multizone) will also be applied.
+//
+// When a product has multiple capabilities,
+// the first matching one in the following order will be used:
+//
+// - chain or matrix: tile.Device
+//
+// - multizone: multizone.Device
+//
+// - hev: hev.Device
+//
+// - relay-only (see lifxlan.ProductCapabilities.IsRelay): relay.Device
+//
+// - color or valid temperature range: light.Device
+//
+// Relay-only products (e.g. LIFX Switch) are never probed with light APIs,
+// as they would just time out.
+//
+// All of them embed lifxlan.Device,
+// so the caller can use a type switch on the returned value.
+// If the product is unknown or has none of the capabilities above,
+// d will be returned as-is.
+//
+// The Wrap function of the chosen subpackage will be called with force being
+// false, so it will still verify that the device actually supports the API.
+//
+// If conn is nil and HardwareVersion or Firmware is not cached yet,
+// a new connection will be made and guaranteed to be closed before returning.
+func Wrap(ctx context.Context, conn net.Conn, d lifxlan.Device) (lifxlan.Device, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if d.HardwareVersion().String() == lifxlan.EmptyHardwareVersion {
+		if err := d.GetHardwareVersion(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	parsed := d.HardwareVersion().Parse()
+	if parsed == nil {
+		return d, nil
+	}
+
+	if d.Firmware().String() == lifxlan.EmptyFirmware {
+		if _, err := d.GetHostFirmware(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	caps := parsed.FeaturesAt(*d.Firmware()).Capabilities()
+
+	switch {
+	default:
+		return d, nil
+	case caps.Chain || caps.Matrix:
+		return tile.Wrap(ctx, d, false)
+	case caps.Multizone:
+		return multizone.Wrap(ctx, d, false)
+	case caps.HEV:
+		return hev.Wrap(ctx, d, false)
+	case caps.IsRelay():
+		return relay.Wrap(ctx, d, false)
+	case caps.IsLight():
+		return light.Wrap(ctx, d, false)
+	}
+}
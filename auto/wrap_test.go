@@ -0,0 +1,154 @@
+package auto_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/auto"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/relay"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestWrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.RawStateHostFirmwarePayload = &lifxlan.RawStateHostFirmwarePayload{
+		VersionMajor: 3,
+		VersionMinor: 70,
+	}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+		// tile.Wrap caches the hardware version from the chain.
+		HardwareVersion: lifxlan.HardwareVersion{
+			VendorID:  1,
+			ProductID: 55,
+		},
+	}
+	service.RawStateDeviceChainPayload = rawChain
+	service.Handlers[multizone.GetColorZones] = mock.ReplyHandler(
+		multizone.StateZone,
+		&multizone.RawStateZonePayload{
+			ZonesCount: 8,
+		},
+	)
+	service.Handlers[hev.GetHevCycle] = mock.ReplyHandler(
+		hev.StateHevCycle,
+		&hev.RawStateHevCyclePayload{},
+	)
+	service.Handlers[relay.GetRPower] = mock.ReplyHandler(
+		relay.StateRPower,
+		&relay.RawRPowerPayload{},
+	)
+
+	for _, c := range []struct {
+		label   string
+		product uint32
+		check   func(lifxlan.Device) bool
+	}{
+		{
+			label:   "Unknown",
+			product: 0,
+			check: func(d lifxlan.Device) bool {
+				_, ok := d.(light.Device)
+				return !ok
+			},
+		},
+		{
+			label:   "Light",
+			product: 1, // LIFX Original 1000
+			check: func(d lifxlan.Device) bool {
+				_, isLight := d.(light.Device)
+				_, isMultizone := d.(multizone.Device)
+				_, isTile := d.(tile.Device)
+				return isLight && !isMultizone && !isTile
+			},
+		},
+		{
+			label:   "Tile",
+			product: 55, // LIFX Tile
+			check: func(d lifxlan.Device) bool {
+				_, ok := d.(tile.Device)
+				return ok
+			},
+		},
+		{
+			label:   "Multizone",
+			product: 32, // LIFX Z
+			check: func(d lifxlan.Device) bool {
+				_, ok := d.(multizone.Device)
+				return ok
+			},
+		},
+		{
+			label:   "HEV",
+			product: 90, // LIFX Clean
+			check: func(d lifxlan.Device) bool {
+				_, ok := d.(hev.Device)
+				return ok
+			},
+		},
+		{
+			label:   "Relay",
+			product: 70, // LIFX Switch
+			check: func(d lifxlan.Device) bool {
+				_, isRelay := d.(relay.Device)
+				_, isLight := d.(light.Device)
+				return isRelay && !isLight
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service.RawStateVersionPayload = &lifxlan.RawStateVersionPayload{
+					Version: lifxlan.HardwareVersion{
+						VendorID:  1,
+						ProductID: c.product,
+					},
+				}
+				// Make sure the hardware version and firmware will be fetched.
+				*device.HardwareVersion() = lifxlan.HardwareVersion{}
+				*device.Firmware() = lifxlan.FirmwareUpgrade{}
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				wrapped, err := auto.Wrap(ctx, nil, device)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if wrapped.HardwareVersion().ProductID != c.product {
+					t.Errorf(
+						"Expected hardware version to be fetched, got %v",
+						wrapped.HardwareVersion(),
+					)
+				}
+				// Firmware is only needed for known products.
+				if c.product != 0 && wrapped.Firmware().String() == lifxlan.EmptyFirmware {
+					t.Error("Expected firmware to be fetched")
+				}
+				if !c.check(wrapped) {
+					t.Errorf("Unexpected wrapped device type %T", wrapped)
+				}
+			},
+		)
+	}
+}
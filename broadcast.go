@@ -0,0 +1,107 @@
+package lifxlan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// BroadcastRepeat is the number of times BroadcastSetPower sends the message.
+//
+// Broadcast messages are not acked,
+// so they are sent multiple times to make it less likely to be lost on the
+// network.
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var BroadcastRepeat = 3
+
+// broadcastInterval is the interval between repeated broadcast messages.
+const broadcastInterval = time.Millisecond * 10
+
+// BroadcastSetPower sets the power of all the devices in the lan with a single
+// broadcast SetPower message,
+// instead of sending it to every device.
+//
+// The message is tagged with target AllDevices,
+// and sent BroadcastRepeat times.
+// It's fire-and-forget:
+// there's no ack for broadcast messages,
+// so this function returns nil error after the messages are sent successfully,
+// without any guarantee that all the devices received them.
+// Use SetPower on the devices instead if you need to be sure.
+//
+// If conn is nil,
+// a new connection to DefaultBroadcastHost will be made and guaranteed to be
+// closed before returning.
+// Otherwise conn should be connected to a broadcast address.
+func BroadcastSetPower(ctx context.Context, conn net.Conn, power Power) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := net.Dial(
+			"udp",
+			net.JoinHostPort(DefaultBroadcastHost, DefaultBroadcastPort),
+		)
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload, err := encodePayload(&RawSetPowerPayload{
+		Level: power,
+	})
+	if err != nil {
+		return err
+	}
+	msg, err := GenerateMessage(
+		Tagged,
+		RandomSource(),
+		AllDevices,
+		0, // flags
+		0, // sequence
+		SetPower,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < BroadcastRepeat; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(broadcastInterval):
+			}
+		}
+		if err := waitForRateLimit(ctx, conn, 0 /* source */); err != nil {
+			return err
+		}
+
+		n, err := conn.Write(msg)
+		if err != nil {
+			return err
+		}
+		if m := MetricsRecorder; m != nil {
+			m.IncSend(SetPower)
+		}
+		if n < len(msg) {
+			return fmt.Errorf(
+				"lifxlan.BroadcastSetPower: only wrote %d out of %d bytes",
+				n,
+				len(msg),
+			)
+		}
+	}
+	debugf("broadcasted %v: power=%v repeat=%d", SetPower, power, BroadcastRepeat)
+	return nil
+}
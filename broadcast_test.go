@@ -0,0 +1,72 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func TestBroadcastSetPower(t *testing.T) {
+	const timeout = time.Millisecond * 200
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	conn, err := net.Dial("udp", listener.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := lifxlan.BroadcastSetPower(ctx, conn, lifxlan.PowerOn); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, lifxlan.ResponseReadBufferSize)
+	for i := 0; i < lifxlan.BroadcastRepeat; i++ {
+		if err := listener.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			t.Fatal(err)
+		}
+		n, _, err := listener.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("Message %d: %v", i, err)
+		}
+
+		header, err := lifxlan.ParseHeader(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !header.Tagged {
+			t.Errorf("Message %d: expected tagged header", i)
+		}
+		if header.Target != lifxlan.AllDevices {
+			t.Errorf("Message %d: target expected %v, got %v", i, lifxlan.AllDevices, header.Target)
+		}
+		if header.Type != lifxlan.SetPower {
+			t.Errorf("Message %d: type expected %v, got %v", i, lifxlan.SetPower, header.Type)
+		}
+		if header.Flags != 0 {
+			t.Errorf("Message %d: flags expected 0, got %d", i, header.Flags)
+		}
+
+		var payload lifxlan.RawSetPowerPayload
+		r := bytes.NewReader(buf[lifxlan.HeaderLength:n])
+		if err := binary.Read(r, binary.LittleEndian, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Level != lifxlan.PowerOn {
+			t.Errorf("Message %d: level expected %v, got %v", i, lifxlan.PowerOn, payload.Level)
+		}
+	}
+}
@@ -6,9 +6,14 @@
 //
 // To run it:
 //
-//     gen-product-map >> product_map.go
+//     gen-product-map -extra extra_products.json >> product_map.go
 //
 // Then manally update the file to remove previous value.
+//
+// The -extra file uses the same format as products.json,
+// and lists products not yet published upstream.
+// Its products are appended to their vendors,
+// unless upstream already has a product with the same ID.
 package main
 
 import (
@@ -22,7 +27,7 @@ import (
 	"go.yhsif.com/lifxlan"
 )
 
-var vendors []struct {
+type vendor struct {
 	ID       uint32           `json:"vid"`
 	Name     string           `json:"name"`
 	Defaults lifxlan.Features `json:"defaults"`
@@ -30,12 +35,20 @@ var vendors []struct {
 	Products []lifxlan.Product `json:"products"`
 }
 
+var vendors []vendor
+
 var url = flag.String(
 	"url",
 	"https://raw.githubusercontent.com/LIFX/products/master/products.json",
 	"The URL to fetch json data.",
 )
 
+var extra = flag.String(
+	"extra",
+	"",
+	"The optional local json file of products to add to the fetched data.",
+)
+
 func errorOut(v ...interface{}) {
 	fmt.Fprintln(os.Stderr, v...)
 	os.Exit(-1)
@@ -54,6 +67,12 @@ func main() {
 		errorOut(err)
 	}
 
+	if *extra != "" {
+		if err := mergeExtra(*extra); err != nil {
+			errorOut(err)
+		}
+	}
+
 	fmt.Println(`var ProductMap = map[uint64]Product{`)
 
 	for _, vendor := range vendors {
@@ -136,3 +155,43 @@ func main() {
 
 	fmt.Println(`}`)
 }
+
+func mergeExtra(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var extraVendors []vendor
+	if err := json.NewDecoder(f).Decode(&extraVendors); err != nil {
+		return err
+	}
+
+	for _, ev := range extraVendors {
+		i := -1
+		for j := range vendors {
+			if vendors[j].ID == ev.ID {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			vendors = append(vendors, ev)
+			continue
+		}
+
+		known := make(map[uint32]bool, len(vendors[i].Products))
+		for _, product := range vendors[i].Products {
+			known[product.ProductID] = true
+		}
+		for _, product := range ev.Products {
+			if known[product.ProductID] {
+				continue
+			}
+			product.Features = lifxlan.MergeFeatures(product.Features, ev.Defaults)
+			vendors[i].Products = append(vendors[i].Products, product)
+		}
+	}
+	return nil
+}
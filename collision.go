@@ -0,0 +1,175 @@
+package lifxlan
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SourceCollisionMode defines how WaitForAcks and WaitForResponses handle
+// possible source collisions.
+//
+// A possible source collision is a response with the source we are waiting
+// for, but a sequence that was never sent with that source recently,
+// which usually means another app on the network happens to use the same
+// source.
+type SourceCollisionMode int
+
+// SourceCollisionMode values.
+const (
+	// SourceCollisionOff disables the detection.
+	SourceCollisionOff SourceCollisionMode = iota
+	// SourceCollisionWarn logs the possible collisions via DebugLogger,
+	// and keeps dropping the responses.
+	SourceCollisionWarn
+	// SourceCollisionFail logs the possible collisions via DebugLogger,
+	// and fails the wait with *SourceCollisionError as the cause.
+	SourceCollisionFail
+)
+
+// SourceCollisionDetection controls the detection of source collisions.
+//
+// It's SourceCollisionOff by default.
+// When enabled,
+// the sequences sent by Device.Send, Pipeline and SendWithRetry are recorded,
+// and WaitForAcks and WaitForResponses compare the responses with the
+// expected source but unexpected sequences against the recorded ones.
+//
+// It's not safe to change it while there are API calls in flight,
+// so it should usually be set at the beginning of main.
+var SourceCollisionDetection SourceCollisionMode
+
+// SourceCollisionWindow is how long a sent sequence is remembered by the
+// source collision detection.
+//
+// Responses to the sequences sent earlier than that will also be considered
+// possible collisions.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var SourceCollisionWindow = time.Second * 10
+
+// SourceCollisionError is the error used when SourceCollisionDetection is
+// SourceCollisionFail and a possible source collision is detected.
+type SourceCollisionError struct {
+	Source   uint32
+	Sequence uint8
+	Message  MessageType
+	Target   Target
+}
+
+var _ error = (*SourceCollisionError)(nil)
+
+func (e *SourceCollisionError) Error() string {
+	return fmt.Sprintf(
+		"lifxlan: possible source collision: received %v from %v with source=%d sequence=%d that was never sent",
+		e.Message,
+		e.Target,
+		e.Source,
+		e.Sequence,
+	)
+}
+
+// sentSequences records the time each sequence of a source was last sent.
+type sentSequences struct {
+	lock sync.Mutex
+	sent [256]time.Time
+	// The last time any sequence was sent.
+	last time.Time
+	// Set when it's removed from sentSequencesMap.
+	removed bool
+}
+
+// sentSequencesMap is a map of source (uint32) to *sentSequences.
+//
+// Sources with nothing sent within SourceCollisionWindow are removed by
+// sweepSentSequences.
+var sentSequencesMap sync.Map
+
+var (
+	lastSweep time.Time
+	sweepLock sync.Mutex
+)
+
+// recordSent records that seq was sent with source,
+// if SourceCollisionDetection is enabled.
+func recordSent(source uint32, seq uint8) {
+	if SourceCollisionDetection == SourceCollisionOff {
+		return
+	}
+	now := time.Now()
+	sweepSentSequences(now)
+	for {
+		v, _ := sentSequencesMap.LoadOrStore(source, new(sentSequences))
+		ss := v.(*sentSequences)
+		ss.lock.Lock()
+		if ss.removed {
+			// Removed by sweepSentSequences after we loaded it, try again.
+			ss.lock.Unlock()
+			continue
+		}
+		ss.sent[seq] = now
+		ss.last = now
+		ss.lock.Unlock()
+		return
+	}
+}
+
+// sweepSentSequences removes the sources with nothing sent within
+// SourceCollisionWindow from sentSequencesMap,
+// at most once every SourceCollisionWindow.
+//
+// Their sequences are already too old to be considered sent recently by
+// checkSourceCollision.
+func sweepSentSequences(now time.Time) {
+	window := SourceCollisionWindow
+	sweepLock.Lock()
+	defer sweepLock.Unlock()
+	if now.Sub(lastSweep) < window {
+		return
+	}
+	lastSweep = now
+	sentSequencesMap.Range(func(k, v interface{}) bool {
+		ss := v.(*sentSequences)
+		ss.lock.Lock()
+		defer ss.lock.Unlock()
+		if now.Sub(ss.last) >= window {
+			ss.removed = true
+			sentSequencesMap.Delete(k)
+		}
+		return true
+	})
+}
+
+// checkSourceCollision checks resp,
+// a response with the expected source but an unexpected sequence,
+// against the recently sent sequences of source.
+//
+// It only returns non-nil error when SourceCollisionDetection is
+// SourceCollisionFail and resp is a possible collision.
+func checkSourceCollision(caller string, source uint32, resp *Response) error {
+	mode := SourceCollisionDetection
+	if mode == SourceCollisionOff {
+		return nil
+	}
+	if v, ok := sentSequencesMap.Load(source); ok {
+		ss := v.(*sentSequences)
+		ss.lock.Lock()
+		sent := ss.sent[resp.Sequence]
+		ss.lock.Unlock()
+		if !sent.IsZero() && time.Since(sent) < SourceCollisionWindow {
+			return nil
+		}
+	}
+	err := &SourceCollisionError{
+		Source:   source,
+		Sequence: resp.Sequence,
+		Message:  resp.Message,
+		Target:   resp.Target,
+	}
+	debugf("%s: WARNING: %v", caller, err)
+	if mode == SourceCollisionFail {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestSourceCollisionDetection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	defer func(orig lifxlan.SourceCollisionMode) {
+		lifxlan.SourceCollisionDetection = orig
+	}(lifxlan.SourceCollisionDetection)
+
+	// The handler acks a sequence never sent before the real ack,
+	// as if another app on the network is using the same source.
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.SetPower: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				other := *orig
+				other.Sequence += 100
+				s.Reply(conn, addr, &other, lifxlan.Acknowledgement, nil)
+				s.Reply(conn, addr, orig, lifxlan.Acknowledgement, nil)
+			},
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	for _, c := range []struct {
+		label string
+		mode  lifxlan.SourceCollisionMode
+		fail  bool
+	}{
+		{
+			label: "Off",
+			mode:  lifxlan.SourceCollisionOff,
+			fail:  false,
+		},
+		{
+			label: "Warn",
+			mode:  lifxlan.SourceCollisionWarn,
+			fail:  false,
+		},
+		{
+			label: "Fail",
+			mode:  lifxlan.SourceCollisionFail,
+			fail:  true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				lifxlan.SourceCollisionDetection = c.mode
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				err := device.SetPower(ctx, nil, lifxlan.PowerOn, true)
+				if !c.fail {
+					if err != nil {
+						t.Errorf("Expected nil error, got %v", err)
+					}
+					return
+				}
+				var collision *lifxlan.SourceCollisionError
+				if !errors.As(err, &collision) {
+					t.Fatalf("Expected *SourceCollisionError, got %v", err)
+				}
+				if collision.Source != device.Source() {
+					t.Errorf("Source expected %d, got %d", device.Source(), collision.Source)
+				}
+				if collision.Message != lifxlan.Acknowledgement {
+					t.Errorf("Message expected %v, got %v", lifxlan.Acknowledgement, collision.Message)
+				}
+			},
+		)
+	}
+}
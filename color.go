@@ -1,8 +1,14 @@
 package lifxlan
 
 import (
+	"context"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"image/color"
 	"math"
+	"net"
 )
 
 // Color is the HSBK color type used in lifx lan API.
@@ -25,8 +31,99 @@ const (
 
 	KelvinMin uint16 = KelvinWarm
 	KelvinMax uint16 = KelvinCool
+
+	// The default kelvin value used by ParseColor.
+	KelvinNeutral uint16 = 3500
+
+	// The widest kelvin range supported by any LIFX device.
+	KelvinLowest  uint16 = 1500
+	KelvinHighest uint16 = 9000
 )
 
+// colorJSON is the JSON representation of Color.
+type colorJSON struct {
+	Hue        uint16 `json:"hue"`
+	Saturation uint16 `json:"saturation"`
+	Brightness uint16 `json:"brightness"`
+	Kelvin     uint16 `json:"kelvin"`
+}
+
+var (
+	_ json.Marshaler   = Color{}
+	_ json.Unmarshaler = (*Color)(nil)
+)
+
+// MarshalJSON implements json.Marshaler.
+//
+// It encodes the raw values in the form of:
+//
+//	{"hue":0,"saturation":0,"brightness":0,"kelvin":3500}
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(colorJSON(c))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It returns an error when kelvin is outside of [KelvinLowest, KelvinHighest],
+// and leaves c unchanged in that case.
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var raw colorJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Kelvin < KelvinLowest || raw.Kelvin > KelvinHighest {
+		return fmt.Errorf(
+			"lifxlan.Color.UnmarshalJSON: kelvin %d out of range [%d, %d]",
+			raw.Kelvin,
+			KelvinLowest,
+			KelvinHighest,
+		)
+	}
+	*c = Color(raw)
+	return nil
+}
+
+// colorLength is the size of Color in messages.
+const colorLength = 8
+
+var (
+	_ encoding.BinaryMarshaler   = Color{}
+	_ encoding.BinaryUnmarshaler = (*Color)(nil)
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// It encodes the color in the little endian layout used in messages:
+// hue, saturation, brightness, kelvin, 2 bytes each.
+func (c Color) MarshalBinary() ([]byte, error) {
+	data := make([]byte, colorLength)
+	c.put(data)
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// It's the reverse of MarshalBinary.
+// Extra bytes after the color are ignored.
+func (c *Color) UnmarshalBinary(data []byte) error {
+	if err := CheckPayloadSize("lifxlan.Color.UnmarshalBinary", data, colorLength); err != nil {
+		return err
+	}
+	c.Hue = binary.LittleEndian.Uint16(data[0:])
+	c.Saturation = binary.LittleEndian.Uint16(data[2:])
+	c.Brightness = binary.LittleEndian.Uint16(data[4:])
+	c.Kelvin = binary.LittleEndian.Uint16(data[6:])
+	return nil
+}
+
+// put writes the color into the first colorLength bytes of data.
+func (c Color) put(data []byte) {
+	binary.LittleEndian.PutUint16(data[0:], c.Hue)
+	binary.LittleEndian.PutUint16(data[2:], c.Saturation)
+	binary.LittleEndian.PutUint16(data[4:], c.Brightness)
+	binary.LittleEndian.PutUint16(data[6:], c.Kelvin)
+}
+
 // Sanitize tries to sanitize the color values to keep them within appropriate
 // boundaries, based on default boundaries.
 func (c *Color) Sanitize() {
@@ -38,6 +135,128 @@ func (c *Color) Sanitize() {
 	}
 }
 
+// Sanitized returns a sanitized copy of c.
+//
+// Kelvin is clamped into [minKelvin, maxKelvin].
+// When minKelvin (or maxKelvin) is 0, KelvinMin (or KelvinMax) is used instead.
+//
+// Kelvin only matters for whites,
+// but on some hardware it still skews fully saturated colors,
+// so for colors with saturation at math.MaxUint16,
+// kelvin is set to KelvinNeutral (then clamped into the range) instead.
+func (c Color) Sanitized(minKelvin, maxKelvin uint16) Color {
+	if minKelvin == 0 {
+		minKelvin = KelvinMin
+	}
+	if maxKelvin == 0 {
+		maxKelvin = KelvinMax
+	}
+	if c.Saturation == math.MaxUint16 {
+		c.Kelvin = KelvinNeutral
+	}
+	if c.Kelvin < minKelvin {
+		c.Kelvin = minKelvin
+	}
+	if c.Kelvin > maxKelvin {
+		c.Kelvin = maxKelvin
+	}
+	return c
+}
+
+// Equal returns true if c and other have exactly the same values.
+func (c Color) Equal(other Color) bool {
+	return c == other
+}
+
+// ApproxEqual returns true if all the values of c and other are within
+// tolerance from each other.
+//
+// It's useful to compare colors read back from devices,
+// as devices might round the values set.
+// Hue is treated as circular, wrapping at 65535
+// (both 0 and 65535 are red, at 0 and 360 degrees),
+// so 65530 and 5 are only 10 apart.
+func (c Color) ApproxEqual(other Color, tolerance uint16) bool {
+	hue := absDiff(c.Hue, other.Hue)
+	// Wrap around.
+	if hue > math.MaxUint16/2 {
+		hue = math.MaxUint16 - hue
+	}
+	return hue <= uint32(tolerance) &&
+		absDiff(c.Saturation, other.Saturation) <= uint32(tolerance) &&
+		absDiff(c.Brightness, other.Brightness) <= uint32(tolerance) &&
+		absDiff(c.Kelvin, other.Kelvin) <= uint32(tolerance)
+}
+
+// Lerp returns the color linearly interpolated from c to to at t,
+// where t = 0 returns c and t = 1 returns to.
+//
+// Saturation, brightness and kelvin are interpolated linearly.
+// Hue is interpolated along the shortest path on the color wheel,
+// wrapping around at 0/65535 when needed,
+// so for example red to magenta won't go through green.
+func (c Color) Lerp(to Color, t float64) Color {
+	const hueRange = 1 << 16
+	hueDelta := int(to.Hue) - int(c.Hue)
+	if hueDelta > hueRange/2 {
+		hueDelta -= hueRange
+	}
+	if hueDelta < -hueRange/2 {
+		hueDelta += hueRange
+	}
+	hue := int(c.Hue) + int(math.Round(float64(hueDelta)*t))
+	hue %= hueRange
+	if hue < 0 {
+		hue += hueRange
+	}
+
+	lerp := func(from, to uint16) uint16 {
+		return uint16(math.Round(float64(from) + (float64(to)-float64(from))*t))
+	}
+	return Color{
+		Hue:        uint16(hue),
+		Saturation: lerp(c.Saturation, to.Saturation),
+		Brightness: lerp(c.Brightness, to.Brightness),
+		Kelvin:     lerp(c.Kelvin, to.Kelvin),
+	}
+}
+
+// Rotate returns the color with its hue rotated by degrees on the color
+// wheel,
+// wrapping around at 0/65535.
+// Positive degrees rotate forward (e.g. red to yellow),
+// negative ones rotate backward.
+//
+// Saturation, brightness and kelvin are preserved.
+func (c Color) Rotate(degrees float64) Color {
+	const hueRange = 1 << 16
+	hue := math.Mod(float64(c.Hue)+math.Round(degrees/360*hueRange), hueRange)
+	if hue < 0 {
+		hue += hueRange
+	}
+	c.Hue = uint16(hue)
+	return c
+}
+
+// Complement returns the complementary color of c,
+// which is c rotated by 180 degrees.
+func (c Color) Complement() Color {
+	return c.Rotate(180)
+}
+
+// Analogous returns the 2 analogous colors of c,
+// which are c rotated by -30 and 30 degrees.
+func (c Color) Analogous() (Color, Color) {
+	return c.Rotate(-30), c.Rotate(30)
+}
+
+func absDiff(a, b uint16) uint32 {
+	if a > b {
+		return uint32(a - b)
+	}
+	return uint32(b - a)
+}
+
 func (d *device) SanitizeColor(color Color) Color {
 	ret := color
 	parsed := d.version.Parse()
@@ -54,6 +273,56 @@ func (d *device) SanitizeColor(color Color) Color {
 	return ret
 }
 
+func (d *device) KelvinRange(ctx context.Context, conn net.Conn) (min, max uint16, err error) {
+	if d.version.String() == EmptyHardwareVersion {
+		if err := d.GetHardwareVersion(ctx, conn); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if d.version.Parse() == nil {
+		return 0, 0, fmt.Errorf(
+			"lifxlan.Device.KelvinRange: unknown product %v",
+			d.version,
+		)
+	}
+	min, max, ok := kelvinRange(d)
+	if !ok {
+		return 0, 0, fmt.Errorf(
+			"lifxlan.Device.KelvinRange: product %v has no temperature range",
+			d.version,
+		)
+	}
+	return min, max, nil
+}
+
+// kelvinRange returns the kelvin range of d from its cached hardware version
+// and firmware version,
+// or false if the product is unknown or has no temperature range.
+func kelvinRange(d Device) (min, max uint16, ok bool) {
+	parsed := d.HardwareVersion().Parse()
+	if parsed == nil {
+		return 0, 0, false
+	}
+	tr := parsed.FeaturesAt(*d.Firmware()).TemperatureRange
+	if !tr.Valid() {
+		return 0, 0, false
+	}
+	return tr.Min(), tr.Max(), true
+}
+
+// CachedKelvinRange is the same as Device.KelvinRange,
+// except that it only uses the cached hardware version and firmware version of
+// d and never blocks,
+// and returns [KelvinLowest, KelvinHighest] instead of an error when the range
+// is unknown.
+func CachedKelvinRange(d Device) (min, max uint16) {
+	if min, max, ok := kelvinRange(d); ok {
+		return min, max
+	}
+	return KelvinLowest, KelvinHighest
+}
+
 // FromColor converts a standard library color into HSBK color.
 //
 // Alpha channel will be ignored and kelvin value will be added.
@@ -133,3 +402,101 @@ func FromColor(c color.Color, kelvin uint16) *Color {
 	}
 	return &ret
 }
+
+// RGBToHSBK converts RGB values into HSBK color with KelvinNeutral.
+//
+// It's a shorthand for FromColor.
+func RGBToHSBK(r, g, b uint8) Color {
+	return *FromColor(
+		color.RGBA{
+			R: r,
+			G: g,
+			B: b,
+			A: math.MaxUint8,
+		},
+		KelvinNeutral,
+	)
+}
+
+// KelvinToRGB converts a color temperature into approximated RGB values,
+// using Tanner Helland's black-body approximation:
+//
+// https://tannerhelland.com/2012/09/18/convert-temperature-rgb-algorithm-code.html
+//
+// kelvin will be clamped into [KelvinLowest, KelvinHighest] first.
+func KelvinToRGB(kelvin uint16) (r, g, b uint8) {
+	if kelvin < KelvinLowest {
+		kelvin = KelvinLowest
+	}
+	if kelvin > KelvinHighest {
+		kelvin = KelvinHighest
+	}
+	temp := float64(kelvin) / 100
+
+	clamp := func(f float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(math.MaxUint8, f))))
+	}
+
+	if temp <= 66 {
+		r = math.MaxUint8
+		g = clamp(99.4708025861*math.Log(temp) - 161.1195681661)
+	} else {
+		r = clamp(329.698727446 * math.Pow(temp-60, -0.1332047592))
+		g = clamp(288.1221695283 * math.Pow(temp-60, -0.0755148492))
+	}
+
+	switch {
+	case temp >= 66:
+		b = math.MaxUint8
+	case temp <= 19:
+		b = 0
+	default:
+		b = clamp(138.5177312231*math.Log(temp-10) - 305.0447927307)
+	}
+	return
+}
+
+// RGB converts the hue, saturation and brightness of the HSBK color into a
+// standard library RGB color.
+//
+// Kelvin value will be ignored and alpha channel will always be opaque.
+//
+// It's the reverse of FromColor.
+func (c Color) RGB() color.RGBA {
+	const hueRate = 360 / float64(1<<16)
+	const sbRate = float64(math.MaxUint16)
+
+	h := float64(c.Hue) * hueRate
+	s := float64(c.Saturation) / sbRate
+	v := float64(c.Brightness) / sbRate
+
+	chroma := v * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - chroma
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = chroma, x, 0
+	case h < 120:
+		r, g, b = x, chroma, 0
+	case h < 180:
+		r, g, b = 0, chroma, x
+	case h < 240:
+		r, g, b = 0, x, chroma
+	case h < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	toUint8 := func(f float64) uint8 {
+		return uint8(math.Round((f + m) * math.MaxUint8))
+	}
+	return color.RGBA{
+		R: toUint8(r),
+		G: toUint8(g),
+		B: toUint8(b),
+		A: math.MaxUint8,
+	}
+}
@@ -0,0 +1,126 @@
+package lifxlan
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NamedColors are the named colors supported by ParseColor.
+var NamedColors = map[string]Color{
+	"red": {
+		Hue:        0,
+		Saturation: math.MaxUint16,
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinNeutral,
+	},
+	"green": {
+		Hue:        21845,
+		Saturation: math.MaxUint16,
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinNeutral,
+	},
+	"blue": {
+		Hue:        43691,
+		Saturation: math.MaxUint16,
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinNeutral,
+	},
+	"white": {
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinNeutral,
+	},
+	"warm": {
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinWarm,
+	},
+	"cool": {
+		Brightness: math.MaxUint16,
+		Kelvin:     KelvinCool,
+	},
+}
+
+// ParseColor parses a string into Color.
+//
+// Supported formats are:
+//
+//	#rrggbb
+//	#rgb
+//	rgb(r, g, b)
+//	one of the NamedColors (case insensitive)
+//
+// For RGB colors, the HSBK color is converted by FromColor with KelvinNeutral.
+func ParseColor(s string) (Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if c, ok := NamedColors[s]; ok {
+		return c, nil
+	}
+
+	if strings.HasPrefix(s, "#") {
+		rgb, err := parseHex(s[1:])
+		if err != nil {
+			return Color{}, fmt.Errorf("lifxlan.ParseColor: %q: %w", s, err)
+		}
+		return *FromColor(rgb, KelvinNeutral), nil
+	}
+
+	if strings.HasPrefix(s, "rgb(") && strings.HasSuffix(s, ")") {
+		parts := strings.Split(s[len("rgb("):len(s)-1], ",")
+		if len(parts) != 3 {
+			return Color{}, fmt.Errorf(
+				"lifxlan.ParseColor: %q: expected 3 values, got %d",
+				s,
+				len(parts),
+			)
+		}
+		var values [3]uint8
+		for i, part := range parts {
+			v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+			if err != nil {
+				return Color{}, fmt.Errorf("lifxlan.ParseColor: %q: %w", s, err)
+			}
+			values[i] = uint8(v)
+		}
+		rgb := color.RGBA{
+			R: values[0],
+			G: values[1],
+			B: values[2],
+			A: math.MaxUint8,
+		}
+		return *FromColor(rgb, KelvinNeutral), nil
+	}
+
+	return Color{}, fmt.Errorf("lifxlan.ParseColor: unrecognized color %q", s)
+}
+
+// parseHex parses "rrggbb" or "rgb" into color.
+func parseHex(s string) (color.RGBA, error) {
+	switch len(s) {
+	default:
+		return color.RGBA{}, fmt.Errorf("invalid hex color length %d", len(s))
+	case 3:
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	case 6:
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return color.RGBA{
+		R: uint8(v >> 16),
+		G: uint8(v >> 8),
+		B: uint8(v),
+		A: math.MaxUint8,
+	}, nil
+}
+
+// Hex returns the "#rrggbb" representation of the color.
+//
+// Kelvin value will be ignored.
+func (c Color) Hex() string {
+	rgb := c.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", rgb.R, rgb.G, rgb.B)
+}
@@ -0,0 +1,159 @@
+package lifxlan_test
+
+import (
+	"image/color"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func TestParseColor(t *testing.T) {
+	for _, c := range []struct {
+		input    string
+		expected lifxlan.Color
+	}{
+		{
+			input: "#ff0000",
+			expected: lifxlan.Color{
+				Hue:        0,
+				Saturation: 65535,
+				Brightness: 65535,
+				Kelvin:     lifxlan.KelvinNeutral,
+			},
+		},
+		{
+			input: "#0F0",
+			expected: lifxlan.Color{
+				Hue:        21845,
+				Saturation: 65535,
+				Brightness: 65535,
+				Kelvin:     lifxlan.KelvinNeutral,
+			},
+		},
+		{
+			input: "rgb(0, 0, 255)",
+			expected: lifxlan.Color{
+				Hue:        43691,
+				Saturation: 65535,
+				Brightness: 65535,
+				Kelvin:     lifxlan.KelvinNeutral,
+			},
+		},
+		{
+			input: "#ff8800",
+			expected: lifxlan.Color{
+				Hue:        5825,
+				Saturation: 65535,
+				Brightness: 65535,
+				Kelvin:     lifxlan.KelvinNeutral,
+			},
+		},
+		{
+			input: " Warm ",
+			expected: lifxlan.Color{
+				Brightness: 65535,
+				Kelvin:     lifxlan.KelvinWarm,
+			},
+		},
+		{
+			input:    "blue",
+			expected: lifxlan.NamedColors["blue"],
+		},
+	} {
+		c := c
+		t.Run(
+			c.input,
+			func(t *testing.T) {
+				got, err := lifxlan.ParseColor(c.input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != c.expected {
+					t.Errorf("Expected %+v, got %+v", c.expected, got)
+				}
+			},
+		)
+	}
+
+	for _, input := range []string{
+		"",
+		"#12",
+		"#12345g",
+		"rgb(1,2)",
+		"rgb(1,2,256)",
+		"purple",
+	} {
+		input := input
+		t.Run(
+			"Invalid"+input,
+			func(t *testing.T) {
+				if _, err := lifxlan.ParseColor(input); err == nil {
+					t.Errorf("Expected error for %q, got nil", input)
+				} else {
+					t.Logf("Got error: %v", err)
+				}
+			},
+		)
+	}
+}
+
+func TestColorRGB(t *testing.T) {
+	for _, c := range []struct {
+		hex string
+		rgb color.RGBA
+	}{
+		{
+			hex: "#000000",
+			rgb: color.RGBA{0, 0, 0, 0xff},
+		},
+		{
+			hex: "#ffffff",
+			rgb: color.RGBA{0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			hex: "#ff0000",
+			rgb: color.RGBA{0xff, 0, 0, 0xff},
+		},
+		{
+			hex: "#00ff00",
+			rgb: color.RGBA{0, 0xff, 0, 0xff},
+		},
+		{
+			hex: "#0000ff",
+			rgb: color.RGBA{0, 0, 0xff, 0xff},
+		},
+		{
+			hex: "#ff8800",
+			rgb: color.RGBA{0xff, 0x88, 0, 0xff},
+		},
+		{
+			hex: "#808080",
+			rgb: color.RGBA{0x80, 0x80, 0x80, 0xff},
+		},
+		{
+			hex: "#123456",
+			rgb: color.RGBA{0x12, 0x34, 0x56, 0xff},
+		},
+	} {
+		c := c
+		t.Run(
+			c.hex,
+			func(t *testing.T) {
+				hsbk := lifxlan.FromColor(c.rgb, kelvin)
+				if got := hsbk.RGB(); got != c.rgb {
+					t.Errorf("RGB expected %v, got %v", c.rgb, got)
+				}
+				if got := hsbk.Hex(); got != c.hex {
+					t.Errorf("Hex expected %q, got %q", c.hex, got)
+				}
+				parsed, err := lifxlan.ParseColor(c.hex)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := parsed.Hex(); got != c.hex {
+					t.Errorf("ParseColor(%q).Hex() got %q", c.hex, got)
+				}
+			},
+		)
+	}
+}
@@ -1,11 +1,16 @@
 package lifxlan_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"image/color"
 	"reflect"
 	"testing"
+	"time"
 
 	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
 )
 
 const kelvin = lifxlan.KelvinCool
@@ -90,3 +95,564 @@ func TestFromColor(t *testing.T) {
 		)
 	}
 }
+
+func TestColorJSON(t *testing.T) {
+	t.Run(
+		"RoundTrip",
+		func(t *testing.T) {
+			c := lifxlan.Color{
+				Hue:        1,
+				Saturation: 2,
+				Brightness: 3,
+				Kelvin:     3500,
+			}
+			const expected = `{"hue":1,"saturation":2,"brightness":3,"kelvin":3500}`
+			data, err := json.Marshal(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != expected {
+				t.Errorf("json.Marshal expected %s, got %s", expected, data)
+			}
+
+			var got lifxlan.Color
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != c {
+				t.Errorf("json.Unmarshal expected %+v, got %+v", c, got)
+			}
+		},
+	)
+
+	for _, kelvin := range []uint16{
+		lifxlan.KelvinLowest,
+		lifxlan.KelvinHighest,
+	} {
+		kelvin := kelvin
+		t.Run(
+			fmt.Sprintf("Kelvin%d", kelvin),
+			func(t *testing.T) {
+				data := fmt.Sprintf(`{"kelvin":%d}`, kelvin)
+				var c lifxlan.Color
+				if err := json.Unmarshal([]byte(data), &c); err != nil {
+					t.Fatal(err)
+				}
+				if c.Kelvin != kelvin {
+					t.Errorf("Kelvin expected %d, got %d", kelvin, c.Kelvin)
+				}
+			},
+		)
+	}
+
+	for _, kelvin := range []uint16{
+		0,
+		lifxlan.KelvinLowest - 1,
+		lifxlan.KelvinHighest + 1,
+	} {
+		kelvin := kelvin
+		t.Run(
+			fmt.Sprintf("InvalidKelvin%d", kelvin),
+			func(t *testing.T) {
+				data := fmt.Sprintf(`{"kelvin":%d}`, kelvin)
+				var c lifxlan.Color
+				if err := json.Unmarshal([]byte(data), &c); err == nil {
+					t.Errorf("Expected error for kelvin %d, got nil", kelvin)
+				} else {
+					t.Logf("Got error: %v", err)
+				}
+			},
+		)
+	}
+}
+
+func TestKelvinToRGB(t *testing.T) {
+	for _, c := range []struct {
+		kelvin  uint16
+		r, g, b uint8
+	}{
+		// Clamped to KelvinLowest.
+		{kelvin: 0, r: 255, g: 108, b: 0},
+		{kelvin: 1000, r: 255, g: 108, b: 0},
+		{kelvin: 1500, r: 255, g: 108, b: 0},
+		{kelvin: 1900, r: 255, g: 132, b: 0},
+		{kelvin: 2000, r: 255, g: 137, b: 14},
+		{kelvin: 2500, r: 255, g: 159, b: 70},
+		{kelvin: 2700, r: 255, g: 167, b: 87},
+		{kelvin: 3500, r: 255, g: 193, b: 141},
+		{kelvin: 4000, r: 255, g: 206, b: 166},
+		{kelvin: 5000, r: 255, g: 228, b: 206},
+		{kelvin: 6500, r: 255, g: 254, b: 250},
+		{kelvin: 6600, r: 255, g: 255, b: 255},
+		{kelvin: 7500, r: 230, g: 235, b: 255},
+		{kelvin: 9000, r: 210, g: 223, b: 255},
+		// Clamped to KelvinHighest.
+		{kelvin: 10000, r: 210, g: 223, b: 255},
+	} {
+		c := c
+		t.Run(
+			fmt.Sprintf("%d", c.kelvin),
+			func(t *testing.T) {
+				r, g, b := lifxlan.KelvinToRGB(c.kelvin)
+				if r != c.r || g != c.g || b != c.b {
+					t.Errorf(
+						"Expected (%d, %d, %d), got (%d, %d, %d)",
+						c.r, c.g, c.b,
+						r, g, b,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestRGBToHSBK(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		r, g, b  uint8
+		expected lifxlan.Color
+	}{
+		{
+			label: "White",
+			r:     255,
+			g:     255,
+			b:     255,
+			expected: lifxlan.Color{
+				Brightness: 65535,
+			},
+		},
+		{
+			label: "Black",
+		},
+		{
+			label: "Gray",
+			r:     0x80,
+			g:     0x80,
+			b:     0x80,
+			expected: lifxlan.Color{
+				Brightness: 0x8080,
+			},
+		},
+		{
+			label: "Red",
+			r:     255,
+			expected: lifxlan.Color{
+				Saturation: 65535,
+				Brightness: 65535,
+			},
+		},
+		{
+			label: "Yellow",
+			r:     255,
+			g:     255,
+			expected: lifxlan.Color{
+				Hue:        10923,
+				Saturation: 65535,
+				Brightness: 65535,
+			},
+		},
+		{
+			label: "Cyan",
+			g:     255,
+			b:     255,
+			expected: lifxlan.Color{
+				Hue:        32768,
+				Saturation: 65535,
+				Brightness: 65535,
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				expected := c.expected
+				expected.Kelvin = lifxlan.KelvinNeutral
+				got := lifxlan.RGBToHSBK(c.r, c.g, c.b)
+				if got != expected {
+					t.Errorf("Expected %+v, got %+v", expected, got)
+				}
+			},
+		)
+	}
+}
+
+func TestColorSanitized(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		color    lifxlan.Color
+		min, max uint16
+		expected uint16
+	}{
+		{
+			label:    "InRange",
+			color:    lifxlan.Color{Kelvin: 3000},
+			min:      2000,
+			max:      6000,
+			expected: 3000,
+		},
+		{
+			label:    "TooWarm",
+			color:    lifxlan.Color{Kelvin: 1000},
+			min:      2000,
+			max:      6000,
+			expected: 2000,
+		},
+		{
+			label:    "TooCool",
+			color:    lifxlan.Color{Kelvin: 9000},
+			min:      2000,
+			max:      6000,
+			expected: 6000,
+		},
+		{
+			label:    "DefaultRange",
+			color:    lifxlan.Color{Kelvin: 1000},
+			expected: lifxlan.KelvinMin,
+		},
+		{
+			label:    "PartiallySaturated",
+			color:    lifxlan.Color{Saturation: 65534, Kelvin: 3000},
+			min:      2000,
+			max:      6000,
+			expected: 3000,
+		},
+		{
+			label:    "Saturated",
+			color:    lifxlan.Color{Saturation: 65535, Kelvin: 3000},
+			min:      2000,
+			max:      6000,
+			expected: lifxlan.KelvinNeutral,
+		},
+		{
+			label:    "SaturatedOutOfRange",
+			color:    lifxlan.Color{Saturation: 65535, Kelvin: 3000},
+			min:      5000,
+			max:      6000,
+			expected: 5000,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				orig := c.color
+				actual := c.color.Sanitized(c.min, c.max)
+				if actual.Kelvin != c.expected {
+					t.Errorf("Kelvin expected %d, got %d", c.expected, actual.Kelvin)
+				}
+				if actual.Hue != orig.Hue || actual.Saturation != orig.Saturation || actual.Brightness != orig.Brightness {
+					t.Errorf("Expected only kelvin to change from %+v, got %+v", orig, actual)
+				}
+				if c.color != orig {
+					t.Errorf("Expected the original color unchanged, got %+v", c.color)
+				}
+			},
+		)
+	}
+}
+
+func TestColorEqual(t *testing.T) {
+	base := lifxlan.Color{
+		Hue:        100,
+		Saturation: 200,
+		Brightness: 300,
+		Kelvin:     3500,
+	}
+	for _, c := range []struct {
+		label     string
+		a, b      lifxlan.Color
+		tolerance uint16
+		equal     bool
+		approx    bool
+	}{
+		{
+			label:     "Same",
+			a:         base,
+			b:         base,
+			tolerance: 0,
+			equal:     true,
+			approx:    true,
+		},
+		{
+			label:     "WithinTolerance",
+			a:         base,
+			b:         lifxlan.Color{Hue: 110, Saturation: 190, Brightness: 305, Kelvin: 3490},
+			tolerance: 10,
+			equal:     false,
+			approx:    true,
+		},
+		{
+			label:     "OutOfTolerance",
+			a:         base,
+			b:         lifxlan.Color{Hue: 100, Saturation: 200, Brightness: 300, Kelvin: 3489},
+			tolerance: 10,
+			equal:     false,
+			approx:    false,
+		},
+		{
+			label:     "HueWrapAround",
+			a:         lifxlan.Color{Hue: 65530},
+			b:         lifxlan.Color{Hue: 5},
+			tolerance: 10,
+			equal:     false,
+			approx:    true,
+		},
+		{
+			label:     "HueWrapAroundReversed",
+			a:         lifxlan.Color{Hue: 5},
+			b:         lifxlan.Color{Hue: 65530},
+			tolerance: 10,
+			equal:     false,
+			approx:    true,
+		},
+		{
+			label:     "HueWrapAroundOutOfTolerance",
+			a:         lifxlan.Color{Hue: 65530},
+			b:         lifxlan.Color{Hue: 5},
+			tolerance: 9,
+			equal:     false,
+			approx:    false,
+		},
+		{
+			label:     "HueRedBothEnds",
+			a:         lifxlan.Color{Hue: 0},
+			b:         lifxlan.Color{Hue: 65535},
+			tolerance: 0,
+			equal:     false,
+			approx:    true,
+		},
+		{
+			label:     "HueOpposite",
+			a:         lifxlan.Color{Hue: 0},
+			b:         lifxlan.Color{Hue: 32768},
+			tolerance: 32766,
+			equal:     false,
+			approx:    false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if actual := c.a.Equal(c.b); actual != c.equal {
+					t.Errorf("%v.Equal(%v) expected %v, got %v", c.a, c.b, c.equal, actual)
+				}
+				if actual := c.a.ApproxEqual(c.b, c.tolerance); actual != c.approx {
+					t.Errorf(
+						"%v.ApproxEqual(%v, %d) expected %v, got %v",
+						c.a,
+						c.b,
+						c.tolerance,
+						c.approx,
+						actual,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestColorLerp(t *testing.T) {
+	from := lifxlan.Color{
+		Hue:        65000,
+		Saturation: 0,
+		Brightness: 100,
+		Kelvin:     2500,
+	}
+	to := lifxlan.Color{
+		Hue:        1000,
+		Saturation: 65535,
+		Brightness: 0,
+		Kelvin:     9000,
+	}
+	for _, c := range []struct {
+		t        float64
+		expected lifxlan.Color
+	}{
+		{
+			t:        0,
+			expected: from,
+		},
+		{
+			t:        1,
+			expected: to,
+		},
+		{
+			t: 0.5,
+			expected: lifxlan.Color{
+				// The short path wraps around 0.
+				Hue:        232,
+				Saturation: 32768,
+				Brightness: 50,
+				Kelvin:     5750,
+			},
+		},
+	} {
+		if got := from.Lerp(to, c.t); got != c.expected {
+			t.Errorf("Lerp(%v) expected %v, got %v", c.t, c.expected, got)
+		}
+	}
+}
+
+func TestColorRotate(t *testing.T) {
+	base := lifxlan.Color{
+		Hue:        0,
+		Saturation: 1,
+		Brightness: 2,
+		Kelvin:     3500,
+	}
+	withHue := func(hue uint16) lifxlan.Color {
+		c := base
+		c.Hue = hue
+		return c
+	}
+
+	if got := base.Complement(); got != withHue(32768) {
+		t.Errorf("Complement expected %v, got %v", withHue(32768), got)
+	}
+	if got := withHue(49152).Complement(); got != withHue(16384) {
+		t.Errorf("Complement of 49152 expected %v, got %v", withHue(16384), got)
+	}
+
+	for _, c := range []struct {
+		hue      uint16
+		degrees  float64
+		expected uint16
+	}{
+		{
+			hue:      0,
+			degrees:  90,
+			expected: 16384,
+		},
+		{
+			hue:      65000,
+			degrees:  90,
+			expected: 15848,
+		},
+		{
+			hue:      0,
+			degrees:  -90,
+			expected: 49152,
+		},
+		{
+			hue:      1000,
+			degrees:  720,
+			expected: 1000,
+		},
+		{
+			hue:      1000,
+			degrees:  -720,
+			expected: 1000,
+		},
+	} {
+		if got := withHue(c.hue).Rotate(c.degrees); got != withHue(c.expected) {
+			t.Errorf("Rotate(%d, %v) expected %v, got %v", c.hue, c.degrees, withHue(c.expected), got)
+		}
+	}
+
+	left, right := base.Analogous()
+	if expected := withHue(60075); left != expected {
+		t.Errorf("Analogous left expected %v, got %v", expected, left)
+	}
+	if expected := withHue(5461); right != expected {
+		t.Errorf("Analogous right expected %v, got %v", expected, right)
+	}
+}
+
+func TestDeviceKelvinRange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label    string
+		product  uint32
+		min, max uint16
+		err      bool
+	}{
+		{
+			label:   "LIFX Z",
+			product: 32,
+			min:     2500,
+			max:     9000,
+		},
+		{
+			label:   "LIFX Clean",
+			product: 90,
+			min:     1500,
+			max:     9000,
+		},
+		{
+			label:   "LIFX Mini White",
+			product: 51,
+			min:     2700,
+			max:     2700,
+		},
+		{
+			label:   "Switch",
+			product: 70,
+			err:     true,
+		},
+		{
+			label:   "Unknown",
+			product: 65535,
+			err:     true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service := &mock.Service{
+					TB:         t,
+					Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+					HandleAcks: true,
+					RawStateVersionPayload: &lifxlan.RawStateVersionPayload{
+						Version: lifxlan.HardwareVersion{
+							VendorID:  1,
+							ProductID: c.product,
+						},
+					},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if min, max := lifxlan.CachedKelvinRange(device); min != lifxlan.KelvinLowest || max != lifxlan.KelvinHighest {
+					t.Errorf(
+						"CachedKelvinRange expected [%d, %d] before fetching, got [%d, %d]",
+						lifxlan.KelvinLowest,
+						lifxlan.KelvinHighest,
+						min,
+						max,
+					)
+				}
+
+				min, max, err := device.KelvinRange(ctx, nil)
+				if c.err {
+					if err == nil {
+						t.Errorf("Expected error, got range [%d, %d]", min, max)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if min != c.min || max != c.max {
+					t.Errorf("KelvinRange expected [%d, %d], got [%d, %d]", c.min, c.max, min, max)
+				}
+				if min, max := lifxlan.CachedKelvinRange(device); min != c.min || max != c.max {
+					t.Errorf("CachedKelvinRange expected [%d, %d], got [%d, %d]", c.min, c.max, min, max)
+				}
+				if device.HardwareVersion().ProductID != c.product {
+					t.Errorf("Expected hardware version to be cached, got %v", device.HardwareVersion())
+				}
+			},
+		)
+	}
+}
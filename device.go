@@ -4,7 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // ServiceType define the type of the service this device provides.
@@ -24,6 +29,26 @@ func (s ServiceType) String() string {
 	}
 }
 
+// Service is a service provided by a device,
+// as reported by the StateService response.
+type Service struct {
+	Type ServiceType
+	Port uint32
+}
+
+// network returns the network name of the service type,
+// as used by net.Dial.
+//
+// It returns empty string for unknown service types.
+func (s ServiceType) network() string {
+	switch s {
+	default:
+		return ""
+	case ServiceUDP:
+		return "udp"
+	}
+}
+
 // Device defines the common interface between lifxlan devices.
 //
 // For the Foo() and GetFoo() function pairs (e.g. Label() and GetLabel()),
@@ -68,7 +93,34 @@ type Device interface {
 	// Target returns the target of this device, usually it's the MAC address.
 	Target() Target
 
-	// Dial tries to establish a connection to this device.
+	// Addr returns the network address of this device.
+	//
+	// For discovered devices it's the address from the StateService response.
+	// For devices created via NewDevice or FromAddr it's the addr passed in
+	// (with DefaultPort added if it's omitted).
+	//
+	// When the host part is an IP address the returned value will be a
+	// *net.UDPAddr (for ServiceUDP).
+	Addr() net.Addr
+
+	// Services returns all the services this device provides.
+	//
+	// For discovered devices,
+	// they are the services reported by the StateService responses received
+	// during discovery,
+	// and more could be added as the discovery continues.
+	// For devices created via NewDevice or FromAddr,
+	// it only contains the service passed in, with the port from addr.
+	Services() []Service
+
+	// MarshalJSON implements json.Marshaler.
+	//
+	// It encodes the device as its DeviceRecord,
+	// which can be reconstructed later via LoadDevices.
+	MarshalJSON() ([]byte, error)
+
+	// Dial tries to establish a connection to this device,
+	// using the UDP service from Services.
 	Dial() (net.Conn, error)
 
 	// Source returns a consistent random source to be used with API calls.
@@ -76,14 +128,119 @@ type Device interface {
 	Source() uint32
 
 	// NextSequence returns the next sequence value to be used with API calls.
+	//
+	// When sequence tracking is enabled (see SetSequenceTracking),
+	// it skips the sequences currently in flight,
+	// and blocks until one of them is no longer in flight if all 256 of them are.
 	NextSequence() uint8
 
+	// SetSequenceTracking enables or disables in-flight sequence tracking on
+	// this device.
+	//
+	// Sequences are only 8 bits and wrap around every 256 messages,
+	// so with many concurrent API calls on the same device,
+	// two in-flight requests could end up with the same sequence,
+	// and WaitForAcks or WaitForResponses could match the wrong response.
+	//
+	// When it's enabled,
+	// every sequence returned by NextSequence (and used by Send) is marked as in
+	// flight,
+	// until its acks or responses are received by WaitForAcks or
+	// WaitForResponses with the source of this device,
+	// or InFlightSequenceTimeout passed.
+	// Sequences of failed or timed out calls stay in flight until
+	// InFlightSequenceTimeout,
+	// so the late responses won't be matched to new requests.
+	//
+	// When all 256 sequences are in flight,
+	// Send blocks until one of them is no longer in flight,
+	// or returns an error when ctx is cancelled.
+	//
+	// It's disabled by default.
+	SetSequenceTracking(enabled bool)
+
+	// SetTimeout sets the default timeout of the API calls on this device.
+	//
+	// When it's set,
+	// WaitForAcks and WaitForResponses with the source of this device (and
+	// therefore all the getters and setters with ack) use it as the timeout
+	// if ctx doesn't have a deadline,
+	// so a ctx without deadline won't block forever when responses are dropped.
+	// An explicit deadline on ctx always takes precedence over it,
+	// even when the deadline is further away than the default timeout.
+	//
+	// Devices wrapped from this device (e.g. light.Wrap) share the same default
+	// timeout.
+	// timeout <= 0 unsets it, which is the default.
+	//
+	// It only changes the Timeout of the Policy of this device,
+	// and keeps the other fields.
+	SetTimeout(timeout time.Duration)
+
+	// Timeout returns the default timeout set via SetTimeout,
+	// or 0 if it's not set.
+	//
+	// It's the same as Policy().Timeout.
+	Timeout() time.Duration
+
+	// SetPolicy sets the Policy of the API calls on this device,
+	// replacing the one set before (including the timeout set via SetTimeout).
+	//
+	// Devices wrapped from this device (e.g. light.Wrap) share the same Policy.
+	SetPolicy(p Policy)
+
+	// Policy returns the Policy set via SetPolicy and SetTimeout,
+	// or DefaultPolicy if it's never set.
+	Policy() Policy
+
+	// Forget releases the states kept outside of this device for its source,
+	// which are needed as WaitForAcks and WaitForResponses only know the
+	// source:
+	// the Policy (see SetPolicy) and the sequence tracker (see
+	// SetSequenceTracking).
+	//
+	// The device goes back to DefaultPolicy with sequence tracking disabled,
+	// and can still be used afterwards.
+	//
+	// It's called automatically once the device is garbage collected,
+	// call it explicitly to release them earlier,
+	// e.g. when replacing the device with a rediscovered one.
+	Forget()
+
+	// Clone returns a copy of this device with the same target, address and
+	// services,
+	// but with its own random source and sequence counter,
+	// so it can be used by another goroutine on its own connection (see Dial)
+	// without cross-talk on response matching.
+	//
+	// The cached properties (label, hardware version and firmware) and the
+	// Policy are copied,
+	// and sequence tracking (see SetSequenceTracking) is enabled on the clone if
+	// it's enabled on this device.
+	// Later changes on either of them don't affect the other one.
+	//
+	// The returned device is always a plain Device,
+	// even if this device is wrapped (e.g. by light.Wrap),
+	// wrap it again if needed.
+	//
+	// Use Clone when the goroutines can afford their own connections,
+	// and SyncConn when they must share a single connection instead.
+	Clone() Device
+
 	// Send generates and sends a message to the device.
 	//
 	// conn must be pre-dialed or this function will fail.
 	//
 	// It calls the device's Target(), Source(), and NextSequence() functions to
 	// fill the appropriate headers.
+	// If ctx carries a non-zero source set via WithSource,
+	// it's used instead of Source().
+	// The tagged bit is set only when Target() is AllDevices (see TaggedFor),
+	// unless overridden via WithTagged.
+	//
+	// payload will be encoded via its MarshalBinary function if it implements
+	// encoding.BinaryMarshaler,
+	// otherwise via binary.Write in little endian.
 	//
 	// The sequence used in this message will be returned.
 	Send(ctx context.Context, conn net.Conn, flags AckResFlag, message MessageType, payload interface{}) (seq uint8, err error)
@@ -100,14 +257,35 @@ type Device interface {
 	// upgrades).
 	SanitizeColor(color Color) Color
 
+	// KelvinRange returns the min and max kelvin supported by the device,
+	// e.g. to be used as the bounds of a color temperature slider.
+	//
+	// The bounds come from ProductMap with the cached firmware version applied
+	// (see SanitizeColor).
+	// If the device's hardware version was never fetched and cached,
+	// it's fetched via GetHardwareVersion first.
+	//
+	// Fixed white devices return the same min and max.
+	// An error is returned if the product is not in ProductMap,
+	// or the product has no temperature range (e.g. it's not a light device).
+	//
+	// If conn is nil and the hardware version needs to be fetched,
+	// a new connection will be made and guaranteed to be closed before returning.
+	KelvinRange(ctx context.Context, conn net.Conn) (min, max uint16, err error)
+
 	// Echo sends a message to the device and waits for a response to ensure that
-	// the device is online and responding
+	// the device is online and responding,
+	// and returns the round trip time.
+	//
+	// payload will be padded or truncated to EchoPayloadLength bytes.
+	// If payload is nil, a random one will be used.
+	// An error will be returned if the device echoed back a different payload.
 	//
 	// If conn is nil,
 	// a new connection will be made and guaranteed to be closed before returning.
 	// You should pre-dial and pass in the conn if you plan to call APIs on this
 	// device repeatedly.
-	Echo(ctx context.Context, conn net.Conn) error
+	Echo(ctx context.Context, conn net.Conn, payload []byte) (rtt time.Duration, err error)
 
 	// GetPower returns the current power level of the device.
 	//
@@ -130,11 +308,130 @@ type Device interface {
 	// this function will only return nil error after it received ack from the
 	// device.
 	SetPower(ctx context.Context, conn net.Conn, power Power, ack bool) error
+	// TogglePower reads the current power level of the device via GetPower,
+	// then sets it to the inverted one via SetPower,
+	// and returns the new power level.
+	//
+	// If ctx is cancelled after reading the current power level,
+	// SetPower message will not be sent and ctx.Err() will be returned.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the SetPower message is sent
+	// successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	TogglePower(ctx context.Context, conn net.Conn, ack bool) (Power, error)
 
 	// The label of the device.
 	Label() *Label
 	GetLabel(ctx context.Context, conn net.Conn) error
 
+	// LabelFetched returns the time the label was last fetched via GetLabel,
+	// or the zero time if it was never fetched.
+	LabelFetched() time.Time
+	// SetLabel sets the label of the device.
+	//
+	// label must be no longer than LabelLength bytes in UTF-8,
+	// otherwise an error will be returned.
+	// On success the cached Label will also be updated.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetLabel(ctx context.Context, conn net.Conn, label string, ack bool) error
+
+	// GetGroup returns the group this device belongs to.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetGroup(ctx context.Context, conn net.Conn) (*Group, error)
+
+	// GetLocation returns the location this device belongs to.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetLocation(ctx context.Context, conn net.Conn) (*Location, error)
+
+	// SetGroup assigns this device to the group identified by guid.
+	//
+	// To put multiple devices into the same group,
+	// use the same guid, label and updatedAt for all of them.
+	// NewGUID can be used to generate a guid for a new group.
+	// updatedAt is used by LIFX apps to decide which group label is the newest,
+	// so it should usually be time.Now().
+	//
+	// label must be no longer than LabelLength bytes in UTF-8,
+	// otherwise an error will be returned.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetGroup(ctx context.Context, conn net.Conn, guid GUID, label string, updatedAt time.Time, ack bool) error
+
+	// SetLocation assigns this device to the location identified by guid.
+	//
+	// It works the same way as SetGroup.
+	SetLocation(ctx context.Context, conn net.Conn, guid GUID, label string, updatedAt time.Time, ack bool) error
+
+	// GetWifiInfo returns the wifi signal info of the device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetWifiInfo(ctx context.Context, conn net.Conn) (*WifiInfo, error)
+
+	// GetHostInfo returns the radio signal and transfer statistics of the
+	// device's host MCU.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetHostInfo(ctx context.Context, conn net.Conn) (*HostInfo, error)
+
+	// GetInfo returns the current time, uptime and downtime of the device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetInfo(ctx context.Context, conn net.Conn) (*DeviceInfo, error)
+
+	// Reboot reboots the device.
+	//
+	// The device reboots immediately without sending any ack,
+	// so this function returns nil error as soon as the message is sent.
+	// The device will be unreachable for several seconds afterwards.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	Reboot(ctx context.Context, conn net.Conn) error
+
 	// The hardware version info of the device.
 	HardwareVersion() *HardwareVersion
 	GetHardwareVersion(ctx context.Context, conn net.Conn) error
@@ -142,6 +439,17 @@ type Device interface {
 	// The firmware version of the device.
 	Firmware() *FirmwareUpgrade
 	GetFirmware(ctx context.Context, conn net.Conn) error
+
+	// GetHostFirmware returns the host firmware info of the device,
+	// including the build time.
+	//
+	// It also updates the cached Firmware.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetHostFirmware(ctx context.Context, conn net.Conn) (*Firmware, error)
 }
 
 var _ Device = (*device)(nil)
@@ -158,51 +466,233 @@ type device struct {
 	source   uint32
 	sequence uint32
 
+	trackerLock sync.Mutex
+	tracker     *sequenceTracker
+
+	// Whether this device holds a reference in devicePolicies,
+	// guarded by policyLock.
+	policyRef bool
+
+	servicesLock sync.Mutex
+	services     []Service
+
 	// Cached properties.
 	label    Label
 	version  HardwareVersion
 	firmware FirmwareUpgrade
+
+	// The time label was last fetched via GetLabel.
+	labelFetched time.Time
 }
 
+// DefaultPort is the default port used by NewDevice and FromAddr when addr
+// doesn't have a port.
+const DefaultPort = DefaultBroadcastPort
+
 // NewDevice creates a new Device.
 //
-// addr must be in "host:port" format and service must be a known service type,
+// addr should be in "host:port" format.
+// If the port is omitted, DefaultPort will be used.
+// service must be a known service type,
 // otherwise the later Dial funcion will fail.
+//
+// NewDevice doesn't validate addr,
+// an invalid addr will only cause the later Dial function to fail.
+// Use FromAddr instead if you want to validate addr upfront
+// (e.g. when reading addrs from a config file).
+//
+// The source of the device will be a random one.
 func NewDevice(addr string, service ServiceType, target Target) Device {
-	return &device{
+	return newDevice(addr, service, target)
+}
+
+func newDevice(addr string, service ServiceType, target Target) *device {
+	if normalized, err := normalizeAddr(addr); err == nil {
+		addr = normalized
+	}
+	// Port is left as 0 if addr is invalid,
+	// in which case Dial uses addr as-is (and fails).
+	var port uint32
+	if _, p, err := net.SplitHostPort(addr); err == nil {
+		if parsed, err := strconv.ParseUint(p, 10, 16); err == nil {
+			port = uint32(parsed)
+		}
+	}
+	d := &device{
 		addr:    addr,
 		service: service,
 		target:  target,
 		source:  RandomSource(),
+		services: []Service{{
+			Type: service,
+			Port: port,
+		}},
 	}
+	// Release the states kept for the source once the device is no longer
+	// used.
+	runtime.SetFinalizer(d, (*device).Forget)
+	return d
+}
+
+// FromAddr creates a new Device from a known address and target,
+// without going through discovery.
+//
+// It's the same as NewDevice,
+// except that it returns an error if addr cannot be parsed as "host:port"
+// (or "host", in which case DefaultPort will be used),
+// or service is not a known service type.
+func FromAddr(addr string, service ServiceType, target Target) (Device, error) {
+	if service.network() == "" {
+		return nil, fmt.Errorf(
+			"lifxlan.FromAddr: unknown device service type: %v",
+			service,
+		)
+	}
+	normalized, err := normalizeAddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("lifxlan.FromAddr: %w", err)
+	}
+	return NewDevice(normalized, service, target), nil
+}
+
+// normalizeAddr validates addr and adds DefaultPort to it if it doesn't have
+// a port.
+func normalizeAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// Try again with the default port.
+		host = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		port = DefaultPort
+		if _, _, e := net.SplitHostPort(net.JoinHostPort(host, port)); e != nil {
+			return "", err
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing host in address %q", addr)
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return "", fmt.Errorf("invalid port in address %q", addr)
+	}
+	return net.JoinHostPort(host, port), nil
 }
 
 func (d *device) String() string {
-	if label := d.Label().String(); label != EmptyLabel {
-		return fmt.Sprintf("%s(%v)", label, d.Target())
+	return FormatDevice(d, "Device")
+}
+
+// FormatDevice renders d for logging in the form of:
+//
+//     LIFX A19 "Kitchen" d0:73:d5:01:23:45 @ 192.168.1.42:56700
+//
+// The product name comes from ProductMap when the hardware version of d is
+// cached and known, otherwise kind is used instead (e.g. "LightDevice").
+// The label is omitted when it's not fetched yet.
+//
+// It only uses the cached info of d so it never blocks,
+// and it's what the String methods of the devices in this package and the
+// subpackages use.
+func FormatDevice(d Device, kind string) string {
+	var sb strings.Builder
+	sb.Grow(64)
+	if product, ok := ProductMap[d.HardwareVersion().ProductMapKey()]; ok {
+		sb.WriteString(product.ProductName)
+	} else {
+		sb.WriteString(kind)
 	}
-	if parsed := d.HardwareVersion().Parse(); parsed != nil {
-		return fmt.Sprintf("%s(%v)", parsed.ProductName, d.Target())
+	if label := d.Label().String(); label != EmptyLabel {
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.Quote(label))
 	}
-	return fmt.Sprintf("Device(%v)", d.Target())
+	sb.WriteByte(' ')
+	var buf [targetStringLength]byte
+	sb.Write(d.Target().appendString(buf[:0]))
+	sb.WriteString(" @ ")
+	sb.WriteString(d.Addr().String())
+	return sb.String()
 }
 
 func (d *device) Target() Target {
 	return d.target
 }
 
+// deviceAddr is the net.Addr implementation used by Device.Addr when addr
+// cannot be parsed into a more specific type.
+type deviceAddr struct {
+	network string
+	addr    string
+}
+
+var _ net.Addr = deviceAddr{}
+
+func (a deviceAddr) Network() string {
+	return a.network
+}
+
+func (a deviceAddr) String() string {
+	return a.addr
+}
+
+func (d *device) Addr() net.Addr {
+	network := d.service.network()
+	if network == "udp" {
+		if host, port, err := net.SplitHostPort(d.addr); err == nil {
+			// IPv6 link-local addresses could come with a zone, e.g. "fe80::1%eth0".
+			var zone string
+			if i := strings.LastIndex(host, "%"); i >= 0 {
+				host, zone = host[:i], host[i+1:]
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+					return &net.UDPAddr{
+						IP:   ip,
+						Port: int(p),
+						Zone: zone,
+					}
+				}
+			}
+		}
+	}
+	return deviceAddr{
+		network: network,
+		addr:    d.addr,
+	}
+}
+
+func (d *device) Services() []Service {
+	d.servicesLock.Lock()
+	defer d.servicesLock.Unlock()
+	services := make([]Service, len(d.services))
+	copy(services, d.services)
+	return services
+}
+
+// addService adds s to the services of the device if it's not already there.
+func (d *device) addService(s Service) {
+	d.servicesLock.Lock()
+	defer d.servicesLock.Unlock()
+	for _, existing := range d.services {
+		if existing == s {
+			return
+		}
+	}
+	d.services = append(d.services, s)
+}
+
 func (d *device) Dial() (net.Conn, error) {
-	var network string
-	switch d.service {
-	default:
-		return nil, fmt.Errorf(
-			"lifxlan.Device.Dial: unknown device service type: %v",
-			d.service,
-		)
-	case ServiceUDP:
-		network = "udp"
+	for _, s := range d.Services() {
+		if s.Type != ServiceUDP {
+			continue
+		}
+		addr := d.addr
+		if host, _, err := net.SplitHostPort(addr); err == nil && s.Port != 0 {
+			addr = net.JoinHostPort(host, strconv.FormatUint(uint64(s.Port), 10))
+		}
+		return net.Dial(s.Type.network(), addr)
 	}
-	return net.Dial(network, d.addr)
+	return nil, fmt.Errorf(
+		"lifxlan.Device.Dial: unknown device service type: %v",
+		d.service,
+	)
 }
 
 func (d *device) Source() uint32 {
@@ -212,5 +702,38 @@ func (d *device) Source() uint32 {
 const uint8mask = uint32(0xff)
 
 func (d *device) NextSequence() uint8 {
+	if tracker := d.getTracker(); tracker != nil {
+		// It never fails with background context.
+		seq, _ := tracker.acquire(context.Background())
+		return seq
+	}
+	return d.nextCounterSequence()
+}
+
+func (d *device) nextCounterSequence() uint8 {
 	return uint8(atomic.AddUint32(&d.sequence, 1) & uint8mask)
 }
+
+func (d *device) Clone() Device {
+	clone := &device{
+		addr:     d.addr,
+		service:  d.service,
+		target:   d.target,
+		source:   RandomSource(),
+		services: d.Services(),
+		label:    d.label,
+		version:  d.version,
+		firmware: d.firmware,
+
+		labelFetched: d.labelFetched,
+	}
+	for clone.source == d.source {
+		clone.source = RandomSource()
+	}
+	clone.SetPolicy(d.Policy())
+	if d.getTracker() != nil {
+		clone.SetSequenceTracking(true)
+	}
+	runtime.SetFinalizer(clone, (*device).Forget)
+	return clone
+}
@@ -0,0 +1,301 @@
+package lifxlan_test
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func TestDeviceAddr(t *testing.T) {
+	for _, c := range []struct {
+		label   string
+		addr    string
+		service lifxlan.ServiceType
+		udp     bool
+	}{
+		{
+			label:   "IPv4",
+			addr:    "192.168.1.2:56700",
+			service: lifxlan.ServiceUDP,
+			udp:     true,
+		},
+		{
+			label:   "IPv6",
+			addr:    "[fe80::1]:56700",
+			service: lifxlan.ServiceUDP,
+			udp:     true,
+		},
+		{
+			label:   "IPv6Zone",
+			addr:    "[fe80::1%eth0]:56700",
+			service: lifxlan.ServiceUDP,
+			udp:     true,
+		},
+		{
+			label:   "IPv6Loopback",
+			addr:    "[::1]:56700",
+			service: lifxlan.ServiceUDP,
+			udp:     true,
+		},
+		{
+			label:   "Hostname",
+			addr:    "lifx.local:56700",
+			service: lifxlan.ServiceUDP,
+		},
+		{
+			label:   "UnknownService",
+			addr:    "192.168.1.2:56700",
+			service: 0,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				d := lifxlan.NewDevice(c.addr, c.service, lifxlan.AllDevices)
+				addr := d.Addr()
+				if got := addr.String(); got != c.addr {
+					t.Errorf("Addr().String() expected %q, got %q", c.addr, got)
+				}
+				if _, ok := addr.(*net.UDPAddr); ok != c.udp {
+					t.Errorf("Addr() expected *net.UDPAddr %v, got %T", c.udp, addr)
+				}
+			},
+		)
+	}
+}
+
+func TestFromAddr(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		addr     string
+		service  lifxlan.ServiceType
+		expected string
+		err      bool
+	}{
+		{
+			label:    "HostPort",
+			addr:     "192.168.1.2:1234",
+			service:  lifxlan.ServiceUDP,
+			expected: "192.168.1.2:1234",
+		},
+		{
+			label:    "DefaultPort",
+			addr:     "192.168.1.2",
+			service:  lifxlan.ServiceUDP,
+			expected: "192.168.1.2:56700",
+		},
+		{
+			label:    "IPv6DefaultPort",
+			addr:     "fe80::1",
+			service:  lifxlan.ServiceUDP,
+			expected: "[fe80::1]:56700",
+		},
+		{
+			label:    "BracketedIPv6DefaultPort",
+			addr:     "[fe80::1]",
+			service:  lifxlan.ServiceUDP,
+			expected: "[fe80::1]:56700",
+		},
+		{
+			label:    "Hostname",
+			addr:     "lifx.local",
+			service:  lifxlan.ServiceUDP,
+			expected: "lifx.local:56700",
+		},
+		{
+			label:   "EmptyHost",
+			addr:    ":56700",
+			service: lifxlan.ServiceUDP,
+			err:     true,
+		},
+		{
+			label:   "InvalidPort",
+			addr:    "192.168.1.2:foo",
+			service: lifxlan.ServiceUDP,
+			err:     true,
+		},
+		{
+			label:   "PortOutOfRange",
+			addr:    "192.168.1.2:65536",
+			service: lifxlan.ServiceUDP,
+			err:     true,
+		},
+		{
+			label:   "UnknownService",
+			addr:    "192.168.1.2:56700",
+			service: 0,
+			err:     true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				const target lifxlan.Target = 1
+				d, err := lifxlan.FromAddr(c.addr, c.service, target)
+				if c.err {
+					if err == nil {
+						t.Errorf("Expected error for %q, got device %v", c.addr, d)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := d.Addr().String(); got != c.expected {
+					t.Errorf("Addr expected %q, got %q", c.expected, got)
+				}
+				if d.Target() != target {
+					t.Errorf("Target expected %v, got %v", target, d.Target())
+				}
+				if d.Source() == 0 {
+					t.Error("Source should be non-zero")
+				}
+			},
+		)
+	}
+}
+
+func TestDeviceServices(t *testing.T) {
+	t.Run(
+		"NewDevice",
+		func(t *testing.T) {
+			d := lifxlan.NewDevice("192.168.1.2", lifxlan.ServiceUDP, lifxlan.AllDevices)
+			expected := []lifxlan.Service{{
+				Type: lifxlan.ServiceUDP,
+				Port: 56700,
+			}}
+			services := d.Services()
+			if !reflect.DeepEqual(services, expected) {
+				t.Errorf("Services expected %v, got %v", expected, services)
+			}
+			// Make sure it returns a copy.
+			services[0].Port = 1
+			if got := d.Services(); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Services expected %v after modifying the returned slice, got %v", expected, got)
+			}
+		},
+	)
+
+	t.Run(
+		"NoUDP",
+		func(t *testing.T) {
+			d := lifxlan.NewDevice("192.168.1.2:56700", 0, lifxlan.AllDevices)
+			if conn, err := d.Dial(); err == nil {
+				conn.Close()
+				t.Error("Expected Dial error without UDP service")
+			}
+		},
+	)
+}
+
+func TestDeviceString(t *testing.T) {
+	const (
+		target = "d0:73:d5:01:23:45"
+		addr   = "192.168.1.42:56700"
+	)
+
+	for _, c := range []struct {
+		label    string
+		record   lifxlan.DeviceRecord
+		expected string
+	}{
+		{
+			label: "Full",
+			record: lifxlan.DeviceRecord{
+				Label: "Kitchen",
+				HardwareVersion: &lifxlan.HardwareVersion{
+					VendorID:  1,
+					ProductID: 27,
+				},
+			},
+			expected: `LIFX A19 "Kitchen" d0:73:d5:01:23:45 @ 192.168.1.42:56700`,
+		},
+		{
+			label: "LabelOnly",
+			record: lifxlan.DeviceRecord{
+				Label: "Kitchen",
+			},
+			expected: `Device "Kitchen" d0:73:d5:01:23:45 @ 192.168.1.42:56700`,
+		},
+		{
+			label: "UnknownProduct",
+			record: lifxlan.DeviceRecord{
+				HardwareVersion: &lifxlan.HardwareVersion{
+					VendorID:  1,
+					ProductID: 0xffff,
+				},
+			},
+			expected: `Device d0:73:d5:01:23:45 @ 192.168.1.42:56700`,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				c.record.Target = target
+				c.record.Addr = addr
+				c.record.Service = lifxlan.ServiceUDP
+				d, err := c.record.Device()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := fmt.Sprint(d); got != c.expected {
+					t.Errorf("String expected %q, got %q", c.expected, got)
+				}
+			},
+		)
+	}
+}
+
+func TestDeviceClone(t *testing.T) {
+	device := lifxlan.NewDevice("127.0.0.1:56700", lifxlan.ServiceUDP, lifxlan.Target(1))
+	device.SetTimeout(time.Second)
+	defer device.SetTimeout(0)
+	// Advance the original device first.
+	for i := 0; i < 5; i++ {
+		device.NextSequence()
+	}
+
+	clone := device.Clone()
+	defer clone.SetTimeout(0)
+	if clone.Target() != device.Target() {
+		t.Errorf("Target expected %v, got %v", device.Target(), clone.Target())
+	}
+	if clone.Addr().String() != device.Addr().String() {
+		t.Errorf("Addr expected %v, got %v", device.Addr(), clone.Addr())
+	}
+	if clone.Source() == device.Source() {
+		t.Errorf("Expected a different source, got %d", clone.Source())
+	}
+	if got := clone.Timeout(); got != time.Second {
+		t.Errorf("Timeout expected to be copied as %v, got %v", time.Second, got)
+	}
+	clone.SetTimeout(time.Millisecond)
+	if got := device.Timeout(); got != time.Second {
+		t.Errorf("Timeout of the original expected to stay %v, got %v", time.Second, got)
+	}
+
+	// Interleaved calls on both devices don't affect each other.
+	prevDevice := device.NextSequence()
+	prevClone := clone.NextSequence()
+	if prevClone != 1 {
+		t.Errorf("The first sequence of the clone expected 1, got %d", prevClone)
+	}
+	for i := 0; i < 300; i++ {
+		seqDevice := device.NextSequence()
+		seqClone := clone.NextSequence()
+		if seqDevice != prevDevice+1 {
+			t.Fatalf("Sequence of the original expected %d, got %d", prevDevice+1, seqDevice)
+		}
+		if seqClone != prevClone+1 {
+			t.Fatalf("Sequence of the clone expected %d, got %d", prevClone+1, seqClone)
+		}
+		prevDevice, prevClone = seqDevice, seqClone
+	}
+}
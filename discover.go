@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"time"
 )
 
 // RawStateServicePayload defines the struct to be used for encoding and
@@ -23,13 +26,28 @@ const (
 	DefaultBroadcastPort = "56700"
 )
 
+// BroadcastRepeatInterval is the interval between the repeated discovery
+// messages sent when DiscoverOptions.Broadcasts > 1.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var BroadcastRepeatInterval = time.Millisecond * 100
+
+// DefaultMulticastHost is the IPv6 multicast group used by IPv6 discovery.
+//
+// LIFX doesn't define a dedicated multicast group,
+// so the link-local all-nodes group is used,
+// which is the IPv6 equivalent of the IPv4 broadcast.
+const DefaultMulticastHost = "ff02::1"
+
 // Discover discovers lifx products in the lan.
 //
 // When broadcastHost is empty (""), DefaultBroadcastHost will be used instead.
 // In most cases that should just work.
 // But if your network has special settings, you can override it via the arg.
 //
-// The function will write discovered devices into devices channel.
+// The function will write discovered devices into devices channel,
+// deduped by their Target.
 // It's the caller's responsibility to read from channel timely to avoid
 // blocking writing.
 // The function is guaranteed to close the channel upon retuning,
@@ -50,73 +68,421 @@ const (
 // The function will only return upon error or when ctx is cancelled.
 // It's the caller's responsibility to make sure that the context is cancelled
 // (e.g. Use context.WithTimeout).
+// If you know how many devices to expect,
+// use DiscoverWithOptions with MaxDevices set to return early instead.
+//
+// It's the same as calling DiscoverWithOptions with only BroadcastHost set.
 func Discover(
 	ctx context.Context,
 	devices chan Device,
 	broadcastHost string,
 ) error {
-	defer close(devices)
+	return DiscoverWithOptions(ctx, devices, DiscoverOptions{
+		BroadcastHost: broadcastHost,
+	})
+}
+
+// DiscoverOptions defines the options used by DiscoverWithOptions.
+type DiscoverOptions struct {
+	// The network to discover devices on, one of:
+	//
+	//     - "" or "udp4": IPv4 broadcast only (the default)
+	//     - "udp6": IPv6 multicast only
+	//     - "udp": both IPv4 broadcast and IPv6 multicast
+	//
+	// IPv6 discovery sends the discovery message to DefaultMulticastHost,
+	// via Interface if it's set,
+	// or via all the up interfaces with multicast support otherwise.
+	Network string
+
+	// The host to broadcast the discovery message to,
+	// only used by IPv4 discovery.
+	//
+	// If it's empty,
+	// the broadcast address of Interface will be used if Interface is set,
+	// otherwise DefaultBroadcastHost will be used.
+	BroadcastHost string
+
+	// If Interface is non-nil,
+	// the listening socket will be bound to the first IPv4 address of the
+	// interface (only when Network is IPv4 only),
+	// and the discovery message will be broadcasted to the broadcast address of
+	// that IPv4 network (unless BroadcastHost is set).
+	// For IPv6 discovery it's the only interface to send the multicast on.
+	//
+	// This is useful on multi-homed hosts (e.g. with an active VPN),
+	// where the broadcast to DefaultBroadcastHost might go out from the wrong
+	// interface.
+	Interface *net.Interface
+
+	// The local address to bind the discovery socket to, in "host:port" format.
+	//
+	// The same socket is used to send the discovery message and to read the
+	// responses,
+	// as the devices reply to the source address of the discovery message.
+	//
+	// If it's empty,
+	// ":" + DefaultBroadcastPort will be used,
+	// with the host part derived from Interface as described above.
+	// If the host part is empty,
+	// the host derived from Interface (if any) will still be used.
+	// Use port "0" to bind to an ephemeral port.
+	//
+	// With a fixed port,
+	// the firewall (or the port mapping of the container) needs to allow
+	// inbound UDP traffic to that port from the devices,
+	// and only one process on the host can bind to it at a time,
+	// so pick a different port if other LIFX apps are running on the same host.
+	// With an ephemeral port,
+	// the replies are only received if the firewall tracks the outgoing
+	// broadcast as a connection,
+	// which is usually not the case for broadcasts or containers.
+	// When running in a Docker container with host networking,
+	// use a fixed port that's allowed by the firewall of the host.
+	ListenAddr string
+
+	// If MaxDevices > 0,
+	// the function returns nil error as soon as MaxDevices distinct devices
+	// (by Target) are discovered,
+	// without waiting for ctx to be cancelled.
+	// The devices failed Probe or not matching Capability are also counted.
+	MaxDevices int
+
+	// The number of times to send the discovery message,
+	// spaced by BroadcastRepeatInterval,
+	// to improve the odds that every device hears it on lossy networks
+	// (e.g. crowded 2.4GHz Wi-Fi).
+	// The responses are still deduped by Target.
+	//
+	// If it's <= 1, the discovery message is only sent once.
+	// Only the first send failing to all the destinations fails the discovery,
+	// failures of the repeated ones are only logged via DebugLogger.
+	Broadcasts int
+
+	// If Probe is non-nil,
+	// it's called with every discovered device before it's written into the
+	// devices channel,
+	// and only the devices that Probe returns nil error for will be written.
+	// It can be used to make sure that the devices answering the discovery
+	// message are also responding to other messages, e.g.:
+	//
+	//     Probe: func(ctx context.Context, d lifxlan.Device) error {
+	//       ctx, cancel := context.WithTimeout(ctx, time.Millisecond*500)
+	//       defer cancel()
+	//       _, err := d.GetHardwareVersion(ctx, nil)
+	//       return err
+	//     },
+	//
+	// Probes run concurrently in their own goroutines with ctx,
+	// so they don't block the discovery.
+	// The function waits for all the running probes before returning,
+	// and when ctx is cancelled the running probes fail with it,
+	// so every device answered the discovery message ends up in either devices
+	// or Failed.
+	// The probes should also use a timeout of their own.
+	Probe func(ctx context.Context, d Device) error
+
+	// If Capability is non-nil,
+	// GetHardwareVersion is called on every discovered device,
+	// and only the devices with a product in ProductMap that Capability
+	// returns true for will be written into the devices channel, e.g.:
+	//
+	//     Capability: func(c lifxlan.ProductCapabilities) bool {
+	//       return c.Matrix
+	//     },
+	//
+	// The capabilities are the ones without any firmware upgrades applied,
+	// the same as LookupProduct.
+	// The devices not matching, or with an unknown product,
+	// are dropped silently (but still counted by MaxDevices).
+	//
+	// It runs concurrently the same way as Probe, before Probe.
+	// The devices failed GetHardwareVersion are written into Failed.
+	Capability func(ProductCapabilities) bool
+
+	// If Failed is non-nil,
+	// the devices failed Probe will be written into it with the error from
+	// Probe,
+	// otherwise they are dropped silently.
+	//
+	// Similar to the devices channel,
+	// it's the caller's responsibility to read from both channels timely,
+	// and the function is guaranteed to close it upon returning.
+	Failed chan DiscoverResult
+}
+
+// MatchCapability fetches the hardware version of d via GetHardwareVersion,
+// and returns whether capability returns true for its product capabilities
+// (without any firmware upgrades applied, the same as LookupProduct).
+//
+// It returns false with nil error if the product is not in ProductMap.
+//
+// It's what DiscoverOptions.Capability uses to filter the discovered devices,
+// and can also be used on devices from DiscoverUnicast or FromAddr.
+func MatchCapability(
+	ctx context.Context,
+	d Device,
+	capability func(ProductCapabilities) bool,
+) (bool, error) {
+	if err := d.GetHardwareVersion(ctx, nil); err != nil {
+		return false, err
+	}
+	parsed := d.HardwareVersion().Parse()
+	if parsed == nil {
+		return false, nil
+	}
+	return capability(parsed.Features.Capabilities()), nil
+}
+
+// DiscoverResult defines a device answered the discovery message,
+// along with the error from further probing on it.
+type DiscoverResult struct {
+	Device Device
+	Err    error
+}
+
+// DiscoverWithOptions is the same as Discover,
+// but with more options.
+//
+// Please refer to the doc of Discover for more details.
+func DiscoverWithOptions(
+	ctx context.Context,
+	devices chan Device,
+	opts DiscoverOptions,
+) error {
+	defer func() {
+		close(devices)
+		if opts.Failed != nil {
+			close(opts.Failed)
+		}
+	}()
 
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
 
-	msg, err := GenerateMessage(
-		Tagged,
-		0, // source
+	// The default listens on both IPv4 and IPv6 for backward compatibility.
+	listenNetwork := "udp"
+	ipv4, ipv6 := true, false
+	switch opts.Network {
+	default:
+		return fmt.Errorf(
+			"lifxlan.DiscoverWithOptions: unknown network %q",
+			opts.Network,
+		)
+	case "":
+	case "udp4":
+		listenNetwork = "udp4"
+	case "udp6":
+		listenNetwork = "udp6"
+		ipv4, ipv6 = false, true
+	case "udp":
+		ipv6 = true
+	}
+
+	listenHost, listenPort := "", DefaultBroadcastPort
+	if opts.ListenAddr != "" {
+		host, port, err := net.SplitHostPort(opts.ListenAddr)
+		if err != nil {
+			return fmt.Errorf(
+				"lifxlan.DiscoverWithOptions: invalid ListenAddr %q: %w",
+				opts.ListenAddr,
+				err,
+			)
+		}
+		listenPort = port
+		listenHost = host
+	}
+	var dests []net.Addr
+	if ipv4 {
+		broadcastHost := opts.BroadcastHost
+		if opts.Interface != nil {
+			ip, broadcast, err := interfaceIPv4(opts.Interface)
+			if err != nil {
+				return err
+			}
+			if !ipv6 && listenHost == "" {
+				// Binding to an IPv4 address makes the socket IPv4 only.
+				listenHost = ip.String()
+			}
+			if broadcastHost == "" {
+				broadcastHost = broadcast.String()
+			}
+		}
+		if broadcastHost == "" {
+			broadcastHost = DefaultBroadcastHost
+		}
+
+		broadcast, err := net.ResolveUDPAddr(
+			"udp",
+			net.JoinHostPort(broadcastHost, DefaultBroadcastPort),
+		)
+		if err != nil {
+			return err
+		}
+		dests = append(dests, broadcast)
+	}
+	if ipv6 {
+		multicast, err := ipv6MulticastAddrs(opts.Interface)
+		if err != nil {
+			return err
+		}
+		dests = append(dests, multicast...)
+	}
+
+	return discover(
+		ctx,
+		devices,
+		listenNetwork,
+		net.JoinHostPort(listenHost, listenPort),
+		dests,
 		AllDevices,
-		0, // flags
-		0, // sequence
-		GetService,
-		nil, // payload
+		opts,
+		"lifxlan.DiscoverWithOptions",
 	)
-	if err != nil {
-		return err
-	}
+}
 
-	conn, err := net.ListenPacket("udp", ":"+DefaultBroadcastPort)
+// discover sends the discovery message to all dests from a socket bound to
+// listenAddr,
+// and handles the StateService responses matching target read from the same
+// socket,
+// until ctx is cancelled or opts.MaxDevices devices are found.
+//
+// It doesn't close the devices and opts.Failed channels,
+// but waits for all the running probes before returning.
+// The message is only considered failed to send when it cannot be sent to any
+// of the dests.
+func discover(
+	ctx context.Context,
+	devices chan Device,
+	listenNetwork string,
+	listenAddr string,
+	dests []net.Addr,
+	target Target,
+	opts DiscoverOptions,
+	caller string,
+) error {
+	msg, err := discoverMessage()
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	if broadcastHost == "" {
-		broadcastHost = DefaultBroadcastHost
-	}
-	broadcast, err := net.ResolveUDPAddr(
-		"udp",
-		net.JoinHostPort(broadcastHost, DefaultBroadcastPort),
-	)
+	conn, err := net.ListenPacket(listenNetwork, listenAddr)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
 
-	n, err := conn.WriteTo(msg, broadcast)
-	if err != nil {
-		return err
+	broadcast := func() (written int, writeErr error) {
+		for _, dest := range dests {
+			if err := writeMessage(conn, msg, dest, caller); err != nil {
+				debugf("%s: failed to send to %v: %v", caller, dest, err)
+				if writeErr == nil {
+					writeErr = err
+				}
+				continue
+			}
+			written++
+		}
+		return written, writeErr
 	}
-	if n < len(msg) {
-		return fmt.Errorf(
-			"lifxlan.Discover: only wrote %d out of %d bytes",
-			n,
-			len(msg),
-		)
+	if written, writeErr := broadcast(); written == 0 {
+		if writeErr == nil {
+			writeErr = fmt.Errorf("%s: no destinations to send to", caller)
+		}
+		return writeErr
+	}
+
+	var wg sync.WaitGroup
+	// Wait for the probes still writing into the channels,
+	// and the repeated broadcasts still writing to conn.
+	defer wg.Wait()
+
+	if opts.Broadcasts > 1 {
+		repeatCtx, cancel := context.WithCancel(ctx)
+		// Stop the repeated broadcasts before waiting for wg.
+		defer cancel()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			timer := time.NewTimer(BroadcastRepeatInterval)
+			defer timer.Stop()
+			for i := 1; i < opts.Broadcasts; i++ {
+				select {
+				case <-repeatCtx.Done():
+					return
+				case <-timer.C:
+				}
+				broadcast()
+				timer.Reset(BroadcastRepeatInterval)
+			}
+		}()
+	}
+	emit := func(d *device) {
+		if opts.Probe == nil && opts.Capability == nil {
+			devices <- d
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fail := func(err error) {
+				debugf("%s: probe on %v failed: %v", caller, d, err)
+				if opts.Failed != nil {
+					opts.Failed <- DiscoverResult{
+						Device: d,
+						Err:    err,
+					}
+				}
+			}
+			if opts.Capability != nil {
+				matched, err := MatchCapability(ctx, d, opts.Capability)
+				if err != nil {
+					fail(err)
+					return
+				}
+				if !matched {
+					debugf("%s: dropped %v: capability not matched", caller, d)
+					return
+				}
+			}
+			if opts.Probe != nil {
+				if err := opts.Probe(ctx, d); err != nil {
+					fail(err)
+					return
+				}
+			}
+			devices <- d
+		}()
 	}
 
-	buf := make([]byte, ResponseReadBufferSize)
+	// The devices found so far, deduped by Target.
+	//
+	// found and others are only accessed by this read loop,
+	// which handles one response at a time,
+	// and the probe goroutines only get the device after it's added to found,
+	// so every Target is emitted at most once without any locking.
+	// The services added to a device already emitted are guarded by the
+	// device's own lock.
+	found := make(map[Target]*device)
+	// The non-UDP services from devices not found yet.
+	others := make(map[Target][]Service)
+	buf := make([]byte, ReadBufferSize(ctx))
 	for {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
-		if err := conn.SetReadDeadline(GetReadDeadline()); err != nil {
+		if err := conn.SetReadDeadline(GetReadDeadlineContext(ctx)); err != nil {
 			return err
 		}
+		stop := interruptReadOnDone(ctx, conn)
 		n, addr, err := conn.ReadFrom(buf)
+		stop()
 		if err != nil {
 			if CheckTimeoutError(err) {
 				continue
@@ -124,34 +490,418 @@ func Discover(
 			return err
 		}
 
-		host, _, err := net.SplitHostPort(addr.String())
+		host, t, service, err := parseService(buf[:n], addr)
 		if err != nil {
-			return err
+			// Stray packets (e.g. mDNS/SSDP broadcasts) on the discovery socket.
+			debugf("%s: ignored invalid response from %v: %v", caller, addr, err)
+			continue
 		}
+		if service == nil || !target.Matches(t) {
+			continue
+		}
+		if d, ok := found[t]; ok {
+			d.addService(*service)
+			continue
+		}
+		if service.Type != ServiceUDP {
+			others[t] = append(others[t], *service)
+			continue
+		}
+		device := newDevice(
+			net.JoinHostPort(host, fmt.Sprintf("%d", service.Port)),
+			service.Type,
+			t,
+		)
+		for _, s := range others[t] {
+			device.addService(s)
+		}
+		delete(others, t)
+		found[t] = device
+		emit(device)
+		if opts.MaxDevices > 0 && len(found) >= opts.MaxDevices {
+			return nil
+		}
+		if target != AllDevices {
+			// There's only one device with the target.
+			return nil
+		}
+	}
+}
+
+// DiscoverAllInterfaces is similar to Discover,
+// but broadcasts the discovery message on all the usable IPv4 interfaces,
+// instead of only DefaultBroadcastHost.
+//
+// The usable interfaces are the ones that are up, non-loopback,
+// and support broadcast, with an IPv4 address.
+// The discovery message is sent to the broadcast address of every one of
+// them,
+// and the responses from all of them are deduped by Target.
+// Interfaces failing to be used (e.g. no IPv4 address or failed to send)
+// are logged via DebugLogger and skipped,
+// it only returns an error if none of them can be used.
+//
+// This is useful on multi-homed hosts when it's unknown which network the
+// devices are on.
+//
+// If target is not AllDevices,
+// only the device with the target will be written into devices channel,
+// and the function returns nil error as soon as it's found.
+//
+// Same as Discover,
+// it's guaranteed to close the devices channel upon returning.
+func DiscoverAllInterfaces(ctx context.Context, devices chan Device, target Target) error {
+	defer close(devices)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-		resp, err := ParseResponse(buf[:n])
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+	var dests []net.Addr
+	seen := make(map[string]bool)
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if iface.Flags&net.FlagUp == 0 ||
+			iface.Flags&net.FlagBroadcast == 0 ||
+			iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		_, broadcast, err := interfaceIPv4(iface)
 		if err != nil {
-			return err
+			debugf("DiscoverAllInterfaces: skipping interface %q: %v", iface.Name, err)
+			continue
+		}
+		addr := net.JoinHostPort(broadcast.String(), DefaultBroadcastPort)
+		if seen[addr] {
+			continue
 		}
-		if resp.Message != StateService {
+		seen[addr] = true
+		dest, err := net.ResolveUDPAddr("udp4", addr)
+		if err != nil {
+			debugf("DiscoverAllInterfaces: skipping interface %q: %v", iface.Name, err)
 			continue
 		}
+		dests = append(dests, dest)
+	}
+	if len(dests) == 0 {
+		return errors.New("lifxlan.DiscoverAllInterfaces: no usable interfaces found")
+	}
 
-		var d RawStateServicePayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
-			return err
+	return discover(
+		ctx,
+		devices,
+		"udp4",
+		":"+DefaultBroadcastPort, // listenAddr
+		dests,
+		target,
+		DiscoverOptions{},
+		"lifxlan.DiscoverAllInterfaces",
+	)
+}
+
+// DiscoverAll runs Discover for timeout,
+// and returns all the discovered devices (deduped by their Target) as a slice.
+//
+// When timeout is reached,
+// it returns the devices found so far with nil error.
+// If ctx is cancelled before that,
+// it returns the devices found so far with ctx.Err().
+//
+// E.g. to list all the devices in the lan:
+//
+//     devices, err := lifxlan.DiscoverAll(context.Background(), time.Second)
+//     if err != nil {
+//       // handle error
+//     }
+//     for _, device := range devices {
+//       // Do something with device
+//     }
+func DiscoverAll(ctx context.Context, timeout time.Duration) ([]Device, error) {
+	discoverCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	devices := make(chan Device)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- Discover(discoverCtx, devices, "")
+	}()
+
+	var found []Device
+	for device := range devices {
+		found = append(found, device)
+	}
+	err := <-errChan
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		// Only discoverCtx timed out.
+		err = nil
+	}
+	return found, err
+}
+
+// DiscoverUnicast sends the discovery message (GetService) directly to addr,
+// and returns the device from the StateService response.
+//
+// addr should be in "host:port" format.
+// The returned device uses the host from addr,
+// and the port from the StateService response.
+//
+// If target is AllDevices, any device responding on addr will be returned.
+// Otherwise only the device matching target will respond.
+//
+// The function returns an error if no StateService response arrives before
+// ctx is cancelled,
+// so it's important to set an appropriate timeout on the context.
+func DiscoverUnicast(ctx context.Context, addr string, target Target) (Device, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	source := RandomSource()
+	msg, err := GenerateMessage(
+		TaggedFor(target),
+		source,
+		target,
+		0, // flags
+		0, // sequence
+		GetService,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	n, err := conn.Write(msg)
+	if err != nil {
+		return nil, err
+	}
+	if n < len(msg) {
+		return nil, fmt.Errorf(
+			"lifxlan.DiscoverUnicast: only wrote %d out of %d bytes",
+			n,
+			len(msg),
+		)
+	}
+
+	// The non-UDP services received before the UDP one.
+	var others []Service
+	for {
+		resps, err := ReadNextResponses(ctx, conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf(
+					"lifxlan.DiscoverUnicast: no StateService response from %s: %w",
+					addr,
+					err,
+				)
+			}
+			return nil, err
 		}
-		switch d.Service {
-		default:
-			// Unknown service, ignore.
-			continue
-		case ServiceUDP:
-			devices <- NewDevice(
-				net.JoinHostPort(host, fmt.Sprintf("%d", d.Port)),
-				d.Service,
+		for _, resp := range resps {
+			if resp.Source != source || resp.Message != StateService {
+				continue
+			}
+
+			var raw RawStateServicePayload
+			r := bytes.NewReader(resp.Payload)
+			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+				return nil, err
+			}
+			if raw.Service != ServiceUDP {
+				others = append(others, Service{
+					Type: raw.Service,
+					Port: raw.Port,
+				})
+				continue
+			}
+			device := newDevice(
+				net.JoinHostPort(host, fmt.Sprintf("%d", raw.Port)),
+				raw.Service,
 				resp.Target,
 			)
+			for _, s := range others {
+				device.addService(s)
+			}
+			return device, nil
+		}
+	}
+}
+
+// discoverMessage generates the GetService message to be broadcasted for
+// discovery.
+func discoverMessage() ([]byte, error) {
+	return GenerateMessage(
+		Tagged,
+		0, // source
+		AllDevices,
+		0, // flags
+		0, // sequence
+		GetService,
+		nil, // payload
+	)
+}
+
+// writeMessage writes msg to addr via conn,
+// and returns an error prefixed by caller if it's not fully written.
+func writeMessage(conn net.PacketConn, msg []byte, addr net.Addr, caller string) error {
+	n, err := conn.WriteTo(msg, addr)
+	if err != nil {
+		return err
+	}
+	if n < len(msg) {
+		return fmt.Errorf(
+			"%s: only wrote %d out of %d bytes",
+			caller,
+			n,
+			len(msg),
+		)
+	}
+	return nil
+}
+
+// parseStateService parses a discovery response read from addr.
+//
+// It returns nil Device and nil error if the response is not a StateService
+// message with UDP service.
+func parseStateService(buf []byte, addr net.Addr) (Device, error) {
+	host, target, service, err := parseService(buf, addr)
+	if err != nil || service == nil {
+		return nil, err
+	}
+	switch service.Type {
+	default:
+		// Unknown service, ignore.
+		return nil, nil
+	case ServiceUDP:
+		return NewDevice(
+			net.JoinHostPort(host, fmt.Sprintf("%d", service.Port)),
+			service.Type,
+			target,
+		), nil
+	}
+}
+
+// parseService parses a discovery response read from addr,
+// and returns the host from addr, the target and the service from the
+// response.
+//
+// It returns nil Service and nil error if the response is not a StateService
+// message.
+func parseService(buf []byte, addr net.Addr) (host string, target Target, service *Service, err error) {
+	host, _, err = net.SplitHostPort(addr.String())
+	if err != nil {
+		return
+	}
+
+	resp, err := ParseResponse(buf)
+	if err != nil {
+		return
+	}
+	if resp.Message != StateService {
+		return
+	}
+
+	var d RawStateServicePayload
+	r := bytes.NewReader(resp.Payload)
+	if err = binary.Read(r, binary.LittleEndian, &d); err != nil {
+		return
+	}
+	target = resp.Target
+	service = &Service{
+		Type: d.Service,
+		Port: d.Port,
+	}
+	return
+}
+
+// interfaceIPv4 returns the first IPv4 address of iface,
+// and the broadcast address of its network.
+func interfaceIPv4(iface *net.Interface) (ip, broadcast net.IP, err error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip4 := ipnet.IP.To4()
+		if ip4 == nil {
+			continue
+		}
+		mask := ipnet.Mask
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+		if len(mask) != net.IPv4len {
+			continue
 		}
+		broadcast = make(net.IP, net.IPv4len)
+		for i := range ip4 {
+			broadcast[i] = ip4[i] | ^mask[i]
+		}
+		return ip4, broadcast, nil
+	}
+	return nil, nil, fmt.Errorf(
+		"lifxlan.DiscoverWithOptions: no IPv4 address found on interface %q",
+		iface.Name,
+	)
+}
+
+// ipv6MulticastAddrs returns the DefaultMulticastHost addrs to send the
+// discovery message to for IPv6 discovery,
+// one for each interface with multicast support,
+// or just the one for iface if it's non-nil.
+func ipv6MulticastAddrs(iface *net.Interface) ([]net.Addr, error) {
+	var ifaces []net.Interface
+	if iface != nil {
+		ifaces = append(ifaces, *iface)
+	} else {
+		all, err := net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range all {
+			if i.Flags&net.FlagUp != 0 && i.Flags&net.FlagMulticast != 0 {
+				ifaces = append(ifaces, i)
+			}
+		}
+	}
+
+	addrs := make([]net.Addr, 0, len(ifaces))
+	for _, i := range ifaces {
+		addr, err := net.ResolveUDPAddr(
+			"udp6",
+			net.JoinHostPort(DefaultMulticastHost+"%"+i.Name, DefaultBroadcastPort),
+		)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New(
+			"lifxlan.DiscoverWithOptions: no interface with multicast support found",
+		)
 	}
+	return addrs, nil
 }
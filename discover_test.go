@@ -0,0 +1,651 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestDiscoverUnicast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.RemoteAddr().String()
+	conn.Close()
+
+	service.Handlers[lifxlan.GetService] = stateServiceHandler(t)
+
+	for _, c := range []struct {
+		label  string
+		target lifxlan.Target
+	}{
+		{
+			label:  "AllDevices",
+			target: lifxlan.AllDevices,
+		},
+		{
+			label:  "Target",
+			target: mock.Target,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				d, err := lifxlan.DiscoverUnicast(ctx, addr, c.target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d.Target() != mock.Target {
+					t.Errorf("Target expected %v, got %v", mock.Target, d.Target())
+				}
+				if got := d.Addr().String(); got != addr {
+					t.Errorf("Addr expected %q, got %q", addr, got)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"NoResponse",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			// The mock service ignores messages with unmatched targets.
+			_, err := lifxlan.DiscoverUnicast(ctx, addr, mock.Target+1)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+}
+
+// stateServiceHandler returns a mock.HandlerFunc that responds GetService with
+// the port of the mock service.
+func stateServiceHandler(t *testing.T) mock.HandlerFunc {
+	return func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		_, port, err := net.SplitHostPort(conn.LocalAddr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := strconv.ParseUint(port, 10, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, lifxlan.RawStateServicePayload{
+			Service: lifxlan.ServiceUDP,
+			Port:    uint32(p),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		s.Reply(conn, addr, orig, lifxlan.StateService, buf.Bytes())
+	}
+}
+
+func TestIPv6(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	// Make sure IPv6 loopback is available.
+	probe, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	probe.Close()
+
+	service := &mock.Service{
+		TB:         t,
+		ListenAddr: "[::1]:",
+		Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+		HandleAcks: true,
+	}
+	service.Handlers[lifxlan.GetService] = stateServiceHandler(t)
+	device := service.Start()
+	defer service.Stop()
+
+	addr := device.Addr().String()
+	if udp, ok := device.Addr().(*net.UDPAddr); !ok || udp.IP.To4() != nil {
+		t.Fatalf("Expected IPv6 *net.UDPAddr, got %T %v", device.Addr(), device.Addr())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	d, err := lifxlan.DiscoverUnicast(ctx, addr, lifxlan.AllDevices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Addr().String(); got != addr {
+		t.Errorf("Addr expected %q, got %q", addr, got)
+	}
+
+	d, err = lifxlan.FromAddr(addr, lifxlan.ServiceUDP, mock.Target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetPower(ctx, nil, lifxlan.PowerOn, true); err != nil {
+		t.Errorf("SetPower over IPv6 failed: %v", err)
+	}
+}
+
+func TestDiscoverWithOptionsNetwork(t *testing.T) {
+	devices := make(chan lifxlan.Device)
+	err := lifxlan.DiscoverWithOptions(context.Background(), devices, lifxlan.DiscoverOptions{
+		Network: "tcp",
+	})
+	if err == nil {
+		t.Error("Expected error with unknown network")
+	}
+	if _, ok := <-devices; ok {
+		t.Error("Expected devices channel to be closed")
+	}
+}
+
+func TestDiscoverWithOptionsProbe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	devices := make(chan lifxlan.Device)
+	failed := make(chan lifxlan.DiscoverResult)
+	err := lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+		Probe: func(ctx context.Context, d lifxlan.Device) error {
+			t.Errorf("Probe called on %v with cancelled context", d)
+			return nil
+		},
+		Failed: failed,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, ok := <-devices; ok {
+		t.Error("Expected devices channel to be closed")
+	}
+	if _, ok := <-failed; ok {
+		t.Error("Expected failed channel to be closed")
+	}
+}
+
+func TestDiscoverAllCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	devices, err := lifxlan.DiscoverAll(ctx, time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(devices) != 0 {
+		t.Errorf("Expected no devices, got %v", devices)
+	}
+}
+
+func TestDiscoverAllInterfacesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	devices := make(chan lifxlan.Device)
+	err := lifxlan.DiscoverAllInterfaces(ctx, devices, lifxlan.AllDevices)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, ok := <-devices; ok {
+		t.Error("Expected devices channel to be closed")
+	}
+}
+
+func TestMatchCapability(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	matrix := func(c lifxlan.ProductCapabilities) bool {
+		return c.Matrix
+	}
+
+	for _, c := range []struct {
+		label    string
+		product  uint32
+		expected bool
+	}{
+		{
+			label:    "Tile",
+			product:  55,
+			expected: true,
+		},
+		{
+			label:    "Original",
+			product:  1,
+			expected: false,
+		},
+		{
+			label:    "Unknown",
+			product:  0xffff,
+			expected: false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service := &mock.Service{
+					TB:         t,
+					Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+					HandleAcks: true,
+					RawStateVersionPayload: &lifxlan.RawStateVersionPayload{
+						Version: lifxlan.HardwareVersion{
+							VendorID:  1,
+							ProductID: c.product,
+						},
+					},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				matched, err := lifxlan.MatchCapability(ctx, device, matrix)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if matched != c.expected {
+					t.Errorf("MatchCapability expected %v, got %v", c.expected, matched)
+				}
+			},
+		)
+	}
+}
+
+func TestDiscoverWithOptionsCapability(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	devices := make(chan lifxlan.Device)
+	err := lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+		Capability: func(c lifxlan.ProductCapabilities) bool {
+			t.Error("Capability called with cancelled context")
+			return true
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, ok := <-devices; ok {
+		t.Error("Expected devices channel to be closed")
+	}
+}
+
+func TestDiscoverWithOptionsListenAddr(t *testing.T) {
+	const timeout = time.Millisecond * 200
+
+	t.Run(
+		"Invalid",
+		func(t *testing.T) {
+			devices := make(chan lifxlan.Device)
+			err := lifxlan.DiscoverWithOptions(context.Background(), devices, lifxlan.DiscoverOptions{
+				ListenAddr: "no-port",
+			})
+			if err == nil {
+				t.Error("Expected error with invalid ListenAddr")
+			}
+			if _, ok := <-devices; ok {
+				t.Error("Expected devices channel to be closed")
+			}
+		},
+	)
+
+	t.Run(
+		"InUse",
+		func(t *testing.T) {
+			taken, err := net.ListenPacket("udp4", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer taken.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			devices := make(chan lifxlan.Device)
+			err = lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+				Network:       "udp4",
+				BroadcastHost: "127.0.0.1",
+				ListenAddr:    taken.LocalAddr().String(),
+			})
+			if err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected bind error on %v, got %v", taken.LocalAddr(), err)
+			}
+			if _, ok := <-devices; ok {
+				t.Error("Expected devices channel to be closed")
+			}
+		},
+	)
+}
+
+func TestDiscoverDuplicates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 300
+		targets = 5
+		repeats = 50
+	)
+
+	for _, c := range []struct {
+		label string
+		probe func(context.Context, lifxlan.Device) error
+	}{
+		{
+			label: "NoProbe",
+		},
+		{
+			label: "Probe",
+			probe: func(context.Context, lifxlan.Device) error {
+				return nil
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				// Find a free port for the discovery socket.
+				tmp, err := net.ListenPacket("udp4", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				listenAddr := tmp.LocalAddr().String()
+				tmp.Close()
+				dest, err := net.ResolveUDPAddr("udp4", listenAddr)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				sender, err := net.ListenPacket("udp4", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer sender.Close()
+
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, lifxlan.RawStateServicePayload{
+					Service: lifxlan.ServiceUDP,
+					Port:    56700,
+				}); err != nil {
+					t.Fatal(err)
+				}
+				msgs := make([][]byte, targets)
+				for i := range msgs {
+					msgs[i], err = lifxlan.GenerateMessage(
+						lifxlan.NotTagged,
+						0, // source
+						lifxlan.Target(i+1),
+						0, // flags
+						0, // sequence
+						lifxlan.StateService,
+						buf.Bytes(),
+					)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				devices := make(chan lifxlan.Device)
+				errChan := make(chan error, 1)
+				go func() {
+					errChan <- lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+						Network:       "udp4",
+						BroadcastHost: "127.0.0.1",
+						ListenAddr:    listenAddr,
+						Probe:         c.probe,
+					})
+				}()
+
+				go func() {
+					// Keep firing until the discovery socket is surely up.
+					for r := 0; r < repeats && ctx.Err() == nil; r++ {
+						for _, msg := range msgs {
+							for i := 0; i < repeats; i++ {
+								sender.WriteTo(msg, dest)
+							}
+						}
+						time.Sleep(time.Millisecond)
+					}
+				}()
+
+				seen := make(map[lifxlan.Target]int)
+				for d := range devices {
+					seen[d.Target()]++
+				}
+				if err := <-errChan; !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+				}
+				if len(seen) != targets {
+					t.Errorf("Expected %d devices, got %v", targets, seen)
+				}
+				for target, n := range seen {
+					if n != 1 {
+						t.Errorf("%v emitted %d times", target, n)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestDiscoverWithOptionsBroadcasts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout    = time.Millisecond * 300
+		broadcasts = 3
+	)
+
+	defer func(orig time.Duration) {
+		lifxlan.BroadcastRepeatInterval = orig
+	}(lifxlan.BroadcastRepeatInterval)
+	lifxlan.BroadcastRepeatInterval = time.Millisecond * 20
+
+	// A fake device listening on the broadcast port,
+	// answering every GetService with a StateService.
+	fake, err := net.ListenPacket("udp4", net.JoinHostPort("127.0.0.1", lifxlan.DefaultBroadcastPort))
+	if err != nil {
+		t.Skipf("Cannot listen on the broadcast port: %v", err)
+	}
+	defer fake.Close()
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, lifxlan.RawStateServicePayload{
+		Service: lifxlan.ServiceUDP,
+		Port:    56700,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := lifxlan.GenerateMessage(
+		lifxlan.NotTagged,
+		0, // source
+		mock.Target,
+		0, // flags
+		0, // sequence
+		lifxlan.StateService,
+		buf.Bytes(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan int, 1)
+	go func() {
+		var n int
+		defer func() {
+			received <- n
+		}()
+		buf := make([]byte, lifxlan.ResponseReadBufferSize)
+		for {
+			size, addr, err := fake.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp, err := lifxlan.ParseResponse(buf[:size])
+			if err != nil || resp.Message != lifxlan.GetService {
+				continue
+			}
+			n++
+			fake.WriteTo(reply, addr)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	devices := make(chan lifxlan.Device)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+			Network:       "udp4",
+			BroadcastHost: "127.0.0.1",
+			ListenAddr:    "127.0.0.1:0",
+			Broadcasts:    broadcasts,
+		})
+	}()
+
+	var found []lifxlan.Device
+	for d := range devices {
+		found = append(found, d)
+	}
+	if err := <-errChan; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(found) != 1 || found[0].Target() != mock.Target {
+		t.Errorf("Expected only %v, got %v", mock.Target, found)
+	}
+
+	fake.Close()
+	if n := <-received; n != broadcasts {
+		t.Errorf("Expected %d GetService messages, got %d", broadcasts, n)
+	}
+}
+
+func TestDiscoverStrayPacket(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 300
+		repeats = 50
+	)
+
+	// Find a free port for the discovery socket.
+	tmp, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listenAddr := tmp.LocalAddr().String()
+	tmp.Close()
+	dest, err := net.ResolveUDPAddr("udp4", listenAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, lifxlan.RawStateServicePayload{
+		Service: lifxlan.ServiceUDP,
+		Port:    56700,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := lifxlan.GenerateMessage(
+		lifxlan.NotTagged,
+		0, // source
+		mock.Target,
+		0, // flags
+		0, // sequence
+		lifxlan.StateService,
+		buf.Bytes(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An SSDP packet on the discovery socket.
+	stray := []byte("M-SEARCH * HTTP/1.1\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	devices := make(chan lifxlan.Device)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- lifxlan.DiscoverWithOptions(ctx, devices, lifxlan.DiscoverOptions{
+			Network:       "udp4",
+			BroadcastHost: "127.0.0.1",
+			ListenAddr:    listenAddr,
+		})
+	}()
+
+	go func() {
+		// Keep firing until the discovery socket is surely up,
+		// always sending the stray packet first.
+		for r := 0; r < repeats && ctx.Err() == nil; r++ {
+			sender.WriteTo(stray, dest)
+			sender.WriteTo(msg, dest)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	var found []lifxlan.Target
+	for d := range devices {
+		found = append(found, d.Target())
+	}
+	if err := <-errChan; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(found) != 1 || found[0] != mock.Target {
+		t.Errorf("Expected to find %v, got %v", mock.Target, found)
+	}
+}
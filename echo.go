@@ -7,69 +7,91 @@ import (
 	"errors"
 	"math/rand"
 	"net"
+	"time"
 )
 
+// EchoPayloadLength is the length of the payload in EchoRequest and
+// EchoResponse messages.
+const EchoPayloadLength = 64
+
+// RawEchoRequestPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/querying-the-device-for-data#echorequest---packet-58
+type RawEchoRequestPayload struct {
+	Echoing [EchoPayloadLength]byte
+}
+
+// RawEchoResponsePayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#echoresponse---packet-59
 type RawEchoResponsePayload struct {
-	Echoing [64]byte
+	Echoing [EchoPayloadLength]byte
 }
 
-func (d *device) Echo(ctx context.Context, conn net.Conn) error {
+func (d *device) Echo(ctx context.Context, conn net.Conn, payload []byte) (time.Duration, error) {
 	if ctx.Err() != nil {
-		return ctx.Err()
+		return 0, ctx.Err()
 	}
 
 	if conn == nil {
 		newConn, err := d.Dial()
 		if err != nil {
-			return err
+			return 0, err
 		}
 		defer newConn.Close()
 		conn = newConn
 
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return 0, ctx.Err()
 		}
 	}
 
-	payload := make([]byte, 64)
-	rand.Read(payload)
+	var req RawEchoRequestPayload
+	if payload == nil {
+		rand.Read(req.Echoing[:])
+	} else {
+		copy(req.Echoing[:], payload)
+	}
 
+	start := time.Now()
 	seq, err := d.Send(
 		ctx,
 		conn,
 		0, // flags
 		EchoRequest,
-		payload,
+		&req,
 	)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	for {
-		resp, err := ReadNextResponse(ctx, conn)
-		if err != nil {
-			return err
-		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-		if resp.Message != EchoResponse {
-			continue
-		}
-
-		var raw RawEchoResponsePayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return err
-		}
-
-		var expected [64]byte
-		copy(expected[:], payload)
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		EchoResponse,
+		1, // count
+	)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	if m := MetricsRecorder; m != nil {
+		m.ObserveRTT(rtt)
+	}
 
-		if raw.Echoing != expected {
-			return errors.New("unexpected echo response value")
-		}
+	var raw RawEchoResponsePayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
+	}
 
-		return nil
+	if raw.Echoing != req.Echoing {
+		return 0, errors.New("lifxlan.Device.Echo: unexpected echo response value")
 	}
+
+	return rtt, nil
 }
@@ -2,9 +2,11 @@ package lifxlan_test
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
+	"go.yhsif.com/lifxlan"
 	"go.yhsif.com/lifxlan/mock"
 )
 
@@ -18,11 +20,62 @@ func TestEcho(t *testing.T) {
 	service, device := mock.StartService(t)
 	defer service.Stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	for _, c := range []struct {
+		label   string
+		payload []byte
+	}{
+		{
+			label:   "Random",
+			payload: nil,
+		},
+		{
+			label:   "Short",
+			payload: []byte("foo"),
+		},
+		{
+			label:   "Long",
+			payload: make([]byte, lifxlan.EchoPayloadLength*2),
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
 
-	err := device.Echo(ctx, nil)
-	if err != nil {
-		t.Fatal(err)
+				rtt, err := device.Echo(ctx, nil, c.payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rtt <= 0 || rtt > timeout {
+					t.Errorf("Unexpected rtt: %v", rtt)
+				}
+			},
+		)
 	}
+
+	t.Run(
+		"Mismatch",
+		func(t *testing.T) {
+			service.Handlers[lifxlan.EchoRequest] = func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				s.Reply(conn, addr, orig, lifxlan.EchoResponse, make([]byte, lifxlan.EchoPayloadLength))
+			}
+			defer delete(service.Handlers, lifxlan.EchoRequest)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := device.Echo(ctx, nil, []byte("foo")); err == nil {
+				t.Error("Expected error for mismatched echo response, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
 }
@@ -184,14 +184,106 @@ type Product struct {
 //
 // Calling with zero firmware will return the same features as the Features
 // field.
+//
+// Upgrades are expected to be sorted in descending order (newest first),
+// as generated by gen-product-map,
+// so that newer upgrades take precedence over older ones.
 func (p Product) FeaturesAt(firmware FirmwareUpgrade) Features {
 	features := make([]Features, 0, len(p.Upgrades)+1)
 	for _, u := range p.Upgrades {
 		if firmware.Less(u) {
-			break
+			continue
 		}
 		features = append(features, u.Features)
 	}
 	features = append(features, p.Features)
 	return MergeFeatures(features...)
 }
+
+// ProductCapabilities defines the capabilities of a product as plain values,
+// with unset features treated as false.
+type ProductCapabilities struct {
+	HEV               bool
+	Color             bool
+	Chain             bool
+	Matrix            bool
+	Relays            bool
+	Buttons           bool
+	Infrared          bool
+	Multizone         bool
+	ExtendedMultizone bool
+
+	// Both are 0 when the product has no valid temperature range
+	// (e.g. not a light device).
+	MinKelvin uint16
+	MaxKelvin uint16
+}
+
+// IsLight returns true if the product is a light,
+// which has color or a valid temperature range.
+func (c ProductCapabilities) IsLight() bool {
+	return c.Color || c.MaxKelvin > 0
+}
+
+// IsRelay returns true if the product is relay-only (e.g. LIFX Switch),
+// which has relays but is not a light (see IsLight),
+// so no light APIs (e.g. GetColor) should be called on it.
+func (c ProductCapabilities) IsRelay() bool {
+	return c.Relays && !c.IsLight()
+}
+
+// Capabilities converts features into ProductCapabilities.
+func (f Features) Capabilities() ProductCapabilities {
+	return ProductCapabilities{
+		HEV:               f.HEV.Get(),
+		Color:             f.Color.Get(),
+		Chain:             f.Chain.Get(),
+		Matrix:            f.Matrix.Get(),
+		Relays:            f.Relays.Get(),
+		Buttons:           f.Buttons.Get(),
+		Infrared:          f.Infrared.Get(),
+		Multizone:         f.Multizone.Get(),
+		ExtendedMultizone: f.ExtendedMultizone.Get(),
+
+		MinKelvin: f.TemperatureRange.Min(),
+		MaxKelvin: f.TemperatureRange.Max(),
+	}
+}
+
+// ProductInfo defines the name and capabilities of a product.
+type ProductInfo struct {
+	VendorID    uint32
+	VendorName  string
+	ProductID   uint32
+	ProductName string
+
+	ProductCapabilities
+}
+
+// LookupProduct looks up the product from ProductMap,
+// and returns its name and capabilities.
+//
+// The capabilities are the ones without any firmware upgrades applied.
+// Some capabilities (e.g. ExtendedMultizone) are only available with newer
+// firmwares, use Product.FeaturesAt(firmware).Capabilities() instead if you
+// know the firmware version of the device.
+//
+// It returns an error if the product is not in ProductMap.
+func LookupProduct(vendor, product uint32) (*ProductInfo, error) {
+	p, ok := ProductMap[ProductMapKey(vendor, product)]
+	if !ok {
+		return nil, fmt.Errorf(
+			"lifxlan.LookupProduct: unknown product (%d, %d)",
+			vendor,
+			product,
+		)
+	}
+	return &ProductInfo{
+		VendorID:    p.VendorID,
+		VendorName:  p.VendorName,
+		ProductID:   p.ProductID,
+		ProductName: p.ProductName,
+
+		ProductCapabilities: p.Features.Capabilities(),
+	}, nil
+}
@@ -0,0 +1,169 @@
+package lifxlan_test
+
+import (
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func TestLookupProduct(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		vendor   uint32
+		product  uint32
+		name     string
+		expected lifxlan.ProductCapabilities
+	}{
+		{
+			label:   "LIFX Z",
+			vendor:  1,
+			product: 32,
+			name:    "LIFX Z",
+			expected: lifxlan.ProductCapabilities{
+				Color:     true,
+				Multizone: true,
+				MinKelvin: 2500,
+				MaxKelvin: 9000,
+			},
+		},
+		{
+			label:   "LIFX Tile",
+			vendor:  1,
+			product: 55,
+			name:    "LIFX Tile",
+			expected: lifxlan.ProductCapabilities{
+				Color:     true,
+				Chain:     true,
+				Matrix:    true,
+				MinKelvin: 2500,
+				MaxKelvin: 9000,
+			},
+		},
+		{
+			label:   "LIFX Switch",
+			vendor:  1,
+			product: 70,
+			name:    "LIFX Switch",
+			expected: lifxlan.ProductCapabilities{
+				Relays:  true,
+				Buttons: true,
+			},
+		},
+		{
+			label:   "LIFX Clean",
+			vendor:  1,
+			product: 90,
+			name:    "LIFX Clean",
+			expected: lifxlan.ProductCapabilities{
+				HEV:       true,
+				Color:     true,
+				MinKelvin: 1500,
+				MaxKelvin: 9000,
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				info, err := lifxlan.LookupProduct(c.vendor, c.product)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.VendorID != c.vendor || info.ProductID != c.product {
+					t.Errorf(
+						"IDs expected (%d, %d), got (%d, %d)",
+						c.vendor,
+						c.product,
+						info.VendorID,
+						info.ProductID,
+					)
+				}
+				if info.VendorName != "LIFX" {
+					t.Errorf("VendorName expected %q, got %q", "LIFX", info.VendorName)
+				}
+				if info.ProductName != c.name {
+					t.Errorf("ProductName expected %q, got %q", c.name, info.ProductName)
+				}
+				if info.ProductCapabilities != c.expected {
+					t.Errorf("Capabilities expected %+v, got %+v", c.expected, info.ProductCapabilities)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"Unknown",
+		func(t *testing.T) {
+			if info, err := lifxlan.LookupProduct(1, 0); err == nil {
+				t.Errorf("Expected error, got %+v", info)
+			}
+		},
+	)
+
+	t.Run(
+		"FeaturesAt",
+		func(t *testing.T) {
+			p := lifxlan.ProductMap[lifxlan.ProductMapKey(1, 32)]
+			caps := p.FeaturesAt(lifxlan.FirmwareUpgrade{Major: 2, Minor: 80}).Capabilities()
+			if !caps.ExtendedMultizone {
+				t.Error("Expected extended multizone with firmware 2.80")
+			}
+			if caps.MinKelvin != 1500 {
+				t.Errorf("MinKelvin expected 1500 with firmware 2.80, got %d", caps.MinKelvin)
+			}
+		},
+	)
+}
+
+func TestProductCapabilitiesPredicates(t *testing.T) {
+	for _, c := range []struct {
+		label   string
+		product uint32
+		light   bool
+		relay   bool
+	}{
+		{
+			label:   "LIFX Switch 70",
+			product: 70,
+			relay:   true,
+		},
+		{
+			label:   "LIFX Switch 71",
+			product: 71,
+			relay:   true,
+		},
+		{
+			label:   "LIFX Switch 89",
+			product: 89,
+			relay:   true,
+		},
+		{
+			label:   "LIFX Z",
+			product: 32,
+			light:   true,
+		},
+		{
+			label:   "LIFX Candle White to Warm",
+			product: 81,
+			light:   true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				info, err := lifxlan.LookupProduct(1, c.product)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.IsLight(); got != c.light {
+					t.Errorf("IsLight expected %v, got %v", c.light, got)
+				}
+				if got := info.IsRelay(); got != c.relay {
+					t.Errorf("IsRelay expected %v, got %v", c.relay, got)
+				}
+			},
+		)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"net"
+	"time"
 )
 
 // RawStateHostFirmwarePayload defines the struct to be used for encoding and
@@ -12,7 +14,8 @@ import (
 //
 // https://lan.developer.lifx.com/docs/information-messages#statehostfirmware---packet-15
 type RawStateHostFirmwarePayload struct {
-	_            uint64  // build
+	// The build timestamp, in nanoseconds since epoch.
+	Build        uint64
 	_            [8]byte // reserved
 	VersionMinor uint16
 	VersionMajor uint16
@@ -27,25 +30,59 @@ func (raw RawStateHostFirmwarePayload) ToFirmware() FirmwareUpgrade {
 	}
 }
 
+// ToHostFirmware converts RawStateHostFirmwarePayload into Firmware.
+func (raw RawStateHostFirmwarePayload) ToHostFirmware() *Firmware {
+	return &Firmware{
+		VersionMajor: raw.VersionMajor,
+		VersionMinor: raw.VersionMinor,
+		Build:        time.Unix(0, int64(raw.Build)).UTC(),
+	}
+}
+
+// Firmware defines the host firmware info of a device.
+type Firmware struct {
+	VersionMajor uint16
+	VersionMinor uint16
+	// The build time of the firmware.
+	Build time.Time
+}
+
+// AtLeast returns true if the firmware version is >= major.minor.
+func (f Firmware) AtLeast(major, minor uint16) bool {
+	if f.VersionMajor != major {
+		return f.VersionMajor > major
+	}
+	return f.VersionMinor >= minor
+}
+
+func (f Firmware) String() string {
+	return fmt.Sprintf("%d.%d", f.VersionMajor, f.VersionMinor)
+}
+
 func (d *device) Firmware() *FirmwareUpgrade {
 	return &d.firmware
 }
 
 func (d *device) GetFirmware(ctx context.Context, conn net.Conn) error {
+	_, err := d.GetHostFirmware(ctx, conn)
+	return err
+}
+
+func (d *device) GetHostFirmware(ctx context.Context, conn net.Conn) (*Firmware, error) {
 	if ctx.Err() != nil {
-		return ctx.Err()
+		return nil, ctx.Err()
 	}
 
 	if conn == nil {
 		newConn, err := d.Dial()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer newConn.Close()
 		conn = newConn
 
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 	}
 
@@ -57,28 +94,27 @@ func (d *device) GetFirmware(ctx context.Context, conn net.Conn) error {
 		nil, // payload
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for {
-		resp, err := ReadNextResponse(ctx, conn)
-		if err != nil {
-			return err
-		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-		if resp.Message != StateHostFirmware {
-			continue
-		}
-
-		var raw RawStateHostFirmwarePayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return err
-		}
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateHostFirmware,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		d.firmware = raw.ToFirmware()
-		return nil
+	var raw RawStateHostFirmwarePayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
 	}
+
+	d.firmware = raw.ToFirmware()
+	return raw.ToHostFirmware(), nil
 }
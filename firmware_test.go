@@ -0,0 +1,66 @@
+package lifxlan_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestFirmwareAtLeast(t *testing.T) {
+	firmware := lifxlan.Firmware{
+		VersionMajor: 3,
+		VersionMinor: 70,
+	}
+	for _, c := range []struct {
+		major, minor uint16
+		expected     bool
+	}{
+		{2, 80, true},
+		{3, 0, true},
+		{3, 70, true},
+		{3, 71, false},
+		{4, 0, false},
+	} {
+		if got := firmware.AtLeast(c.major, c.minor); got != c.expected {
+			t.Errorf("%v.AtLeast(%d, %d) expected %v, got %v", firmware, c.major, c.minor, c.expected, got)
+		}
+	}
+}
+
+func TestGetHostFirmware(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	build := time.Date(2020, time.June, 1, 12, 34, 56, 0, time.UTC)
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStateHostFirmwarePayload = &lifxlan.RawStateHostFirmwarePayload{
+		Build:        uint64(build.UnixNano()),
+		VersionMajor: 3,
+		VersionMinor: 70,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	firmware, err := device.GetHostFirmware(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firmware.VersionMajor != 3 || firmware.VersionMinor != 70 {
+		t.Errorf("Firmware version expected 3.70, got %v", firmware)
+	}
+	if !firmware.Build.Equal(build) {
+		t.Errorf("Build expected %v, got %v", build, firmware.Build)
+	}
+	if got := device.Firmware().String(); got != "(3, 70)" {
+		t.Errorf("Cached firmware expected (3, 70), got %s", got)
+	}
+}
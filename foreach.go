@@ -0,0 +1,73 @@
+package lifxlan
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// ForEachDeviceFunc is the function to be called by ForEachDevice.
+//
+// conn is the connection dialed for d,
+// it will be closed by ForEachDevice after the function returns.
+type ForEachDeviceFunc func(ctx context.Context, d Device, conn net.Conn) error
+
+// ForEachDevice dials all devices and calls fn on them in parallel,
+// with at most concurrency calls running at the same time.
+// If concurrency <= 0, all devices will be handled at the same time.
+//
+// Each call gets its own child context of ctx,
+// which is cancelled after the call returns.
+// Once ctx is cancelled,
+// devices not yet started will be skipped with ctx.Err() as their errors.
+//
+// The returned errors are in the same order as devices,
+// with nil for devices that fn returned nil error.
+func ForEachDevice(
+	ctx context.Context,
+	devices []Device,
+	concurrency int,
+	fn ForEachDeviceFunc,
+) []error {
+	errs := make([]error, len(devices))
+	if concurrency <= 0 || concurrency > len(devices) {
+		concurrency = len(devices)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, d Device) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+
+			conn, err := d.Dial()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer conn.Close()
+
+			deviceCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			errs[i] = fn(deviceCtx, d, conn)
+		}(i, d)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,100 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestForEachDevice(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 500
+	const n = 6
+	const concurrency = 2
+
+	devices := make([]lifxlan.Device, n)
+	for i := range devices {
+		service, device := mock.StartService(t)
+		defer service.Stop()
+		devices[i] = device
+	}
+	errFailed := errors.New("failed")
+
+	var running, maxRunning int32
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	errs := lifxlan.ForEachDevice(
+		ctx,
+		devices,
+		concurrency,
+		func(ctx context.Context, d lifxlan.Device, conn net.Conn) error {
+			current := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+
+			if conn == nil {
+				t.Error("Expected non-nil conn")
+			}
+			if d == devices[1] {
+				return errFailed
+			}
+			// Give other goroutines a chance to run concurrently.
+			time.Sleep(time.Millisecond * 10)
+			return d.SetPower(ctx, conn, lifxlan.PowerOn, true)
+		},
+	)
+
+	if len(errs) != n {
+		t.Fatalf("Expected %d errors, got %d", n, len(errs))
+	}
+	for i, err := range errs {
+		if i == 1 {
+			if err != errFailed {
+				t.Errorf("errs[%d] expected %v, got %v", i, errFailed, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("errs[%d] expected nil, got %v", i, err)
+		}
+	}
+	if maxRunning > concurrency {
+		t.Errorf("Expected at most %d concurrent calls, got %d", concurrency, maxRunning)
+	}
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			errs := lifxlan.ForEachDevice(
+				ctx,
+				devices,
+				concurrency,
+				func(ctx context.Context, d lifxlan.Device, conn net.Conn) error {
+					t.Errorf("Unexpected call on %v", d)
+					return nil
+				},
+			)
+			for i, err := range errs {
+				if err != context.Canceled {
+					t.Errorf("errs[%d] expected %v, got %v", i, context.Canceled, err)
+				}
+			}
+		},
+	)
+}
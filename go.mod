@@ -1,7 +1,3 @@
-module github.com/subtlepseudonym/lifxlan
+module go.yhsif.com/lifxlan
 
 go 1.15
-
-require go.yhsif.com/lifxlan v0.0.0-00010101000000-000000000000
-
-replace go.yhsif.com/lifxlan => github.com/subtlepseudonym/lifxlan v0.3.3
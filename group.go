@@ -0,0 +1,316 @@
+package lifxlan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// GUIDLength is the length of the raw GUID used in messages.
+const GUIDLength = 16
+
+// GUID defines the raw group/location id in message payloads according to:
+//
+// https://lan.developer.lifx.com/docs/information-messages#stategroup---packet-53
+type GUID [GUIDLength]byte
+
+func (id GUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// NewGUID generates a random (version 4 UUID style) GUID,
+// to be used with SetGroup and SetLocation.
+func NewGUID() GUID {
+	var id GUID
+	rand.Read(id[:])
+	// Version 4
+	id[6] = (id[6] & 0x0f) | 0x40
+	// Variant RFC 4122
+	id[8] = (id[8] & 0x3f) | 0x80
+	return id
+}
+
+// RawStateGroupPayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#stategroup---packet-53
+type RawStateGroupPayload struct {
+	Group     GUID
+	Label     Label
+	UpdatedAt uint64
+}
+
+// RawStateLocationPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statelocation---packet-50
+type RawStateLocationPayload struct {
+	Location  GUID
+	Label     Label
+	UpdatedAt uint64
+}
+
+// RawSetGroupPayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setgroup---packet-52
+type RawSetGroupPayload RawStateGroupPayload
+
+// RawSetLocationPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setlocation---packet-49
+type RawSetLocationPayload RawStateLocationPayload
+
+// Group defines the group a device belongs to, as returned by GetGroup.
+type Group struct {
+	GUID  GUID
+	Label Label
+
+	// Nanoseconds since unix epoch, as reported by the device.
+	Timestamp uint64
+}
+
+// UpdatedAt returns the time the group was last updated, in local time.
+func (g Group) UpdatedAt() time.Time {
+	return time.Unix(0, int64(g.Timestamp))
+}
+
+// Location defines the location a device belongs to, as returned by
+// GetLocation.
+type Location struct {
+	GUID  GUID
+	Label Label
+
+	// Nanoseconds since unix epoch, as reported by the device.
+	Timestamp uint64
+}
+
+// UpdatedAt returns the time the location was last updated, in local time.
+func (l Location) UpdatedAt() time.Time {
+	return time.Unix(0, int64(l.Timestamp))
+}
+
+func (d *device) GetGroup(ctx context.Context, conn net.Conn) (*Group, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetGroup,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateGroup,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateGroupPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &Group{
+		GUID:      raw.Group,
+		Label:     raw.Label,
+		Timestamp: raw.UpdatedAt,
+	}, nil
+}
+
+func (d *device) GetLocation(ctx context.Context, conn net.Conn) (*Location, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetLocation,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateLocation,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateLocationPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &Location{
+		GUID:      raw.Location,
+		Label:     raw.Label,
+		Timestamp: raw.UpdatedAt,
+	}, nil
+}
+
+func (d *device) SetGroup(
+	ctx context.Context,
+	conn net.Conn,
+	guid GUID,
+	label string,
+	updatedAt time.Time,
+	ack bool,
+) error {
+	if err := checkLabel("lifxlan.Device.SetGroup", label); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload := RawSetGroupPayload{
+		Group:     guid,
+		UpdatedAt: uint64(updatedAt.UnixNano()),
+	}
+	payload.Label.Set(label)
+
+	var flags AckResFlag
+	if ack {
+		flags |= FlagAckRequired
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		flags,
+		SetGroup,
+		&payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return WaitForAcks(ctx, conn, d.Source(), seq)
+	}
+	return nil
+}
+
+func (d *device) SetLocation(
+	ctx context.Context,
+	conn net.Conn,
+	guid GUID,
+	label string,
+	updatedAt time.Time,
+	ack bool,
+) error {
+	if err := checkLabel("lifxlan.Device.SetLocation", label); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload := RawSetLocationPayload{
+		Location:  guid,
+		UpdatedAt: uint64(updatedAt.UnixNano()),
+	}
+	payload.Label.Set(label)
+
+	var flags AckResFlag
+	if ack {
+		flags |= FlagAckRequired
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		flags,
+		SetLocation,
+		&payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return WaitForAcks(ctx, conn, d.Source(), seq)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestGUID(t *testing.T) {
+	id := lifxlan.GUID{
+		0x01, 0x23, 0x45, 0x67,
+		0x89, 0xab,
+		0xcd, 0xef,
+		0x00, 0x11,
+		0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+	}
+	const expected = "01234567-89ab-cdef-0011-223344556677"
+	if got := id.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestGetGroupLocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	id := lifxlan.GUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	var label lifxlan.Label
+	label.Set("Kitchen")
+	updated := time.Date(2020, time.November, 1, 2, 3, 4, 5, time.UTC)
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.Handlers[lifxlan.GetGroup] = mock.ReplyHandler(
+		lifxlan.StateGroup,
+		&lifxlan.RawStateGroupPayload{
+			Group:     id,
+			Label:     label,
+			UpdatedAt: uint64(updated.UnixNano()),
+		},
+	)
+	service.Handlers[lifxlan.GetLocation] = mock.ReplyHandler(
+		lifxlan.StateLocation,
+		&lifxlan.RawStateLocationPayload{
+			Location:  id,
+			Label:     label,
+			UpdatedAt: uint64(updated.UnixNano()),
+		},
+	)
+
+	t.Run(
+		"GetGroup",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			group, err := device.GetGroup(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if group.GUID.String() != id.String() {
+				t.Errorf("GUID expected %v, got %v", id, group.GUID)
+			}
+			if group.Label.String() != label.String() {
+				t.Errorf("Label expected %q, got %q", label, group.Label)
+			}
+			if !group.UpdatedAt().Equal(updated) {
+				t.Errorf("UpdatedAt expected %v, got %v", updated, group.UpdatedAt())
+			}
+			if loc := group.UpdatedAt().Location(); loc != time.Local {
+				t.Errorf("UpdatedAt expected to be in local time, got %v", loc)
+			}
+		},
+	)
+
+	t.Run(
+		"GetLocation",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			location, err := device.GetLocation(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if location.GUID.String() != id.String() {
+				t.Errorf("GUID expected %v, got %v", id, location.GUID)
+			}
+			if location.Label.String() != label.String() {
+				t.Errorf("Label expected %q, got %q", label, location.Label)
+			}
+			if !location.UpdatedAt().Equal(updated) {
+				t.Errorf("UpdatedAt expected %v, got %v", updated, location.UpdatedAt())
+			}
+		},
+	)
+}
+
+func TestNewGUID(t *testing.T) {
+	a := lifxlan.NewGUID()
+	b := lifxlan.NewGUID()
+	if a == b {
+		t.Errorf("Expected different GUIDs, got %v twice", a)
+	}
+	for _, id := range []lifxlan.GUID{a, b} {
+		if version := id[6] >> 4; version != 4 {
+			t.Errorf("%v: version expected 4, got %d", id, version)
+		}
+		if variant := id[8] >> 6; variant != 2 {
+			t.Errorf("%v: variant expected 2, got %d", id, variant)
+		}
+	}
+}
+
+func TestSetGroupLocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	id := lifxlan.NewGUID()
+	const label = "Living Room"
+	updated := time.Now()
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	var group lifxlan.RawStateGroupPayload
+	service.Handlers[lifxlan.SetGroup] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &group); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service.Handlers[lifxlan.GetGroup] = mock.ReplyHandler(lifxlan.StateGroup, &group)
+
+	var location lifxlan.RawStateLocationPayload
+	service.Handlers[lifxlan.SetLocation] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &location); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service.Handlers[lifxlan.GetLocation] = mock.ReplyHandler(lifxlan.StateLocation, &location)
+
+	t.Run(
+		"SetGroup",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.SetGroup(ctx, nil, id, label, updated, true); err != nil {
+				t.Fatal(err)
+			}
+			if group.UpdatedAt != uint64(updated.UnixNano()) {
+				t.Errorf("UpdatedAt expected %d, got %d", updated.UnixNano(), group.UpdatedAt)
+			}
+			got, err := device.GetGroup(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.GUID != id {
+				t.Errorf("GUID expected %v, got %v", id, got.GUID)
+			}
+			if got.Label.String() != label {
+				t.Errorf("Label expected %q, got %q", label, got.Label)
+			}
+			if !got.UpdatedAt().Equal(updated) {
+				t.Errorf("UpdatedAt expected %v, got %v", updated, got.UpdatedAt())
+			}
+		},
+	)
+
+	t.Run(
+		"SetLocation",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.SetLocation(ctx, nil, id, label, updated, true); err != nil {
+				t.Fatal(err)
+			}
+			got, err := device.GetLocation(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.GUID != id {
+				t.Errorf("GUID expected %v, got %v", id, got.GUID)
+			}
+			if got.Label.String() != label {
+				t.Errorf("Label expected %q, got %q", label, got.Label)
+			}
+			if !got.UpdatedAt().Equal(updated) {
+				t.Errorf("UpdatedAt expected %v, got %v", updated, got.UpdatedAt())
+			}
+		},
+	)
+
+	t.Run(
+		"LabelTooLong",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			label := "0123456789012345678901234567890123456789"
+			if err := device.SetGroup(ctx, nil, id, label, updated, true); err == nil {
+				t.Error("Expected error for label too long, got nil")
+			}
+			if err := device.SetLocation(ctx, nil, id, label, updated, true); err == nil {
+				t.Error("Expected error for label too long, got nil")
+			}
+		},
+	)
+}
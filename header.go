@@ -3,6 +3,7 @@ package lifxlan
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"math"
 	"math/rand"
 	"time"
@@ -22,15 +23,34 @@ func init() {
 //
 // - addressable: 1 bit, must be 1
 //
-// - protocol: 12 bits, must be 1024
+// - protocol: 12 bits, must be Protocol
 type TaggedHeader uint16
 
+// Protocol is the protocol number used by lifxlan messages.
+const Protocol = 1024
+
 // Tagged and non-tagged versions of TaggedHeader.
+//
+// The tagged bit tells the devices how to interpret the target of the message:
+// Tagged means the message is addressed to all the devices receiving it and
+// the target is ignored (it must be AllDevices),
+// NotTagged means only the device matching the target should handle it.
+// Devices ignore messages with a tagged bit that doesn't match the target,
+// so it should almost always be chosen via TaggedFor.
 const (
-	NotTagged TaggedHeader = 1<<12 + 1024
+	NotTagged TaggedHeader = 1<<12 + Protocol
 	Tagged    TaggedHeader = 1<<13 + NotTagged
 )
 
+// TaggedFor returns Tagged if target is AllDevices,
+// NotTagged otherwise.
+func TaggedFor(target Target) TaggedHeader {
+	if target == AllDevices {
+		return Tagged
+	}
+	return NotTagged
+}
+
 // AckResFlag is the 8-bit header that could include:
 //
 // - ack_required: if set all sent messages will expect an ack response.
@@ -65,6 +85,19 @@ const HeaderLength = 36
 
 // ResponseReadBufferSize is the recommended buffer size to read UDP responses.
 // It's big enough for all the payloads.
+//
+// It's the default read buffer size used by ReadNextResponse,
+// which can be overridden per call via WithReadBufferSize.
+// The minimum safe sizes (HeaderLength plus the biggest payload) for each
+// family of messages are:
+//
+// - lifxlan, light and hev packages: 128
+//
+// - multizone package: multizone.MinReadBufferSize (697)
+//
+// - tile package: tile.MinReadBufferSize (918)
+//
+// - relay package: relay.MinReadBufferSize (847)
 const ResponseReadBufferSize = 4096
 
 // GenerateMessage generates the message to send.
@@ -97,6 +130,90 @@ func GenerateMessage(
 	return buf.Bytes(), nil
 }
 
+// BuildHeader builds the 36-byte header of a message,
+// without going through GenerateMessage or Device.Send.
+//
+// The tagged bit is chosen via TaggedFor.
+// The size field of the header is HeaderLength + payloadLen,
+// truncated to 16 bits.
+// No validation is done on the args,
+// so it can also be used to craft malformed messages for testing.
+func BuildHeader(
+	source uint32,
+	target Target,
+	flags AckResFlag,
+	sequence uint8,
+	msg MessageType,
+	payloadLen int,
+) []byte {
+	tagged := TaggedFor(target)
+	buf := make([]byte, HeaderLength)
+	binary.LittleEndian.PutUint16(buf[0:], uint16(HeaderLength+payloadLen))
+	binary.LittleEndian.PutUint16(buf[2:], uint16(tagged))
+	binary.LittleEndian.PutUint32(buf[4:], source)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(target))
+	buf[22] = byte(flags)
+	buf[23] = sequence
+	binary.LittleEndian.PutUint16(buf[32:], uint16(msg))
+	return buf
+}
+
+// Header is the parsed header of a message.
+//
+// https://lan.developer.lifx.com/docs/packet-contents#header
+type Header struct {
+	// Frame header
+	Size        uint16
+	Origin      uint8
+	Tagged      bool
+	Addressable bool
+	Protocol    uint16
+	Source      uint32
+
+	// Frame address
+	Target   Target
+	Flags    AckResFlag
+	Sequence uint8
+
+	// Protocol header
+	Type MessageType
+}
+
+// ParseHeader parses the header from the first HeaderLength bytes of msg.
+//
+// Unlike ParseResponse,
+// it doesn't verify that the size field of the header matches len(msg),
+// and the reserved fields are ignored,
+// so it can also be used to inspect malformed or truncated messages.
+// It only returns an error when msg is shorter than HeaderLength.
+func ParseHeader(msg []byte) (*Header, error) {
+	if len(msg) < int(HeaderLength) {
+		return nil, fmt.Errorf(
+			"lifxlan.ParseHeader: header size not enough: %d < %d",
+			len(msg),
+			HeaderLength,
+		)
+	}
+
+	var d RawHeader
+	r := bytes.NewReader(msg[:HeaderLength])
+	if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
+		return nil, err
+	}
+	return &Header{
+		Size:        d.Size,
+		Origin:      uint8(d.Tagged >> 14),
+		Tagged:      d.Tagged&(1<<13) != 0,
+		Addressable: d.Tagged&(1<<12) != 0,
+		Protocol:    uint16(d.Tagged & (1<<12 - 1)),
+		Source:      d.Source,
+		Target:      d.Target,
+		Flags:       d.Flags,
+		Sequence:    d.Sequence,
+		Type:        d.Type,
+	}, nil
+}
+
 var maxSource int64 = math.MaxUint32
 
 // RandomSource generates a random number to be used as source.
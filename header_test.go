@@ -56,6 +56,74 @@ func TestParseResponseError(t *testing.T) {
 			}
 		},
 	)
+
+	t.Run(
+		"Truncated",
+		func(t *testing.T) {
+			msg, err := lifxlan.GenerateMessage(
+				lifxlan.NotTagged,
+				1234, // source
+				lifxlan.AllDevices,
+				0, // flags
+				1, // sequence
+				lifxlan.StatePower,
+				make([]byte, 2),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := lifxlan.ParseResponse(msg); err != nil {
+				t.Fatalf("Expected no error for the full msg, got %v", err)
+			}
+			for _, n := range []int{len(msg) - 1, lifxlan.HeaderLength, lifxlan.HeaderLength / 2} {
+				buf := msg[:n]
+				if _, err := lifxlan.ParseResponse(buf); err == nil {
+					t.Errorf("Expected error for msg truncated to %d bytes: % x", n, buf)
+				} else {
+					t.Logf("Got error for %d bytes: %v", n, err)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"WrongProtocol",
+		func(t *testing.T) {
+			for _, tagged := range []lifxlan.TaggedHeader{
+				0,            // not addressable, protocol 0
+				1<<12 + 1023, // addressable, protocol 1023
+				lifxlan.Tagged + 1,
+			} {
+				msg, err := lifxlan.GenerateMessage(
+					tagged,
+					1234, // source
+					lifxlan.AllDevices,
+					0, // flags
+					1, // sequence
+					lifxlan.Acknowledgement,
+					nil, // payload
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := lifxlan.ParseResponse(msg); err == nil {
+					t.Errorf("Expected wrong protocol error for msg % x", msg)
+				} else {
+					t.Logf("Got error for %#x: %v", uint16(tagged), err)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"SSDP",
+		func(t *testing.T) {
+			buf := []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\n\r\n")
+			if _, err := lifxlan.ParseResponse(buf); err == nil {
+				t.Errorf("Expected error for SSDP packet %q", buf)
+			}
+		},
+	)
 }
 
 func TestHeader(t *testing.T) {
@@ -166,6 +234,133 @@ func TestHeader(t *testing.T) {
 	)
 }
 
+func TestBuildHeader(t *testing.T) {
+	for _, c := range []struct {
+		label  string
+		tagged lifxlan.TaggedHeader
+		target lifxlan.Target
+	}{
+		{
+			label:  "Tagged",
+			tagged: lifxlan.Tagged,
+			target: lifxlan.AllDevices,
+		},
+		{
+			label:  "NotTagged",
+			tagged: lifxlan.NotTagged,
+			target: lifxlan.Target(1234),
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				const (
+					flags      = lifxlan.FlagAckRequired
+					sequence   = 42
+					msgType    = lifxlan.MessageType(4321)
+					payloadLen = 10
+				)
+				source := lifxlan.RandomSource()
+
+				msg, err := lifxlan.GenerateMessage(
+					c.tagged,
+					source,
+					c.target,
+					flags,
+					sequence,
+					msgType,
+					make([]byte, payloadLen),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := msg[:lifxlan.HeaderLength]
+
+				actual := lifxlan.BuildHeader(source, c.target, flags, sequence, msgType, payloadLen)
+				if !bytes.Equal(actual, expected) {
+					t.Errorf("BuildHeader expected % x, got % x", expected, actual)
+				}
+			},
+		)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	t.Run(
+		"RoundTrip",
+		func(t *testing.T) {
+			source := lifxlan.RandomSource()
+			expected := lifxlan.Header{
+				Size:        lifxlan.HeaderLength + 10,
+				Origin:      0,
+				Tagged:      false,
+				Addressable: true,
+				Protocol:    1024,
+				Source:      source,
+				Target:      lifxlan.Target(1234),
+				Flags:       lifxlan.FlagResRequired,
+				Sequence:    42,
+				Type:        lifxlan.MessageType(4321),
+			}
+			msg := lifxlan.BuildHeader(
+				expected.Source,
+				expected.Target,
+				expected.Flags,
+				expected.Sequence,
+				expected.Type,
+				10, // payloadLen
+			)
+			header, err := lifxlan.ParseHeader(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *header != expected {
+				t.Errorf("ParseHeader expected %+v, got %+v", expected, *header)
+			}
+		},
+	)
+
+	t.Run(
+		"Tagged",
+		func(t *testing.T) {
+			msg := lifxlan.BuildHeader(0, lifxlan.AllDevices, 0, 0, lifxlan.GetService, 0)
+			header, err := lifxlan.ParseHeader(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !header.Tagged {
+				t.Errorf("Expected tagged header, got %+v", *header)
+			}
+		},
+	)
+
+	t.Run(
+		"SizeMismatch",
+		func(t *testing.T) {
+			// The size field says there's a payload, but it's missing.
+			msg := lifxlan.BuildHeader(0, lifxlan.AllDevices, 0, 0, lifxlan.GetService, 10)
+			header, err := lifxlan.ParseHeader(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Size != lifxlan.HeaderLength+10 {
+				t.Errorf("Size expected %d, got %d", lifxlan.HeaderLength+10, header.Size)
+			}
+		},
+	)
+
+	t.Run(
+		"SizeNotEnough",
+		func(t *testing.T) {
+			msg := make([]byte, lifxlan.HeaderLength-1)
+			if _, err := lifxlan.ParseHeader(msg); err == nil {
+				t.Errorf("Expected size not enough error for msg % x", msg)
+			}
+		},
+	)
+}
+
 func TestRandomSource(t *testing.T) {
 	n := 0
 	f := func() bool {
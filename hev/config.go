@@ -0,0 +1,141 @@
+package hev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// RawHevCycleConfigurationPayload defines the struct to be used for encoding
+// and decoding.
+//
+// It's the payload of both SetHevCycleConfiguration and
+// StateHevCycleConfiguration messages:
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#sethevcycleconfiguration---packet-146
+type RawHevCycleConfigurationPayload struct {
+	Indication      bool
+	DurationSeconds uint32
+}
+
+// HevCycleConfiguration defines the default configuration of HEV cycles
+// returned by GetHevCycleConfiguration.
+type HevCycleConfiguration struct {
+	// Whether the device will briefly flash green at the end of a HEV cycle.
+	Indication bool
+
+	// The default duration of a HEV cycle.
+	Duration time.Duration
+}
+
+func (hd *device) SetHevCycleConfiguration(
+	ctx context.Context,
+	conn net.Conn,
+	indication bool,
+	durationSeconds uint32,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := hd.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := hd.Send(
+		ctx,
+		conn,
+		flags,
+		SetHevCycleConfiguration,
+		&RawHevCycleConfigurationPayload{
+			Indication:      indication,
+			DurationSeconds: durationSeconds,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, hd.Source(), seq)
+	}
+	return nil
+}
+
+func (hd *device) GetHevCycleConfiguration(
+	ctx context.Context,
+	conn net.Conn,
+) (*HevCycleConfiguration, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := hd.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := hd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetHevCycleConfiguration,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		hd.Source(),
+		seq,
+		StateHevCycleConfiguration,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawHevCycleConfigurationPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &HevCycleConfiguration{
+		Indication: raw.Indication,
+		Duration:   time.Duration(raw.DurationSeconds) * time.Second,
+	}, nil
+}
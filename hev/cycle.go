@@ -0,0 +1,262 @@
+package hev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// RawSetHevCyclePayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#sethevcycle---packet-143
+type RawSetHevCyclePayload struct {
+	Enable          bool
+	DurationSeconds uint32
+}
+
+// RawStateHevCyclePayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statehevcycle---packet-144
+type RawStateHevCyclePayload struct {
+	DurationSeconds  uint32
+	RemainingSeconds uint32
+	LastPower        bool
+}
+
+// HevCycleState defines the state of a HEV cycle returned by GetHevCycle.
+type HevCycleState struct {
+	// The duration of the current (or last) HEV cycle.
+	Duration time.Duration
+
+	// The remaining time of the current HEV cycle,
+	// 0 means there's no HEV cycle running.
+	Remaining time.Duration
+
+	// Whether the device was powered on before the HEV cycle started.
+	//
+	// The device returns to this power state when the HEV cycle finishes,
+	// but not when the HEV cycle is stopped early,
+	// so you can use it to restore the device's power in that case.
+	LastPower bool
+}
+
+// Running returns true if a HEV cycle is currently running.
+func (s HevCycleState) Running() bool {
+	return s.Remaining > 0
+}
+
+// HevCycleResult defines the result of the last HEV cycle.
+//
+// https://lan.developer.lifx.com/docs/field-types#lasthevcycleresult
+type HevCycleResult uint8
+
+// HevCycleResult values.
+const (
+	HevCycleResultSuccess              HevCycleResult = 0
+	HevCycleResultBusy                 HevCycleResult = 1
+	HevCycleResultInterruptedByReset   HevCycleResult = 2
+	HevCycleResultInterruptedByHomekit HevCycleResult = 3
+	HevCycleResultInterruptedByLAN     HevCycleResult = 4
+	HevCycleResultInterruptedByCloud   HevCycleResult = 5
+	HevCycleResultNone                 HevCycleResult = 255
+)
+
+func (r HevCycleResult) String() string {
+	switch r {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint8(r))
+	case HevCycleResultSuccess:
+		return "Success"
+	case HevCycleResultBusy:
+		return "Busy"
+	case HevCycleResultInterruptedByReset:
+		return "InterruptedByReset"
+	case HevCycleResultInterruptedByHomekit:
+		return "InterruptedByHomekit"
+	case HevCycleResultInterruptedByLAN:
+		return "InterruptedByLAN"
+	case HevCycleResultInterruptedByCloud:
+		return "InterruptedByCloud"
+	case HevCycleResultNone:
+		return "None"
+	}
+}
+
+// RawStateLastHevCycleResultPayload defines the struct to be used for encoding
+// and decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statelasthevcycleresult---packet-149
+type RawStateLastHevCycleResultPayload struct {
+	Result HevCycleResult
+}
+
+func (hd *device) SetHevCycle(
+	ctx context.Context,
+	conn net.Conn,
+	enable bool,
+	durationSeconds uint32,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := hd.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := hd.Send(
+		ctx,
+		conn,
+		flags,
+		SetHevCycle,
+		&RawSetHevCyclePayload{
+			Enable:          enable,
+			DurationSeconds: durationSeconds,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, hd.Source(), seq)
+	}
+	return nil
+}
+
+func (hd *device) GetHevCycle(
+	ctx context.Context,
+	conn net.Conn,
+) (*HevCycleState, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := hd.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := hd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetHevCycle,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		hd.Source(),
+		seq,
+		StateHevCycle,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateHevCyclePayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &HevCycleState{
+		Duration:  time.Duration(raw.DurationSeconds) * time.Second,
+		Remaining: time.Duration(raw.RemainingSeconds) * time.Second,
+		LastPower: raw.LastPower,
+	}, nil
+}
+
+func (hd *device) GetLastHevCycleResult(
+	ctx context.Context,
+	conn net.Conn,
+) (HevCycleResult, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := hd.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := hd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetLastHevCycleResult,
+		nil, // payload
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		hd.Source(),
+		seq,
+		StateLastHevCycleResult,
+		1, // count
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var raw RawStateLastHevCycleResultPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
+	}
+
+	return raw.Result, nil
+}
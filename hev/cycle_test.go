@@ -0,0 +1,203 @@
+package hev_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func stateHevCycleHandler(t *testing.T, raw *hev.RawStateHevCyclePayload) mock.HandlerFunc {
+	return func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, raw); err != nil {
+			t.Error(err)
+			return
+		}
+		s.Reply(conn, addr, orig, hev.StateHevCycle, buf.Bytes())
+	}
+}
+
+func wrapDevice(t *testing.T, device lifxlan.Device) hev.Device {
+	t.Helper()
+
+	const timeout = time.Millisecond * 200
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	hd, err := hev.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hd
+}
+
+func TestHevCycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	state := &hev.RawStateHevCyclePayload{}
+	service.Handlers[hev.GetHevCycle] = stateHevCycleHandler(t, state)
+
+	hd := wrapDevice(t, device)
+
+	t.Run(
+		"SetHevCycle",
+		func(t *testing.T) {
+			var called bool
+			service.Handlers[hev.SetHevCycle] = func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				called = true
+				var raw hev.RawSetHevCyclePayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Fatal(err)
+				}
+				expected := hev.RawSetHevCyclePayload{
+					Enable:          true,
+					DurationSeconds: 7200,
+				}
+				if raw != expected {
+					t.Errorf("SetHevCycle payload expected %+v, got %+v", expected, raw)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := hd.SetHevCycle(ctx, nil, true, 7200, true); err != nil {
+				t.Fatal(err)
+			}
+			if !called {
+				t.Error("SetHevCycle message not received.")
+			}
+		},
+	)
+
+	for _, c := range []struct {
+		label string
+		raw   hev.RawStateHevCyclePayload
+	}{
+		{
+			label: "Running",
+			raw: hev.RawStateHevCyclePayload{
+				DurationSeconds:  7200,
+				RemainingSeconds: 3600,
+				LastPower:        true,
+			},
+		},
+		{
+			label: "NotRunning",
+			raw: hev.RawStateHevCyclePayload{
+				DurationSeconds: 7200,
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			"GetHevCycle/"+c.label,
+			func(t *testing.T) {
+				*state = c.raw
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				got, err := hd.GetHevCycle(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := hev.HevCycleState{
+					Duration:  time.Duration(c.raw.DurationSeconds) * time.Second,
+					Remaining: time.Duration(c.raw.RemainingSeconds) * time.Second,
+					LastPower: c.raw.LastPower,
+				}
+				if *got != expected {
+					t.Errorf("GetHevCycle expected %+v, got %+v", expected, *got)
+				}
+				if got.Running() != (c.raw.RemainingSeconds > 0) {
+					t.Errorf("Running() returned %v for %+v", got.Running(), *got)
+				}
+			},
+		)
+	}
+}
+
+func TestHevCycleConfiguration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[hev.GetHevCycle] = stateHevCycleHandler(t, &hev.RawStateHevCyclePayload{})
+
+	var config hev.RawHevCycleConfigurationPayload
+	service.Handlers[hev.SetHevCycleConfiguration] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service.Handlers[hev.GetHevCycleConfiguration] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, &config); err != nil {
+			t.Fatal(err)
+		}
+		s.Reply(conn, addr, orig, hev.StateHevCycleConfiguration, buf.Bytes())
+	}
+
+	hd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := hd.SetHevCycleConfiguration(ctx, nil, true, 3600, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := hd.GetHevCycleConfiguration(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := hev.HevCycleConfiguration{
+		Indication: true,
+		Duration:   time.Hour,
+	}
+	if *got != expected {
+		t.Errorf("GetHevCycleConfiguration expected %+v, got %+v", expected, *got)
+	}
+}
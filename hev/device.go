@@ -0,0 +1,84 @@
+package hev
+
+import (
+	"context"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// Device is a wrapped lifxlan.Device that provides HEV related APIs.
+type Device interface {
+	light.Device
+
+	// GetHevCycle returns the state of the current (or last) HEV cycle.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetHevCycle(ctx context.Context, conn net.Conn) (*HevCycleState, error)
+
+	// SetHevCycle starts or stops a HEV cycle.
+	//
+	// When durationSeconds is 0,
+	// the default duration from the HEV cycle configuration will be used.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetHevCycle(ctx context.Context, conn net.Conn, enable bool, durationSeconds uint32, ack bool) error
+
+	// GetLastHevCycleResult returns the result of the last HEV cycle.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetLastHevCycleResult(ctx context.Context, conn net.Conn) (HevCycleResult, error)
+
+	// GetHevCycleConfiguration returns the default configuration used by HEV
+	// cycles.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetHevCycleConfiguration(ctx context.Context, conn net.Conn) (*HevCycleConfiguration, error)
+
+	// SetHevCycleConfiguration sets the default configuration used by HEV
+	// cycles.
+	//
+	// When indication is true,
+	// the device will briefly flash green at the end of a HEV cycle.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetHevCycleConfiguration(ctx context.Context, conn net.Conn, indication bool, durationSeconds uint32, ack bool) error
+}
+
+type device struct {
+	light.Device
+}
+
+var _ Device = (*device)(nil)
+
+func (hd *device) String() string {
+	return lifxlan.FormatDevice(hd, "HevDevice")
+}
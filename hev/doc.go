@@ -0,0 +1,9 @@
+// Package hev implements LIFX LAN Protocol for LIFX devices with HEV
+// (High Energy Visible) light, e.g. LIFX Clean:
+//
+// https://lan.developer.lifx.com/docs/hev
+//
+// A HEV device is also a light device and implements all light APIs.
+//
+// Please refer to its parent package for more background/context.
+package hev // import "go.yhsif.com/lifxlan/hev"
@@ -0,0 +1,42 @@
+package hev_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/hev"
+)
+
+// This example demonstrates how to stop a running HEV cycle and restore the
+// device's power to what it was before the HEV cycle started.
+func Example() {
+	// Need proper initialization on real code.
+	var (
+		device hev.Device
+		// Important to set timeout to context when requiring ack.
+		timeout time.Duration
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state, err := device.GetHevCycle(ctx, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !state.Running() {
+		return
+	}
+	if err := device.SetHevCycle(ctx, nil, false, 0, true); err != nil {
+		log.Fatal(err)
+	}
+	power := lifxlan.PowerOff
+	if state.LastPower {
+		power = lifxlan.PowerOn
+	}
+	if err := device.SetPower(ctx, nil, power, true); err != nil {
+		log.Fatal(err)
+	}
+}
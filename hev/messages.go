@@ -0,0 +1,40 @@
+package hev
+
+import (
+	"encoding/binary"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// HEV related MessageType values.
+const (
+	GetHevCycle   lifxlan.MessageType = 142
+	SetHevCycle   lifxlan.MessageType = 143
+	StateHevCycle lifxlan.MessageType = 144
+
+	GetHevCycleConfiguration   lifxlan.MessageType = 145
+	SetHevCycleConfiguration   lifxlan.MessageType = 146
+	StateHevCycleConfiguration lifxlan.MessageType = 147
+
+	GetLastHevCycleResult   lifxlan.MessageType = 148
+	StateLastHevCycleResult lifxlan.MessageType = 149
+)
+
+func init() {
+	for msg, size := range map[lifxlan.MessageType]lifxlan.PayloadSize{
+		GetHevCycle:              {Name: "GetHevCycle", Size: 0},
+		SetHevCycle:              {Name: "SetHevCycle", Size: binary.Size(RawSetHevCyclePayload{})},
+		GetHevCycleConfiguration: {Name: "GetHevCycleConfiguration", Size: 0},
+		SetHevCycleConfiguration: {Name: "SetHevCycleConfiguration", Size: binary.Size(RawHevCycleConfigurationPayload{})},
+		GetLastHevCycleResult:    {Name: "GetLastHevCycleResult", Size: 0},
+	} {
+		lifxlan.PayloadSizes[msg] = size
+	}
+	for msg, resp := range map[lifxlan.MessageType]lifxlan.MessageType{
+		GetHevCycle:              StateHevCycle,
+		GetHevCycleConfiguration: StateHevCycleConfiguration,
+		GetLastHevCycleResult:    StateLastHevCycleResult,
+	} {
+		lifxlan.ExpectedResponses[msg] = resp
+	}
+}
@@ -0,0 +1,68 @@
+package hev_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/hev"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestWrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	var label lifxlan.Label
+	label.Set("foo")
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{
+		Label: label,
+	}
+
+	t.Run(
+		"Normal",
+		func(t *testing.T) {
+			service.Handlers[hev.GetHevCycle] = stateHevCycleHandler(
+				t,
+				&hev.RawStateHevCyclePayload{},
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			hd, err := hev.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatalf("Expected successful wrapping, got: %v", err)
+			}
+			if hd.Label().String() != label.String() {
+				t.Errorf("Label expected %v, got %v", label, hd.Label())
+			}
+		},
+	)
+
+	t.Run(
+		"StateUnhandled",
+		func(t *testing.T) {
+			const msg = hev.GetHevCycle
+
+			service.Handlers[msg] = mock.StateUnhandledHandler(msg)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := hev.Wrap(ctx, device, false); err == nil {
+				t.Error("Expected Wrap to return error, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+}
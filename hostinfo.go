@@ -0,0 +1,89 @@
+package lifxlan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+)
+
+// RawStateHostInfoPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statehostinfo---packet-13
+type RawStateHostInfoPayload struct {
+	Signal  float32
+	TxBytes uint32
+	RxBytes uint32
+	_       [2]byte // reserved
+}
+
+// HostInfo defines the host MCU radio info returned by GetHostInfo.
+type HostInfo struct {
+	// The raw signal value reported by the device,
+	// in the same format as WifiInfo.Signal.
+	Signal float32
+
+	// The number of bytes transmitted and received since power on.
+	TxBytes uint32
+	RxBytes uint32
+}
+
+// RSSI converts the raw signal value into RSSI the same way as WifiInfo.RSSI.
+func (h HostInfo) RSSI() int {
+	return signalRSSI(h.Signal)
+}
+
+func (d *device) GetHostInfo(ctx context.Context, conn net.Conn) (*HostInfo, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetHostInfo,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateHostInfo,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateHostInfoPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &HostInfo{
+		Signal:  raw.Signal,
+		TxBytes: raw.TxBytes,
+		RxBytes: raw.RxBytes,
+	}, nil
+}
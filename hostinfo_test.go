@@ -0,0 +1,49 @@
+package lifxlan_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestGetHostInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	expected := lifxlan.HostInfo{
+		Signal:  1e-6,
+		TxBytes: 1234,
+		RxBytes: 5678,
+	}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.Handlers[lifxlan.GetHostInfo] = mock.ReplyHandler(
+		lifxlan.StateHostInfo,
+		&lifxlan.RawStateHostInfoPayload{
+			Signal:  expected.Signal,
+			TxBytes: expected.TxBytes,
+			RxBytes: expected.RxBytes,
+		},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	info, err := device.GetHostInfo(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *info != expected {
+		t.Errorf("HostInfo expected %+v, got %+v", expected, *info)
+	}
+	if rssi := info.RSSI(); rssi != -60 {
+		t.Errorf("RSSI expected -60, got %d", rssi)
+	}
+}
@@ -0,0 +1,95 @@
+package lifxlan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"time"
+)
+
+// RawStateInfoPayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#stateinfo---packet-35
+type RawStateInfoPayload struct {
+	Time     uint64 // nanoseconds since unix epoch
+	Uptime   uint64 // nanoseconds
+	Downtime uint64 // nanoseconds
+}
+
+// DeviceInfo defines the device runtime info returned by GetInfo.
+type DeviceInfo struct {
+	// The current time according to the device.
+	Time time.Time
+
+	// How long the device has been powered on.
+	Uptime time.Duration
+
+	// How long the device was powered off before the last power on,
+	// with a precision of roughly 5 seconds.
+	Downtime time.Duration
+}
+
+// nanosToDuration converts uint64 nanoseconds into time.Duration,
+// values overflowing time.Duration will be capped at its max value.
+func nanosToDuration(ns uint64) time.Duration {
+	if ns > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(ns)
+}
+
+func (d *device) GetInfo(ctx context.Context, conn net.Conn) (*DeviceInfo, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetInfo,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateInfo,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateInfoPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &DeviceInfo{
+		Time:     time.Unix(0, int64(nanosToDuration(raw.Time))),
+		Uptime:   nanosToDuration(raw.Uptime),
+		Downtime: nanosToDuration(raw.Downtime),
+	}, nil
+}
@@ -0,0 +1,81 @@
+package lifxlan_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestGetInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	now := time.Date(2020, time.November, 1, 2, 3, 4, 5, time.UTC)
+
+	for _, c := range []struct {
+		label    string
+		raw      lifxlan.RawStateInfoPayload
+		expected lifxlan.DeviceInfo
+	}{
+		{
+			label: "Normal",
+			raw: lifxlan.RawStateInfoPayload{
+				Time:     uint64(now.UnixNano()),
+				Uptime:   uint64(time.Hour),
+				Downtime: uint64(time.Second * 5),
+			},
+			expected: lifxlan.DeviceInfo{
+				Time:     now,
+				Uptime:   time.Hour,
+				Downtime: time.Second * 5,
+			},
+		},
+		{
+			label: "Overflow",
+			raw: lifxlan.RawStateInfoPayload{
+				Time:     uint64(now.UnixNano()),
+				Uptime:   math.MaxUint64,
+				Downtime: math.MaxInt64 + 1,
+			},
+			expected: lifxlan.DeviceInfo{
+				Time:     now,
+				Uptime:   math.MaxInt64,
+				Downtime: math.MaxInt64,
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.Handlers[lifxlan.GetInfo] = mock.ReplyHandler(lifxlan.StateInfo, &c.raw)
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				info, err := device.GetInfo(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.Time.Equal(c.expected.Time) {
+					t.Errorf("Time expected %v, got %v", c.expected.Time, info.Time)
+				}
+				if info.Uptime != c.expected.Uptime {
+					t.Errorf("Uptime expected %v, got %v", c.expected.Uptime, info.Uptime)
+				}
+				if info.Downtime != c.expected.Downtime {
+					t.Errorf("Downtime expected %v, got %v", c.expected.Downtime, info.Downtime)
+				}
+			},
+		)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/binary"
 	"flag"
+	"fmt"
 	"net"
+	"time"
 )
 
 // EmptyLabel is the constant to be compared against Device.Label().String().
@@ -22,6 +24,13 @@ type RawStateLabelPayload struct {
 	Label Label
 }
 
+// RawSetLabelPayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setlabel---packet-24
+type RawSetLabelPayload struct {
+	Label Label
+}
+
 // LabelLength is the length of the raw label used in messages.
 const LabelLength = 32
 
@@ -87,25 +96,158 @@ func (d *device) GetLabel(ctx context.Context, conn net.Conn) error {
 		return err
 	}
 
-	for {
-		resp, err := ReadNextResponse(ctx, conn)
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateLabel,
+		1, // count
+	)
+	if err != nil {
+		return err
+	}
+
+	var raw RawStateLabelPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return err
+	}
+
+	d.label = raw.Label
+	d.labelFetched = time.Now()
+	return nil
+}
+
+func (d *device) LabelFetched() time.Time {
+	return d.labelFetched
+}
+
+// LabelCacheTTL is the duration RefreshLabels considers the cached label of a
+// device fresh after it's fetched via GetLabel.
+//
+// Devices with their labels fetched within LabelCacheTTL are skipped by
+// RefreshLabels without any network traffic.
+// The default is 0, which means RefreshLabels always fetches the labels.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var LabelCacheTTL time.Duration
+
+// labelFresh returns true if the label of d was fetched within LabelCacheTTL.
+func labelFresh(d Device) bool {
+	ttl := LabelCacheTTL
+	if ttl <= 0 {
+		return false
+	}
+	fetched := d.LabelFetched()
+	if fetched.IsZero() {
+		return false
+	}
+	return time.Since(fetched) < ttl
+}
+
+// RefreshLabels fetches the labels of devices via GetLabel in parallel,
+// with at most concurrency calls running at the same time
+// (see ForEachDevice),
+// so their cached Label are updated.
+//
+// Devices with labels fetched within LabelCacheTTL are skipped.
+//
+// The returned errors are in the same order as devices,
+// with nil for devices that are refreshed successfully or skipped,
+// so a single unreachable device doesn't fail the whole batch.
+func RefreshLabels(ctx context.Context, devices []Device, concurrency int) []error {
+	errs := make([]error, len(devices))
+	stale := make([]Device, 0, len(devices))
+	indices := make([]int, 0, len(devices))
+	for i, d := range devices {
+		if labelFresh(d) {
+			continue
+		}
+		stale = append(stale, d)
+		indices = append(indices, i)
+	}
+
+	for i, err := range ForEachDevice(
+		ctx,
+		stale,
+		concurrency,
+		func(ctx context.Context, d Device, conn net.Conn) error {
+			return d.GetLabel(ctx, conn)
+		},
+	) {
+		errs[indices[i]] = err
+	}
+	return errs
+}
+
+// checkLabel returns an error if label is too long to be encoded into Label
+// without truncation.
+func checkLabel(caller, label string) error {
+	if len(label) > LabelLength {
+		return fmt.Errorf(
+			"%s: label too long: %d > %d bytes",
+			caller,
+			len(label),
+			LabelLength,
+		)
+	}
+	return nil
+}
+
+func (d *device) SetLabel(
+	ctx context.Context,
+	conn net.Conn,
+	label string,
+	ack bool,
+) error {
+	if err := checkLabel("lifxlan.Device.SetLabel", label); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
 		if err != nil {
 			return err
 		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-		if resp.Message != StateLabel {
-			continue
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+	}
 
-		var raw RawStateLabelPayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+	var payload RawSetLabelPayload
+	payload.Label.Set(label)
+
+	var flags AckResFlag
+	if ack {
+		flags |= FlagAckRequired
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		flags,
+		SetLabel,
+		&payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		if err := WaitForAcks(ctx, conn, d.Source(), seq); err != nil {
 			return err
 		}
-
-		d.label = raw.Label
-		return nil
 	}
+
+	d.label = payload.Label
+	return nil
 }
@@ -1,7 +1,11 @@
 package lifxlan_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -113,3 +117,191 @@ func TestGetLabel(t *testing.T) {
 		t.Errorf("Label expected %v, got %v", expected, device.Label())
 	}
 }
+
+func TestSetLabel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStateLabelPayload = &lifxlan.RawStateLabelPayload{}
+	service.Handlers[lifxlan.SetLabel] = func(
+		s *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw lifxlan.RawSetLabelPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Fatal(err)
+		}
+		s.RawStateLabelPayload.Label = raw.Label
+	}
+
+	for _, c := range []struct {
+		label string
+		ack   bool
+	}{
+		{
+			label: "foo",
+			ack:   true,
+		},
+		{
+			label: "中文",
+			ack:   true,
+		},
+		{
+			label: "01234567890123456789012345678901",
+			ack:   true,
+		},
+		{
+			label: "bar",
+			ack:   false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := device.SetLabel(ctx, nil, c.label, c.ack); err != nil {
+					t.Fatal(err)
+				}
+				if got := device.Label().String(); got != c.label {
+					t.Errorf("Cached label expected %q, got %q", c.label, got)
+				}
+				*device.Label() = lifxlan.Label{}
+
+				if !c.ack {
+					// Wait for the message to reach the mock service.
+					time.Sleep(time.Millisecond * 10)
+				}
+				if err := device.GetLabel(ctx, nil); err != nil {
+					t.Fatal(err)
+				}
+				if got := device.Label().String(); got != c.label {
+					t.Errorf("Label expected %q, got %q", c.label, got)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"TooLong",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			// 33 bytes in utf8
+			label := "中文678901234567890123456789012"
+			if err := device.SetLabel(ctx, nil, label, true); err == nil {
+				t.Error("Expected error for label too long, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+}
+
+func TestRefreshLabels(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	defer func(orig time.Duration) {
+		lifxlan.LabelCacheTTL = orig
+	}(lifxlan.LabelCacheTTL)
+	lifxlan.LabelCacheTTL = 0
+
+	labels := []string{"foo", "bar"}
+	var calls int32
+	devices := make([]lifxlan.Device, 0, len(labels)+1)
+	for _, label := range labels {
+		var raw lifxlan.RawStateLabelPayload
+		raw.Label.Set(label)
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, raw); err != nil {
+			t.Fatal(err)
+		}
+		payload := buf.Bytes()
+
+		service := &mock.Service{
+			TB: t,
+			Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+				lifxlan.GetLabel: func(
+					s *mock.Service,
+					conn net.PacketConn,
+					addr net.Addr,
+					orig *lifxlan.Response,
+				) {
+					atomic.AddInt32(&calls, 1)
+					s.Reply(conn, addr, orig, lifxlan.StateLabel, payload)
+				},
+			},
+			HandleAcks: true,
+		}
+		devices = append(devices, service.Start())
+		defer service.Stop()
+	}
+
+	// An unreachable device.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	devices = append(devices, lifxlan.NewDevice(conn.LocalAddr().String(), lifxlan.ServiceUDP, mock.Target))
+
+	refresh := func(t *testing.T) []error {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return lifxlan.RefreshLabels(ctx, devices, 2)
+	}
+
+	errs := refresh(t)
+	for i, label := range labels {
+		if errs[i] != nil {
+			t.Errorf("#%d: Expected nil error, got %v", i, errs[i])
+		}
+		if got := devices[i].Label().String(); got != label {
+			t.Errorf("#%d: Label expected %q, got %q", i, label, got)
+		}
+	}
+	if errs[len(labels)] == nil {
+		t.Error("Expected error from the unreachable device")
+	}
+	if got := atomic.LoadInt32(&calls); got != int32(len(labels)) {
+		t.Errorf("Expected %d GetLabel calls, got %d", len(labels), got)
+	}
+	for i := range labels {
+		if devices[i].LabelFetched().IsZero() {
+			t.Errorf("#%d: Expected LabelFetched to be set", i)
+		}
+	}
+	if fetched := devices[len(labels)].LabelFetched(); !fetched.IsZero() {
+		t.Errorf("Expected zero LabelFetched from the unreachable device, got %v", fetched)
+	}
+
+	lifxlan.LabelCacheTTL = time.Minute
+	errs = refresh(t)
+	for i := range labels {
+		if errs[i] != nil {
+			t.Errorf("#%d: Expected nil error with cached label, got %v", i, errs[i])
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != int32(len(labels)) {
+		t.Errorf("Expected no more GetLabel calls within LabelCacheTTL, got %d", got-int32(len(labels)))
+	}
+	if errs[len(labels)] == nil {
+		t.Error("Expected error from the unreachable device without cached label")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
+	"math"
 	"net"
 	"time"
 
@@ -19,6 +21,45 @@ type RawSetColorPayload struct {
 	Duration lifxlan.TransitionTime
 }
 
+// rawSetColorPayloadLength is the size of RawSetColorPayload in messages.
+const rawSetColorPayloadLength = 13
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawSetColorPayload) MarshalBinary() ([]byte, error) {
+	data := make([]byte, rawSetColorPayloadLength)
+	if err := putColor(data[1:], p.Color); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[9:], uint32(p.Duration))
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawSetColorPayload) UnmarshalBinary(data []byte) error {
+	if err := lifxlan.CheckPayloadSize(
+		"lifxlan/light.RawSetColorPayload.UnmarshalBinary",
+		data,
+		rawSetColorPayloadLength,
+	); err != nil {
+		return err
+	}
+	if err := p.Color.UnmarshalBinary(data[1:]); err != nil {
+		return err
+	}
+	p.Duration = lifxlan.TransitionTime(binary.LittleEndian.Uint32(data[9:]))
+	return nil
+}
+
+// putColor writes the encoded c into the beginning of data.
+func putColor(data []byte, c lifxlan.Color) error {
+	encoded, err := c.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	copy(data, encoded)
+	return nil
+}
+
 func (ld *device) SetColor(
 	ctx context.Context,
 	conn net.Conn,
@@ -26,6 +67,86 @@ func (ld *device) SetColor(
 	transition time.Duration,
 	ack bool,
 ) error {
+	return ld.setColor(ctx, conn, ld.sanitizeColor(color), transition, ack)
+}
+
+// sanitizeColor sanitizes color the way SetColor does.
+func (ld *device) sanitizeColor(color *lifxlan.Color) lifxlan.Color {
+	c := *color
+	if SanitizeSaturatedKelvin {
+		c = c.Sanitized(lifxlan.KelvinLowest, lifxlan.KelvinHighest)
+	}
+	return ld.SanitizeColor(c)
+}
+
+func (ld *device) SetColorOpt(
+	ctx context.Context,
+	conn net.Conn,
+	color *lifxlan.Color,
+	transition time.Duration,
+	window time.Duration,
+) (acked bool, err error) {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return false, fmt.Errorf("lifxlan/light.SetColorOpt: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return false, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := ld.Send(
+		ctx,
+		conn,
+		lifxlan.FlagAckRequired,
+		SetColor,
+		&RawSetColorPayload{
+			Color:    ld.sanitizeColor(color),
+			Duration: lifxlan.ConvertDuration(transition),
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return lifxlan.WaitForAcksWithin(ctx, conn, ld.Source(), window, seq)
+}
+
+// SanitizeSaturatedKelvin controls whether SetColor also sanitizes the color
+// via lifxlan.Color.Sanitized before sending it,
+// which normalizes the kelvin of fully saturated colors.
+//
+// The color is always sanitized by lifxlan.Device.SanitizeColor regardless.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var SanitizeSaturatedKelvin bool
+
+// setColor is the same as SetColor, but without sanitizing color.
+func (ld *device) setColor(
+	ctx context.Context,
+	conn net.Conn,
+	color lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/light.SetColor: %w", err)
+	}
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -55,7 +176,7 @@ func (ld *device) SetColor(
 		flags,
 		SetColor,
 		&RawSetColorPayload{
-			Color:    ld.SanitizeColor(*color),
+			Color:    color,
 			Duration: lifxlan.ConvertDuration(transition),
 		},
 	)
@@ -114,27 +235,115 @@ func (ld *device) GetColor(
 	}
 
 	// Read
-	for {
-		resp, err := lifxlan.ReadNextResponse(ctx, conn)
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		ld.Source(),
+		seq,
+		State,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStatePayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	*ld.Label() = raw.Label
+	// Make a copy so we don't pin the whole raw payload from gc.
+	color := raw.Color
+	return &color, nil
+}
+
+// AdjustBrightnessValue adds delta percent of the full brightness range to
+// brightness,
+// and clamps the result into [0, 65535].
+//
+// For example a delta of 10 increases brightness by about 6554,
+// and a delta of -100 always results in 0.
+func AdjustBrightnessValue(brightness uint16, delta int) uint16 {
+	v := float64(brightness) + math.Round(float64(delta)*math.MaxUint16/100)
+	if v < 0 {
+		return 0
+	}
+	if v > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(v)
+}
+
+func (ld *device) AdjustBrightness(
+	ctx context.Context,
+	conn net.Conn,
+	delta int,
+	transition time.Duration,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if resp.Sequence != seq || resp.Source != ld.Source() {
-			continue
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		if resp.Message != State {
-			continue
+	}
+
+	color, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		return err
+	}
+	color.Brightness = AdjustBrightnessValue(color.Brightness, delta)
+	return ld.SetColor(ctx, conn, color, transition, ack)
+}
+
+func (ld *device) SetKelvin(
+	ctx context.Context,
+	conn net.Conn,
+	kelvin uint16,
+	transition time.Duration,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
 		}
+		defer newConn.Close()
+		conn = newConn
 
-		var raw RawStatePayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return nil, err
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+	}
 
-		*ld.Label() = raw.Label
-		// Make a copy so we don't pin the whole raw payload from gc.
-		color := raw.Color
-		return &color, nil
+	color, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		return err
+	}
+	min, max := lifxlan.CachedKelvinRange(ld)
+	if kelvin < min {
+		kelvin = min
+	}
+	if kelvin > max {
+		kelvin = max
 	}
+	color.Kelvin = kelvin
+	color.Saturation = 0
+	return ld.setColor(ctx, conn, *color, transition, ack)
 }
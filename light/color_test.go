@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"net"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -155,3 +156,475 @@ func TestSetColor(t *testing.T) {
 		},
 	)
 }
+
+func TestSetColorOpt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 200
+		window  = time.Millisecond * 30
+	)
+
+	color := &lifxlan.Color{
+		Hue:        1,
+		Saturation: 2,
+		Brightness: 3,
+		Kelvin:     3500,
+	}
+
+	for _, c := range []struct {
+		label      string
+		handleAcks bool
+	}{
+		{
+			label:      "Acked",
+			handleAcks: true,
+		},
+		{
+			label:      "NotAcked",
+			handleAcks: false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var lock sync.Mutex
+				var received []light.RawSetColorPayload
+				service := &mock.Service{
+					TB:         t,
+					HandleAcks: c.handleAcks,
+					Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+						light.SetColor: func(
+							_ *mock.Service,
+							_ net.PacketConn,
+							_ net.Addr,
+							orig *lifxlan.Response,
+						) {
+							var raw light.RawSetColorPayload
+							r := bytes.NewReader(orig.Payload)
+							if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+								t.Error(err)
+								return
+							}
+							lock.Lock()
+							defer lock.Unlock()
+							received = append(received, raw)
+						},
+					},
+					RawStatePayload: &light.RawStatePayload{},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				ld, err := light.Wrap(ctx, device, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				acked, err := ld.SetColorOpt(ctx, nil, color, time.Second, window)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if acked != c.handleAcks {
+					t.Errorf("acked expected %v, got %v", c.handleAcks, acked)
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if len(received) != 1 {
+					t.Fatalf("Expected 1 SetColor message, got %d", len(received))
+				}
+				if received[0].Color != *color {
+					t.Errorf("Color expected %v, got %v", *color, received[0].Color)
+				}
+			},
+		)
+	}
+}
+
+func TestAdjustBrightnessValue(t *testing.T) {
+	for _, c := range []struct {
+		label      string
+		brightness uint16
+		delta      int
+		expected   uint16
+	}{
+		{
+			label:      "Zero",
+			brightness: 1000,
+			delta:      0,
+			expected:   1000,
+		},
+		{
+			label:      "Up",
+			brightness: 1000,
+			delta:      10,
+			expected:   1000 + 6554,
+		},
+		{
+			label:      "Down",
+			brightness: 10000,
+			delta:      -10,
+			expected:   10000 - 6554,
+		},
+		{
+			label:      "ClampHigh",
+			brightness: 60000,
+			delta:      10,
+			expected:   65535,
+		},
+		{
+			label:      "ExactHigh",
+			brightness: 0,
+			delta:      100,
+			expected:   65535,
+		},
+		{
+			label:      "ClampLow",
+			brightness: 1000,
+			delta:      -10,
+			expected:   0,
+		},
+		{
+			label:      "ExactLow",
+			brightness: 65535,
+			delta:      -100,
+			expected:   0,
+		},
+		{
+			label:      "Huge",
+			brightness: 1,
+			delta:      1 << 20,
+			expected:   65535,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if got := light.AdjustBrightnessValue(c.brightness, c.delta); got != c.expected {
+					t.Errorf(
+						"AdjustBrightnessValue(%d, %d) expected %d, got %d",
+						c.brightness,
+						c.delta,
+						c.expected,
+						got,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestAdjustBrightness(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	current := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{
+		Color: current,
+	}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var set *lifxlan.Color
+	service.Handlers[light.SetColor] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw light.RawSetColorPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		set = &raw.Color
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := ld.AdjustBrightness(ctx, nil, -20, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if set == nil {
+		t.Fatal("SetColor message not received")
+	}
+	expected := current
+	expected.Brightness = light.AdjustBrightnessValue(current.Brightness, -20)
+	if *set != expected {
+		t.Errorf("Color expected %v, got %v", expected, *set)
+	}
+}
+
+func TestSetKelvin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	mockProductMap(t)
+
+	const timeout = time.Millisecond * 200
+
+	current := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{
+		Color: current,
+	}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var set *lifxlan.Color
+	service.Handlers[light.SetColor] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw light.RawSetColorPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		set = &raw.Color
+	}
+
+	for _, c := range []struct {
+		label    string
+		version  lifxlan.HardwareVersion
+		kelvin   uint16
+		expected uint16
+	}{
+		{
+			label:    "Unknown",
+			kelvin:   2000,
+			expected: 2000,
+		},
+		{
+			label:    "UnknownClampLow",
+			kelvin:   1000,
+			expected: lifxlan.KelvinLowest,
+		},
+		{
+			label:    "UnknownClampHigh",
+			kelvin:   10000,
+			expected: lifxlan.KelvinHighest,
+		},
+		{
+			label: "Known",
+			version: lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 1,
+			},
+			kelvin:   150,
+			expected: 150,
+		},
+		{
+			label: "KnownClamp",
+			version: lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 1,
+			},
+			kelvin:   1000,
+			expected: 200,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				*ld.HardwareVersion() = c.version
+				set = nil
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := ld.SetKelvin(ctx, nil, c.kelvin, 0, true); err != nil {
+					t.Fatal(err)
+				}
+				if set == nil {
+					t.Fatal("SetColor message not received")
+				}
+				expected := current
+				expected.Kelvin = c.expected
+				expected.Saturation = 0
+				if *set != expected {
+					t.Errorf("Color expected %v, got %v", expected, *set)
+				}
+			},
+		)
+	}
+}
+
+func TestSanitizeSaturatedKelvin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	defer func(orig bool) {
+		light.SanitizeSaturatedKelvin = orig
+	}(light.SanitizeSaturatedKelvin)
+
+	server, device := mock.StartServer(t, mock.State{})
+	defer server.Stop()
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	color := lifxlan.Color{
+		Hue:        1,
+		Saturation: 65535,
+		Brightness: 3,
+		Kelvin:     3000,
+	}
+
+	for _, c := range []struct {
+		label    string
+		sanitize bool
+		expected uint16
+	}{
+		{
+			label:    "Disabled",
+			sanitize: false,
+			expected: 3000,
+		},
+		{
+			label:    "Enabled",
+			sanitize: true,
+			expected: lifxlan.KelvinNeutral,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				light.SanitizeSaturatedKelvin = c.sanitize
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := ld.SetColor(ctx, nil, &color, 0, true); err != nil {
+					t.Fatal(err)
+				}
+				if actual := server.State().Color.Kelvin; actual != c.expected {
+					t.Errorf("Kelvin expected %d, got %d", c.expected, actual)
+				}
+			},
+		)
+	}
+}
+
+func TestGetColorStale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	staleColor := lifxlan.Color{Hue: 1, Kelvin: 3500}
+	freshColor := lifxlan.Color{Hue: 2, Kelvin: 3500}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(color lifxlan.Color) []byte {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, &light.RawStatePayload{
+			Color: color,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	service.Handlers[light.Get] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		// Late responses to a previous call and to another source.
+		prevSeq := *orig
+		prevSeq.Sequence--
+		s.Reply(conn, addr, &prevSeq, light.State, encode(staleColor))
+		otherSource := *orig
+		otherSource.Source++
+		s.Reply(conn, addr, &otherSource, light.State, encode(staleColor))
+
+		s.Reply(conn, addr, orig, light.State, encode(freshColor))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := ld.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	color, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *color != freshColor {
+		t.Errorf("GetColor expected %+v, got %+v", freshColor, *color)
+	}
+}
@@ -2,7 +2,6 @@ package light
 
 import (
 	"context"
-	"fmt"
 	"net"
 	"time"
 
@@ -23,6 +22,9 @@ type Device interface {
 
 	// SetColor sets the light device with the given color.
 	//
+	// The color is sanitized via SanitizeColor before sending,
+	// and also via lifxlan.Color.Sanitized if SanitizeSaturatedKelvin is true.
+	//
 	// If conn is nil,
 	// a new connection will be made and guaranteed to be closed before returning.
 	// You should pre-dial and pass in the conn if you plan to call APIs on this
@@ -35,9 +37,40 @@ type Device interface {
 	// device.
 	SetColor(ctx context.Context, conn net.Conn, color *lifxlan.Color, transition time.Duration, ack bool) error
 
+	// SetColorOpt is the best effort confirmation version of SetColor.
+	//
+	// It sends the same message as SetColor with ack required,
+	// and waits for the ack for up to window,
+	// or lifxlan.DefaultAckWindow if window <= 0
+	// (see lifxlan.WaitForAcksWithin).
+	// acked reports whether the ack arrived in time,
+	// a missing ack is not considered an error.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	SetColorOpt(ctx context.Context, conn net.Conn, color *lifxlan.Color, transition time.Duration, window time.Duration) (acked bool, err error)
+
+	// GetLightPower returns the current power level of the light device.
+	//
+	// It's the same as the GetPower from lifxlan.Device,
+	// except that it uses the light specific GetLightPower message.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetLightPower(ctx context.Context, conn net.Conn) (lifxlan.Power, error)
+
 	// SetLightPower sets the power level of the device and specifies how long it
 	// will take to transition to the new power state.
 	//
+	// Unlike the SetPower from lifxlan.Device,
+	// it supports a transition duration,
+	// e.g. to fade a light on over 2 seconds.
+	// transition is encoded in milliseconds.
+	//
 	// If conn is nil,
 	// a new connection will be made and guaranteed to be closed before returning.
 	// You should pre-dial and pass in the conn if you plan to call APIs on this
@@ -50,10 +83,155 @@ type Device interface {
 	// device.
 	SetLightPower(ctx context.Context, conn net.Conn, power lifxlan.Power, transition time.Duration, ack bool) error
 
+	// AdjustBrightness reads the current color of the device via GetColor,
+	// adjusts its brightness by delta percent (see AdjustBrightnessValue),
+	// and sets it back via SetColor.
+	//
+	// Hue, saturation and kelvin are preserved,
+	// so a colored light stays colored.
+	//
+	// The current color is always read from the device right before the change
+	// instead of any cached state,
+	// so changes made by other clients (e.g. the LIFX app) since the last call
+	// are respected.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the SetColor message is sent
+	// successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	AdjustBrightness(ctx context.Context, conn net.Conn, delta int, transition time.Duration, ack bool) error
+
+	// SetKelvin reads the current color of the device via GetColor,
+	// replaces its kelvin value,
+	// and sets it back via SetColor.
+	//
+	// Please note that it also sets saturation to 0,
+	// as kelvin has no visible effect on a saturated color.
+	// Brightness and hue are preserved.
+	// Same as AdjustBrightness,
+	// the current color is always read from the device instead of any cached
+	// state.
+	//
+	// kelvin will be clamped into the supported temperature range of the
+	// device based on its cached HardwareVersion and Firmware,
+	// or [KelvinLowest, KelvinHighest] if the hardware version was never fetched
+	// and cached.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the SetColor message is sent
+	// successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetKelvin(ctx context.Context, conn net.Conn, kelvin uint16, transition time.Duration, ack bool) error
+
 	// SetWaveform sends SetWaveformOptional message as defined in
 	//
 	// https://lan.developer.lifx.com/docs/changing-a-device#setwaveformoptional---packet-119
+	//
+	// See also SetWaveformOptional.
 	SetWaveform(ctx context.Context, conn net.Conn, args *SetWaveformArgs, ack bool) error
+
+	// SetWaveformOptional is the same as SetWaveform,
+	// but takes the set_* flags of SetWaveformOptional message explicitly,
+	// overriding the Keep* fields of args.
+	//
+	// Only the HSBK components with their set* arg being true will be changed.
+	// For example, passing true only to setBrightness pulses the brightness
+	// without changing the current hue, saturation and kelvin.
+	SetWaveformOptional(
+		ctx context.Context,
+		conn net.Conn,
+		setHue, setSaturation, setBrightness, setKelvin bool,
+		args *SetWaveformArgs,
+		ack bool,
+	) error
+
+	// Flash flashes the device to color for cycles times,
+	// with each cycle lasting period.
+	//
+	// It uses SetWaveform with WaveformPulse and Transient set to true,
+	// so the device returns to its original color automatically afterwards.
+	// Half of each period is spent on color.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	// In both cases it returns before the flashing finishes.
+	Flash(ctx context.Context, conn net.Conn, color lifxlan.Color, period time.Duration, cycles uint16, ack bool) error
+
+	// Breathe gently pulses the device to color and back for cycles times,
+	// with each cycle lasting period.
+	//
+	// It uses SetWaveform with WaveformSine and Transient set to true,
+	// so the device returns to its original color automatically afterwards.
+	//
+	// peak is mapped to SkewRatio directly and controls where in a cycle color
+	// is at its maximum:
+	// 0.5 is a symmetric breath,
+	// lower values reach color earlier and spend longer fading back to the
+	// original color,
+	// higher values are the reverse.
+	// It will be clamped into [0, 1].
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	// In both cases it returns before the breathing finishes.
+	Breathe(ctx context.Context, conn net.Conn, color lifxlan.Color, period time.Duration, cycles uint16, peak float64, ack bool) error
+
+	// GetInfrared returns the current max infrared brightness of the device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// Devices without infrared capability never reply,
+	// in which case this function returns an error wrapping ctx.Err() after ctx
+	// is cancelled.
+	// So it's important to set an appropriate timeout on the context.
+	GetInfrared(ctx context.Context, conn net.Conn) (uint16, error)
+
+	// SetInfrared sets the max infrared brightness of the device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetInfrared(ctx context.Context, conn net.Conn, brightness uint16, ack bool) error
 }
 
 type device struct {
@@ -63,11 +241,5 @@ type device struct {
 var _ Device = (*device)(nil)
 
 func (ld *device) String() string {
-	if label := ld.Label().String(); label != lifxlan.EmptyLabel {
-		return fmt.Sprintf("%s(%v)", label, ld.Target())
-	}
-	if parsed := ld.HardwareVersion().Parse(); parsed != nil {
-		return fmt.Sprintf("%s(%v)", parsed.ProductName, ld.Target())
-	}
-	return fmt.Sprintf("LightDevice(%v)", ld.Target())
+	return lifxlan.FormatDevice(ld, "LightDevice")
 }
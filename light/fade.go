@@ -0,0 +1,162 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// EasingFunc maps the progress t of a fade in [0, 1] to the eased progress,
+// which is also expected to be in [0, 1],
+// with EasingFunc(0) == 0 and EasingFunc(1) == 1.
+type EasingFunc func(t float64) float64
+
+// Built-in EasingFunc values.
+var (
+	// Linear changes the brightness at a constant rate.
+	Linear EasingFunc = func(t float64) float64 {
+		return t
+	}
+
+	// EaseIn starts slowly and speeds up towards the end (cubic),
+	// which is good for sunrise style wake-up lights.
+	EaseIn EasingFunc = func(t float64) float64 {
+		return t * t * t
+	}
+
+	// EaseInOut starts and ends slowly (smoothstep).
+	EaseInOut EasingFunc = func(t float64) float64 {
+		return t * t * (3 - 2*t)
+	}
+)
+
+// FadeStepInterval is the interval between the SetColor messages sent by
+// FadePower.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var FadeStepInterval = time.Second
+
+// FadePower fades ld on or off over duration,
+// by stepping the brightness with easing (Linear if nil),
+// one SetColor message every FadeStepInterval.
+//
+// When fading on,
+// the brightness starts from 0 and ends at the current brightness of ld
+// (or full brightness if it's 0).
+// When fading off,
+// the brightness goes from the current one down towards 0,
+// with the last step being the power off itself,
+// then the original brightness is restored after the light is off,
+// so the next power on isn't stuck at a low brightness.
+// Hue, saturation and kelvin are preserved.
+// If ld is already at power it only makes sure the power is set.
+//
+// All the steps except the last one are sent without waiting for acks,
+// the power changes and the last step wait for them,
+// so the light is left exactly at power when it returns nil error.
+// If conn is a *lifxlan.RateLimitedConn the rate limiter is respected.
+// It returns ctx.Err() if ctx is cancelled in the middle of the fade.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func FadePower(
+	ctx context.Context,
+	conn net.Conn,
+	ld Device,
+	power lifxlan.Power,
+	duration time.Duration,
+	easing EasingFunc,
+) error {
+	if duration < 0 {
+		return errors.New("lifxlan/light.FadePower: negative duration")
+	}
+	if easing == nil {
+		easing = Linear
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	current, err := ld.GetLightPower(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if current.On() == power.On() {
+		return ld.SetLightPower(ctx, conn, power, 0, true)
+	}
+	color, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	steps := 1
+	interval := FadeStepInterval
+	if interval > 0 && duration > interval {
+		steps = int(duration / interval)
+	}
+	interval = duration / time.Duration(steps)
+
+	brightnessAt := func(t float64) uint16 {
+		eased := math.Max(0, math.Min(1, easing(t)))
+		return uint16(math.Round(eased * float64(color.Brightness)))
+	}
+
+	if power.On() {
+		if color.Brightness == 0 {
+			color.Brightness = math.MaxUint16
+		}
+		target := *color
+		start := target
+		start.Brightness = 0
+		if err := ld.SetColor(ctx, conn, &start, 0, true); err != nil {
+			return err
+		}
+		if err := ld.SetLightPower(ctx, conn, power, 0, true); err != nil {
+			return err
+		}
+		return runSteps(ctx, steps, interval, func(i int, last bool) error {
+			step := target
+			if !last {
+				step.Brightness = brightnessAt(float64(i) / float64(steps))
+			}
+			return ld.SetColor(ctx, conn, &step, interval, last)
+		})
+	}
+
+	original := *color
+	if err := runSteps(ctx, steps, interval, func(i int, last bool) error {
+		if last {
+			// The last step is the power off itself.
+			return ld.SetLightPower(ctx, conn, power, interval, true)
+		}
+		step := original
+		step.Brightness = original.Brightness - brightnessAt(float64(i)/float64(steps))
+		return ld.SetColor(ctx, conn, &step, interval, false)
+	}); err != nil {
+		return err
+	}
+	// Wait for the power off transition before restoring the brightness.
+	if err := sleep(ctx, interval); err != nil {
+		return err
+	}
+	return ld.SetColor(ctx, conn, &original, 0, true)
+}
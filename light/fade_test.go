@@ -0,0 +1,140 @@
+package light_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestEasingFuncs(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		easing   light.EasingFunc
+		expected [3]float64
+	}{
+		{
+			label:    "Linear",
+			easing:   light.Linear,
+			expected: [3]float64{0, 0.5, 1},
+		},
+		{
+			label:    "EaseIn",
+			easing:   light.EaseIn,
+			expected: [3]float64{0, 0.125, 1},
+		},
+		{
+			label:    "EaseInOut",
+			easing:   light.EaseInOut,
+			expected: [3]float64{0, 0.5, 1},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				for i, x := range []float64{0, 0.5, 1} {
+					if got := c.easing(x); math.Abs(got-c.expected[i]) > 1e-9 {
+						t.Errorf("%s(%v) expected %v, got %v", c.label, x, c.expected[i], got)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestFadePower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	defer func(orig time.Duration) {
+		light.FadeStepInterval = orig
+	}(light.FadeStepInterval)
+	light.FadeStepInterval = time.Millisecond * 10
+
+	color := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+
+	start := func(t *testing.T, power lifxlan.Power) (*mock.Server, light.Device) {
+		t.Helper()
+
+		server, device := mock.StartServer(t, mock.State{
+			Power: power,
+			Color: color,
+		})
+		t.Cleanup(server.Stop)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		ld, err := light.Wrap(ctx, device, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return server, ld
+	}
+
+	for _, c := range []struct {
+		label string
+		from  lifxlan.Power
+		to    lifxlan.Power
+	}{
+		{
+			label: "On",
+			from:  lifxlan.PowerOff,
+			to:    lifxlan.PowerOn,
+		},
+		{
+			label: "Off",
+			from:  lifxlan.PowerOn,
+			to:    lifxlan.PowerOff,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				server, ld := start(t, c.from)
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := light.FadePower(ctx, nil, ld, c.to, time.Millisecond*30, light.EaseIn); err != nil {
+					t.Fatal(err)
+				}
+				state := server.State()
+				if state.Power.On() != c.to.On() {
+					t.Errorf("Power expected %v, got %v", c.to, state.Power)
+				}
+				if state.Color != color {
+					t.Errorf("Color expected %v, got %v", color, state.Color)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"Cancel",
+		func(t *testing.T) {
+			_, ld := start(t, lifxlan.PowerOff)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := light.FadePower(ctx, nil, ld, lifxlan.PowerOn, time.Second, nil); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+}
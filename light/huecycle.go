@@ -0,0 +1,107 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// HueCycleStepInterval is the interval between the SetColor messages sent by
+// HueCycle.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var HueCycleStepInterval = time.Millisecond * 500
+
+// minHueCycleSteps is the minimal number of steps in a single cycle of
+// HueCycle,
+// so every step is less than half of the hue wheel,
+// and the device always transitions towards the right direction.
+const minHueCycleSteps = 3
+
+// HueCycle cycles the hue of ld around the full hue wheel once every period,
+// with the given saturation and brightness,
+// until ctx is cancelled.
+//
+// The cycle starts from the current hue of ld,
+// and the kelvin of ld is kept (both are read via GetColor).
+// The hue is stepped by one SetColor message every HueCycleStepInterval
+// (or more frequently if period is too short for at least 3 steps),
+// with a transition of the step interval so the steps blend together into a
+// smooth cycle.
+// The messages are sent without waiting for acks.
+// If conn is a *lifxlan.RateLimitedConn the rate limiter is respected.
+//
+// This works on any color light,
+// unlike the firmware effects that are only available on some devices.
+//
+// The function will only return upon error or when ctx is cancelled,
+// in which case ctx.Err() will be returned,
+// leaving the device at the last step sent.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func HueCycle(
+	ctx context.Context,
+	conn net.Conn,
+	ld Device,
+	period time.Duration,
+	saturation, brightness uint16,
+) error {
+	if period <= 0 {
+		return errors.New("lifxlan/light.HueCycle: period must be positive")
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	current, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	steps := minHueCycleSteps
+	if interval := HueCycleStepInterval; interval > 0 && period/interval > minHueCycleSteps {
+		steps = int(period / interval)
+	}
+	interval := period / time.Duration(steps)
+
+	color := lifxlan.Color{
+		Hue:        current.Hue,
+		Saturation: saturation,
+		Brightness: brightness,
+		Kelvin:     current.Kelvin,
+	}
+	for i := 1; ; i = i%steps + 1 {
+		offset := uint64(i) * (math.MaxUint16 + 1) / uint64(steps)
+		step := color
+		step.Hue = current.Hue + uint16(offset)
+		if err := ld.SetColor(ctx, conn, &step, interval, false); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package light_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestHueCycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout    = time.Millisecond * 200
+		steps      = 8
+		saturation = 0xffff
+		brightness = 0x8000
+	)
+
+	defer func(orig time.Duration) {
+		light.HueCycleStepInterval = orig
+	}(light.HueCycleStepInterval)
+	light.HueCycleStepInterval = time.Millisecond * 10
+
+	var lock sync.Mutex
+	var received []lifxlan.Color
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			light.SetColor: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw light.RawSetColorPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw.Color)
+			},
+		},
+		RawStatePayload: &light.RawStatePayload{
+			Color: lifxlan.Color{
+				Hue:    0xf000,
+				Kelvin: lifxlan.KelvinNeutral,
+			},
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ld, err := light.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := light.HueCycle(ctx, nil, ld, 0, saturation, brightness); err == nil {
+		t.Error("Expected error for zero period, got nil")
+	}
+
+	err = light.HueCycle(ctx, nil, ld, light.HueCycleStepInterval*steps, saturation, brightness)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	lock.Lock()
+	n := len(received)
+	if n < steps+1 {
+		lock.Unlock()
+		t.Fatalf("Expected more than a full cycle of %d steps, got %d", steps, n)
+	}
+	const delta = 0x10000 / steps
+	prev := uint16(0xf000)
+	for i, c := range received {
+		// Monotonic around the wheel, with wrapping.
+		if diff := c.Hue - prev; diff != delta {
+			t.Errorf("#%d: Hue expected to advance by %d from %d, got %d", i, delta, prev, c.Hue)
+		}
+		prev = c.Hue
+		if c.Saturation != saturation || c.Brightness != brightness || c.Kelvin != lifxlan.KelvinNeutral {
+			t.Errorf("#%d: Unexpected color %v", i, c)
+		}
+	}
+	lock.Unlock()
+
+	// Make sure it stopped sending after cancellation.
+	time.Sleep(light.HueCycleStepInterval * 3)
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) != n {
+		t.Errorf("Expected no more messages after cancellation, got %d more", len(received)-n)
+	}
+}
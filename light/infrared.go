@@ -0,0 +1,130 @@
+package light
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// RawInfraredPayload defines the struct to be used for encoding and decoding.
+//
+// It's the payload of both SetInfrared and StateInfrared messages:
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setinfrared---packet-122
+type RawInfraredPayload struct {
+	Brightness uint16
+}
+
+func (ld *device) SetInfrared(
+	ctx context.Context,
+	conn net.Conn,
+	brightness uint16,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := ld.Send(
+		ctx,
+		conn,
+		flags,
+		SetInfrared,
+		&RawInfraredPayload{
+			Brightness: brightness,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, ld.Source(), seq)
+	}
+	return nil
+}
+
+func (ld *device) GetInfrared(
+	ctx context.Context,
+	conn net.Conn,
+) (uint16, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := ld.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetInfrared,
+		nil, // payload
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		ld.Source(),
+		seq,
+		StateInfrared,
+		1, // count
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf(
+				"lifxlan/light.GetInfrared: no StateInfrared response from %v, it might not support infrared: %w",
+				ld,
+				err,
+			)
+		}
+		return 0, err
+	}
+
+	var raw RawInfraredPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
+	}
+
+	return raw.Brightness, nil
+}
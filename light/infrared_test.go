@@ -0,0 +1,106 @@
+package light_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestInfrared(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(
+		"Unsupported",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := ld.GetInfrared(ctx, nil); err == nil {
+				t.Error("Expected error for unsupported device, got nil")
+			} else if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected error wrapping context.DeadlineExceeded, got %v", err)
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+
+	var brightness uint16
+	service.Handlers[light.SetInfrared] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw light.RawInfraredPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Fatal(err)
+		}
+		brightness = raw.Brightness
+	}
+	service.Handlers[light.GetInfrared] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(
+			buf,
+			binary.LittleEndian,
+			&light.RawInfraredPayload{
+				Brightness: brightness,
+			},
+		); err != nil {
+			t.Fatal(err)
+		}
+		s.Reply(conn, addr, orig, light.StateInfrared, buf.Bytes())
+	}
+
+	t.Run(
+		"Supported",
+		func(t *testing.T) {
+			const expected = 12345
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := ld.SetInfrared(ctx, nil, expected, true); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ld.GetInfrared(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != expected {
+				t.Errorf("GetInfrared expected %d, got %d", expected, got)
+			}
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package light
 
 import (
+	"encoding/binary"
+
 	"go.yhsif.com/lifxlan"
 )
 
@@ -9,6 +11,32 @@ const (
 	Get                 lifxlan.MessageType = 101
 	SetColor            lifxlan.MessageType = 102
 	State               lifxlan.MessageType = 107
+	GetLightPower       lifxlan.MessageType = 116
 	SetLightPower       lifxlan.MessageType = 117
+	StateLightPower     lifxlan.MessageType = 118
 	SetWaveformOptional lifxlan.MessageType = 119
+	GetInfrared         lifxlan.MessageType = 120
+	StateInfrared       lifxlan.MessageType = 121
+	SetInfrared         lifxlan.MessageType = 122
 )
+
+func init() {
+	for msg, size := range map[lifxlan.MessageType]lifxlan.PayloadSize{
+		Get:                 {Name: "Get", Size: 0},
+		SetColor:            {Name: "SetColor", Size: binary.Size(RawSetColorPayload{})},
+		GetLightPower:       {Name: "GetLightPower", Size: 0},
+		SetLightPower:       {Name: "SetLightPower", Size: binary.Size(RawSetLightPowerPayload{})},
+		SetWaveformOptional: {Name: "SetWaveformOptional", Size: binary.Size(RawSetWaveformOptionalPayload{})},
+		GetInfrared:         {Name: "GetInfrared", Size: 0},
+		SetInfrared:         {Name: "SetInfrared", Size: binary.Size(RawInfraredPayload{})},
+	} {
+		lifxlan.PayloadSizes[msg] = size
+	}
+	for msg, resp := range map[lifxlan.MessageType]lifxlan.MessageType{
+		Get:           State,
+		GetLightPower: StateLightPower,
+		GetInfrared:   StateInfrared,
+	} {
+		lifxlan.ExpectedResponses[msg] = resp
+	}
+}
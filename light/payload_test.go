@@ -0,0 +1,136 @@
+package light_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+type binaryPayload interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+func TestPayloadBinary(t *testing.T) {
+	color := lifxlan.Color{
+		Hue:        0x5555,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     3500,
+	}
+	colorBytes := []byte{0x55, 0x55, 0xff, 0xff, 0x00, 0x80, 0xac, 0x0d}
+
+	concat := func(parts ...[]byte) []byte {
+		var ret []byte
+		for _, part := range parts {
+			ret = append(ret, part...)
+		}
+		return ret
+	}
+
+	for _, c := range []struct {
+		label    string
+		payload  binaryPayload
+		empty    binaryPayload
+		expected []byte
+	}{
+		{
+			label: "RawSetColorPayload",
+			payload: &light.RawSetColorPayload{
+				Color:    color,
+				Duration: 1024,
+			},
+			empty: &light.RawSetColorPayload{},
+			expected: concat(
+				[]byte{0x00}, // reserved
+				colorBytes,
+				[]byte{0x00, 0x04, 0x00, 0x00}, // Duration
+			),
+		},
+		{
+			label: "RawSetLightPowerPayload",
+			payload: &light.RawSetLightPowerPayload{
+				Level:    lifxlan.PowerOn,
+				Duration: 2000,
+			},
+			empty: &light.RawSetLightPowerPayload{},
+			expected: []byte{
+				0xff, 0xff, // Level
+				0xd0, 0x07, 0x00, 0x00, // Duration
+			},
+		},
+		{
+			label: "RawStateLightPowerPayload",
+			payload: &light.RawStateLightPowerPayload{
+				Level: lifxlan.PowerOn,
+			},
+			empty:    &light.RawStateLightPowerPayload{},
+			expected: []byte{0xff, 0xff},
+		},
+		{
+			label: "RawSetWaveformOptionalPayload",
+			payload: &light.RawSetWaveformOptionalPayload{
+				Transient:     1,
+				Color:         color,
+				Period:        1000,
+				Cycles:        1.5,
+				SkewRatio:     -32768,
+				Waveform:      light.WaveformPulse,
+				SetHue:        1,
+				SetSaturation: 0,
+				SetBrightness: 1,
+				SetKelvin:     0,
+			},
+			empty: &light.RawSetWaveformOptionalPayload{},
+			expected: concat(
+				[]byte{0x00}, // reserved
+				[]byte{0x01}, // Transient
+				colorBytes,
+				[]byte{0xe8, 0x03, 0x00, 0x00}, // Period
+				[]byte{0x00, 0x00, 0xc0, 0x3f}, // Cycles
+				[]byte{0x00, 0x80},             // SkewRatio
+				[]byte{0x04},                   // Waveform
+				[]byte{0x01, 0x00, 0x01, 0x00}, // SetHue, SetSaturation, SetBrightness, SetKelvin
+			),
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				data, err := c.payload.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, c.expected) {
+					t.Errorf("MarshalBinary expected %v, got %v", c.expected, data)
+				}
+
+				// It should match the binary.Write encoding.
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, c.payload); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, buf.Bytes()) {
+					t.Errorf("MarshalBinary expected to match binary.Write %v, got %v", buf.Bytes(), data)
+				}
+
+				if err := c.empty.UnmarshalBinary(data); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(c.empty, c.payload) {
+					t.Errorf("UnmarshalBinary expected %+v, got %+v", c.payload, c.empty)
+				}
+
+				if err := c.empty.UnmarshalBinary(data[:len(data)-1]); err == nil {
+					t.Error("Expected UnmarshalBinary error with short data")
+				}
+			},
+		)
+	}
+}
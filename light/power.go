@@ -1,7 +1,10 @@
 package light
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
 	"net"
 	"time"
 
@@ -16,6 +19,118 @@ type RawSetLightPowerPayload struct {
 	Duration lifxlan.TransitionTime
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawSetLightPowerPayload) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 6)
+	binary.LittleEndian.PutUint16(data[0:], uint16(p.Level))
+	binary.LittleEndian.PutUint32(data[2:], uint32(p.Duration))
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawSetLightPowerPayload) UnmarshalBinary(data []byte) error {
+	if err := lifxlan.CheckPayloadSize(
+		"lifxlan/light.RawSetLightPowerPayload.UnmarshalBinary",
+		data,
+		6,
+	); err != nil {
+		return err
+	}
+	p.Level = lifxlan.Power(binary.LittleEndian.Uint16(data[0:]))
+	p.Duration = lifxlan.TransitionTime(binary.LittleEndian.Uint32(data[2:]))
+	return nil
+}
+
+// RawStateLightPowerPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statelightpower---packet-118
+type RawStateLightPowerPayload struct {
+	Level lifxlan.Power
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawStateLightPowerPayload) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, uint16(p.Level))
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawStateLightPowerPayload) UnmarshalBinary(data []byte) error {
+	if err := lifxlan.CheckPayloadSize(
+		"lifxlan/light.RawStateLightPowerPayload.UnmarshalBinary",
+		data,
+		2,
+	); err != nil {
+		return err
+	}
+	p.Level = lifxlan.Power(binary.LittleEndian.Uint16(data))
+	return nil
+}
+
+func (ld *device) GetLightPower(
+	ctx context.Context,
+	conn net.Conn,
+) (lifxlan.Power, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := ld.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetLightPower,
+		nil, // payload
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		ld.Source(),
+		seq,
+		StateLightPower,
+		1, // count
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf(
+				"lifxlan/light.GetLightPower: no StateLightPower response from %v: %w",
+				ld,
+				err,
+			)
+		}
+		return 0, err
+	}
+
+	var raw RawStateLightPowerPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
+	}
+
+	return raw.Level, nil
+}
+
 func (ld *device) SetLightPower(
 	ctx context.Context,
 	conn net.Conn,
@@ -23,6 +138,10 @@ func (ld *device) SetLightPower(
 	transition time.Duration,
 	ack bool,
 ) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/light.SetLightPower: %w", err)
+	}
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -65,3 +184,73 @@ func (ld *device) SetLightPower(
 	}
 	return nil
 }
+
+// SetPowerColor sets ld to power and color together,
+// in the order that avoids showing any unwanted color.
+//
+// Some firmware restores the previous color on power on,
+// while others come up at a default color.
+// So when powering on,
+// the color is set first without transition while the light is still off,
+// and the power is then set with transition,
+// so the light powers up straight into color,
+// without a flash of the old one.
+// The SetColor message always waits for its ack in this case
+// (regardless of ack),
+// as the messages could otherwise be reordered on the network.
+//
+// When powering off,
+// the power is set first and the color is then set with the same transition,
+// so the color change blends into the fade out,
+// and the next power on (e.g. from a physical switch) starts at color.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// If ack is false,
+// this function returns nil error after the last API is sent successfully.
+// If ack is true,
+// this function will only return nil error after it received acks of all the
+// APIs from the device.
+func SetPowerColor(
+	ctx context.Context,
+	conn net.Conn,
+	ld Device,
+	power lifxlan.Power,
+	color lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/light.SetPowerColor: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if power.On() {
+		if err := ld.SetColor(ctx, conn, &color, 0, true); err != nil {
+			return err
+		}
+		return ld.SetLightPower(ctx, conn, power, transition, ack)
+	}
+
+	if err := ld.SetLightPower(ctx, conn, power, transition, ack); err != nil {
+		return err
+	}
+	return ld.SetColor(ctx, conn, &color, transition, ack)
+}
@@ -0,0 +1,190 @@
+package light_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestRawSetLightPowerPayload(t *testing.T) {
+	payload := light.RawSetLightPowerPayload{
+		Level:    lifxlan.PowerOn,
+		Duration: lifxlan.ConvertDuration(time.Second * 2),
+	}
+	if payload.Duration != 2000 {
+		t.Errorf("Duration expected 2000, got %d", payload.Duration)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0xff, 0xff, // Level
+		0xd0, 0x07, 0x00, 0x00, // Duration: 2000
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Encoded payload expected %v, got %v", expected, buf.Bytes())
+	}
+}
+
+func TestLightPower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	server, device := mock.StartServer(t, mock.State{})
+	defer server.Stop()
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := ld.SetLightPower(ctx, nil, lifxlan.PowerOn, time.Second*2, true); err != nil {
+		t.Fatal(err)
+	}
+	power, err := ld.GetLightPower(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !power.On() {
+		t.Errorf("GetLightPower expected %v, got %v", lifxlan.PowerOn, power)
+	}
+}
+
+func TestLightPowerDurationOutOfRange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	server, device := mock.StartServer(t, mock.State{})
+	defer server.Stop()
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	const duration = time.Hour * 24 * 60
+	if err := ld.SetLightPower(ctx, nil, lifxlan.PowerOn, duration, true); !errors.Is(err, lifxlan.ErrDurationOutOfRange) {
+		t.Errorf("SetLightPower expected ErrDurationOutOfRange, got %v", err)
+	}
+	if err := ld.SetColor(ctx, nil, &lifxlan.Color{Kelvin: lifxlan.KelvinNeutral}, duration, true); !errors.Is(err, lifxlan.ErrDurationOutOfRange) {
+		t.Errorf("SetColor expected ErrDurationOutOfRange, got %v", err)
+	}
+	power, err := ld.GetLightPower(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power.On() {
+		t.Error("Expected power to stay off after the rejected SetLightPower")
+	}
+}
+
+func TestSetPowerColor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	color := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+
+	for _, c := range []struct {
+		label    string
+		power    lifxlan.Power
+		expected []lifxlan.MessageType
+	}{
+		{
+			label:    "On",
+			power:    lifxlan.PowerOn,
+			expected: []lifxlan.MessageType{light.SetColor, light.SetLightPower},
+		},
+		{
+			label:    "Off",
+			power:    lifxlan.PowerOff,
+			expected: []lifxlan.MessageType{light.SetLightPower, light.SetColor},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var lock sync.Mutex
+				var received []lifxlan.MessageType
+				record := func(
+					_ *mock.Service,
+					_ net.PacketConn,
+					_ net.Addr,
+					orig *lifxlan.Response,
+				) {
+					lock.Lock()
+					defer lock.Unlock()
+					received = append(received, orig.Message)
+				}
+				service := &mock.Service{
+					TB:         t,
+					HandleAcks: true,
+					Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+						light.SetColor:      record,
+						light.SetLightPower: record,
+					},
+					RawStatePayload: &light.RawStatePayload{},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				ld, err := light.Wrap(ctx, device, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := light.SetPowerColor(ctx, nil, ld, c.power, color, time.Millisecond*100, true); err != nil {
+					t.Fatal(err)
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if !reflect.DeepEqual(received, c.expected) {
+					t.Errorf("Messages expected %v, got %v", c.expected, received)
+				}
+			},
+		)
+	}
+}
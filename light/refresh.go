@@ -0,0 +1,49 @@
+package light
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// RefreshColors fetches the current colors of lights via GetColor in parallel,
+// with at most concurrency calls running at the same time
+// (see lifxlan.ForEachDevice),
+// e.g. to populate the color swatches of a dashboard.
+//
+// The returned map only contains the colors of the lights fetched
+// successfully, keyed by their targets.
+// The returned errors are in the same order as lights,
+// with nil for lights fetched successfully,
+// so a single unreachable light doesn't fail the whole batch.
+func RefreshColors(
+	ctx context.Context,
+	lights []Device,
+	concurrency int,
+) (map[lifxlan.Target]lifxlan.Color, []error) {
+	devices := make([]lifxlan.Device, len(lights))
+	for i, ld := range lights {
+		devices[i] = ld
+	}
+
+	var lock sync.Mutex
+	colors := make(map[lifxlan.Target]lifxlan.Color, len(lights))
+	errs := lifxlan.ForEachDevice(
+		ctx,
+		devices,
+		concurrency,
+		func(ctx context.Context, d lifxlan.Device, conn net.Conn) error {
+			color, err := d.(Device).GetColor(ctx, conn)
+			if err != nil {
+				return err
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			colors[d.Target()] = *color
+			return nil
+		},
+	)
+	return colors, errs
+}
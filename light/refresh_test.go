@@ -0,0 +1,86 @@
+package light_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestRefreshColors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const count = 4
+	// The light that doesn't respond to Get.
+	const failing = 2
+
+	lights := make([]light.Device, count)
+	expected := make(map[lifxlan.Target]lifxlan.Color, count)
+	for i := 0; i < count; i++ {
+		target := lifxlan.Target(i + 1)
+		color := lifxlan.Color{
+			Hue:    uint16(i) * 1000,
+			Kelvin: 3500,
+		}
+		service := &mock.Service{
+			TB:         t,
+			Target:     target,
+			HandleAcks: true,
+			Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+			RawStatePayload: &light.RawStatePayload{
+				Color: color,
+			},
+		}
+		device := service.Start()
+		defer service.Stop()
+
+		ld, err := func() (light.Device, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			return light.Wrap(ctx, device, false)
+		}()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lights[i] = ld
+
+		if i == failing {
+			service.Stop()
+			continue
+		}
+		expected[target] = color
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	colors, errs := light.RefreshColors(ctx, lights, 2)
+	if len(errs) != count {
+		t.Fatalf("Expected %d errors, got %v", count, errs)
+	}
+	for i, err := range errs {
+		if i == failing {
+			if err == nil {
+				t.Errorf("Expected error for light %d", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Light %d: %v", i, err)
+		}
+	}
+	if len(colors) != len(expected) {
+		t.Errorf("Expected %d colors, got %v", len(expected), colors)
+	}
+	for target, color := range expected {
+		if got, ok := colors[target]; !ok || got != color {
+			t.Errorf("Color of %v expected %v, got %v", target, color, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Transition fades ld from its current color to to over duration,
+// by sending steps SetColor messages spaced evenly over duration.
+//
+// It's useful for devices that don't handle the native transition well,
+// or very slow fades (e.g. a 30 minute wake-up light) where the duration
+// doesn't fit into a single message.
+//
+// The starting color is read via GetColor.
+// Each step sets the color interpolated via lifxlan.Color.Lerp,
+// with a transition of duration/steps so the steps blend together.
+// All the steps except the last one are sent without waiting for acks,
+// the last one waits for the ack from the device.
+// If conn is a *lifxlan.RateLimitedConn the rate limiter is respected.
+//
+// It returns ctx.Err() if ctx is cancelled in the middle of the transition,
+// leaving the device at the last step sent.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func Transition(
+	ctx context.Context,
+	conn net.Conn,
+	ld Device,
+	to lifxlan.Color,
+	duration time.Duration,
+	steps int,
+) error {
+	if steps <= 0 {
+		return errors.New("lifxlan/light.Transition: steps must be positive")
+	}
+	if duration < 0 {
+		return errors.New("lifxlan/light.Transition: negative duration")
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := ld.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	from, err := ld.GetColor(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	interval := duration / time.Duration(steps)
+	return runSteps(ctx, steps, interval, func(i int, last bool) error {
+		color := from.Lerp(to, float64(i)/float64(steps))
+		if last {
+			// Avoid rounding errors on the final color.
+			color = to
+		}
+		return ld.SetColor(ctx, conn, &color, interval, last)
+	})
+}
+
+// runSteps calls step with i in [1, steps] in order,
+// waiting interval between the calls,
+// with last set to true for the last call.
+//
+// It returns ctx.Err() if ctx is cancelled while waiting.
+func runSteps(
+	ctx context.Context,
+	steps int,
+	interval time.Duration,
+	step func(i int, last bool) error,
+) error {
+	for i := 1; i <= steps; i++ {
+		last := i == steps
+		if err := step(i, last); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleep waits for d, or returns ctx.Err() if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
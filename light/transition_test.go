@@ -0,0 +1,147 @@
+package light_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestTransition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	from := lifxlan.Color{
+		Hue:        60000,
+		Saturation: 0xffff,
+		Brightness: 0,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	to := lifxlan.Color{
+		Hue:        5000,
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+
+	start := func(t *testing.T) (light.Device, func() []light.RawSetColorPayload) {
+		t.Helper()
+
+		var lock sync.Mutex
+		var received []light.RawSetColorPayload
+		service := &mock.Service{
+			TB: t,
+			Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+				light.SetColor: func(
+					_ *mock.Service,
+					_ net.PacketConn,
+					_ net.Addr,
+					orig *lifxlan.Response,
+				) {
+					var raw light.RawSetColorPayload
+					r := bytes.NewReader(orig.Payload)
+					if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+						t.Error(err)
+						return
+					}
+					lock.Lock()
+					defer lock.Unlock()
+					received = append(received, raw)
+				},
+			},
+			HandleAcks: true,
+			RawStatePayload: &light.RawStatePayload{
+				Color: from,
+			},
+		}
+		device := service.Start()
+		t.Cleanup(service.Stop)
+
+		ld, err := func() (light.Device, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			return light.Wrap(ctx, device, false)
+		}()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ld, func() []light.RawSetColorPayload {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]light.RawSetColorPayload(nil), received...)
+		}
+	}
+
+	t.Run(
+		"Steps",
+		func(t *testing.T) {
+			const steps = 4
+			const duration = time.Millisecond * 40
+
+			ld, received := start(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := light.Transition(ctx, nil, ld, to, duration, steps); err != nil {
+				t.Fatal(err)
+			}
+			got := received()
+			if len(got) != steps {
+				t.Fatalf("Expected %d SetColor messages, got %d", steps, len(got))
+			}
+			for i, raw := range got {
+				expected := from.Lerp(to, float64(i+1)/steps)
+				if raw.Color != expected {
+					t.Errorf("#%d: Color expected %v, got %v", i, expected, raw.Color)
+				}
+				if d := raw.Duration.Duration(); d != duration/steps {
+					t.Errorf("#%d: Duration expected %v, got %v", i, duration/steps, d)
+				}
+			}
+			// Make sure the hue took the short path through 0.
+			if hue := got[0].Color.Hue; hue < from.Hue {
+				t.Errorf("Expected hue to wrap around, got %d", hue)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancel",
+		func(t *testing.T) {
+			const steps = 10
+
+			ld, received := start(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := light.Transition(ctx, nil, ld, to, time.Second, steps); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			if n := len(received()); n >= steps {
+				t.Errorf("Expected fewer than %d SetColor messages, got %d", steps, n)
+			}
+		},
+	)
+
+	t.Run(
+		"InvalidSteps",
+		func(t *testing.T) {
+			if err := light.Transition(context.Background(), nil, nil, to, time.Second, 0); err == nil {
+				t.Error("Expected error with 0 steps")
+			}
+		},
+	)
+}
@@ -2,6 +2,8 @@ package light
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
 	"math"
 	"net"
 	"time"
@@ -68,6 +70,52 @@ type RawSetWaveformOptionalPayload struct {
 	SetKelvin     BoolUint8
 }
 
+// rawSetWaveformOptionalPayloadLength is the size of
+// RawSetWaveformOptionalPayload in messages.
+const rawSetWaveformOptionalPayloadLength = 25
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawSetWaveformOptionalPayload) MarshalBinary() ([]byte, error) {
+	data := make([]byte, rawSetWaveformOptionalPayloadLength)
+	data[1] = uint8(p.Transient)
+	if err := putColor(data[2:], p.Color); err != nil {
+		return nil, err
+	}
+	binary.LittleEndian.PutUint32(data[10:], uint32(p.Period))
+	binary.LittleEndian.PutUint32(data[14:], math.Float32bits(p.Cycles))
+	binary.LittleEndian.PutUint16(data[18:], uint16(p.SkewRatio))
+	data[20] = uint8(p.Waveform)
+	data[21] = uint8(p.SetHue)
+	data[22] = uint8(p.SetSaturation)
+	data[23] = uint8(p.SetBrightness)
+	data[24] = uint8(p.SetKelvin)
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawSetWaveformOptionalPayload) UnmarshalBinary(data []byte) error {
+	if err := lifxlan.CheckPayloadSize(
+		"lifxlan/light.RawSetWaveformOptionalPayload.UnmarshalBinary",
+		data,
+		rawSetWaveformOptionalPayloadLength,
+	); err != nil {
+		return err
+	}
+	p.Transient = BoolUint8(data[1])
+	if err := p.Color.UnmarshalBinary(data[2:]); err != nil {
+		return err
+	}
+	p.Period = lifxlan.TransitionTime(binary.LittleEndian.Uint32(data[10:]))
+	p.Cycles = math.Float32frombits(binary.LittleEndian.Uint32(data[14:]))
+	p.SkewRatio = int16(binary.LittleEndian.Uint16(data[18:]))
+	p.Waveform = Waveform(data[20])
+	p.SetHue = BoolUint8(data[21])
+	p.SetSaturation = BoolUint8(data[22])
+	p.SetBrightness = BoolUint8(data[23])
+	p.SetKelvin = BoolUint8(data[24])
+	return nil
+}
+
 // SetWaveformArgs is the args to be translated into
 // RawSetWaveformOptionalPayload.
 type SetWaveformArgs struct {
@@ -86,7 +134,9 @@ type SetWaveformArgs struct {
 	// Type of waveform.
 	Waveform Waveform
 
-	// SkewRatio should be in range [0, 1] and it is only used with WaveformPulse.
+	// SkewRatio should be in range [0, 1].
+	// It's the duty cycle with WaveformPulse,
+	// and the peak position with WaveformSine (see Device.Breathe).
 	//
 	// https://lan.developer.lifx.com/docs/waveforms#pulse
 	SkewRatio float64
@@ -110,12 +160,86 @@ type SetWaveformArgs struct {
 	KeepKelvin     bool
 }
 
+// newWaveformArgs creates SetWaveformArgs with the given args,
+// and SkewRatio 0.5.
+func newWaveformArgs(
+	waveform Waveform,
+	color lifxlan.Color,
+	period time.Duration,
+	cycles float32,
+	transient bool,
+) *SetWaveformArgs {
+	return &SetWaveformArgs{
+		Transient: transient,
+		Color:     &color,
+		Period:    period,
+		Cycles:    cycles,
+		Waveform:  waveform,
+		SkewRatio: 0.5,
+	}
+}
+
+// NewSaw creates SetWaveformArgs for WaveformSaw.
+//
+// The light changes linearly from the current color to color over each
+// period,
+// then jumps back to the current color at the start of the next cycle.
+//
+// https://lan.developer.lifx.com/docs/waveforms#saw
+func NewSaw(color lifxlan.Color, period time.Duration, cycles float32, transient bool) *SetWaveformArgs {
+	return newWaveformArgs(WaveformSaw, color, period, cycles, transient)
+}
+
+// NewSine creates SetWaveformArgs for WaveformSine with SkewRatio 0.5.
+//
+// The light smoothly transitions to color and back over each period,
+// peaking at the middle of the period.
+// Adjust SkewRatio to move the peak (see Device.Breathe).
+//
+// https://lan.developer.lifx.com/docs/waveforms#sine
+func NewSine(color lifxlan.Color, period time.Duration, cycles float32, transient bool) *SetWaveformArgs {
+	return newWaveformArgs(WaveformSine, color, period, cycles, transient)
+}
+
+// NewHalfSine creates SetWaveformArgs for WaveformHalfSine.
+//
+// The light smoothly transitions to color over each period,
+// then jumps back to the current color at the start of the next cycle.
+//
+// https://lan.developer.lifx.com/docs/waveforms#half-sine
+func NewHalfSine(color lifxlan.Color, period time.Duration, cycles float32, transient bool) *SetWaveformArgs {
+	return newWaveformArgs(WaveformHalfSine, color, period, cycles, transient)
+}
+
+// NewTriangle creates SetWaveformArgs for WaveformTriangle.
+//
+// The light changes linearly to color and back over each period.
+//
+// https://lan.developer.lifx.com/docs/waveforms#triangle
+func NewTriangle(color lifxlan.Color, period time.Duration, cycles float32, transient bool) *SetWaveformArgs {
+	return newWaveformArgs(WaveformTriangle, color, period, cycles, transient)
+}
+
+// NewPulse creates SetWaveformArgs for WaveformPulse with SkewRatio 0.5.
+//
+// The light switches between the current color and color without transition,
+// spending SkewRatio (the duty cycle) of each period on color.
+//
+// https://lan.developer.lifx.com/docs/waveforms#pulse
+func NewPulse(color lifxlan.Color, period time.Duration, cycles float32, transient bool) *SetWaveformArgs {
+	return newWaveformArgs(WaveformPulse, color, period, cycles, transient)
+}
+
 func (ld *device) SetWaveform(
 	ctx context.Context,
 	conn net.Conn,
 	args *SetWaveformArgs,
 	ack bool,
 ) error {
+	if err := lifxlan.CheckDuration(args.Period); err != nil {
+		return fmt.Errorf("lifxlan/light.SetWaveform: %w", err)
+	}
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -166,3 +290,54 @@ func (ld *device) SetWaveform(
 	}
 	return nil
 }
+
+func (ld *device) SetWaveformOptional(
+	ctx context.Context,
+	conn net.Conn,
+	setHue, setSaturation, setBrightness, setKelvin bool,
+	args *SetWaveformArgs,
+	ack bool,
+) error {
+	optional := *args
+	optional.KeepHue = !setHue
+	optional.KeepSaturation = !setSaturation
+	optional.KeepBrightness = !setBrightness
+	optional.KeepKelvin = !setKelvin
+	return ld.SetWaveform(ctx, conn, &optional, ack)
+}
+
+func (ld *device) Flash(
+	ctx context.Context,
+	conn net.Conn,
+	color lifxlan.Color,
+	period time.Duration,
+	cycles uint16,
+	ack bool,
+) error {
+	return ld.SetWaveform(
+		ctx,
+		conn,
+		NewPulse(color, period, float32(cycles), true),
+		ack,
+	)
+}
+
+func (ld *device) Breathe(
+	ctx context.Context,
+	conn net.Conn,
+	color lifxlan.Color,
+	period time.Duration,
+	cycles uint16,
+	peak float64,
+	ack bool,
+) error {
+	if peak < 0 {
+		peak = 0
+	}
+	if peak > 1 {
+		peak = 1
+	}
+	args := NewSine(color, period, float32(cycles), true)
+	args.SkewRatio = peak
+	return ld.SetWaveform(ctx, conn, args, ack)
+}
@@ -1,10 +1,17 @@
 package light_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
+	"net"
 	"testing"
+	"time"
 
+	"go.yhsif.com/lifxlan"
 	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
 )
 
 func TestBool2Uint8(t *testing.T) {
@@ -47,3 +54,347 @@ func TestConvertSkewRatio(t *testing.T) {
 		)
 	}
 }
+
+func TestWaveformConstructors(t *testing.T) {
+	color := lifxlan.Color{Hue: 1, Saturation: 2, Brightness: 3, Kelvin: 3500}
+	const (
+		period = time.Second
+		cycles = 2.5
+	)
+
+	for _, c := range []struct {
+		label    string
+		newFunc  func(lifxlan.Color, time.Duration, float32, bool) *light.SetWaveformArgs
+		expected light.Waveform
+	}{
+		{
+			label:    "Saw",
+			newFunc:  light.NewSaw,
+			expected: light.WaveformSaw,
+		},
+		{
+			label:    "Sine",
+			newFunc:  light.NewSine,
+			expected: light.WaveformSine,
+		},
+		{
+			label:    "HalfSine",
+			newFunc:  light.NewHalfSine,
+			expected: light.WaveformHalfSine,
+		},
+		{
+			label:    "Triangle",
+			newFunc:  light.NewTriangle,
+			expected: light.WaveformTriangle,
+		},
+		{
+			label:    "Pulse",
+			newFunc:  light.NewPulse,
+			expected: light.WaveformPulse,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				args := c.newFunc(color, period, cycles, true)
+				if args.Waveform != c.expected {
+					t.Errorf("Waveform expected %v, got %v", c.expected, args.Waveform)
+				}
+				if *args.Color != color {
+					t.Errorf("Color expected %+v, got %+v", color, *args.Color)
+				}
+				if args.Period != period {
+					t.Errorf("Period expected %v, got %v", period, args.Period)
+				}
+				if args.Cycles != cycles {
+					t.Errorf("Cycles expected %v, got %v", cycles, args.Cycles)
+				}
+				if !args.Transient {
+					t.Error("Transient expected true, got false")
+				}
+				if args.SkewRatio != 0.5 {
+					t.Errorf("SkewRatio expected 0.5, got %v", args.SkewRatio)
+				}
+				if args.KeepHue || args.KeepSaturation || args.KeepBrightness || args.KeepKelvin {
+					t.Errorf("Expected all the colors to be set, got %+v", args)
+				}
+			},
+		)
+	}
+}
+
+func TestSetWaveformOptional(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload []byte
+	service.Handlers[light.SetWaveformOptional] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		payload = append([]byte(nil), orig.Payload...)
+	}
+
+	for _, c := range []struct {
+		label string
+		set   [4]bool // hue, saturation, brightness, kelvin
+	}{
+		{
+			label: "All",
+			set:   [4]bool{true, true, true, true},
+		},
+		{
+			label: "None",
+			set:   [4]bool{false, false, false, false},
+		},
+		{
+			label: "Hue",
+			set:   [4]bool{true, false, false, false},
+		},
+		{
+			label: "Saturation",
+			set:   [4]bool{false, true, false, false},
+		},
+		{
+			label: "Brightness",
+			set:   [4]bool{false, false, true, false},
+		},
+		{
+			label: "Kelvin",
+			set:   [4]bool{false, false, false, true},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				args := &light.SetWaveformArgs{
+					Color:    &lifxlan.Color{Kelvin: lifxlan.KelvinNeutral},
+					Waveform: light.WaveformSine,
+					// Should be overridden by the set* args.
+					KeepHue:        !c.set[0],
+					KeepSaturation: c.set[1],
+					KeepBrightness: c.set[2],
+					KeepKelvin:     c.set[3],
+				}
+				if err := ld.SetWaveformOptional(
+					ctx,
+					nil, // conn
+					c.set[0],
+					c.set[1],
+					c.set[2],
+					c.set[3],
+					args,
+					true, // ack
+				); err != nil {
+					t.Fatal(err)
+				}
+
+				// reserved(1) transient(1) color(8) period(4) cycles(4)
+				// skew_ratio(2) waveform(1) set_*(4)
+				const expectedSize = 25
+				if len(payload) != expectedSize {
+					t.Fatalf("Payload size expected %d, got %d", expectedSize, len(payload))
+				}
+				if got := light.Waveform(payload[20]); got != light.WaveformSine {
+					t.Errorf("Waveform expected %d, got %d", light.WaveformSine, got)
+				}
+				var expected [4]byte
+				for i, set := range c.set {
+					expected[i] = byte(light.Bool2Uint8(set))
+				}
+				var got [4]byte
+				copy(got[:], payload[21:])
+				if got != expected {
+					t.Errorf("set_* flags expected %v, got %v", expected, got)
+				}
+			},
+		)
+	}
+}
+
+// waveformHandler returns a mock.HandlerFunc that decodes the received
+// SetWaveformOptional payload into raw.
+func waveformHandler(t *testing.T, raw **light.RawSetWaveformOptionalPayload) mock.HandlerFunc {
+	return func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		payload := new(light.RawSetWaveformOptionalPayload)
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, payload); err != nil {
+			t.Error(err)
+			return
+		}
+		*raw = payload
+	}
+}
+
+func TestFlash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw *light.RawSetWaveformOptionalPayload
+	service.Handlers[light.SetWaveformOptional] = waveformHandler(t, &raw)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	const period = time.Millisecond * 500
+	const cycles = 3
+	color := lifxlan.Color{
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	if err := ld.Flash(ctx, nil, color, period, cycles, true); err != nil {
+		t.Fatal(err)
+	}
+	if raw == nil {
+		t.Fatal("SetWaveformOptional message not received")
+	}
+	if raw.Transient != 1 {
+		t.Errorf("Transient expected 1, got %d", raw.Transient)
+	}
+	if raw.Waveform != light.WaveformPulse {
+		t.Errorf("Waveform expected %d, got %d", light.WaveformPulse, raw.Waveform)
+	}
+	if expected := lifxlan.ConvertDuration(period); raw.Period != expected {
+		t.Errorf("Period expected %d, got %d", expected, raw.Period)
+	}
+	if raw.Cycles != cycles {
+		t.Errorf("Cycles expected %d, got %v", cycles, raw.Cycles)
+	}
+	if raw.Color != color {
+		t.Errorf("Color expected %v, got %v", color, raw.Color)
+	}
+}
+
+func TestBreathe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+
+	ld, err := func() (light.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return light.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw *light.RawSetWaveformOptionalPayload
+	service.Handlers[light.SetWaveformOptional] = waveformHandler(t, &raw)
+
+	for _, c := range []struct {
+		label    string
+		peak     float64
+		expected int16
+	}{
+		{
+			label:    "0",
+			peak:     0,
+			expected: -32768,
+		},
+		{
+			label:    "0.5",
+			peak:     0.5,
+			expected: 0,
+		},
+		{
+			label:    "1",
+			peak:     1,
+			expected: 32767,
+		},
+		{
+			label:    "ClampLow",
+			peak:     -1,
+			expected: -32768,
+		},
+		{
+			label:    "ClampHigh",
+			peak:     2,
+			expected: 32767,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				raw = nil
+				color := lifxlan.Color{
+					Brightness: 0xffff,
+					Kelvin:     lifxlan.KelvinNeutral,
+				}
+				if err := ld.Breathe(ctx, nil, color, time.Second, 2, c.peak, true); err != nil {
+					t.Fatal(err)
+				}
+				if raw == nil {
+					t.Fatal("SetWaveformOptional message not received")
+				}
+				if raw.SkewRatio != c.expected {
+					t.Errorf("SkewRatio expected %d, got %d", c.expected, raw.SkewRatio)
+				}
+				if raw.Waveform != light.WaveformSine {
+					t.Errorf("Waveform expected %d, got %d", light.WaveformSine, raw.Waveform)
+				}
+				if raw.Transient != 1 {
+					t.Errorf("Transient expected 1, got %d", raw.Transient)
+				}
+				if raw.Cycles != 2 {
+					t.Errorf("Cycles expected 2, got %v", raw.Cycles)
+				}
+			},
+		)
+	}
+}
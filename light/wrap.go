@@ -54,36 +54,43 @@ func Wrap(ctx context.Context, d lifxlan.Device, force bool) (Device, error) {
 		return nil, err
 	}
 
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, d.Source())
+	defer cancel()
+
 	for {
-		resp, err := lifxlan.ReadNextResponse(ctx, conn)
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
 		if err != nil {
 			return nil, err
 		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-
-		switch resp.Message {
-		case State:
-			var raw RawStatePayload
-			r := bytes.NewReader(resp.Payload)
-			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-				return nil, err
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, d.Source()) {
+				continue
 			}
 
-			ld := &device{
-				Device: d,
-			}
-			*ld.Label() = raw.Label
-			return ld, nil
+			switch resp.Message {
+			case State:
+				var raw RawStatePayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+
+				ld := &device{
+					Device: d,
+				}
+				*ld.Label() = raw.Label
+				return ld, nil
 
-		case lifxlan.StateUnhandled:
-			var raw lifxlan.RawStateUnhandledPayload
-			r := bytes.NewReader(resp.Payload)
-			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-				return nil, err
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				return nil, &lifxlan.UnhandledMessageError{
+					Type: raw.UnhandledType,
+				}
 			}
-			return nil, raw
 		}
 	}
 }
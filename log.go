@@ -0,0 +1,29 @@
+package lifxlan
+
+// Logger defines the interface used by the protocol layer to log debug
+// messages.
+//
+// *log.Logger from the standard library satisfies this interface.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// DebugLogger is the logger used to log debug messages from the protocol
+// layer, e.g. messages sent, responses dropped, and read timeouts.
+//
+// It's nil by default, which disables debug logging.
+// It's intentionally defined as variable,
+// so the user could turn on verbose tracing via a single assignment, e.g.
+//
+//     lifxlan.DebugLogger = log.New(os.Stderr, "lifxlan: ", log.LstdFlags)
+//
+// It's not safe to change it while there are API calls in flight,
+// so it should usually be set at the beginning of main.
+var DebugLogger Logger
+
+// debugf logs to DebugLogger if it's set.
+func debugf(format string, args ...interface{}) {
+	if l := DebugLogger; l != nil {
+		l.Printf(format, args...)
+	}
+}
@@ -0,0 +1,64 @@
+package lifxlan_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+type recordLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) Printf(format string, args ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) contains(substr string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDebugLogger(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	logger := new(recordLogger)
+	lifxlan.DebugLogger = logger
+	defer func() {
+		lifxlan.DebugLogger = nil
+	}()
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := device.SetPower(ctx, nil, lifxlan.PowerOn, true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprintf("sent %v to %v", lifxlan.SetPower, device.Target())
+	if !logger.contains(expected) {
+		t.Errorf("Expected log line containing %q, got %q", expected, logger.lines)
+	}
+}
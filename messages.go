@@ -10,15 +10,65 @@ const (
 
 	GetService        MessageType = 2
 	StateService      MessageType = 3
+	GetHostInfo       MessageType = 12
+	StateHostInfo     MessageType = 13
 	GetHostFirmware   MessageType = 14
 	StateHostFirmware MessageType = 15
+	GetWifiInfo       MessageType = 16
+	StateWifiInfo     MessageType = 17
 	GetPower          MessageType = 20
 	StatePower        MessageType = 22
 	SetPower          MessageType = 21
 	GetLabel          MessageType = 23
+	SetLabel          MessageType = 24
 	StateLabel        MessageType = 25
 	GetVersion        MessageType = 32
 	StateVersion      MessageType = 33
+	GetInfo           MessageType = 34
+	StateInfo         MessageType = 35
+	SetReboot         MessageType = 38
+	GetLocation       MessageType = 48
+	SetLocation       MessageType = 49
+	StateLocation     MessageType = 50
+	GetGroup          MessageType = 51
+	SetGroup          MessageType = 52
+	StateGroup        MessageType = 53
 	EchoRequest       MessageType = 58
 	EchoResponse      MessageType = 59
 )
+
+// ExpectedResponses maps the message types requesting states (e.g. GetPower)
+// to the message types of the responses they expect (e.g. StatePower).
+//
+// The subpackages add their message types into the map in their init
+// functions,
+// you could also add other message types to the map by yourself, e.g.:
+//
+//     func init() {
+//         lifxlan.ExpectedResponses[getFoo] = stateFoo
+//     }
+var ExpectedResponses = map[MessageType]MessageType{
+	GetService:      StateService,
+	GetHostInfo:     StateHostInfo,
+	GetHostFirmware: StateHostFirmware,
+	GetWifiInfo:     StateWifiInfo,
+	GetPower:        StatePower,
+	GetLabel:        StateLabel,
+	GetVersion:      StateVersion,
+	GetInfo:         StateInfo,
+	GetLocation:     StateLocation,
+	GetGroup:        StateGroup,
+	EchoRequest:     EchoResponse,
+}
+
+// ExpectedResponse returns the message type of the response expected for msg,
+// as defined in ExpectedResponses.
+//
+// Some devices might reply with a different message type in special cases
+// (e.g. a multizone device with a single zone replies StateZone instead of
+// StateMultiZone),
+// which is not covered here.
+func ExpectedResponse(msg MessageType) (MessageType, bool) {
+	resp, ok := ExpectedResponses[msg]
+	return resp, ok
+}
@@ -0,0 +1,66 @@
+package lifxlan_test
+
+import (
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/relay"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestExpectedResponse(t *testing.T) {
+	for _, c := range []struct {
+		msg      lifxlan.MessageType
+		expected lifxlan.MessageType
+		ok       bool
+	}{
+		{
+			msg:      lifxlan.GetPower,
+			expected: lifxlan.StatePower,
+			ok:       true,
+		},
+		{
+			msg:      lifxlan.EchoRequest,
+			expected: lifxlan.EchoResponse,
+			ok:       true,
+		},
+		{
+			msg:      light.Get,
+			expected: light.State,
+			ok:       true,
+		},
+		{
+			msg:      multizone.GetColorZones,
+			expected: multizone.StateMultiZone,
+			ok:       true,
+		},
+		{
+			msg:      tile.GetTileState64,
+			expected: tile.StateTileState64,
+			ok:       true,
+		},
+		{
+			msg:      relay.GetRPower,
+			expected: relay.StateRPower,
+			ok:       true,
+		},
+		{
+			msg: lifxlan.SetPower,
+			ok:  false,
+		},
+	} {
+		got, ok := lifxlan.ExpectedResponse(c.msg)
+		if ok != c.ok || got != c.expected {
+			t.Errorf(
+				"ExpectedResponse(%d) expected %d, %v, got %d, %v",
+				c.msg,
+				c.expected,
+				c.ok,
+				got,
+				ok,
+			)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package lifxlan
+
+import (
+	"time"
+)
+
+// Metrics defines the interface used by the protocol layer to report
+// instrumentation data.
+//
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncSend is called every time a message is written to a connection,
+	// including the resends by SendWithRetry.
+	IncSend(message MessageType)
+
+	// IncAck is called every time an expected ack is received by WaitForAcks.
+	IncAck()
+
+	// IncAckTimeout is called every time WaitForAcks returns because the
+	// context is cancelled or timed out before all acks are received.
+	IncAckTimeout()
+
+	// IncRetry is called every time SendWithRetry resends a message.
+	IncRetry()
+
+	// ObserveRTT is called with the round trip time measured by Echo and
+	// SendWithRetry (from the last attempt to the ack).
+	ObserveRTT(rtt time.Duration)
+}
+
+// MetricsRecorder is the Metrics used by the protocol layer.
+//
+// It's nil by default, which disables instrumentation.
+// Similar to DebugLogger,
+// it's not safe to change it while there are API calls in flight,
+// so it should usually be set at the beginning of main.
+var MetricsRecorder Metrics
@@ -0,0 +1,147 @@
+package lifxlan_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+type fakeMetrics struct {
+	lock        sync.Mutex
+	sends       map[lifxlan.MessageType]int
+	acks        int
+	ackTimeouts int
+	retries     int
+	rtts        int
+}
+
+var _ lifxlan.Metrics = (*fakeMetrics)(nil)
+
+func (m *fakeMetrics) IncSend(message lifxlan.MessageType) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.sends == nil {
+		m.sends = make(map[lifxlan.MessageType]int)
+	}
+	m.sends[message]++
+}
+
+func (m *fakeMetrics) IncAck() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.acks++
+}
+
+func (m *fakeMetrics) IncAckTimeout() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.ackTimeouts++
+}
+
+func (m *fakeMetrics) IncRetry() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.retries++
+}
+
+func (m *fakeMetrics) ObserveRTT(time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rtts++
+}
+
+func TestMetricsRecorder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 500
+
+	t.Run(
+		"SetPower",
+		func(t *testing.T) {
+			m := new(fakeMetrics)
+			lifxlan.MetricsRecorder = m
+			defer func() {
+				lifxlan.MetricsRecorder = nil
+			}()
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.SetPower(ctx, nil, lifxlan.PowerOn, true); err != nil {
+				t.Fatal(err)
+			}
+
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			if got := m.sends[lifxlan.SetPower]; got != 1 {
+				t.Errorf("SetPower sends expected 1, got %d", got)
+			}
+			if m.acks != 1 {
+				t.Errorf("Acks expected 1, got %d", m.acks)
+			}
+			if m.ackTimeouts != 0 {
+				t.Errorf("Ack timeouts expected 0, got %d", m.ackTimeouts)
+			}
+		},
+	)
+
+	t.Run(
+		"SendWithRetry",
+		func(t *testing.T) {
+			m := new(fakeMetrics)
+			lifxlan.MetricsRecorder = m
+			defer func() {
+				lifxlan.MetricsRecorder = nil
+			}()
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			service.AcksToDrop = 1
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := lifxlan.SendWithRetry(
+				ctx,
+				nil, // conn
+				device,
+				lifxlan.FlagAckRequired,
+				lifxlan.SetPower,
+				[]byte{0xff, 0xff},
+				lifxlan.RetryOptions{
+					MaxAttempts:    3,
+					InitialBackoff: time.Millisecond * 20,
+				},
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			if got := m.sends[lifxlan.SetPower]; got != 2 {
+				t.Errorf("SetPower sends expected 2, got %d", got)
+			}
+			if m.retries != 1 {
+				t.Errorf("Retries expected 1, got %d", m.retries)
+			}
+			if m.ackTimeouts != 1 {
+				t.Errorf("Ack timeouts expected 1, got %d", m.ackTimeouts)
+			}
+			if m.acks != 1 {
+				t.Errorf("Acks expected 1, got %d", m.acks)
+			}
+			if m.rtts != 1 {
+				t.Errorf("RTT observations expected 1, got %d", m.rtts)
+			}
+		},
+	)
+}
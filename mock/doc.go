@@ -1,5 +1,12 @@
 // Package mock implements a mocked lifxlan device listening on localhost,
 // which can be used in test code to test API calls.
 //
+// Service answers requests with fixed payloads set by the test code,
+// while Server keeps an in-memory state (power, color, label,
+// and optionally multizone zones and tile pixels) that's
+// updated by the set messages it receives.
+// Both can be configured to drop requests and to delay responses,
+// to exercise retry and timeout logic.
+//
 // Please refer to examples to see how to use them in the test code.
 package mock // import "go.yhsif.com/lifxlan/mock"
@@ -7,6 +7,7 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 
 	"go.yhsif.com/lifxlan"
 	"go.yhsif.com/lifxlan/light"
@@ -70,6 +71,18 @@ func DefaultHandlerFunc(
 		}
 		s.Reply(conn, addr, orig, lifxlan.StateVersion, buf.Bytes())
 
+	case lifxlan.GetHostFirmware:
+		buf := new(bytes.Buffer)
+		if err := binary.Write(
+			buf,
+			binary.LittleEndian,
+			s.RawStateHostFirmwarePayload,
+		); err != nil {
+			s.TB.Log(err)
+			return
+		}
+		s.Reply(conn, addr, orig, lifxlan.StateHostFirmware, buf.Bytes())
+
 	case lifxlan.EchoRequest:
 		buf := new(bytes.Buffer)
 		var echoing [64]byte
@@ -156,6 +169,24 @@ func StateUnhandledHandler(msg lifxlan.MessageType) HandlerFunc {
 	}
 }
 
+// ReplyHandler generates a HandlerFunc to reply msg with payload,
+// encoded via binary.Write in little endian.
+func ReplyHandler(msg lifxlan.MessageType, payload interface{}) HandlerFunc {
+	return func(
+		s *Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+			s.TB.Error(err)
+			return
+		}
+		s.Reply(conn, addr, orig, msg, buf.Bytes())
+	}
+}
+
 var _ HandlerFunc = DefaultHandlerFunc
 
 // Service is a mocked device listening on localhost.
@@ -165,10 +196,30 @@ type Service struct {
 	// Testing context
 	TB testing.TB
 
+	// The addr to listen on, ListenAddr will be used if it's empty.
+	//
+	// For example, set it to "[::1]:" to listen on IPv6 loopback.
+	ListenAddr string
+
+	// The target of the mocked device, Target will be used if it's 0.
+	//
+	// Requests to other targets will be ignored.
+	Target lifxlan.Target
+
 	// When AcksToDrop > 0 and it's supposed to send an ack,
 	// the ack won't be send and AcksToDrop will decrease by 1.
 	AcksToDrop int
 
+	// When RequestsToDrop > 0,
+	// the next received request will be dropped without being handled or acked,
+	// as if it's lost on the network,
+	// and RequestsToDrop will decrease by 1.
+	RequestsToDrop int
+
+	// If Delay > 0, every received request will be handled after Delay,
+	// to simulate a slow network or device.
+	Delay time.Duration
+
 	// Any custom HandlerFunc to be used besides DefaultHandlerFunc.
 	Handlers map[lifxlan.MessageType]HandlerFunc
 
@@ -184,6 +235,7 @@ type Service struct {
 	RawStatePowerPayload        *lifxlan.RawStatePowerPayload
 	RawStateLabelPayload        *lifxlan.RawStateLabelPayload
 	RawStateVersionPayload      *lifxlan.RawStateVersionPayload
+	RawStateHostFirmwarePayload *lifxlan.RawStateHostFirmwarePayload
 	RawStatePayload             *light.RawStatePayload
 	RawStateDeviceChainPayload  *tile.RawStateDeviceChainPayload
 	RawStateTileState64Payloads []*tile.RawStateTileState64Payload
@@ -218,7 +270,11 @@ func (s *Service) Start() lifxlan.Device {
 	s.Context = ctx
 	s.Cancel = cancel
 
-	conn, err := net.ListenPacket("udp", ListenAddr)
+	addr := s.ListenAddr
+	if addr == "" {
+		addr = ListenAddr
+	}
+	conn, err := net.ListenPacket("udp", addr)
 	if err != nil {
 		s.TB.Fatal(err)
 	}
@@ -229,7 +285,7 @@ func (s *Service) Start() lifxlan.Device {
 	return lifxlan.NewDevice(
 		conn.LocalAddr().String(),
 		lifxlan.ServiceUDP,
-		Target,
+		s.target(),
 	)
 }
 
@@ -243,6 +299,14 @@ func (s *Service) Stop() {
 	s.wg.Wait()
 }
 
+// target returns the target of the mocked device.
+func (s *Service) target() lifxlan.Target {
+	if s.Target != 0 {
+		return s.Target
+	}
+	return Target
+}
+
 // Reply replies a request.
 func (s *Service) Reply(
 	conn net.PacketConn,
@@ -260,7 +324,7 @@ func (s *Service) Reply(
 	msg, err := lifxlan.GenerateMessage(
 		lifxlan.NotTagged,
 		orig.Source,
-		Target,
+		s.target(),
 		orig.Flags,
 		orig.Sequence,
 		message,
@@ -322,11 +386,28 @@ func (s *Service) handler(conn net.PacketConn) {
 			continue
 		}
 
-		if !orig.Target.Matches(Target) {
+		if !orig.Target.Matches(s.target()) {
 			s.TB.Logf("Ignoring unmatched target %v", orig.Target)
 			continue
 		}
 
+		if s.RequestsToDrop > 0 {
+			s.RequestsToDrop--
+			s.TB.Logf("Dropping request %v", orig.Message)
+			continue
+		}
+
+		if s.Delay > 0 {
+			timer := time.NewTimer(s.Delay)
+			select {
+			case <-s.Context.Done():
+				timer.Stop()
+				s.TB.Log(s.Context.Err())
+				return
+			case <-timer.C:
+			}
+		}
+
 		handler := s.Handlers[orig.Message]
 		if handler == nil {
 			handler = DefaultHandlerFunc
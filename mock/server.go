@@ -0,0 +1,489 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// State is the in-memory state of a device mocked by Server.
+type State struct {
+	Power lifxlan.Power
+	Color lifxlan.Color
+	Label lifxlan.Label
+
+	// If Zones is non-empty,
+	// the server also emulates a multizone strip with len(Zones) zones
+	// (at most 255).
+	Zones []lifxlan.Color
+
+	// If Tiles is non-empty,
+	// the server also emulates a tile chain with len(Tiles) tiles
+	// (at most tile.MaxTilesInChain).
+	Tiles []Tile
+}
+
+// Tile is the in-memory state of a single tile in a chain mocked by Server.
+type Tile struct {
+	// The tile as reported in StateDeviceChain messages.
+	//
+	// Width and Height must be set,
+	// and Width * Height must not exceed tile.ColorsPerTile.
+	Device tile.RawTileDevice
+
+	// The pixels of the tile, row by row.
+	Colors [tile.ColorsPerTile]lifxlan.Color
+}
+
+// clone returns a deep copy of st.
+func (st State) clone() State {
+	if st.Zones != nil {
+		st.Zones = append([]lifxlan.Color(nil), st.Zones...)
+	}
+	if st.Tiles != nil {
+		st.Tiles = append([]Tile(nil), st.Tiles...)
+	}
+	return st
+}
+
+// Server is a mocked light device backed by an in-memory State.
+//
+// Unlike using Service directly with its Raw*Payload fields,
+// set messages sent to a Server update its State,
+// and get messages are answered from the current State,
+// the same way a real device would.
+//
+// It handles the following messages:
+//
+// - GetService: answered with StateService of the port Server is listening on
+//
+// - GetPower, SetPower
+//
+// - GetLabel, SetLabel
+//
+// - light.Get, light.SetColor, light.GetLightPower, light.SetLightPower
+//
+// - multizone.GetColorZones, multizone.SetColorZones: when State.Zones is
+// non-empty.
+// GetColorZones is answered with one StateMultiZone message for every 8 zones
+// in the requested range,
+// or a single StateZone message when only one zone is requested.
+// SetColorZones changes are buffered until applied,
+// as defined by multizone.ApplyRequest.
+//
+// - tile.GetDeviceChain, tile.GetTileState64, tile.SetTileState64: when
+// State.Tiles is non-empty.
+// GetTileState64 is answered with one StateTileState64 message per requested
+// tile,
+// with the pixels previously set by SetTileState64.
+//
+// Set messages with FlagResRequired are also answered with the matching State
+// message.
+// All the other messages are passed to DefaultHandlerFunc.
+//
+// The embedded Service can be used to customize the behavior further,
+// e.g. to drop requests or to delay responses.
+type Server struct {
+	*Service
+
+	lock  sync.Mutex
+	state State
+	// The buffered zones not applied yet.
+	pendingZones []lifxlan.Color
+}
+
+// Device is a lifxlan.Device connected to a Server.
+//
+// All the API calls on Device go through the network to the Server,
+// the Server and State functions can be used to inspect and modify the
+// in-memory state from test code directly.
+type Device struct {
+	lifxlan.Device
+
+	server *Server
+}
+
+var _ lifxlan.Device = (*Device)(nil)
+
+// StartServer starts a mock server with its initial State set to initial,
+// returns the server and the device.
+func StartServer(tb testing.TB, initial State) (*Server, *Device) {
+	tb.Helper()
+
+	service := &Service{
+		TB:         tb,
+		Handlers:   make(map[lifxlan.MessageType]HandlerFunc),
+		HandleAcks: true,
+	}
+	s := &Server{
+		Service: service,
+		state:   initial.clone(),
+	}
+	for _, msg := range []lifxlan.MessageType{
+		lifxlan.GetService,
+		lifxlan.GetPower,
+		lifxlan.SetPower,
+		lifxlan.GetLabel,
+		lifxlan.SetLabel,
+		light.Get,
+		light.SetColor,
+		light.GetLightPower,
+		light.SetLightPower,
+		multizone.GetColorZones,
+		multizone.SetColorZones,
+		tile.GetDeviceChain,
+		tile.GetTileState64,
+		tile.SetTileState64,
+	} {
+		service.Handlers[msg] = s.handle
+	}
+	return s, &Device{
+		Device: service.Start(),
+		server: s,
+	}
+}
+
+// Server returns the Server d is connected to.
+func (d *Device) Server() *Server {
+	return d.server
+}
+
+// State returns a copy of the current state of the server.
+func (s *Server) State() State {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.clone()
+}
+
+// SetState replaces the current state of the server,
+// and discards all the buffered zone changes.
+func (s *Server) SetState(state State) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.state = state.clone()
+	s.pendingZones = nil
+}
+
+func (s *Server) handle(
+	service *Service,
+	conn net.PacketConn,
+	addr net.Addr,
+	orig *lifxlan.Response,
+) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	resRequired := orig.Flags&lifxlan.FlagResRequired != 0
+	switch orig.Message {
+	default:
+		DefaultHandlerFunc(service, conn, addr, orig)
+
+	case lifxlan.GetService:
+		_, portStr, err := net.SplitHostPort(conn.LocalAddr().String())
+		if err != nil {
+			service.TB.Log(err)
+			return
+		}
+		port, err := strconv.ParseUint(portStr, 10, 32)
+		if err != nil {
+			service.TB.Log(err)
+			return
+		}
+		s.reply(conn, addr, orig, lifxlan.StateService, &lifxlan.RawStateServicePayload{
+			Service: lifxlan.ServiceUDP,
+			Port:    uint32(port),
+		})
+
+	case lifxlan.GetPower:
+		s.replyPower(conn, addr, orig)
+
+	case lifxlan.SetPower:
+		var raw lifxlan.RawSetPowerPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.state.Power = raw.Level
+		if resRequired {
+			s.replyPower(conn, addr, orig)
+		}
+
+	case lifxlan.GetLabel:
+		s.replyLabel(conn, addr, orig)
+
+	case lifxlan.SetLabel:
+		var raw lifxlan.RawSetLabelPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.state.Label = raw.Label
+		if resRequired {
+			s.replyLabel(conn, addr, orig)
+		}
+
+	case light.Get:
+		s.replyLight(conn, addr, orig)
+
+	case light.SetColor:
+		var raw light.RawSetColorPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.state.Color = raw.Color
+		if resRequired {
+			s.replyLight(conn, addr, orig)
+		}
+
+	case light.GetLightPower:
+		s.replyLightPower(conn, addr, orig)
+
+	case light.SetLightPower:
+		var raw light.RawSetLightPowerPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.state.Power = raw.Level
+		if resRequired {
+			s.replyLightPower(conn, addr, orig)
+		}
+
+	case multizone.GetColorZones:
+		if len(s.state.Zones) == 0 {
+			DefaultHandlerFunc(service, conn, addr, orig)
+			return
+		}
+		var raw multizone.RawGetColorZonesPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.replyZones(conn, addr, orig, int(raw.StartIndex), int(raw.EndIndex))
+
+	case multizone.SetColorZones:
+		if len(s.state.Zones) == 0 {
+			DefaultHandlerFunc(service, conn, addr, orig)
+			return
+		}
+		var raw multizone.RawSetColorZonesPayload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.setZones(&raw)
+		if resRequired {
+			s.replyZones(conn, addr, orig, int(raw.StartIndex), int(raw.EndIndex))
+		}
+
+	case tile.GetDeviceChain:
+		if len(s.state.Tiles) == 0 {
+			DefaultHandlerFunc(service, conn, addr, orig)
+			return
+		}
+		payload := &tile.RawStateDeviceChainPayload{
+			TotalCount: uint8(len(s.state.Tiles)),
+		}
+		for i := range s.state.Tiles {
+			payload.TileDevices[i] = s.state.Tiles[i].Device
+		}
+		s.reply(conn, addr, orig, tile.StateDeviceChain, payload)
+
+	case tile.GetTileState64:
+		if len(s.state.Tiles) == 0 {
+			DefaultHandlerFunc(service, conn, addr, orig)
+			return
+		}
+		var raw tile.RawGetTileState64Payload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.replyTiles(conn, addr, orig, &raw)
+
+	case tile.SetTileState64:
+		if len(s.state.Tiles) == 0 {
+			DefaultHandlerFunc(service, conn, addr, orig)
+			return
+		}
+		var raw tile.RawSetTileState64Payload
+		if !s.decode(orig, &raw) {
+			return
+		}
+		s.setTiles(&raw)
+		if resRequired {
+			s.replyTiles(conn, addr, orig, &tile.RawGetTileState64Payload{
+				TileIndex: raw.TileIndex,
+				Length:    raw.Length,
+				X:         raw.X,
+				Y:         raw.Y,
+				Width:     raw.Width,
+			})
+		}
+	}
+}
+
+// setZones handles a SetColorZones message.
+func (s *Server) setZones(raw *multizone.RawSetColorZonesPayload) {
+	if s.pendingZones == nil {
+		s.pendingZones = append([]lifxlan.Color(nil), s.state.Zones...)
+	}
+	if raw.Apply != multizone.ApplyOnly {
+		for i := int(raw.StartIndex); i <= int(raw.EndIndex) && i < len(s.pendingZones); i++ {
+			s.pendingZones[i] = raw.Color
+		}
+	}
+	if raw.Apply != multizone.NoApply {
+		s.state.Zones = s.pendingZones
+		s.pendingZones = nil
+	}
+}
+
+// replyZones replies the zones in range [start, end] the same way a real
+// multizone device would.
+func (s *Server) replyZones(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response, start, end int) {
+	zones := s.state.Zones
+	if start >= len(zones) {
+		s.TB.Logf("Ignoring zone index %d out of range [0, %d)", start, len(zones))
+		return
+	}
+	if end >= len(zones) {
+		end = len(zones) - 1
+	}
+
+	if start == end {
+		s.reply(conn, addr, orig, multizone.StateZone, &multizone.RawStateZonePayload{
+			ZonesCount: uint8(len(zones)),
+			ZoneIndex:  uint8(start),
+			Color:      zones[start],
+		})
+		return
+	}
+
+	for i := start; i <= end; i += multizone.ZonesPerStateMultiZone {
+		payload := &multizone.RawStateMultiZonePayload{
+			ZonesCount: uint8(len(zones)),
+			ZoneIndex:  uint8(i),
+		}
+		copy(payload.Colors[:], zones[i:])
+		s.reply(conn, addr, orig, multizone.StateMultiZone, payload)
+	}
+}
+
+// tileRange returns the tiles in range [index, index+length) that are in the
+// chain.
+func (s *Server) tileRange(index, length uint8) []Tile {
+	start := int(index)
+	if start >= len(s.state.Tiles) {
+		return nil
+	}
+	end := start + int(length)
+	if end > len(s.state.Tiles) {
+		end = len(s.state.Tiles)
+	}
+	return s.state.Tiles[start:end]
+}
+
+// pixelIndex returns the index into Tile.Colors of the i-th color in a
+// rectangle starting at (x, y) with width,
+// or -1 if it's outside of t.
+func (t *Tile) pixelIndex(x, y, width uint8, i int) int {
+	if width == 0 {
+		return -1
+	}
+	px := int(x) + i%int(width)
+	py := int(y) + i/int(width)
+	if px >= int(t.Device.Width) || py >= int(t.Device.Height) {
+		return -1
+	}
+	return py*int(t.Device.Width) + px
+}
+
+// setTiles handles a SetTileState64 message.
+func (s *Server) setTiles(raw *tile.RawSetTileState64Payload) {
+	tiles := s.tileRange(raw.TileIndex, raw.Length)
+	for i := range tiles {
+		t := &tiles[i]
+		for j, c := range raw.Colors {
+			if index := t.pixelIndex(raw.X, raw.Y, raw.Width, j); index >= 0 {
+				t.Colors[index] = c
+			}
+		}
+	}
+}
+
+// replyTiles replies a StateTileState64 message for each requested tile.
+func (s *Server) replyTiles(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response, raw *tile.RawGetTileState64Payload) {
+	tiles := s.tileRange(raw.TileIndex, raw.Length)
+	for i := range tiles {
+		t := &tiles[i]
+		payload := &tile.RawStateTileState64Payload{
+			TileIndex: raw.TileIndex + uint8(i),
+			X:         raw.X,
+			Y:         raw.Y,
+			Width:     raw.Width,
+		}
+		for j := range payload.Colors {
+			if index := t.pixelIndex(raw.X, raw.Y, raw.Width, j); index >= 0 {
+				payload.Colors[j] = t.Colors[index]
+			}
+		}
+		s.reply(conn, addr, orig, tile.StateTileState64, payload)
+	}
+}
+
+// decode decodes the payload of orig into raw.
+//
+// It logs the error and returns false if the payload can't be decoded.
+func (s *Server) decode(orig *lifxlan.Response, raw interface{}) bool {
+	r := bytes.NewReader(orig.Payload)
+	if err := binary.Read(r, binary.LittleEndian, raw); err != nil {
+		s.TB.Log(err)
+		return false
+	}
+	return true
+}
+
+// reply encodes payload and replies it as message.
+func (s *Server) reply(
+	conn net.PacketConn,
+	addr net.Addr,
+	orig *lifxlan.Response,
+	message lifxlan.MessageType,
+	payload interface{},
+) {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+		s.TB.Log(err)
+		return
+	}
+	s.Reply(conn, addr, orig, message, buf.Bytes())
+}
+
+func (s *Server) replyPower(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response) {
+	s.reply(conn, addr, orig, lifxlan.StatePower, &lifxlan.RawStatePowerPayload{
+		Level: s.state.Power,
+	})
+}
+
+func (s *Server) replyLightPower(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response) {
+	s.reply(conn, addr, orig, light.StateLightPower, &light.RawStateLightPowerPayload{
+		Level: s.state.Power,
+	})
+}
+
+func (s *Server) replyLabel(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response) {
+	s.reply(conn, addr, orig, lifxlan.StateLabel, &lifxlan.RawStateLabelPayload{
+		Label: s.state.Label,
+	})
+}
+
+func (s *Server) replyLight(conn net.PacketConn, addr net.Addr, orig *lifxlan.Response) {
+	s.reply(conn, addr, orig, light.State, &light.RawStatePayload{
+		Color: s.state.Color,
+		Power: s.state.Power,
+		Label: s.state.Label,
+	})
+}
@@ -0,0 +1,441 @@
+package mock_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func makeColors(n int) []lifxlan.Color {
+	colors := make([]lifxlan.Color, n)
+	for i := range colors {
+		colors[i] = lifxlan.Color{
+			Hue:        uint16(i * 100),
+			Saturation: 65535,
+			Brightness: uint16(i + 1),
+			Kelvin:     lifxlan.KelvinNeutral,
+		}
+	}
+	return colors
+}
+
+func TestServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	var label lifxlan.Label
+	label.Set("foo")
+	initial := mock.State{
+		Power: lifxlan.PowerOff,
+		Color: lifxlan.Color{
+			Hue:        1,
+			Saturation: 2,
+			Brightness: 3,
+			Kelvin:     lifxlan.KelvinNeutral,
+		},
+		Label: label,
+	}
+
+	t.Run(
+		"DiscoverUnicast",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			discovered, err := lifxlan.DiscoverUnicast(ctx, device.Addr().String(), lifxlan.AllDevices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if discovered.Target() != mock.Target {
+				t.Errorf("Target expected %v, got %v", mock.Target, discovered.Target())
+			}
+			if discovered.Addr().String() != device.Addr().String() {
+				t.Errorf("Addr expected %v, got %v", device.Addr(), discovered.Addr())
+			}
+		},
+	)
+
+	t.Run(
+		"Power",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			power, err := device.GetPower(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if power != initial.Power {
+				t.Errorf("GetPower expected %v, got %v", initial.Power, power)
+			}
+
+			if err := device.SetPower(ctx, nil, lifxlan.PowerOn, true); err != nil {
+				t.Fatal(err)
+			}
+			if actual := server.State().Power; actual != lifxlan.PowerOn {
+				t.Errorf("State.Power expected %v, got %v", lifxlan.PowerOn, actual)
+			}
+			power, err = device.GetPower(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if power != lifxlan.PowerOn {
+				t.Errorf("GetPower expected %v, got %v", lifxlan.PowerOn, power)
+			}
+		},
+	)
+
+	t.Run(
+		"Label",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.GetLabel(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			if device.Label().String() != "foo" {
+				t.Errorf("Label expected %q, got %q", "foo", device.Label())
+			}
+
+			if err := device.SetLabel(ctx, nil, "bar", true); err != nil {
+				t.Fatal(err)
+			}
+			if actual := server.State().Label; actual.String() != "bar" {
+				t.Errorf("State.Label expected %q, got %q", "bar", actual)
+			}
+		},
+	)
+
+	t.Run(
+		"Color",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			ld, err := light.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			color, err := ld.GetColor(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *color != initial.Color {
+				t.Errorf("GetColor expected %v, got %v", initial.Color, *color)
+			}
+
+			expected := lifxlan.Color{
+				Hue:        4,
+				Saturation: 5,
+				Brightness: 6,
+				Kelvin:     lifxlan.KelvinWarm,
+			}
+			if err := ld.SetColor(ctx, nil, &expected, 0, true); err != nil {
+				t.Fatal(err)
+			}
+			if actual := server.State().Color; actual != expected {
+				t.Errorf("State.Color expected %v, got %v", expected, actual)
+			}
+
+			if err := ld.SetLightPower(ctx, nil, lifxlan.PowerOn, 0, true); err != nil {
+				t.Fatal(err)
+			}
+			if actual := server.State().Power; actual != lifxlan.PowerOn {
+				t.Errorf("State.Power expected %v, got %v", lifxlan.PowerOn, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"SetState",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+
+			state := initial
+			state.Power = lifxlan.PowerOn
+			device.Server().SetState(state)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			power, err := device.GetPower(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if power != lifxlan.PowerOn {
+				t.Errorf("GetPower expected %v, got %v", lifxlan.PowerOn, power)
+			}
+		},
+	)
+
+	t.Run(
+		"RequestsToDrop",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+			server.RequestsToDrop = 1
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			// Make sure that the attempts time out in time.
+			ctx = lifxlan.WithReadTimeout(ctx, time.Millisecond*5)
+
+			buf := new(bytes.Buffer)
+			if err := binary.Write(buf, binary.LittleEndian, &lifxlan.RawSetPowerPayload{
+				Level: lifxlan.PowerOn,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			send := func(maxAttempts int) error {
+				return lifxlan.SendWithRetry(
+					ctx,
+					nil, // conn
+					device,
+					lifxlan.FlagAckRequired,
+					lifxlan.SetPower,
+					buf.Bytes(),
+					lifxlan.RetryOptions{
+						MaxAttempts:    maxAttempts,
+						InitialBackoff: time.Millisecond * 20,
+					},
+				)
+			}
+
+			if err := send(1); err == nil {
+				t.Error("Expected error when the only attempt is dropped")
+			}
+			if actual := server.State().Power; actual != initial.Power {
+				t.Errorf("State.Power expected %v, got %v", initial.Power, actual)
+			}
+
+			server.RequestsToDrop = 1
+			if err := send(2); err != nil {
+				t.Fatal(err)
+			}
+			if actual := server.State().Power; actual != lifxlan.PowerOn {
+				t.Errorf("State.Power expected %v, got %v", lifxlan.PowerOn, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"Delay",
+		func(t *testing.T) {
+			server, device := mock.StartServer(t, initial)
+			defer server.Stop()
+			server.Delay = timeout
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout/2)
+			defer cancel()
+
+			if _, err := device.GetPower(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+}
+
+func TestServerMultizone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		zones    int
+		messages int
+	}{
+		{zones: 8, messages: 1},
+		{zones: 20, messages: 3},
+		{zones: 82, messages: 11},
+	} {
+		c := c
+		t.Run(
+			fmt.Sprintf("%d", c.zones),
+			func(t *testing.T) {
+				zones := makeColors(c.zones)
+				server, device := mock.StartServer(t, mock.State{
+					Zones: zones,
+				})
+				defer server.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				t.Run(
+					"Messages",
+					func(t *testing.T) {
+						conn, err := device.Dial()
+						if err != nil {
+							t.Fatal(err)
+						}
+						defer conn.Close()
+
+						seq, err := device.Send(
+							ctx,
+							conn,
+							0, // flags
+							multizone.GetColorZones,
+							&multizone.RawGetColorZonesPayload{
+								StartIndex: 0,
+								EndIndex:   255,
+							},
+						)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						readCtx, readCancel := context.WithTimeout(ctx, time.Millisecond*50)
+						defer readCancel()
+						readCtx = lifxlan.WithReadTimeout(readCtx, time.Millisecond*10)
+						var n int
+						for {
+							resp, err := lifxlan.ReadNextResponse(readCtx, conn)
+							if err != nil {
+								break
+							}
+							if resp.Sequence == seq && resp.Message == multizone.StateMultiZone {
+								n++
+							}
+						}
+						if n != c.messages {
+							t.Errorf("Expected %d StateMultiZone messages, got %d", c.messages, n)
+						}
+					},
+				)
+
+				md, err := multizone.Wrap(ctx, device, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := md.GetColorZones(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, zones) {
+					t.Errorf("GetColorZones expected %v, got %v", zones, got)
+				}
+
+				color := lifxlan.Color{
+					Hue:    1,
+					Kelvin: lifxlan.KelvinWarm,
+				}
+				if err := md.SetColorZones(ctx, nil, 2, 4, color, 0, multizone.NoApply, true); err != nil {
+					t.Fatal(err)
+				}
+				if actual := server.State().Zones; !reflect.DeepEqual(actual, zones) {
+					t.Errorf("Expected zones unchanged before applying, got %v", actual)
+				}
+				if err := md.SetColorZones(ctx, nil, 0, 0, color, 0, multizone.Apply, true); err != nil {
+					t.Fatal(err)
+				}
+				expected := append([]lifxlan.Color(nil), zones...)
+				for _, i := range []int{0, 2, 3, 4} {
+					expected[i] = color
+				}
+				got, err = md.GetColorZones(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("GetColorZones expected %v, got %v", expected, got)
+				}
+			},
+		)
+	}
+}
+
+func TestServerTile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	tiles := make([]mock.Tile, 2)
+	for i := range tiles {
+		tiles[i].Device = tile.RawTileDevice{
+			UserX:  float32(i),
+			Width:  8,
+			Height: 8,
+		}
+	}
+	server, device := mock.StartServer(t, mock.State{
+		Tiles: tiles,
+	})
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	td, err := tile.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(td.Tiles()) != len(tiles) {
+		t.Fatalf("Expected %d tiles, got %d", len(tiles), len(td.Tiles()))
+	}
+
+	colors := makeColors(tile.ColorsPerTile)
+	if err := td.SetTileColors(ctx, nil, 1, colors, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	state := server.State()
+	if !reflect.DeepEqual(state.Tiles[1].Colors[:], colors) {
+		t.Errorf("Tile 1 colors expected %v, got %v", colors, state.Tiles[1].Colors)
+	}
+	if state.Tiles[0].Colors != tiles[0].Colors {
+		t.Errorf("Tile 0 colors expected unchanged, got %v", state.Tiles[0].Colors)
+	}
+
+	cb, err := td.GetColors(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := makeColors(len(cb) * len(cb[0]))
+	for x := range cb {
+		for y := range cb[x] {
+			color := all[x*len(cb[x])+y]
+			cb[x][y] = &color
+		}
+	}
+	if err := td.SetColors(ctx, nil, cb, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := td.GetColors(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cb) {
+		t.Errorf("GetColors expected %v, got %v", cb, got)
+	}
+}
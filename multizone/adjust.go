@@ -0,0 +1,131 @@
+package multizone
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// AdjustKelvinValue adds delta to kelvin,
+// and clamps the result into [min, max].
+func AdjustKelvinValue(kelvin uint16, delta int, min, max uint16) uint16 {
+	v := int(kelvin) + delta
+	if v < int(min) {
+		return min
+	}
+	if v > int(max) {
+		return max
+	}
+	return uint16(v)
+}
+
+func (md *device) AdjustZonesKelvin(
+	ctx context.Context,
+	conn net.Conn,
+	start, end uint8,
+	delta int,
+	ack bool,
+) error {
+	min, max := lifxlan.CachedKelvinRange(md)
+	return md.adjustZones(
+		ctx,
+		conn,
+		"lifxlan/multizone.AdjustZonesKelvin",
+		start,
+		end,
+		func(c lifxlan.Color) lifxlan.Color {
+			c.Kelvin = AdjustKelvinValue(c.Kelvin, delta, min, max)
+			return c
+		},
+		ack,
+	)
+}
+
+func (md *device) AdjustZonesBrightness(
+	ctx context.Context,
+	conn net.Conn,
+	start, end uint8,
+	delta int,
+	ack bool,
+) error {
+	return md.adjustZones(
+		ctx,
+		conn,
+		"lifxlan/multizone.AdjustZonesBrightness",
+		start,
+		end,
+		func(c lifxlan.Color) lifxlan.Color {
+			c.Brightness = light.AdjustBrightnessValue(c.Brightness, delta)
+			return c
+		},
+		ack,
+	)
+}
+
+// adjustZones reads the current colors of the zones,
+// applies adjust to the ones in range [start, end],
+// and writes them back.
+func (md *device) adjustZones(
+	ctx context.Context,
+	conn net.Conn,
+	caller string,
+	start, end uint8,
+	adjust func(lifxlan.Color) lifxlan.Color,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if start > end {
+		return fmt.Errorf("%s: invalid zone range [%d, %d]", caller, start, end)
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var zones []lifxlan.Color
+	var err error
+	if md.SupportsExtendedColorZones() {
+		zones, err = md.GetExtendedColorZones(ctx, conn)
+		if md.markExtendedUnhandled(err) {
+			zones, err = md.GetColorZones(ctx, conn)
+		}
+	} else {
+		zones, err = md.GetColorZones(ctx, conn)
+	}
+	if err != nil {
+		return err
+	}
+	if int(start) >= len(zones) {
+		return fmt.Errorf(
+			"%s: start zone %d out of range, device has %d zones",
+			caller,
+			start,
+			len(zones),
+		)
+	}
+	last := int(end)
+	if last >= len(zones) {
+		last = len(zones) - 1
+	}
+
+	colors := make([]lifxlan.Color, 0, last-int(start)+1)
+	for _, c := range zones[start : last+1] {
+		colors = append(colors, adjust(c))
+	}
+	return md.setZoneColorsAt(ctx, conn, int(start), colors, 0, ack)
+}
@@ -0,0 +1,203 @@
+package multizone_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+func TestAdjustKelvinValue(t *testing.T) {
+	for _, c := range []struct {
+		kelvin   uint16
+		delta    int
+		expected uint16
+	}{
+		{
+			kelvin:   3500,
+			delta:    500,
+			expected: 4000,
+		},
+		{
+			kelvin:   3500,
+			delta:    -500,
+			expected: 3000,
+		},
+		{
+			kelvin:   8800,
+			delta:    500,
+			expected: lifxlan.KelvinHighest,
+		},
+		{
+			kelvin:   1700,
+			delta:    -500,
+			expected: lifxlan.KelvinLowest,
+		},
+	} {
+		got := multizone.AdjustKelvinValue(c.kelvin, c.delta, lifxlan.KelvinLowest, lifxlan.KelvinHighest)
+		if got != c.expected {
+			t.Errorf("AdjustKelvinValue(%d, %d) expected %d, got %d", c.kelvin, c.delta, c.expected, got)
+		}
+	}
+}
+
+func TestAdjustZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 16
+
+	zones := makeZones(n)
+	zones[0].Kelvin = 1700
+	zones[n-1].Kelvin = 8800
+
+	var lock sync.Mutex
+	var received []multizone.RawSetColorZonesPayload
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			multizone.GetColorZones: zonesHandler(t, zones),
+			multizone.SetColorZones: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw multizone.RawSetColorZonesPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw)
+			},
+		},
+		HandleAcks:      true,
+		RawStatePayload: &light.RawStatePayload{},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	md := wrapDevice(t, device)
+
+	for _, c := range []struct {
+		label    string
+		start    uint8
+		end      uint8
+		adjust   func(ctx context.Context, start, end uint8) error
+		expected func(i int) lifxlan.Color
+	}{
+		{
+			label: "KelvinUp",
+			// end is beyond the last zone and should be clamped.
+			start: n - 2,
+			end:   n + 4,
+			adjust: func(ctx context.Context, start, end uint8) error {
+				return md.AdjustZonesKelvin(ctx, nil, start, end, 500, true)
+			},
+			expected: func(i int) lifxlan.Color {
+				c := zones[i]
+				c.Kelvin = multizone.AdjustKelvinValue(c.Kelvin, 500, lifxlan.KelvinLowest, lifxlan.KelvinHighest)
+				return c
+			},
+		},
+		{
+			label: "KelvinDown",
+			start: 0,
+			end:   1,
+			adjust: func(ctx context.Context, start, end uint8) error {
+				return md.AdjustZonesKelvin(ctx, nil, start, end, -500, true)
+			},
+			expected: func(i int) lifxlan.Color {
+				c := zones[i]
+				c.Kelvin = multizone.AdjustKelvinValue(c.Kelvin, -500, lifxlan.KelvinLowest, lifxlan.KelvinHighest)
+				return c
+			},
+		},
+		{
+			label: "BrightnessDown",
+			start: 0,
+			end:   2,
+			adjust: func(ctx context.Context, start, end uint8) error {
+				return md.AdjustZonesBrightness(ctx, nil, start, end, -10, true)
+			},
+			expected: func(i int) lifxlan.Color {
+				c := zones[i]
+				c.Brightness = 0
+				return c
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				lock.Lock()
+				received = nil
+				lock.Unlock()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := c.adjust(ctx, c.start, c.end); err != nil {
+					t.Fatal(err)
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				last := int(c.end)
+				if last >= n {
+					last = n - 1
+				}
+				count := last - int(c.start) + 1
+				if len(received) != count {
+					t.Fatalf("Expected %d SetColorZones messages, got %d", count, len(received))
+				}
+				for i, raw := range received {
+					index := int(c.start) + i
+					if raw.StartIndex != uint8(index) || raw.EndIndex != uint8(index) {
+						t.Errorf("#%d: Expected zone range [%d, %d], got [%d, %d]", i, index, index, raw.StartIndex, raw.EndIndex)
+					}
+					// SetColorZones also clamps kelvin into the range of the device.
+					if expected := md.SanitizeColor(c.expected(index)); raw.Color != expected {
+						t.Errorf("#%d: Color expected %v, got %v", i, expected, raw.Color)
+					}
+					apply := multizone.NoApply
+					if i == count-1 {
+						apply = multizone.Apply
+					}
+					if raw.Apply != apply {
+						t.Errorf("#%d: Apply expected %d, got %d", i, apply, raw.Apply)
+					}
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"OutOfRange",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := md.AdjustZonesKelvin(ctx, nil, n, n+1, 500, true); err == nil {
+				t.Error("Expected error with start beyond the last zone")
+			}
+			if err := md.AdjustZonesBrightness(ctx, nil, 2, 1, 10, true); err == nil {
+				t.Error("Expected error with start after end")
+			}
+		},
+	)
+}
@@ -0,0 +1,355 @@
+package multizone
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// ApplyRequest defines whether and when the device should apply a color change
+// to the zones.
+//
+// Every SetColorZones message with Apply is rendered by the device right away,
+// so updating a whole strip one zone (or one range) at a time with Apply shows
+// all the intermediate states as a visible wipe or flicker.
+// Staging all but the last change with NoApply buffers them on the device,
+// and the last change with Apply (or a final ApplyZones call with ApplyOnly)
+// commits all of them at once:
+//
+//     for i, c := range colors {
+//       if err := md.SetColorZones(ctx, conn, uint8(i), uint8(i), c, 0, multizone.NoApply, true); err != nil {
+//         // handle error
+//       }
+//     }
+//     if err := md.ApplyZones(ctx, conn, true); err != nil {
+//       // handle error
+//     }
+//
+// https://lan.developer.lifx.com/docs/field-types#multizoneapplicationrequest
+type ApplyRequest uint8
+
+// ApplyRequest values.
+const (
+	// Don't apply the change yet, the change will be buffered.
+	NoApply ApplyRequest = 0
+	// Apply the change along with all previously buffered changes.
+	Apply ApplyRequest = 1
+	// Ignore the color in the message and only apply the buffered changes.
+	ApplyOnly ApplyRequest = 2
+)
+
+func (a ApplyRequest) String() string {
+	switch a {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint8(a))
+	case NoApply:
+		return "NoApply"
+	case Apply:
+		return "Apply"
+	case ApplyOnly:
+		return "ApplyOnly"
+	}
+}
+
+// ZonesPerStateMultiZone is the number of zones carried by a single
+// StateMultiZone message.
+const ZonesPerStateMultiZone = 8
+
+// RawSetColorZonesPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setcolorzones---packet-501
+type RawSetColorZonesPayload struct {
+	StartIndex uint8
+	EndIndex   uint8
+	Color      lifxlan.Color
+	Duration   lifxlan.TransitionTime
+	Apply      ApplyRequest
+}
+
+// RawGetColorZonesPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/querying-the-device-for-data#getcolorzones---packet-502
+type RawGetColorZonesPayload struct {
+	StartIndex uint8
+	EndIndex   uint8
+}
+
+// RawStateZonePayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statezone---packet-503
+type RawStateZonePayload struct {
+	ZonesCount uint8
+	ZoneIndex  uint8
+	Color      lifxlan.Color
+}
+
+// RawStateMultiZonePayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statemultizone---packet-506
+type RawStateMultiZonePayload struct {
+	ZonesCount uint8
+	ZoneIndex  uint8
+	Colors     [ZonesPerStateMultiZone]lifxlan.Color
+}
+
+func (md *device) SetColorZones(
+	ctx context.Context,
+	conn net.Conn,
+	start, end uint8,
+	color lifxlan.Color,
+	transition time.Duration,
+	apply ApplyRequest,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/multizone.SetColorZones: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		flags,
+		SetColorZones,
+		&RawSetColorZonesPayload{
+			StartIndex: start,
+			EndIndex:   end,
+			Color:      md.SanitizeColor(color),
+			Duration:   lifxlan.ConvertDuration(transition),
+			Apply:      apply,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, md.Source(), seq)
+	}
+	return nil
+}
+
+func (md *device) ApplyZones(ctx context.Context, conn net.Conn, ack bool) error {
+	// The zones and color are ignored by the device with ApplyOnly.
+	return md.SetColorZones(
+		ctx,
+		conn,
+		0,
+		0,
+		lifxlan.ColorBlack,
+		0,
+		ApplyOnly,
+		ack,
+	)
+}
+
+func (md *device) SetAllZones(
+	ctx context.Context,
+	conn net.Conn,
+	color lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if md.zonesCount <= math.MaxUint8+1 || !md.SupportsExtendedColorZones() {
+		// Devices clamp the end index to their last zone,
+		// so [0, 255] covers all the zones addressable by SetColorZones.
+		return md.SetColorZones(
+			ctx,
+			conn,
+			0,
+			math.MaxUint8,
+			color,
+			transition,
+			Apply,
+			ack,
+		)
+	}
+
+	// The zones after 255 can only be addressed by extended messages.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	colors := make([]lifxlan.Color, md.zonesCount)
+	for i := range colors {
+		colors[i] = color
+	}
+	return md.setExtendedZoneColorsAt(ctx, conn, 0, colors, transition, ack)
+}
+
+func (md *device) GetColorZones(
+	ctx context.Context,
+	conn net.Conn,
+) ([]lifxlan.Color, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetColorZones,
+		&RawGetColorZonesPayload{
+			StartIndex: 0,
+			EndIndex:   255,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read responses
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, md.Source())
+	defer cancel()
+	var zones zoneCollector
+	for {
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, md.Source()) {
+				continue
+			}
+
+			r := bytes.NewReader(resp.Payload)
+			switch resp.Message {
+			default:
+				continue
+
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf(
+					"lifxlan/multizone.GetColorZones: %w",
+					&lifxlan.UnhandledMessageError{Type: raw.UnhandledType},
+				)
+
+			case StateZone:
+				var raw RawStateZonePayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				zones.add(int(raw.ZonesCount), int(raw.ZoneIndex), raw.Color)
+				// Legacy single segment devices answer the whole range with a single
+				// StateZone message and nothing else.
+				// Other devices could mix StateZone with StateMultiZone responses,
+				// or send one StateZone per zone,
+				// so keep reading until all the zones are received.
+				if raw.ZonesCount == 1 {
+					md.zonesCount = len(zones.colors)
+					return zones.colors, nil
+				}
+
+			case StateMultiZone:
+				var raw RawStateMultiZonePayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				zones.add(int(raw.ZonesCount), int(raw.ZoneIndex), raw.Colors[:]...)
+			}
+
+			if zones.done() {
+				md.zonesCount = len(zones.colors)
+				return zones.colors, nil
+			}
+		}
+	}
+}
+
+// zoneCollector reassembles zone colors from multiple responses.
+type zoneCollector struct {
+	colors   []lifxlan.Color
+	received []bool
+	n        int
+}
+
+// add adds colors starting at index, reported by a response with the given
+// total zones count.
+//
+// Colors with an index beyond count are ignored,
+// as StateMultiZone responses are always padded to 8 colors.
+func (zc *zoneCollector) add(count, index int, colors ...lifxlan.Color) {
+	if zc.colors == nil {
+		zc.colors = make([]lifxlan.Color, count)
+		zc.received = make([]bool, count)
+	}
+	for i, c := range colors {
+		zi := index + i
+		if zi >= len(zc.colors) {
+			break
+		}
+		zc.colors[zi] = c
+		if !zc.received[zi] {
+			zc.received[zi] = true
+			zc.n++
+		}
+	}
+}
+
+// done returns true if colors for all the zones are received.
+func (zc *zoneCollector) done() bool {
+	return zc.colors != nil && zc.n >= len(zc.colors)
+}
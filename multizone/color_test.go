@@ -0,0 +1,633 @@
+package multizone_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+// zonesHandler returns a mock.HandlerFunc that answers GetColorZones messages
+// the same way a real multizone device would.
+func zonesHandler(t *testing.T, zones []lifxlan.Color) mock.HandlerFunc {
+	return func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw multizone.RawGetColorZonesPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		end := int(raw.EndIndex)
+		if end >= len(zones) {
+			end = len(zones) - 1
+		}
+
+		if raw.StartIndex == raw.EndIndex {
+			buf := new(bytes.Buffer)
+			if err := binary.Write(
+				buf,
+				binary.LittleEndian,
+				&multizone.RawStateZonePayload{
+					ZonesCount: uint8(len(zones)),
+					ZoneIndex:  raw.StartIndex,
+					Color:      zones[raw.StartIndex],
+				},
+			); err != nil {
+				t.Error(err)
+				return
+			}
+			s.Reply(conn, addr, orig, multizone.StateZone, buf.Bytes())
+			return
+		}
+
+		for i := int(raw.StartIndex); i <= end; i += multizone.ZonesPerStateMultiZone {
+			payload := &multizone.RawStateMultiZonePayload{
+				ZonesCount: uint8(len(zones)),
+				ZoneIndex:  uint8(i),
+			}
+			copy(payload.Colors[:], zones[i:])
+			buf := new(bytes.Buffer)
+			if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+				t.Error(err)
+				return
+			}
+			s.Reply(conn, addr, orig, multizone.StateMultiZone, buf.Bytes())
+		}
+	}
+}
+
+func makeZones(n int) []lifxlan.Color {
+	zones := make([]lifxlan.Color, n)
+	for i := range zones {
+		zones[i] = lifxlan.Color{
+			Hue:        uint16(i * 100),
+			Saturation: 65535,
+			Brightness: uint16(i),
+			Kelvin:     3500,
+		}
+	}
+	return zones
+}
+
+func TestGetColorZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, n := range []int{1, 16, 20, 80} {
+		n := n
+		t.Run(
+			fmt.Sprintf("%d", n),
+			func(t *testing.T) {
+				zones := makeZones(n)
+
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.RawStatePayload = &light.RawStatePayload{}
+				service.Handlers[multizone.GetColorZones] = zonesHandler(t, zones)
+
+				md := wrapDevice(t, device)
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				got, err := md.GetColorZones(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, zones) {
+					t.Errorf("GetColorZones expected %+v, got %+v", zones, got)
+				}
+			},
+		)
+	}
+}
+
+func TestGetColorZonesStateZone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label string
+		n     int
+	}{
+		{
+			// A legacy single segment device.
+			label: "Single",
+			n:     1,
+		},
+		{
+			label: "PerZone",
+			n:     4,
+		},
+	} {
+		c := c
+		t.Run(c.label, func(t *testing.T) {
+			zones := makeZones(c.n)
+			// Answer every GetColorZones with one StateZone per zone,
+			// in reverse order.
+			service := &mock.Service{
+				TB: t,
+				Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+					multizone.GetColorZones: func(
+						s *mock.Service,
+						conn net.PacketConn,
+						addr net.Addr,
+						orig *lifxlan.Response,
+					) {
+						for i := len(zones) - 1; i >= 0; i-- {
+							buf := new(bytes.Buffer)
+							if err := binary.Write(
+								buf,
+								binary.LittleEndian,
+								&multizone.RawStateZonePayload{
+									ZonesCount: uint8(len(zones)),
+									ZoneIndex:  uint8(i),
+									Color:      zones[i],
+								},
+							); err != nil {
+								t.Error(err)
+								return
+							}
+							s.Reply(conn, addr, orig, multizone.StateZone, buf.Bytes())
+						}
+					},
+				},
+				RawStatePayload: &light.RawStatePayload{},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			md := wrapDevice(t, device)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			got, err := md.GetColorZones(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, zones) {
+				t.Errorf("GetColorZones expected %+v, got %+v", zones, got)
+			}
+		})
+	}
+}
+
+func TestGetColorZonesMixed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 12
+
+	zones := makeZones(n)
+	// Answer every GetColorZones with StateZone for the zones after the first
+	// StateMultiZone, sending one of them before the StateMultiZone.
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			multizone.GetColorZones: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				zone := func(i int) {
+					buf := new(bytes.Buffer)
+					if err := binary.Write(
+						buf,
+						binary.LittleEndian,
+						&multizone.RawStateZonePayload{
+							ZonesCount: n,
+							ZoneIndex:  uint8(i),
+							Color:      zones[i],
+						},
+					); err != nil {
+						t.Error(err)
+						return
+					}
+					s.Reply(conn, addr, orig, multizone.StateZone, buf.Bytes())
+				}
+
+				zone(multizone.ZonesPerStateMultiZone)
+				payload := &multizone.RawStateMultiZonePayload{
+					ZonesCount: n,
+					ZoneIndex:  0,
+				}
+				copy(payload.Colors[:], zones)
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+					t.Error(err)
+					return
+				}
+				s.Reply(conn, addr, orig, multizone.StateMultiZone, buf.Bytes())
+				for i := multizone.ZonesPerStateMultiZone + 1; i < n; i++ {
+					zone(i)
+				}
+			},
+		},
+		RawStatePayload: &light.RawStatePayload{},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	md := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	got, err := md.GetColorZones(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, zones) {
+		t.Errorf("GetColorZones expected %+v, got %+v", zones, got)
+	}
+}
+
+func TestGetColorZonesUnhandled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const msg = multizone.GetColorZones
+
+	// Answers the GetColorZones sent by Wrap, then rejects the following ones.
+	var lock sync.Mutex
+	var calls int
+	zones := zonesHandler(t, makeZones(8))
+	unhandled := mock.StateUnhandledHandler(msg)
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			msg: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				lock.Lock()
+				calls++
+				first := calls == 1
+				lock.Unlock()
+				if first {
+					zones(s, conn, addr, orig)
+					return
+				}
+				unhandled(s, conn, addr, orig)
+			},
+		},
+		RawStatePayload: &light.RawStatePayload{},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	md := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := md.GetColorZones(ctx, nil)
+	var e *lifxlan.UnhandledMessageError
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected *lifxlan.UnhandledMessageError, got %v", err)
+	}
+	if e.Type != msg {
+		t.Errorf("Unhandled type expected %d, got %d", msg, e.Type)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error before timeout, got %v", err)
+	}
+}
+
+func TestSetColorZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(16))
+
+	md := wrapDevice(t, device)
+
+	expected := multizone.RawSetColorZonesPayload{
+		StartIndex: 2,
+		EndIndex:   5,
+		Color: lifxlan.Color{
+			Hue:        1,
+			Saturation: 2,
+			Brightness: 3,
+			Kelvin:     3500,
+		},
+		Duration: 1000,
+		Apply:    multizone.ApplyOnly,
+	}
+
+	var called bool
+	service.Handlers[multizone.SetColorZones] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		called = true
+		var raw multizone.RawSetColorZonesPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if raw != expected {
+			t.Errorf("SetColorZones payload expected %+v, got %+v", expected, raw)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := md.SetColorZones(
+		ctx,
+		nil,
+		expected.StartIndex,
+		expected.EndIndex,
+		expected.Color,
+		expected.Duration.Duration(),
+		expected.Apply,
+		true,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("SetColorZones message not received.")
+	}
+}
+
+func TestApplyZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 4
+
+	var lock sync.Mutex
+	var received []multizone.ApplyRequest
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			multizone.GetColorZones: zonesHandler(t, makeZones(n)),
+			multizone.SetColorZones: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw multizone.RawSetColorZonesPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw.Apply)
+			},
+		},
+		RawStatePayload: &light.RawStatePayload{},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	md := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := md.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i, c := range makeZones(n) {
+		if err := md.SetColorZones(
+			ctx,
+			conn,
+			uint8(i),
+			uint8(i),
+			c,
+			0,
+			multizone.NoApply,
+			true,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := md.ApplyZones(ctx, conn, true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []multizone.ApplyRequest{
+		multizone.NoApply,
+		multizone.NoApply,
+		multizone.NoApply,
+		multizone.NoApply,
+		multizone.ApplyOnly,
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Apply flags expected %v, got %v", expected, received)
+	}
+}
+
+func TestSetAllZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	color := lifxlan.Color{Hue: 0, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+
+	t.Run(
+		"Legacy",
+		func(t *testing.T) {
+			var lock sync.Mutex
+			var received []*lifxlan.Response
+			record := func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, orig)
+			}
+			service := &mock.Service{
+				TB:         t,
+				HandleAcks: true,
+				Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+					multizone.GetColorZones:         zonesHandler(t, makeZones(16)),
+					multizone.SetColorZones:         record,
+					multizone.SetExtendedColorZones: record,
+				},
+				RawStatePayload: &light.RawStatePayload{},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			md := wrapDevice(t, device)
+			// LIFX Z, supports extended multizone messages.
+			*md.HardwareVersion() = lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 32,
+			}
+			*md.Firmware() = lifxlan.FirmwareUpgrade{
+				Major: 2,
+				Minor: 77,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := md.SetAllZones(ctx, nil, color, time.Second, true); err != nil {
+				t.Fatal(err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(received) != 1 {
+				t.Fatalf("Expected exactly 1 message, got %d", len(received))
+			}
+			if received[0].Message != multizone.SetColorZones {
+				t.Fatalf("Expected SetColorZones, got %d", received[0].Message)
+			}
+			var raw multizone.RawSetColorZonesPayload
+			r := bytes.NewReader(received[0].Payload)
+			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+				t.Fatal(err)
+			}
+			if raw.StartIndex != 0 || raw.EndIndex != 255 {
+				t.Errorf("Expected zone range [0, 255], got [%d, %d]", raw.StartIndex, raw.EndIndex)
+			}
+			if raw.Color != color {
+				t.Errorf("Color expected %v, got %v", color, raw.Color)
+			}
+			if raw.Apply != multizone.Apply {
+				t.Errorf("Apply expected %v, got %v", multizone.Apply, raw.Apply)
+			}
+		},
+	)
+
+	t.Run(
+		"Extended",
+		func(t *testing.T) {
+			const n = 300
+
+			var lock sync.Mutex
+			var received []multizone.RawSetExtendedColorZonesPayload
+			service := &mock.Service{
+				TB:         t,
+				HandleAcks: true,
+				Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+					multizone.GetColorZones:         zonesHandler(t, makeZones(16)),
+					multizone.GetExtendedColorZones: extendedZonesHandler(t, makeZones(n)),
+					multizone.SetExtendedColorZones: func(
+						_ *mock.Service,
+						_ net.PacketConn,
+						_ net.Addr,
+						orig *lifxlan.Response,
+					) {
+						var raw multizone.RawSetExtendedColorZonesPayload
+						r := bytes.NewReader(orig.Payload)
+						if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+							t.Error(err)
+							return
+						}
+						lock.Lock()
+						defer lock.Unlock()
+						received = append(received, raw)
+					},
+				},
+				RawStatePayload: &light.RawStatePayload{},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			md := wrapDevice(t, device)
+			*md.HardwareVersion() = lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 32,
+			}
+			*md.Firmware() = lifxlan.FirmwareUpgrade{
+				Major: 2,
+				Minor: 77,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			// Caches the zones count.
+			if _, err := md.GetExtendedColorZones(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := md.SetAllZones(ctx, nil, color, 0, true); err != nil {
+				t.Fatal(err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			var total int
+			for i, raw := range received {
+				apply := multizone.NoApply
+				if i == len(received)-1 {
+					apply = multizone.Apply
+				}
+				if raw.Apply != apply {
+					t.Errorf("#%d: Apply expected %v, got %v", i, apply, raw.Apply)
+				}
+				if int(raw.ZoneIndex) != total {
+					t.Errorf("#%d: ZoneIndex expected %d, got %d", i, total, raw.ZoneIndex)
+				}
+				for _, c := range raw.Colors[:raw.ColorsCount] {
+					if c != color {
+						t.Errorf("#%d: Color expected %v, got %v", i, color, c)
+						break
+					}
+				}
+				total += int(raw.ColorsCount)
+			}
+			if total != n {
+				t.Errorf("Expected %d zones set, got %d", n, total)
+			}
+		},
+	)
+}
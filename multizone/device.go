@@ -0,0 +1,292 @@
+package multizone
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// Device is a wrapped lifxlan.Device that provides multizone related APIs.
+type Device interface {
+	light.Device
+
+	// ZonesCount returns the number of zones on this device.
+	//
+	// The number is cached by Wrap, GetColorZones and GetExtendedColorZones.
+	// When it's not cached, this function sends a GetColorZones message for
+	// the first zone only and reads the zones count from the response,
+	// without collecting the colors of all the zones.
+	//
+	// For linked devices (e.g. a LIFX Beam with corners),
+	// it's the combined number of zones across all segments.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	ZonesCount(ctx context.Context, conn net.Conn) (int, error)
+
+	// GetColorZones returns the current colors of all the zones on this device,
+	// in zone index order.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// The device replies with one StateMultiZone message for every 8 zones,
+	// and this function will wait until all the zones are received.
+	// Some devices reply with StateZone messages instead, or mixed in,
+	// in which case every color is placed at its zone index,
+	// and it still waits until all the zones are received,
+	// unless the StateZone reports only 1 zone
+	// (older single segment devices).
+	// In case of one or more of the responses get dropped on the network,
+	// this function will wait until context is cancelled.
+	// So it's important to set an appropriate timeout on the context.
+	//
+	// If the device replies StateUnhandled,
+	// the returned error wraps a *lifxlan.UnhandledMessageError.
+	GetColorZones(ctx context.Context, conn net.Conn) ([]lifxlan.Color, error)
+
+	// SetColorZones sets the zones in range [start, end] (inclusive) to color.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetColorZones(ctx context.Context, conn net.Conn, start, end uint8, color lifxlan.Color, transition time.Duration, apply ApplyRequest, ack bool) error
+
+	// ApplyZones commits all the zone changes buffered on the device by
+	// previous SetColorZones calls with NoApply,
+	// by sending a SetColorZones message with ApplyOnly.
+	//
+	// See ApplyRequest for why staging the changes matters.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	ApplyZones(ctx context.Context, conn net.Conn, ack bool) error
+
+	// SetAllZones sets all the zones of the device to color.
+	//
+	// It sends a single SetColorZones message with zone range [0, 255],
+	// which the device clamps to its last zone.
+	// For devices known to have more than 256 zones (via ZonesCount or
+	// GetColorZones),
+	// it uses SetExtendedColorZones instead when SupportsExtendedColorZones
+	// returns true.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack(s) from
+	// the device.
+	SetAllZones(ctx context.Context, conn net.Conn, color lifxlan.Color, transition time.Duration, ack bool) error
+
+	// SupportsExtendedColorZones returns true if the device is known to support
+	// extended multizone messages (SetExtendedColorZones and
+	// GetExtendedColorZones).
+	//
+	// It's based on the cached HardwareVersion and Firmware of the device,
+	// so it will return false if the device's hardware version was never fetched
+	// and cached.
+	// As extended multizone support usually comes with a firmware upgrade,
+	// you should also fetch and cache the firmware version (via GetFirmware)
+	// before calling this function.
+	//
+	// Once the device replied StateUnhandled to an extended multizone message,
+	// it's cached that the device doesn't support them,
+	// and this function returns false from then on.
+	SupportsExtendedColorZones() bool
+
+	// GetExtendedColorZones is the same as GetColorZones,
+	// but uses the extended multizone messages,
+	// which carry up to 82 zones per response.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// Only call this function when SupportsExtendedColorZones returns true,
+	// otherwise it might block until the context is cancelled.
+	// If the device replies StateUnhandled,
+	// the returned error wraps a *lifxlan.UnhandledMessageError.
+	GetExtendedColorZones(ctx context.Context, conn net.Conn) ([]lifxlan.Color, error)
+
+	// SetExtendedColorZones sets the colors of len(colors) zones starting from
+	// index in a single message.
+	//
+	// At most MaxExtendedColorZones colors can be set in one call,
+	// it returns an error when colors is longer than that.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	//
+	// Only call this function when SupportsExtendedColorZones returns true,
+	// otherwise use SetColorZones instead.
+	SetExtendedColorZones(ctx context.Context, conn net.Conn, index uint16, colors []lifxlan.Color, transition time.Duration, apply ApplyRequest, ack bool) error
+
+	// GetMultiZoneEffect returns the current firmware effect running on the
+	// device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetMultiZoneEffect(ctx context.Context, conn net.Conn) (*MultiZoneEffect, error)
+
+	// SetMultiZoneEffect starts (or stops, with MultiZoneEffectOff) a firmware
+	// effect on the device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetMultiZoneEffect(ctx context.Context, conn net.Conn, effect MultiZoneEffect, ack bool) error
+
+	// SetGradient paints a smooth gradient from from to to across all the zones
+	// of the device, as calculated by GradientColors.
+	//
+	// It uses SetZoneColors to set the zones.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the APIs are sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received acks of all
+	// the messages from the device.
+	SetGradient(ctx context.Context, conn net.Conn, from, to lifxlan.Color, transition time.Duration, ack bool) error
+
+	// SetZoneColors sets the colors of the zones starting from the first one,
+	// one color per zone.
+	//
+	// It uses SetExtendedColorZones when SupportsExtendedColorZones returns
+	// true, otherwise it sends one SetColorZones message per zone,
+	// and only applies them with the last one.
+	// If ack is true and the device replies StateUnhandled to the first
+	// SetExtendedColorZones message,
+	// it falls back to SetColorZones transparently.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the APIs are sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received acks of all
+	// the messages from the device.
+	SetZoneColors(ctx context.Context, conn net.Conn, colors []lifxlan.Color, transition time.Duration, ack bool) error
+
+	// AdjustZonesKelvin reads the current colors of the zones,
+	// adds delta to the kelvin of the zones in range [start, end] (inclusive),
+	// and writes them back.
+	//
+	// Hue, saturation and brightness of every zone are preserved.
+	// The result kelvin will be clamped into the supported temperature range of
+	// the device based on its cached HardwareVersion and Firmware,
+	// or [KelvinLowest, KelvinHighest] if the hardware version was never fetched
+	// and cached.
+	//
+	// end will be clamped to the last zone of the device.
+	// It returns an error if start is after end or beyond the last zone.
+	//
+	// The zones are read via GetExtendedColorZones and written via
+	// SetExtendedColorZones when SupportsExtendedColorZones returns true,
+	// otherwise GetColorZones and SetColorZones are used.
+	// If the device replies StateUnhandled to the extended multizone messages,
+	// it falls back to GetColorZones and SetColorZones transparently.
+	// They are always read from the device right before the change instead of
+	// any cached state,
+	// so changes made by other clients (e.g. the LIFX app) since the last call
+	// are respected.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the APIs are sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received acks of all
+	// the messages from the device.
+	AdjustZonesKelvin(ctx context.Context, conn net.Conn, start, end uint8, delta int, ack bool) error
+
+	// AdjustZonesBrightness is similar to AdjustZonesKelvin,
+	// except that it adjusts the brightness of the zones by delta percent
+	// (see light.AdjustBrightnessValue)
+	// and preserves hue, saturation and kelvin.
+	AdjustZonesBrightness(ctx context.Context, conn net.Conn, start, end uint8, delta int, ack bool) error
+}
+
+type device struct {
+	light.Device
+
+	// The number of zones reported by the device.
+	zonesCount int
+
+	// Set to non-zero atomically when the device replied StateUnhandled to an
+	// extended multizone message.
+	extendedUnhandled int32
+}
+
+var _ Device = (*device)(nil)
+
+func (md *device) String() string {
+	return lifxlan.FormatDevice(md, "MultizoneDevice")
+}
+
+func (md *device) SupportsExtendedColorZones() bool {
+	if atomic.LoadInt32(&md.extendedUnhandled) != 0 {
+		return false
+	}
+	parsed := md.HardwareVersion().Parse()
+	if parsed == nil {
+		return false
+	}
+	return parsed.FeaturesAt(*md.Firmware()).ExtendedMultizone.Get()
+}
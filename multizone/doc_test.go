@@ -0,0 +1,38 @@
+package multizone_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+// This example demonstrates how to set all the zones on a multizone device to
+// the same color.
+func Example() {
+	// Need proper initialization on real code.
+	var (
+		device multizone.Device
+		// Important to set timeout to context when requiring ack.
+		timeout time.Duration
+		// Color to set.
+		color lifxlan.Color
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := device.SetColorZones(
+		ctx,
+		nil, // conn, use nil so that SetColorZones will maintain it for us
+		0,   // start
+		255, // end
+		color,
+		0, // fade in duration
+		multizone.Apply,
+		true, // ack
+	); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package multizone
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// MultiZoneEffectType defines the type of a multizone firmware effect.
+//
+// https://lan.developer.lifx.com/docs/field-types#multizoneeffecttype
+type MultiZoneEffectType uint8
+
+// MultiZoneEffectType values.
+const (
+	MultiZoneEffectOff  MultiZoneEffectType = 0
+	MultiZoneEffectMove MultiZoneEffectType = 1
+)
+
+func (e MultiZoneEffectType) String() string {
+	switch e {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint8(e))
+	case MultiZoneEffectOff:
+		return "Off"
+	case MultiZoneEffectMove:
+		return "Move"
+	}
+}
+
+// MoveDirection defines the direction of the move effect.
+type MoveDirection uint32
+
+// MoveDirection values.
+const (
+	MoveDirectionRight MoveDirection = 0
+	MoveDirectionLeft  MoveDirection = 1
+)
+
+func (d MoveDirection) String() string {
+	switch d {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint32(d))
+	case MoveDirectionRight:
+		return "Right"
+	case MoveDirectionLeft:
+		return "Left"
+	}
+}
+
+// MultiZoneEffectParametersLength is the length of the effect specific
+// parameters in multizone effect messages.
+const MultiZoneEffectParametersLength = 32
+
+// RawMultiZoneEffectSettings defines the struct to be used for encoding and
+// decoding.
+//
+// It's the payload of both SetMultiZoneEffect and StateMultiZoneEffect
+// messages:
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setmultizoneeffect---packet-508
+type RawMultiZoneEffectSettings struct {
+	InstanceID uint32
+	Type       MultiZoneEffectType
+	_          [2]byte // reserved
+	Speed      lifxlan.TransitionTime
+	Duration   uint64  // nanoseconds
+	_          [8]byte // reserved
+	Parameters [MultiZoneEffectParametersLength]byte
+}
+
+// Direction returns the direction of the move effect stored in the parameters.
+func (raw *RawMultiZoneEffectSettings) Direction() MoveDirection {
+	return MoveDirection(binary.LittleEndian.Uint32(raw.Parameters[4:8]))
+}
+
+// SetDirection stores the direction of the move effect into the parameters.
+func (raw *RawMultiZoneEffectSettings) SetDirection(d MoveDirection) {
+	binary.LittleEndian.PutUint32(raw.Parameters[4:8], uint32(d))
+}
+
+// MultiZoneEffect defines a multizone firmware effect.
+type MultiZoneEffect struct {
+	// A user chosen id to identify this effect.
+	InstanceID uint32
+
+	Type MultiZoneEffectType
+
+	// The speed of the effect, e.g. the duration of one cycle.
+	Speed time.Duration
+
+	// How long the effect should run for,
+	// 0 means forever.
+	Duration time.Duration
+
+	// Only used by MultiZoneEffectMove.
+	Direction MoveDirection
+}
+
+// Raw converts MultiZoneEffect into RawMultiZoneEffectSettings.
+func (e MultiZoneEffect) Raw() *RawMultiZoneEffectSettings {
+	raw := &RawMultiZoneEffectSettings{
+		InstanceID: e.InstanceID,
+		Type:       e.Type,
+		Speed:      lifxlan.ConvertDuration(e.Speed),
+		Duration:   uint64(e.Duration),
+	}
+	if e.Type == MultiZoneEffectMove {
+		raw.SetDirection(e.Direction)
+	}
+	return raw
+}
+
+// ParseMultiZoneEffect parses RawMultiZoneEffectSettings into a
+// MultiZoneEffect.
+func ParseMultiZoneEffect(raw *RawMultiZoneEffectSettings) *MultiZoneEffect {
+	e := &MultiZoneEffect{
+		InstanceID: raw.InstanceID,
+		Type:       raw.Type,
+		Speed:      raw.Speed.Duration(),
+		Duration:   time.Duration(raw.Duration),
+	}
+	if e.Type == MultiZoneEffectMove {
+		e.Direction = raw.Direction()
+	}
+	return e
+}
+
+func (md *device) SetMultiZoneEffect(
+	ctx context.Context,
+	conn net.Conn,
+	effect MultiZoneEffect,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(effect.Speed); err != nil {
+		return fmt.Errorf("lifxlan/multizone.SetMultiZoneEffect: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		flags,
+		SetMultiZoneEffect,
+		effect.Raw(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, md.Source(), seq)
+	}
+	return nil
+}
+
+func (md *device) GetMultiZoneEffect(
+	ctx context.Context,
+	conn net.Conn,
+) (*MultiZoneEffect, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetMultiZoneEffect,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		md.Source(),
+		seq,
+		StateMultiZoneEffect,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawMultiZoneEffectSettings
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return ParseMultiZoneEffect(&raw), nil
+}
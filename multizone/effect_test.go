@@ -0,0 +1,123 @@
+package multizone_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+func TestMultiZoneEffect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(16))
+
+	md := wrapDevice(t, device)
+
+	var payload []byte
+	service.Handlers[multizone.SetMultiZoneEffect] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		payload = append([]byte(nil), orig.Payload...)
+	}
+	service.Handlers[multizone.GetMultiZoneEffect] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		s.Reply(conn, addr, orig, multizone.StateMultiZoneEffect, payload)
+	}
+
+	for _, c := range []struct {
+		label  string
+		effect multizone.MultiZoneEffect
+		// from SetMultiZoneEffect docs:
+		// instanceid(4) type(1) reserved(2) speed(4) duration(8) reserved(8)
+		// parameters(32)
+		expected []byte
+	}{
+		{
+			label: "Move",
+			effect: multizone.MultiZoneEffect{
+				InstanceID: 0x01020304,
+				Type:       multizone.MultiZoneEffectMove,
+				Speed:      time.Second * 5,
+				Duration:   time.Nanosecond * 0x0102,
+				Direction:  multizone.MoveDirectionLeft,
+			},
+			expected: concatBytes(
+				[]byte{0x04, 0x03, 0x02, 0x01},
+				[]byte{0x01},
+				make([]byte, 2),
+				[]byte{0x88, 0x13, 0x00, 0x00}, // 5000ms
+				[]byte{0x02, 0x01, 0, 0, 0, 0, 0, 0},
+				make([]byte, 8),
+				[]byte{0, 0, 0, 0, 0x01, 0, 0, 0},
+				make([]byte, 24),
+			),
+		},
+		{
+			label: "Off",
+			effect: multizone.MultiZoneEffect{
+				Type: multizone.MultiZoneEffectOff,
+				// Direction is ignored by Off.
+				Direction: multizone.MoveDirectionLeft,
+			},
+			expected: make([]byte, 59),
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := md.SetMultiZoneEffect(ctx, nil, c.effect, true); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(payload, c.expected) {
+					t.Errorf("Payload expected %v, got %v", c.expected, payload)
+				}
+
+				got, err := md.GetMultiZoneEffect(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := c.effect
+				if expected.Type != multizone.MultiZoneEffectMove {
+					expected.Direction = 0
+				}
+				if !reflect.DeepEqual(*got, expected) {
+					t.Errorf("GetMultiZoneEffect expected %+v, got %+v", expected, *got)
+				}
+			},
+		)
+	}
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, p := range parts {
+		buf.Write(p)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,220 @@
+package multizone
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// MaxExtendedColorZones is the max number of zones can be carried by a single
+// extended multizone message.
+const MaxExtendedColorZones = 82
+
+// MinReadBufferSize is the minimum read buffer size
+// (see lifxlan.WithReadBufferSize) for the responses of the APIs in this
+// package.
+//
+// It's the size of a StateExtendedColorZones message,
+// the biggest one in this package.
+const MinReadBufferSize = lifxlan.HeaderLength + 661
+
+// RawSetExtendedColorZonesPayload defines the struct to be used for encoding
+// and decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setextendedcolorzones---packet-510
+type RawSetExtendedColorZonesPayload struct {
+	Duration    lifxlan.TransitionTime
+	Apply       ApplyRequest
+	ZoneIndex   uint16
+	ColorsCount uint8
+	Colors      [MaxExtendedColorZones]lifxlan.Color
+}
+
+// RawStateExtendedColorZonesPayload defines the struct to be used for encoding
+// and decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#stateextendedcolorzones---packet-512
+type RawStateExtendedColorZonesPayload struct {
+	ZonesCount  uint16
+	ZoneIndex   uint16
+	ColorsCount uint8
+	Colors      [MaxExtendedColorZones]lifxlan.Color
+}
+
+func (md *device) SetExtendedColorZones(
+	ctx context.Context,
+	conn net.Conn,
+	index uint16,
+	colors []lifxlan.Color,
+	transition time.Duration,
+	apply ApplyRequest,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/multizone.SetExtendedColorZones: %w", err)
+	}
+
+	if len(colors) > MaxExtendedColorZones {
+		return fmt.Errorf(
+			"lifxlan/multizone.SetExtendedColorZones: too many colors: %d > %d",
+			len(colors),
+			MaxExtendedColorZones,
+		)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload := &RawSetExtendedColorZonesPayload{
+		Duration:    lifxlan.ConvertDuration(transition),
+		Apply:       apply,
+		ZoneIndex:   index,
+		ColorsCount: uint8(len(colors)),
+	}
+	for i, c := range colors {
+		payload.Colors[i] = md.SanitizeColor(c)
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		flags,
+		SetExtendedColorZones,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, md.Source(), seq)
+	}
+	return nil
+}
+
+func (md *device) GetExtendedColorZones(
+	ctx context.Context,
+	conn net.Conn,
+) ([]lifxlan.Color, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := md.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetExtendedColorZones,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read responses
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, md.Source())
+	defer cancel()
+	var zones zoneCollector
+	for {
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, md.Source()) {
+				continue
+			}
+			switch resp.Message {
+			default:
+				continue
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf(
+					"lifxlan/multizone.GetExtendedColorZones: %w",
+					&lifxlan.UnhandledMessageError{Type: raw.UnhandledType},
+				)
+			case StateExtendedColorZones:
+			}
+
+			var raw RawStateExtendedColorZonesPayload
+			r := bytes.NewReader(resp.Payload)
+			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+				return nil, err
+			}
+			count := int(raw.ColorsCount)
+			if count > MaxExtendedColorZones {
+				count = MaxExtendedColorZones
+			}
+			zones.add(int(raw.ZonesCount), int(raw.ZoneIndex), raw.Colors[:count]...)
+
+			if zones.done() {
+				md.zonesCount = len(zones.colors)
+				return zones.colors, nil
+			}
+		}
+	}
+}
+
+// markExtendedUnhandled returns true if err is caused by the device replying
+// StateUnhandled to an extended multizone message,
+// and caches that the device doesn't support them in that case,
+// so SupportsExtendedColorZones returns false from then on.
+func (md *device) markExtendedUnhandled(err error) bool {
+	var unhandled *lifxlan.UnhandledMessageError
+	if !errors.As(err, &unhandled) {
+		return false
+	}
+	switch unhandled.Type {
+	default:
+		return false
+	case SetExtendedColorZones, GetExtendedColorZones:
+	}
+	atomic.StoreInt32(&md.extendedUnhandled, 1)
+	return true
+}
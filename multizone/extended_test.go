@@ -0,0 +1,381 @@
+package multizone_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+// extendedZonesHandler returns a mock.HandlerFunc that answers
+// GetExtendedColorZones messages the same way a real multizone device would.
+func extendedZonesHandler(t *testing.T, zones []lifxlan.Color) mock.HandlerFunc {
+	return func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		for i := 0; i < len(zones); i += multizone.MaxExtendedColorZones {
+			payload := &multizone.RawStateExtendedColorZonesPayload{
+				ZonesCount: uint16(len(zones)),
+				ZoneIndex:  uint16(i),
+			}
+			payload.ColorsCount = uint8(copy(payload.Colors[:], zones[i:]))
+			buf := new(bytes.Buffer)
+			if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+				t.Error(err)
+				return
+			}
+			s.Reply(conn, addr, orig, multizone.StateExtendedColorZones, buf.Bytes())
+		}
+	}
+}
+
+func TestSupportsExtendedColorZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(16))
+
+	md := wrapDevice(t, device)
+	if md.SupportsExtendedColorZones() {
+		t.Error("Expected false without cached hardware version")
+	}
+
+	// LIFX Z
+	*md.HardwareVersion() = lifxlan.HardwareVersion{
+		VendorID:  1,
+		ProductID: 32,
+	}
+	*md.Firmware() = lifxlan.FirmwareUpgrade{
+		Major: 2,
+		Minor: 76,
+	}
+	if md.SupportsExtendedColorZones() {
+		t.Errorf("Expected false with firmware %v", md.Firmware())
+	}
+	*md.Firmware() = lifxlan.FirmwareUpgrade{
+		Major: 2,
+		Minor: 77,
+	}
+	if !md.SupportsExtendedColorZones() {
+		t.Errorf("Expected true with firmware %v", md.Firmware())
+	}
+}
+
+func TestGetExtendedColorZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, n := range []int{16, 82, 120} {
+		n := n
+		t.Run(
+			fmt.Sprintf("%d", n),
+			func(t *testing.T) {
+				zones := makeZones(n)
+
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.RawStatePayload = &light.RawStatePayload{}
+				service.Handlers[multizone.GetColorZones] = zonesHandler(t, zones)
+				service.Handlers[multizone.GetExtendedColorZones] = extendedZonesHandler(t, zones)
+
+				md := wrapDevice(t, device)
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				got, err := md.GetExtendedColorZones(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, zones) {
+					t.Errorf("GetExtendedColorZones expected %+v, got %+v", zones, got)
+				}
+			},
+		)
+	}
+}
+
+func TestGetExtendedColorZonesReadBufferSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	expected := lifxlan.HeaderLength + binary.Size(multizone.RawStateExtendedColorZonesPayload{})
+	if multizone.MinReadBufferSize != expected {
+		t.Errorf("MinReadBufferSize expected %d, got %d", expected, multizone.MinReadBufferSize)
+	}
+
+	zones := makeZones(multizone.MaxExtendedColorZones)
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, zones)
+	service.Handlers[multizone.GetExtendedColorZones] = extendedZonesHandler(t, zones)
+
+	md := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// Too small for a StateExtendedColorZones message.
+	ctx = lifxlan.WithReadBufferSize(ctx, 128)
+
+	got, err := md.GetExtendedColorZones(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, zones) {
+		t.Errorf("GetExtendedColorZones expected %+v, got %+v", zones, got)
+	}
+}
+
+func TestSetExtendedColorZones(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(16))
+
+	md := wrapDevice(t, device)
+
+	t.Run(
+		"TooManyColors",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			colors := makeZones(multizone.MaxExtendedColorZones + 1)
+			if err := md.SetExtendedColorZones(
+				ctx,
+				nil,
+				0,
+				colors,
+				0,
+				multizone.Apply,
+				false,
+			); err == nil {
+				t.Error("Expected error for too many colors, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Normal",
+		func(t *testing.T) {
+			colors := makeZones(10)
+
+			var called bool
+			service.Handlers[multizone.SetExtendedColorZones] = func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				called = true
+				var raw multizone.RawSetExtendedColorZonesPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Fatal(err)
+				}
+				if raw.ZoneIndex != 3 {
+					t.Errorf("ZoneIndex expected 3, got %d", raw.ZoneIndex)
+				}
+				if raw.Apply != multizone.Apply {
+					t.Errorf("Apply expected %v, got %v", multizone.Apply, raw.Apply)
+				}
+				if int(raw.ColorsCount) != len(colors) {
+					t.Errorf("ColorsCount expected %d, got %d", len(colors), raw.ColorsCount)
+				}
+				if got := raw.Colors[:raw.ColorsCount]; !reflect.DeepEqual(got, colors) {
+					t.Errorf("Colors expected %+v, got %+v", colors, got)
+				}
+				for i := int(raw.ColorsCount); i < len(raw.Colors); i++ {
+					if raw.Colors[i] != (lifxlan.Color{}) {
+						t.Errorf("Colors[%d] expected zero value, got %+v", i, raw.Colors[i])
+					}
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := md.SetExtendedColorZones(
+				ctx,
+				nil,
+				3,
+				colors,
+				0,
+				multizone.Apply,
+				true,
+			); err != nil {
+				t.Fatal(err)
+			}
+			if !called {
+				t.Error("SetExtendedColorZones message not received.")
+			}
+		},
+	)
+}
+
+func TestExtendedColorZonesUnhandled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 8
+
+	var lock sync.Mutex
+	var received []multizone.RawSetColorZonesPayload
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			multizone.GetColorZones:         zonesHandler(t, makeZones(n)),
+			multizone.GetExtendedColorZones: mock.StateUnhandledHandler(multizone.GetExtendedColorZones),
+			multizone.SetExtendedColorZones: mock.StateUnhandledHandler(multizone.SetExtendedColorZones),
+			multizone.SetColorZones: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw multizone.RawSetColorZonesPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw)
+			},
+		},
+		RawStatePayload: &light.RawStatePayload{},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	newDevice := func(t *testing.T) multizone.Device {
+		t.Helper()
+
+		lock.Lock()
+		received = nil
+		lock.Unlock()
+
+		md := wrapDevice(t, device)
+		// LIFX Z
+		*md.HardwareVersion() = lifxlan.HardwareVersion{
+			VendorID:  1,
+			ProductID: 32,
+		}
+		*md.Firmware() = lifxlan.FirmwareUpgrade{
+			Major: 2,
+			Minor: 77,
+		}
+		if !md.SupportsExtendedColorZones() {
+			t.Fatal("Expected extended multizone support before the fallback")
+		}
+		return md
+	}
+
+	checkFallback := func(t *testing.T, md multizone.Device, count int) {
+		t.Helper()
+
+		if md.SupportsExtendedColorZones() {
+			t.Error("Expected no extended multizone support after the fallback")
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if len(received) != count {
+			t.Fatalf("Expected %d SetColorZones messages, got %d", count, len(received))
+		}
+		for i, raw := range received {
+			apply := multizone.NoApply
+			if i == count-1 {
+				apply = multizone.Apply
+			}
+			if raw.Apply != apply {
+				t.Errorf("#%d: Apply expected %d, got %d", i, apply, raw.Apply)
+			}
+		}
+	}
+
+	t.Run(
+		"SetGradient",
+		func(t *testing.T) {
+			md := newDevice(t)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			from := lifxlan.Color{Saturation: 65535, Kelvin: 3500}
+			to := lifxlan.Color{Hue: 54613, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+			if err := md.SetGradient(ctx, nil, from, to, 0, true); err != nil {
+				t.Fatal(err)
+			}
+			checkFallback(t, md, n)
+		},
+	)
+
+	t.Run(
+		"AdjustZonesBrightness",
+		func(t *testing.T) {
+			md := newDevice(t)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := md.AdjustZonesBrightness(ctx, nil, 2, 4, 10, true); err != nil {
+				t.Fatal(err)
+			}
+			checkFallback(t, md, 3)
+		},
+	)
+
+	t.Run(
+		"GetExtendedColorZones",
+		func(t *testing.T) {
+			md := newDevice(t)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			_, err := md.GetExtendedColorZones(ctx, nil)
+			var unhandled *lifxlan.UnhandledMessageError
+			if !errors.As(err, &unhandled) {
+				t.Fatalf("Expected *lifxlan.UnhandledMessageError, got %v", err)
+			}
+			if unhandled.Type != multizone.GetExtendedColorZones {
+				t.Errorf("Unhandled type expected %d, got %d", multizone.GetExtendedColorZones, unhandled.Type)
+			}
+		},
+	)
+}
@@ -0,0 +1,188 @@
+package multizone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// GradientColors returns n colors linearly interpolated from from to to
+// (both inclusive).
+//
+// The colors are interpolated via lifxlan.Color.Lerp,
+// so for example a red to magenta gradient won't go through green.
+func GradientColors(from, to lifxlan.Color, n int) []lifxlan.Color {
+	if n <= 0 {
+		return nil
+	}
+	colors := make([]lifxlan.Color, n)
+	if n == 1 {
+		colors[0] = from
+		return colors
+	}
+
+	for i := range colors {
+		colors[i] = from.Lerp(to, float64(i)/float64(n-1))
+	}
+	return colors
+}
+
+func (md *device) SetGradient(
+	ctx context.Context,
+	conn net.Conn,
+	from, to lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	count, err := md.ZonesCount(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return errors.New("lifxlan/multizone.SetGradient: no zones found")
+	}
+	return md.SetZoneColors(
+		ctx,
+		conn,
+		GradientColors(from, to, count),
+		transition,
+		ack,
+	)
+}
+
+func (md *device) SetZoneColors(
+	ctx context.Context,
+	conn net.Conn,
+	colors []lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if len(colors) == 0 {
+		return nil
+	}
+	if len(colors) > math.MaxUint8+1 && !md.SupportsExtendedColorZones() {
+		return fmt.Errorf(
+			"lifxlan/multizone.SetZoneColors: too many colors: %d",
+			len(colors),
+		)
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return md.setZoneColorsAt(ctx, conn, 0, colors, transition, ack)
+}
+
+// setZoneColorsAt sets the colors of the zones starting from index,
+// one color per zone,
+// the same way as SetZoneColors.
+//
+// conn must not be nil.
+func (md *device) setZoneColorsAt(
+	ctx context.Context,
+	conn net.Conn,
+	index int,
+	colors []lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if md.SupportsExtendedColorZones() {
+		err := md.setExtendedZoneColorsAt(ctx, conn, index, colors, transition, ack)
+		if !md.markExtendedUnhandled(err) {
+			return err
+		}
+		// The device doesn't support extended multizone messages after all,
+		// fall back to the legacy ones below.
+		if index+len(colors) > math.MaxUint8+1 {
+			return err
+		}
+	}
+
+	// Buffer all the zones and only apply them with the last one.
+	for i, c := range colors {
+		apply := NoApply
+		if i == len(colors)-1 {
+			apply = Apply
+		}
+		if err := md.SetColorZones(
+			ctx,
+			conn,
+			uint8(index+i),
+			uint8(index+i),
+			c,
+			transition,
+			apply,
+			ack,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setExtendedZoneColorsAt is the extended multizone part of setZoneColorsAt.
+func (md *device) setExtendedZoneColorsAt(
+	ctx context.Context,
+	conn net.Conn,
+	index int,
+	colors []lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	for i := 0; i < len(colors); i += MaxExtendedColorZones {
+		end := i + MaxExtendedColorZones
+		apply := NoApply
+		if end >= len(colors) {
+			end = len(colors)
+			apply = Apply
+		}
+		if err := md.SetExtendedColorZones(
+			ctx,
+			conn,
+			uint16(index+i),
+			colors[i:end],
+			transition,
+			apply,
+			ack,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
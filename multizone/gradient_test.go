@@ -0,0 +1,177 @@
+package multizone_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+func TestGradientColors(t *testing.T) {
+	red := lifxlan.Color{
+		Hue:        0,
+		Saturation: 65535,
+		Brightness: 0,
+		Kelvin:     3500,
+	}
+	magenta := lifxlan.Color{
+		Hue:        54613, // 300 degrees
+		Saturation: 65535,
+		Brightness: 65535,
+		Kelvin:     3500,
+	}
+
+	t.Run(
+		"RedToMagenta",
+		func(t *testing.T) {
+			// Hue should go backwards through 0/65535 instead of through green.
+			expected := []lifxlan.Color{
+				{Hue: 0, Saturation: 65535, Brightness: 0, Kelvin: 3500},
+				{Hue: 63976, Saturation: 65535, Brightness: 9362, Kelvin: 3500},
+				{Hue: 62415, Saturation: 65535, Brightness: 18724, Kelvin: 3500},
+				{Hue: 60855, Saturation: 65535, Brightness: 28086, Kelvin: 3500},
+				{Hue: 59294, Saturation: 65535, Brightness: 37449, Kelvin: 3500},
+				{Hue: 57734, Saturation: 65535, Brightness: 46811, Kelvin: 3500},
+				{Hue: 56173, Saturation: 65535, Brightness: 56173, Kelvin: 3500},
+				{Hue: 54613, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+			}
+			actual := multizone.GradientColors(red, magenta, 8)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("GradientColors expected %v, got %v", expected, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"MagentaToRed",
+		func(t *testing.T) {
+			actual := multizone.GradientColors(magenta, red, 8)
+			forward := multizone.GradientColors(red, magenta, 8)
+			for i := range actual {
+				if actual[i] != forward[len(forward)-1-i] {
+					t.Errorf(
+						"GradientColors[%d] expected %v, got %v",
+						i,
+						forward[len(forward)-1-i],
+						actual[i],
+					)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Kelvin",
+		func(t *testing.T) {
+			expected := []lifxlan.Color{
+				{Kelvin: 2500},
+				{Kelvin: 3500},
+				{Kelvin: 4500},
+				{Kelvin: 5500},
+			}
+			actual := multizone.GradientColors(
+				lifxlan.Color{Kelvin: 2500},
+				lifxlan.Color{Kelvin: 5500},
+				4,
+			)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("GradientColors expected %v, got %v", expected, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"Single",
+		func(t *testing.T) {
+			expected := []lifxlan.Color{red}
+			actual := multizone.GradientColors(red, magenta, 1)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("GradientColors expected %v, got %v", expected, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"Empty",
+		func(t *testing.T) {
+			if actual := multizone.GradientColors(red, magenta, 0); len(actual) != 0 {
+				t.Errorf("GradientColors expected empty, got %v", actual)
+			}
+		},
+	)
+}
+
+func TestSetGradient(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 8
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(n))
+
+	md := wrapDevice(t, device)
+
+	from := lifxlan.Color{Saturation: 65535, Kelvin: 3500}
+	to := lifxlan.Color{Hue: 54613, Saturation: 65535, Brightness: 65535, Kelvin: 3500}
+
+	var lock sync.Mutex
+	var received []multizone.RawSetColorZonesPayload
+	service.Handlers[multizone.SetColorZones] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw multizone.RawSetColorZonesPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Fatal(err)
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, raw)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := md.SetGradient(ctx, nil, from, to, time.Second, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	expected := multizone.GradientColors(from, to, n)
+	if len(received) != n {
+		t.Fatalf("Expected %d SetColorZones messages, got %d", n, len(received))
+	}
+	for i, raw := range received {
+		apply := multizone.NoApply
+		if i == n-1 {
+			apply = multizone.Apply
+		}
+		if raw.StartIndex != uint8(i) || raw.EndIndex != uint8(i) {
+			t.Errorf("#%d: Expected zone range [%d, %d], got [%d, %d]", i, i, i, raw.StartIndex, raw.EndIndex)
+		}
+		if raw.Color != expected[i] {
+			t.Errorf("#%d: Color expected %v, got %v", i, expected[i], raw.Color)
+		}
+		if raw.Apply != apply {
+			t.Errorf("#%d: Apply expected %d, got %d", i, apply, raw.Apply)
+		}
+	}
+}
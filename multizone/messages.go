@@ -0,0 +1,42 @@
+package multizone
+
+import (
+	"encoding/binary"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Multizone related MessageType values.
+const (
+	SetColorZones  lifxlan.MessageType = 501
+	GetColorZones  lifxlan.MessageType = 502
+	StateZone      lifxlan.MessageType = 503
+	StateMultiZone lifxlan.MessageType = 506
+
+	GetMultiZoneEffect   lifxlan.MessageType = 507
+	SetMultiZoneEffect   lifxlan.MessageType = 508
+	StateMultiZoneEffect lifxlan.MessageType = 509
+
+	SetExtendedColorZones   lifxlan.MessageType = 510
+	GetExtendedColorZones   lifxlan.MessageType = 511
+	StateExtendedColorZones lifxlan.MessageType = 512
+)
+
+func init() {
+	for msg, size := range map[lifxlan.MessageType]lifxlan.PayloadSize{
+		SetColorZones:         {Name: "SetColorZones", Size: binary.Size(RawSetColorZonesPayload{})},
+		GetColorZones:         {Name: "GetColorZones", Size: binary.Size(RawGetColorZonesPayload{})},
+		GetMultiZoneEffect:    {Name: "GetMultiZoneEffect", Size: 0},
+		SetMultiZoneEffect:    {Name: "SetMultiZoneEffect", Size: binary.Size(RawMultiZoneEffectSettings{})},
+		GetExtendedColorZones: {Name: "GetExtendedColorZones", Size: 0},
+	} {
+		lifxlan.PayloadSizes[msg] = size
+	}
+	for msg, resp := range map[lifxlan.MessageType]lifxlan.MessageType{
+		GetColorZones:         StateMultiZone,
+		GetMultiZoneEffect:    StateMultiZoneEffect,
+		GetExtendedColorZones: StateExtendedColorZones,
+	} {
+		lifxlan.ExpectedResponses[msg] = resp
+	}
+}
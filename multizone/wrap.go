@@ -0,0 +1,159 @@
+package multizone
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// Wrap tries to wrap a lifxlan.Device into a multizone device.
+//
+// When force is false and d is already a multizone device,
+// d will be casted and returned directly.
+// Otherwise, this function calls a multizone device API,
+// and only returns a non-nil Device if it supports the API.
+//
+// If the device is not a multizone device,
+// the function might block until ctx is cancelled.
+//
+// When returning a valid multizone device,
+// the device's Label and number of zones are guaranteed to be cached.
+func Wrap(ctx context.Context, d lifxlan.Device, force bool) (Device, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if !force {
+		if t, ok := d.(Device); ok {
+			return t, nil
+		}
+	}
+
+	ld, err := light.Wrap(ctx, d, force)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	count, err := queryZonesCount(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("lifxlan/multizone.Wrap: no zones found")
+	}
+	return &device{
+		Device:     ld,
+		zonesCount: count,
+	}, nil
+}
+
+// queryZonesCount sends a GetColorZones message for the first zone only,
+// and returns the zones count from the response.
+func queryZonesCount(ctx context.Context, conn net.Conn, d lifxlan.Device) (int, error) {
+	const msg = GetColorZones
+
+	// Only ask for the first zone, which will be answered with a single
+	// StateZone message.
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		msg,
+		&RawGetColorZonesPayload{
+			StartIndex: 0,
+			EndIndex:   0,
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, d.Source())
+	defer cancel()
+
+	for {
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
+		if err != nil {
+			return 0, err
+		}
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, d.Source()) {
+				continue
+			}
+
+			r := bytes.NewReader(resp.Payload)
+			switch resp.Message {
+			default:
+				continue
+
+			case StateZone:
+				var raw RawStateZonePayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return 0, err
+				}
+				return int(raw.ZonesCount), nil
+
+			case StateMultiZone:
+				var raw RawStateMultiZonePayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return 0, err
+				}
+				return int(raw.ZonesCount), nil
+
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return 0, err
+				}
+				return 0, &lifxlan.UnhandledMessageError{
+					Type: raw.UnhandledType,
+				}
+			}
+		}
+	}
+}
+
+func (md *device) ZonesCount(ctx context.Context, conn net.Conn) (int, error) {
+	if md.zonesCount > 0 {
+		return md.zonesCount, nil
+	}
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := md.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	count, err := queryZonesCount(ctx, conn, md)
+	if err != nil {
+		return 0, err
+	}
+	md.zonesCount = count
+	return count, nil
+}
@@ -0,0 +1,138 @@
+package multizone_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+)
+
+func wrapDevice(t *testing.T, device lifxlan.Device) multizone.Device {
+	t.Helper()
+
+	const timeout = time.Millisecond * 200
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	md, err := multizone.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return md
+}
+
+func TestWrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	var label lifxlan.Label
+	label.Set("foo")
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{
+		Label: label,
+	}
+
+	t.Run(
+		"Normal",
+		func(t *testing.T) {
+			service.Handlers[multizone.GetColorZones] = zonesHandler(t, makeZones(16))
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			md, err := multizone.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatalf("Expected successful wrapping, got: %v", err)
+			}
+			if md.Label().String() != label.String() {
+				t.Errorf("Label expected %v, got %v", label, md.Label())
+			}
+		},
+	)
+
+	t.Run(
+		"StateUnhandled",
+		func(t *testing.T) {
+			const msg = multizone.GetColorZones
+
+			service.Handlers[msg] = mock.StateUnhandledHandler(msg)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := multizone.Wrap(ctx, device, false); err == nil {
+				t.Error("Expected Wrap to return error, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+}
+
+func TestZonesCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, n := range []int{1, 8, 16, 82} {
+		n := n
+		t.Run(
+			fmt.Sprintf("%d", n),
+			func(t *testing.T) {
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.RawStatePayload = &light.RawStatePayload{}
+
+				var lock sync.Mutex
+				var queries int
+				handler := zonesHandler(t, makeZones(n))
+				service.Handlers[multizone.GetColorZones] = func(
+					s *mock.Service,
+					conn net.PacketConn,
+					addr net.Addr,
+					orig *lifxlan.Response,
+				) {
+					lock.Lock()
+					queries++
+					lock.Unlock()
+					handler(s, conn, addr, orig)
+				}
+
+				md := wrapDevice(t, device)
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				for i := 0; i < 3; i++ {
+					count, err := md.ZonesCount(ctx, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if count != n {
+						t.Errorf("ZonesCount expected %d, got %d", n, count)
+					}
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if queries != 1 {
+					t.Errorf("Expected 1 GetColorZones message from Wrap only, got %d", queries)
+				}
+			},
+		)
+	}
+}
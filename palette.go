@@ -0,0 +1,109 @@
+package lifxlan
+
+import (
+	"encoding"
+	"fmt"
+)
+
+// MaxPaletteColors is the max number of colors in a Palette.
+const MaxPaletteColors = 16
+
+// PaletteLength is the size of Palette in messages.
+const PaletteLength = 1 + MaxPaletteColors*colorLength
+
+// Palette is the list of colors used by firmware effects
+// (e.g. the morph and flame tile effects).
+//
+// In messages it's encoded as a fixed size layout of the count of the colors,
+// followed by MaxPaletteColors colors,
+// with the unused slots zeroed.
+//
+// A Palette with more than MaxPaletteColors colors is invalid.
+type Palette []Color
+
+var (
+	_ encoding.BinaryMarshaler   = Palette(nil)
+	_ encoding.BinaryUnmarshaler = (*Palette)(nil)
+)
+
+// Add appends color to p.
+//
+// It returns an error and leaves p unchanged if p is already full.
+func (p *Palette) Add(color Color) error {
+	if len(*p) >= MaxPaletteColors {
+		return fmt.Errorf(
+			"lifxlan.Palette.Add: palette is full with %d colors",
+			len(*p),
+		)
+	}
+	*p = append(*p, color)
+	return nil
+}
+
+// Validate returns an error if p has more than MaxPaletteColors colors.
+func (p Palette) Validate() error {
+	if len(p) > MaxPaletteColors {
+		return fmt.Errorf(
+			"lifxlan.Palette: too many colors: %d > %d",
+			len(p),
+			MaxPaletteColors,
+		)
+	}
+	return nil
+}
+
+// Array returns the fixed size layout of p used by the raw payload structs.
+//
+// It's only meaningful when p is valid,
+// colors beyond MaxPaletteColors are dropped.
+func (p Palette) Array() (count uint8, colors [MaxPaletteColors]Color) {
+	n := copy(colors[:], p)
+	return uint8(n), colors
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// It encodes p into PaletteLength bytes in the little endian layout used in
+// messages: the count of the colors in 1 byte,
+// then MaxPaletteColors colors with the unused slots zeroed.
+//
+// It returns an error if p is not valid.
+func (p Palette) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("lifxlan.Palette.MarshalBinary: %w", err)
+	}
+	data := make([]byte, PaletteLength)
+	data[0] = uint8(len(p))
+	for i, c := range p {
+		c.put(data[1+i*colorLength:])
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// It's the reverse of MarshalBinary.
+// Extra bytes after the palette are ignored.
+//
+// It returns an error and leaves p unchanged if the count is larger than
+// MaxPaletteColors.
+func (p *Palette) UnmarshalBinary(data []byte) error {
+	if err := CheckPayloadSize("lifxlan.Palette.UnmarshalBinary", data, PaletteLength); err != nil {
+		return err
+	}
+	count := int(data[0])
+	if count > MaxPaletteColors {
+		return fmt.Errorf(
+			"lifxlan.Palette.UnmarshalBinary: too many colors: %d > %d",
+			count,
+			MaxPaletteColors,
+		)
+	}
+	palette := make(Palette, count)
+	for i := range palette {
+		// It can't fail as the size is already checked.
+		palette[i].UnmarshalBinary(data[1+i*colorLength:])
+	}
+	*p = palette
+	return nil
+}
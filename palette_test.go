@@ -0,0 +1,92 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func TestPaletteMarshalBinary(t *testing.T) {
+	var palette lifxlan.Palette
+	for _, c := range []lifxlan.Color{
+		{Hue: 0, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+		{Hue: 21845, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+		{Hue: 43690, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+	} {
+		if err := palette.Add(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := palette.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != lifxlan.PaletteLength {
+		t.Fatalf("Expected %d bytes, got %d", lifxlan.PaletteLength, len(data))
+	}
+
+	var raw struct {
+		Count  uint8
+		Colors [lifxlan.MaxPaletteColors]lifxlan.Color
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Count != 3 {
+		t.Errorf("Count expected 3, got %d", raw.Count)
+	}
+	for i, c := range raw.Colors {
+		expected := lifxlan.Color{}
+		if i < len(palette) {
+			expected = palette[i]
+		}
+		if c != expected {
+			t.Errorf("Colors[%d] expected %+v, got %+v", i, expected, c)
+		}
+	}
+
+	count, colors := palette.Array()
+	if count != raw.Count || colors != raw.Colors {
+		t.Errorf("Array expected %d, %+v, got %d, %+v", raw.Count, raw.Colors, count, colors)
+	}
+
+	var decoded lifxlan.Palette
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, palette) {
+		t.Errorf("UnmarshalBinary expected %+v, got %+v", palette, decoded)
+	}
+}
+
+func TestPaletteTooManyColors(t *testing.T) {
+	palette := make(lifxlan.Palette, lifxlan.MaxPaletteColors)
+	if err := palette.Validate(); err != nil {
+		t.Errorf("Validate expected nil error for a full palette, got %v", err)
+	}
+	if err := palette.Add(lifxlan.Color{}); err == nil {
+		t.Error("Add expected error for a full palette, got nil")
+	}
+	if len(palette) != lifxlan.MaxPaletteColors {
+		t.Errorf("Add expected to leave the palette unchanged, got %d colors", len(palette))
+	}
+
+	palette = append(palette, lifxlan.Color{})
+	if err := palette.Validate(); err == nil {
+		t.Error("Validate expected error for too many colors, got nil")
+	}
+	if data, err := palette.MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary expected error for too many colors, got %x", data)
+	}
+
+	data := make([]byte, lifxlan.PaletteLength)
+	data[0] = lifxlan.MaxPaletteColors + 1
+	var decoded lifxlan.Palette
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		t.Errorf("UnmarshalBinary expected error for too many colors, got %+v", decoded)
+	}
+}
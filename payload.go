@@ -0,0 +1,119 @@
+package lifxlan
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+)
+
+// encodePayload encodes payload into bytes to be used in messages.
+//
+// If payload implements encoding.BinaryMarshaler (e.g. RawSetPowerPayload),
+// its MarshalBinary will be used.
+// Otherwise it's encoded via binary.Write in little endian.
+func encodePayload(payload interface{}) ([]byte, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	if m, ok := payload.(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CheckPayloadSize returns an error prefixed by caller if data is shorter than
+// size.
+//
+// It's useful when implementing encoding.BinaryUnmarshaler for payloads,
+// e.g. in the subpackages.
+func CheckPayloadSize(caller string, data []byte, size int) error {
+	if len(data) < size {
+		return fmt.Errorf(
+			"%s: payload size not enough: %d < %d",
+			caller,
+			len(data),
+			size,
+		)
+	}
+	return nil
+}
+
+// ValidatePayloadSize controls whether Device.Send, Pipeline.Add and
+// SendWithRetry validate the payload sizes of the messages listed in
+// PayloadSizes before sending them.
+//
+// Devices silently ignore messages with wrong payload sizes,
+// so without the validation the API calls would just time out waiting for the
+// responses.
+// With the validation they fail immediately with a descriptive error instead.
+//
+// It's disabled by default.
+var ValidatePayloadSize bool
+
+// PayloadSize defines the expected payload size of a message type.
+type PayloadSize struct {
+	// The name of the message type, used in the error messages.
+	Name string
+
+	// The expected size of the payload, in bytes.
+	Size int
+}
+
+// PayloadSizes maps the fixed payload size message types to their expected
+// payload sizes,
+// to be used when ValidatePayloadSize is true.
+//
+// Message types not in the map are not validated.
+// The subpackages add their message types into the map in their init
+// functions,
+// you could also add other message types to the map by yourself, e.g.:
+//
+//     func init() {
+//         lifxlan.PayloadSizes[newMessageType] = lifxlan.PayloadSize{
+//             Name: "NewMessageType",
+//             Size: 42,
+//         }
+//     }
+var PayloadSizes = map[MessageType]PayloadSize{
+	GetService:      {Name: "GetService", Size: 0},
+	GetHostInfo:     {Name: "GetHostInfo", Size: 0},
+	GetHostFirmware: {Name: "GetHostFirmware", Size: 0},
+	GetWifiInfo:     {Name: "GetWifiInfo", Size: 0},
+	GetPower:        {Name: "GetPower", Size: 0},
+	SetPower:        {Name: "SetPower", Size: binary.Size(RawSetPowerPayload{})},
+	GetLabel:        {Name: "GetLabel", Size: 0},
+	SetLabel:        {Name: "SetLabel", Size: binary.Size(RawSetLabelPayload{})},
+	GetVersion:      {Name: "GetVersion", Size: 0},
+	GetInfo:         {Name: "GetInfo", Size: 0},
+	SetReboot:       {Name: "SetReboot", Size: 0},
+	GetLocation:     {Name: "GetLocation", Size: 0},
+	SetLocation:     {Name: "SetLocation", Size: binary.Size(RawSetLocationPayload{})},
+	GetGroup:        {Name: "GetGroup", Size: 0},
+	SetGroup:        {Name: "SetGroup", Size: binary.Size(RawSetGroupPayload{})},
+	EchoRequest:     {Name: "EchoRequest", Size: binary.Size(RawEchoRequestPayload{})},
+}
+
+// validatePayloadSize returns an error prefixed by caller if
+// ValidatePayloadSize is true and the size of data doesn't match the
+// PayloadSizes entry of message.
+func validatePayloadSize(caller string, message MessageType, data []byte) error {
+	if !ValidatePayloadSize {
+		return nil
+	}
+	expected, ok := PayloadSizes[message]
+	if !ok || len(data) == expected.Size {
+		return nil
+	}
+	return fmt.Errorf(
+		"%s: %s expects %d-byte payload, got %d",
+		caller,
+		expected.Name,
+		expected.Size,
+		len(data),
+	)
+}
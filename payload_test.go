@@ -0,0 +1,269 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+type binaryPayload interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+func TestPayloadBinary(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		payload  binaryPayload
+		empty    binaryPayload
+		expected []byte
+	}{
+		{
+			label: "Color",
+			payload: &lifxlan.Color{
+				Hue:        0x5555,
+				Saturation: 0xffff,
+				Brightness: 0x8000,
+				Kelvin:     3500,
+			},
+			empty: &lifxlan.Color{},
+			expected: []byte{
+				0x55, 0x55, // Hue
+				0xff, 0xff, // Saturation
+				0x00, 0x80, // Brightness
+				0xac, 0x0d, // Kelvin
+			},
+		},
+		{
+			label: "RawSetPowerPayload",
+			payload: &lifxlan.RawSetPowerPayload{
+				Level: lifxlan.PowerOn,
+			},
+			empty:    &lifxlan.RawSetPowerPayload{},
+			expected: []byte{0xff, 0xff},
+		},
+		{
+			label: "RawStatePowerPayload",
+			payload: &lifxlan.RawStatePowerPayload{
+				Level: 0x1234,
+			},
+			empty:    &lifxlan.RawStatePowerPayload{},
+			expected: []byte{0x34, 0x12},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				data, err := c.payload.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, c.expected) {
+					t.Errorf("MarshalBinary expected %v, got %v", c.expected, data)
+				}
+
+				// It should match the binary.Write encoding.
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, c.payload); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(data, buf.Bytes()) {
+					t.Errorf("MarshalBinary expected to match binary.Write %v, got %v", buf.Bytes(), data)
+				}
+
+				if err := c.empty.UnmarshalBinary(data); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(c.empty, c.payload) {
+					t.Errorf("UnmarshalBinary expected %+v, got %+v", c.payload, c.empty)
+				}
+
+				if err := c.empty.UnmarshalBinary(data[:len(data)-1]); err == nil {
+					t.Error("Expected UnmarshalBinary error with short data")
+				}
+			},
+		)
+	}
+}
+
+// customPayload is a payload with a custom MarshalBinary
+// that doesn't match its binary.Write encoding.
+type customPayload struct {
+	Value uint8
+}
+
+func (p customPayload) MarshalBinary() ([]byte, error) {
+	return []byte{p.Value, p.Value}, nil
+}
+
+type failingPayload struct{}
+
+func (failingPayload) MarshalBinary() ([]byte, error) {
+	return nil, errors.New("failed")
+}
+
+func TestSendBinaryMarshaler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 200
+		message = lifxlan.MessageType(1000)
+	)
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	received := make(chan []byte, 1)
+	service.Handlers[message] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		received <- orig.Payload
+	}
+
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := device.Send(ctx, conn, 0, message, failingPayload{}); err == nil {
+		t.Error("Expected error from MarshalBinary")
+	}
+
+	if _, err := device.Send(ctx, conn, 0, message, &customPayload{Value: 42}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	case payload := <-received:
+		if expected := []byte{42, 42}; !bytes.Equal(payload, expected) {
+			t.Errorf("Payload expected %v, got %v", expected, payload)
+		}
+	}
+}
+
+func TestValidatePayloadSize(t *testing.T) {
+	defer func(orig bool) {
+		lifxlan.ValidatePayloadSize = orig
+	}(lifxlan.ValidatePayloadSize)
+
+	// SetColor expects RawSetColorPayload, not a bare color.
+	color := &lifxlan.Color{Kelvin: 3500}
+
+	device := lifxlan.NewDevice("127.0.0.1:56700", lifxlan.ServiceUDP, lifxlan.AllDevices)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	t.Run(
+		"Disabled",
+		func(t *testing.T) {
+			lifxlan.ValidatePayloadSize = false
+			if _, err := device.Send(ctx, client, 0, light.SetColor, color); err != nil {
+				t.Errorf("Expected nil error with validation disabled, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Mismatch",
+		func(t *testing.T) {
+			lifxlan.ValidatePayloadSize = true
+			const expected = "SetColor expects 13-byte payload, got 8"
+
+			_, err := device.Send(ctx, client, 0, light.SetColor, color)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Send expected error %q, got %v", expected, err)
+			}
+
+			p := lifxlan.NewPipeline(device)
+			_, err = p.Add(0, light.SetColor, color)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Pipeline.Add expected error %q, got %v", expected, err)
+			}
+			if p.Len() != 0 {
+				t.Errorf("Expected nothing queued, got Len %d", p.Len())
+			}
+
+			data, err := color.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = lifxlan.SendWithRetry(ctx, client, device, 0, light.SetColor, data, lifxlan.RetryOptions{})
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("SendWithRetry expected error %q, got %v", expected, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Match",
+		func(t *testing.T) {
+			lifxlan.ValidatePayloadSize = true
+			if _, err := device.Send(ctx, client, 0, light.SetColor, &light.RawSetColorPayload{
+				Color: *color,
+			}); err != nil {
+				t.Errorf("Expected nil error with correct payload, got %v", err)
+			}
+			if _, err := device.Send(ctx, client, 0, lifxlan.GetPower, nil); err != nil {
+				t.Errorf("Expected nil error with empty payload, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Unlisted",
+		func(t *testing.T) {
+			lifxlan.ValidatePayloadSize = true
+			// Variable sized messages are not validated.
+			if _, err := device.Send(ctx, client, 0, lifxlan.MessageType(0xffff), color); err != nil {
+				t.Errorf("Expected nil error for unlisted message type, got %v", err)
+			}
+		},
+	)
+}
+
+func TestCheckPayloadSize(t *testing.T) {
+	const caller = "caller"
+	data := make([]byte, 4)
+	if err := lifxlan.CheckPayloadSize(caller, data, 4); err != nil {
+		t.Errorf("Expected nil error for exact size, got %v", err)
+	}
+	if err := lifxlan.CheckPayloadSize(caller, data, 2); err != nil {
+		t.Errorf("Expected nil error for longer data, got %v", err)
+	}
+	err := lifxlan.CheckPayloadSize(caller, data, 6)
+	if err == nil || !strings.HasPrefix(err.Error(), caller+": ") {
+		t.Errorf("Expected error prefixed by %q, got %v", caller, err)
+	}
+}
@@ -0,0 +1,165 @@
+package lifxlan
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// Pipeline queues multiple messages to a device,
+// so they can be sent together and their acks can be waited together,
+// instead of waiting for the ack of each message before sending the next one.
+//
+// A Pipeline is not safe for concurrent use.
+//
+// Example:
+//
+//     p := lifxlan.NewPipeline(device)
+//     if _, err := p.Add(lifxlan.FlagAckRequired, lifxlan.SetPower, &lifxlan.RawSetPowerPayload{
+//       Level: lifxlan.PowerOn,
+//     }); err != nil {
+//       // handle error
+//     }
+//     // Add more messages
+//     if err := p.Flush(ctx, conn); err != nil {
+//       // handle error
+//     }
+//     if err := p.Wait(ctx, conn); err != nil {
+//       // handle error
+//     }
+//
+// To control multiple devices together,
+// use one Pipeline per device,
+// Flush all of them first then Wait for all of them.
+type Pipeline struct {
+	dev Device
+
+	queued []pipelineMessage
+	// The sequences written by Flush but not acked yet.
+	pending []uint8
+}
+
+type pipelineMessage struct {
+	message MessageType
+	flags   AckResFlag
+	seq     uint8
+	data    []byte
+}
+
+// NewPipeline creates a new Pipeline for dev.
+func NewPipeline(dev Device) *Pipeline {
+	return &Pipeline{
+		dev: dev,
+	}
+}
+
+// Add encodes and queues a message,
+// and returns the sequence assigned to it.
+//
+// payload will be encoded the same way as Device.Send.
+// The message won't be sent until Flush is called.
+func (p *Pipeline) Add(flags AckResFlag, message MessageType, payload interface{}) (seq uint8, err error) {
+	var data []byte
+	data, err = encodePayload(payload)
+	if err != nil {
+		return
+	}
+	if err = validatePayloadSize("lifxlan.Pipeline.Add", message, data); err != nil {
+		return
+	}
+	seq = p.dev.NextSequence()
+	data, err = GenerateMessage(
+		TaggedFor(p.dev.Target()),
+		p.dev.Source(),
+		p.dev.Target(),
+		flags,
+		seq,
+		message,
+		data,
+	)
+	if err != nil {
+		return
+	}
+	p.queued = append(p.queued, pipelineMessage{
+		message: message,
+		flags:   flags,
+		seq:     seq,
+		data:    data,
+	})
+	return
+}
+
+// Len returns the number of queued messages not flushed yet.
+func (p *Pipeline) Len() int {
+	return len(p.queued)
+}
+
+// Flush writes all the queued messages to conn in order,
+// respecting the RateLimiter if conn is a *RateLimitedConn.
+//
+// conn must be pre-dialed or this function will fail.
+//
+// The sequences of the written messages with FlagAckRequired will be waited by
+// the next Wait call.
+// On error, the messages not written yet stay queued,
+// so Flush can be called again to retry.
+func (p *Pipeline) Flush(ctx context.Context, conn net.Conn) error {
+	for len(p.queued) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := waitForRateLimit(ctx, conn, p.dev.Source()); err != nil {
+			return err
+		}
+
+		msg := p.queued[0]
+		n, err := conn.Write(msg.data)
+		if err != nil {
+			return err
+		}
+		recordSent(p.dev.Source(), msg.seq)
+		if m := MetricsRecorder; m != nil {
+			m.IncSend(msg.message)
+		}
+		debugf(
+			"sent %v to %v: source=%d sequence=%d flags=%d",
+			msg.message,
+			p.dev.Target(),
+			p.dev.Source(),
+			msg.seq,
+			msg.flags,
+		)
+		if n < len(msg.data) {
+			return fmt.Errorf(
+				"lifxlan.Pipeline.Flush: only wrote %d out of %d bytes",
+				n,
+				len(msg.data),
+			)
+		}
+
+		p.queued = p.queued[1:]
+		if msg.flags&FlagAckRequired != 0 {
+			p.pending = append(p.pending, msg.seq)
+		}
+	}
+	p.queued = nil
+	return nil
+}
+
+// Wait waits for the acks of all the flushed messages with FlagAckRequired
+// at once via WaitForAcks.
+//
+// It returns nil error immediately if there are no acks to wait for.
+// The pending sequences are cleared upon returning,
+// regardless of the result.
+//
+// If this function returns an error,
+// the error would be of type *WaitForAcksError,
+// with the sequences never acked in its Missing field.
+func (p *Pipeline) Wait(ctx context.Context, conn net.Conn) error {
+	pending := p.pending
+	p.pending = nil
+	// The messages were generated with the source of the device.
+	ctx = WithSource(ctx, 0)
+	return WaitForAcks(ctx, conn, p.dev.Source(), pending...)
+}
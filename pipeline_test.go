@@ -0,0 +1,165 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	addAll := func(t *testing.T, p *lifxlan.Pipeline) {
+		t.Helper()
+		for _, flags := range []lifxlan.AckResFlag{
+			lifxlan.FlagAckRequired,
+			0,
+			lifxlan.FlagAckRequired,
+			lifxlan.FlagAckRequired,
+		} {
+			if _, err := p.Add(flags, lifxlan.SetPower, &lifxlan.RawSetPowerPayload{
+				Level: lifxlan.PowerOn,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p.Len() != 4 {
+			t.Errorf("Len expected 4, got %d", p.Len())
+		}
+	}
+
+	t.Run(
+		"Acks",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			p := lifxlan.NewPipeline(device)
+			addAll(t, p)
+			if err := p.Flush(ctx, conn); err != nil {
+				t.Fatal(err)
+			}
+			if p.Len() != 0 {
+				t.Errorf("Len expected 0 after Flush, got %d", p.Len())
+			}
+			if err := p.Wait(ctx, conn); err != nil {
+				t.Fatal(err)
+			}
+			// Nothing to wait for anymore.
+			if err := p.Wait(ctx, conn); err != nil {
+				t.Errorf("Expected nil error without pending acks, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"NotEnoughAcks",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			// 4 messages will be acked by the mock service, drop 2 of them so only
+			// 2 of the 3 ack-required ones will be received.
+			service.AcksToDrop = 2
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			p := lifxlan.NewPipeline(device)
+			addAll(t, p)
+			if err := p.Flush(ctx, conn); err != nil {
+				t.Fatal(err)
+			}
+			err = p.Wait(ctx, conn)
+			var e *lifxlan.WaitForAcksError
+			if !errors.As(err, &e) {
+				t.Fatalf("Expected *WaitForAcksError, got %v", err)
+			}
+			if len(e.Total) != 3 {
+				t.Errorf("Expected to wait for 3 acks, got %v", e.Total)
+			}
+		},
+	)
+
+	t.Run(
+		"RateLimit",
+		func(t *testing.T) {
+			const rate = 20
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			rlc := lifxlan.NewRateLimitedConn(conn, lifxlan.NewRateLimiter(rate, 1))
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout*2)
+			defer cancel()
+
+			p := lifxlan.NewPipeline(device)
+			addAll(t, p)
+			start := time.Now()
+			if err := p.Flush(ctx, rlc); err != nil {
+				t.Fatal(err)
+			}
+			// The first message uses the burst token.
+			if elapsed, expected := time.Since(start), time.Second*3/rate; elapsed < expected {
+				t.Errorf("Flush expected to take at least %v, took %v", expected, elapsed)
+			}
+			if err := p.Wait(ctx, rlc); err != nil {
+				t.Fatal(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			p := lifxlan.NewPipeline(device)
+			addAll(t, p)
+			if err := p.Flush(ctx, conn); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+			if p.Len() != 4 {
+				t.Errorf("Expected messages to stay queued, got Len %d", p.Len())
+			}
+		},
+	)
+}
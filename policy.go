@@ -0,0 +1,157 @@
+package lifxlan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Policy defines the default reliability settings of the API calls on a
+// device,
+// so they can be tuned in one place instead of on every call.
+//
+// The zero value of every field means "use the global default",
+// the same as when no Policy is set.
+//
+// The settings can still be overridden per call:
+// an explicit deadline on ctx takes precedence over Timeout,
+// WithReadTimeout takes precedence over ReadTimeout,
+// the non-zero fields of the RetryOptions passed to SendWithRetry take
+// precedence over Retry,
+// and a *RateLimitedConn takes precedence over RateLimiter.
+type Policy struct {
+	// The default timeout of the API calls on the device,
+	// applied when ctx doesn't have a deadline (see Device.SetTimeout).
+	//
+	// If it's <= 0, no default timeout is applied.
+	Timeout time.Duration
+
+	// The read timeout of a single read on the connection
+	// (see WithReadTimeout).
+	//
+	// If it's <= 0, UDPReadTimeout will be used.
+	ReadTimeout time.Duration
+
+	// The retry options used by SendWithRetry for the fields not set in the
+	// RetryOptions passed in.
+	Retry RetryOptions
+
+	// If non-nil,
+	// Device.Send and SendWithRetry call RateLimiter.Wait before writing every
+	// message to a conn that's not a *RateLimitedConn.
+	//
+	// As a RateLimiter should usually only be used for a single device,
+	// don't share the same Policy with a non-nil RateLimiter among devices.
+	RateLimiter *RateLimiter
+}
+
+// DefaultPolicy returns the Policy used by devices without a Policy set,
+// which matches the behavior before Policy was introduced.
+func DefaultPolicy() Policy {
+	return Policy{
+		Retry: RetryOptions{
+			MaxAttempts:    DefaultRetryMaxAttempts,
+			InitialBackoff: DefaultRetryInitialBackoff,
+		},
+	}
+}
+
+// sharedPolicy is the Policy shared by all the devices with the same source.
+type sharedPolicy struct {
+	policy Policy
+	// The number of devices with a Policy set.
+	refs int
+}
+
+// devicePolicies maps sources to the policies set via Device.SetPolicy and
+// Device.SetTimeout.
+//
+// WaitForAcks and WaitForResponses only know the source,
+// so this is how they find the policy of the device.
+// An entry is removed once the last device holding it goes back to
+// DefaultPolicy or is forgotten (see Device.Forget).
+var (
+	devicePolicies = make(map[uint32]*sharedPolicy)
+	policyLock     sync.RWMutex
+)
+
+// sourcePolicy returns the policy set for source,
+// or DefaultPolicy if it's not set.
+func sourcePolicy(source uint32) Policy {
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	if sp, ok := devicePolicies[source]; ok {
+		return sp.policy
+	}
+	return DefaultPolicy()
+}
+
+// setPolicyLocked sets the policy of the source of d to p,
+// or releases the reference of d if p is DefaultPolicy.
+//
+// policyLock must be held by the caller.
+func (d *device) setPolicyLocked(p Policy) {
+	sp := devicePolicies[d.source]
+	if p == DefaultPolicy() {
+		if !d.policyRef {
+			return
+		}
+		d.policyRef = false
+		if sp == nil {
+			return
+		}
+		sp.refs--
+		if sp.refs <= 0 {
+			delete(devicePolicies, d.source)
+			return
+		}
+		sp.policy = p
+		return
+	}
+	if sp == nil {
+		sp = new(sharedPolicy)
+		devicePolicies[d.source] = sp
+	}
+	if !d.policyRef {
+		d.policyRef = true
+		sp.refs++
+	}
+	sp.policy = p
+}
+
+func (d *device) SetPolicy(p Policy) {
+	policyLock.Lock()
+	defer policyLock.Unlock()
+	d.setPolicyLocked(p)
+}
+
+func (d *device) Policy() Policy {
+	return sourcePolicy(d.source)
+}
+
+func (d *device) Forget() {
+	d.SetPolicy(DefaultPolicy())
+	d.SetSequenceTracking(false)
+}
+
+// ApplyPolicy returns a copy of ctx with the Timeout and ReadTimeout of the
+// policy of the device with source applied,
+// unless they are already overridden on ctx.
+//
+// WaitForAcks, WaitForResponses and SendAndWait already apply it.
+// Device API implementations with their own response reading loops should call
+// it before reading.
+//
+// The returned cancel function must be called after the ctx is no longer used.
+func ApplyPolicy(ctx context.Context, source uint32) (context.Context, context.CancelFunc) {
+	p := sourcePolicy(source)
+	if p.ReadTimeout > 0 {
+		if d, ok := ctx.Value(readTimeoutKey{}).(time.Duration); !ok || d <= 0 {
+			ctx = WithReadTimeout(ctx, p.ReadTimeout)
+		}
+	}
+	if _, ok := ctx.Deadline(); ok || p.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.Timeout)
+}
@@ -0,0 +1,208 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	var lock sync.Mutex
+	var received int
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.SetPower: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				_ *lifxlan.Response,
+			) {
+				lock.Lock()
+				defer lock.Unlock()
+				received++
+			},
+		},
+		// Never ack.
+		HandleAcks: false,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	reset := func(t *testing.T, p lifxlan.Policy) {
+		t.Helper()
+
+		device.SetPolicy(p)
+		t.Cleanup(func() {
+			device.SetPolicy(lifxlan.DefaultPolicy())
+		})
+
+		lock.Lock()
+		defer lock.Unlock()
+		received = 0
+	}
+
+	t.Run(
+		"Default",
+		func(t *testing.T) {
+			if p := device.Policy(); !reflect.DeepEqual(p, lifxlan.DefaultPolicy()) {
+				t.Errorf("Policy expected %+v by default, got %+v", lifxlan.DefaultPolicy(), p)
+			}
+
+			device.SetTimeout(timeout)
+			defer device.SetTimeout(0)
+			expected := lifxlan.DefaultPolicy()
+			expected.Timeout = timeout
+			if p := device.Policy(); !reflect.DeepEqual(p, expected) {
+				t.Errorf("Policy expected %+v after SetTimeout, got %+v", expected, p)
+			}
+		},
+	)
+
+	t.Run(
+		"Forget",
+		func(t *testing.T) {
+			reset(t, lifxlan.Policy{Timeout: timeout})
+			device.SetSequenceTracking(true)
+			device.Forget()
+			if p := device.Policy(); !reflect.DeepEqual(p, lifxlan.DefaultPolicy()) {
+				t.Errorf("Policy expected %+v after Forget, got %+v", lifxlan.DefaultPolicy(), p)
+			}
+
+			// The clone keeps its own policy after the original is forgotten.
+			device.SetTimeout(timeout)
+			clone := device.Clone()
+			defer clone.Forget()
+			device.Forget()
+			if got := clone.Timeout(); got != timeout {
+				t.Errorf("Clone timeout expected %v, got %v", timeout, got)
+			}
+		},
+	)
+
+	t.Run(
+		"Timeout",
+		func(t *testing.T) {
+			const d = time.Millisecond * 50
+			reset(t, lifxlan.Policy{Timeout: d})
+			if got := device.Timeout(); got != d {
+				t.Errorf("Timeout expected %v, got %v", d, got)
+			}
+
+			start := time.Now()
+			err := device.SetPower(context.Background(), nil, lifxlan.PowerOn, true)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > timeout {
+				t.Errorf("Expected to time out after %v, took %v", d, elapsed)
+			}
+		},
+	)
+
+	t.Run(
+		"ReadTimeout",
+		func(t *testing.T) {
+			const d = time.Millisecond * 20
+			reset(t, lifxlan.Policy{ReadTimeout: d})
+
+			ctx, cancel := lifxlan.ApplyPolicy(context.Background(), device.Source())
+			defer cancel()
+			if got := lifxlan.ReadTimeout(ctx); got != d {
+				t.Errorf("ReadTimeout expected %v, got %v", d, got)
+			}
+
+			// Explicit read timeout on ctx takes precedence.
+			ctx, cancel = lifxlan.ApplyPolicy(
+				lifxlan.WithReadTimeout(context.Background(), time.Second),
+				device.Source(),
+			)
+			defer cancel()
+			if got := lifxlan.ReadTimeout(ctx); got != time.Second {
+				t.Errorf("ReadTimeout expected %v, got %v", time.Second, got)
+			}
+		},
+	)
+
+	t.Run(
+		"Retry",
+		func(t *testing.T) {
+			reset(t, lifxlan.Policy{
+				Retry: lifxlan.RetryOptions{
+					MaxAttempts:    2,
+					InitialBackoff: time.Millisecond * 20,
+				},
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			err := lifxlan.SendWithRetry(
+				ctx,
+				nil,
+				device,
+				lifxlan.FlagAckRequired,
+				lifxlan.SetPower,
+				nil,
+				lifxlan.RetryOptions{},
+			)
+			var retryErr *lifxlan.SendWithRetryError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("Expected *lifxlan.SendWithRetryError, got %v", err)
+			}
+			if retryErr.Attempts != 2 {
+				t.Errorf("Attempts expected 2, got %d", retryErr.Attempts)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if received != 2 {
+				t.Errorf("Expected 2 messages received, got %d", received)
+			}
+		},
+	)
+
+	t.Run(
+		"RateLimiter",
+		func(t *testing.T) {
+			reset(t, lifxlan.Policy{
+				// One message every 10 seconds.
+				RateLimiter: lifxlan.NewRateLimiter(0.1, 1),
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := device.SetPower(ctx, nil, lifxlan.PowerOn, false); err != nil {
+				t.Fatal(err)
+			}
+			err := device.SetPower(ctx, nil, lifxlan.PowerOn, false)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the second message to be rate limited, got %v", err)
+			}
+
+			// A *RateLimitedConn takes precedence.
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			rlc := lifxlan.NewRateLimitedConn(conn, lifxlan.NewRateLimiter(0, 5))
+			ctx, cancel = context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := device.SetPower(ctx, rlc, lifxlan.PowerOn, false); err != nil {
+				t.Errorf("Expected the conn limiter to be used, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,190 @@
+package lifxlan
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultConnPoolTTL is the default idle TTL used by ConnPool.
+const DefaultConnPoolTTL = time.Minute
+
+// ErrConnPoolClosed is the error returned by ConnPool.Get after the pool is
+// closed.
+var ErrConnPoolClosed = errors.New("lifxlan.ConnPool: pool closed")
+
+// ConnPool reuses connections to devices, one connection per Target.
+//
+// A connection is exclusively leased to a single caller between Get and the
+// release function it returns,
+// as WaitForAcks and WaitForResponses on the same connection would conflict
+// with each other.
+// Concurrent Get calls for the same Target block until the connection is
+// released or ctx is cancelled.
+//
+// Connections idle for longer than TTL will be closed and evicted on the next
+// Get call.
+//
+// The zero value is ready to use with DefaultConnPoolTTL.
+type ConnPool struct {
+	// If TTL <= 0, DefaultConnPoolTTL will be used.
+	TTL time.Duration
+
+	lock   sync.Mutex
+	conns  map[Target]*pooledConn
+	closed bool
+}
+
+type pooledConn struct {
+	conn     net.Conn
+	lease    chan struct{}
+	lastUsed time.Time
+}
+
+// NewConnPool creates a new ConnPool with the given idle TTL.
+func NewConnPool(ttl time.Duration) *ConnPool {
+	return &ConnPool{
+		TTL: ttl,
+	}
+}
+
+func (p *ConnPool) ttl() time.Duration {
+	if p.TTL <= 0 {
+		return DefaultConnPoolTTL
+	}
+	return p.TTL
+}
+
+// Get returns the pooled connection to dev,
+// dialing a new one via dev.Dial if needed.
+//
+// The read and write deadlines of the returned connection are reset.
+//
+// On nil error,
+// the caller must call release after it's done with the connection,
+// and must not close the connection.
+func (p *ConnPool) Get(ctx context.Context, dev Device) (conn net.Conn, release func(), err error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, nil, ErrConnPoolClosed
+		}
+		p.evictLocked()
+		if p.conns == nil {
+			p.conns = make(map[Target]*pooledConn)
+		}
+		pc := p.conns[dev.Target()]
+		if pc == nil {
+			pc = &pooledConn{
+				lease: make(chan struct{}, 1),
+			}
+			p.conns[dev.Target()] = pc
+		}
+		p.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case pc.lease <- struct{}{}:
+		}
+
+		p.lock.Lock()
+		if p.closed || p.conns[dev.Target()] != pc {
+			// It was evicted while we were waiting, try again.
+			p.lock.Unlock()
+			<-pc.lease
+			continue
+		}
+		p.lock.Unlock()
+
+		if pc.conn == nil {
+			newConn, err := dev.Dial()
+			if err != nil {
+				<-pc.lease
+				return nil, nil, err
+			}
+			pc.conn = newConn
+		}
+		if err := pc.conn.SetDeadline(time.Time{}); err != nil {
+			p.discard(dev.Target(), pc)
+			return nil, nil, err
+		}
+
+		var once sync.Once
+		return pc.conn, func() {
+			once.Do(func() {
+				p.release(pc)
+			})
+		}, nil
+	}
+}
+
+func (p *ConnPool) release(pc *pooledConn) {
+	p.lock.Lock()
+	pc.lastUsed = time.Now()
+	if p.closed {
+		pc.conn.Close()
+	}
+	p.lock.Unlock()
+	<-pc.lease
+}
+
+// discard closes and removes a leased pc from the pool.
+func (p *ConnPool) discard(target Target, pc *pooledConn) {
+	p.lock.Lock()
+	if p.conns[target] == pc {
+		delete(p.conns, target)
+	}
+	p.lock.Unlock()
+	pc.conn.Close()
+	<-pc.lease
+}
+
+// evictLocked closes and removes idle connections.
+//
+// p.lock must be held by the caller.
+func (p *ConnPool) evictLocked() {
+	deadline := time.Now().Add(-p.ttl())
+	for target, pc := range p.conns {
+		select {
+		default:
+			// Currently leased.
+		case pc.lease <- struct{}{}:
+			if pc.conn != nil && pc.lastUsed.Before(deadline) {
+				pc.conn.Close()
+				delete(p.conns, target)
+			}
+			<-pc.lease
+		}
+	}
+}
+
+// Close closes all idle connections in the pool.
+//
+// Connections currently leased will be closed when they are released.
+// Get calls after Close will return ErrConnPoolClosed.
+func (p *ConnPool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+	for target, pc := range p.conns {
+		select {
+		default:
+			// Currently leased, will be closed on release.
+		case pc.lease <- struct{}{}:
+			if pc.conn != nil {
+				pc.conn.Close()
+			}
+			delete(p.conns, target)
+			<-pc.lease
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestConnPool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	t.Run(
+		"Reuse",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			pool := lifxlan.NewConnPool(time.Minute)
+			defer pool.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			conn1, release, err := pool.Get(ctx, device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := device.SetPower(ctx, conn1, lifxlan.PowerOn, true); err != nil {
+				t.Fatal(err)
+			}
+			release()
+
+			conn2, release, err := pool.Get(ctx, device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+			if conn1 != conn2 {
+				t.Errorf("Expected the same conn, got %v and %v", conn1, conn2)
+			}
+			if err := device.SetPower(ctx, conn2, lifxlan.PowerOff, true); err != nil {
+				t.Fatal(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Exclusive",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			pool := lifxlan.NewConnPool(time.Minute)
+			defer pool.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			_, release, err := pool.Get(ctx, device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+
+			shortCtx, shortCancel := context.WithTimeout(ctx, time.Millisecond*20)
+			defer shortCancel()
+			if _, _, err := pool.Get(shortCtx, device); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded while leased, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Evict",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			pool := lifxlan.NewConnPool(time.Millisecond * 10)
+			defer pool.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			conn1, release, err := pool.Get(ctx, device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			release()
+
+			time.Sleep(time.Millisecond * 20)
+
+			conn2, release, err := pool.Get(ctx, device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+			if conn1 == conn2 {
+				t.Error("Expected a new conn after TTL")
+			}
+			if err := device.SetPower(ctx, conn2, lifxlan.PowerOn, true); err != nil {
+				t.Fatal(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Closed",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			pool := lifxlan.NewConnPool(time.Minute)
+			pool.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, _, err := pool.Get(ctx, device); !errors.Is(err, lifxlan.ErrConnPoolClosed) {
+				t.Errorf("Expected ErrConnPoolClosed, got %v", err)
+			}
+		},
+	)
+}
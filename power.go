@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"net"
 )
 
@@ -14,21 +15,42 @@ import (
 type Power uint16
 
 // Power values.
+//
+// The devices only accept PowerOn and PowerOff in SetPower messages,
+// but some firmware reports intermediate levels in StatePower messages during
+// power transitions.
+// Always use On to check the state instead of comparing the raw values.
 const (
 	PowerOn  Power = 65535
 	PowerOff Power = 0
 )
 
+// BoolToPower returns PowerOn if on is true, PowerOff otherwise.
+func BoolToPower(on bool) Power {
+	if on {
+		return PowerOn
+	}
+	return PowerOff
+}
+
 // On returns whether this power level value represents on state.
+//
+// All the non-zero levels, including the intermediate ones, are on.
 func (p Power) On() bool {
 	return p != PowerOff
 }
 
+// String returns "on" or "off",
+// with the raw value appended for intermediate levels, e.g. "on (32768)".
 func (p Power) String() string {
-	if p.On() {
+	switch p {
+	case PowerOn:
 		return "on"
+	case PowerOff:
+		return "off"
+	default:
+		return fmt.Sprintf("on (%d)", uint16(p))
 	}
-	return "off"
 }
 
 // RawStatePowerPayload defines the struct to be used for encoding and decoding.
@@ -38,6 +60,34 @@ type RawStatePowerPayload struct {
 	Level Power
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawStatePowerPayload) MarshalBinary() ([]byte, error) {
+	return marshalPower(p.Level), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawStatePowerPayload) UnmarshalBinary(data []byte) error {
+	level, err := unmarshalPower("lifxlan.RawStatePowerPayload.UnmarshalBinary", data)
+	if err != nil {
+		return err
+	}
+	p.Level = level
+	return nil
+}
+
+func marshalPower(level Power) []byte {
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, uint16(level))
+	return data
+}
+
+func unmarshalPower(caller string, data []byte) (Power, error) {
+	if err := CheckPayloadSize(caller, data, 2); err != nil {
+		return 0, err
+	}
+	return Power(binary.LittleEndian.Uint16(data)), nil
+}
+
 func (d *device) GetPower(ctx context.Context, conn net.Conn) (Power, error) {
 	if ctx.Err() != nil {
 		return 0, ctx.Err()
@@ -67,26 +117,25 @@ func (d *device) GetPower(ctx context.Context, conn net.Conn) (Power, error) {
 		return 0, err
 	}
 
-	for {
-		resp, err := ReadNextResponse(ctx, conn)
-		if err != nil {
-			return 0, err
-		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-		if resp.Message != StatePower {
-			continue
-		}
-
-		var raw RawStatePowerPayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return 0, err
-		}
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StatePower,
+		1, // count
+	)
+	if err != nil {
+		return 0, err
+	}
 
-		return raw.Level, nil
+	var raw RawStatePowerPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
 	}
+
+	return raw.Level, nil
 }
 
 // RawSetPowerPayload defines the struct to be used for encoding and decoding.
@@ -96,6 +145,21 @@ type RawSetPowerPayload struct {
 	Level Power
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (p RawSetPowerPayload) MarshalBinary() ([]byte, error) {
+	return marshalPower(p.Level), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *RawSetPowerPayload) UnmarshalBinary(data []byte) error {
+	level, err := unmarshalPower("lifxlan.RawSetPowerPayload.UnmarshalBinary", data)
+	if err != nil {
+		return err
+	}
+	p.Level = level
+	return nil
+}
+
 func (d *device) SetPower(
 	ctx context.Context,
 	conn net.Conn,
@@ -145,3 +209,92 @@ func (d *device) SetPower(
 
 	return nil
 }
+
+func (d *device) TogglePower(
+	ctx context.Context,
+	conn net.Conn,
+	ack bool,
+) (Power, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	current, err := d.GetPower(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	power := PowerOn
+	if current.On() {
+		power = PowerOff
+	}
+
+	// SetPower also checks ctx.Err() first,
+	// so nothing is sent if ctx is already cancelled at this point.
+	if err := d.SetPower(ctx, conn, power, ack); err != nil {
+		return 0, err
+	}
+	return power, nil
+}
+
+// EnsurePower makes sure that dev is at the power state of want,
+// and returns whether a change was made.
+//
+// It reads the current power first,
+// and only sends a SetPower message (with ack) if its state (see Power.On)
+// differs from want,
+// so it's safe to be called repeatedly (e.g. by rule engines) without
+// toggling the device or the brief dip some devices show on a redundant
+// SetPower.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func EnsurePower(
+	ctx context.Context,
+	conn net.Conn,
+	dev Device,
+	want Power,
+) (changed bool, err error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return false, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+	}
+
+	current, err := dev.GetPower(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+	if current.On() == want.On() {
+		return false, nil
+	}
+
+	if err := dev.SetPower(ctx, conn, want, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"net"
+	"sync"
 	"testing"
 	"testing/quick"
 	"time"
@@ -46,6 +49,21 @@ func TestPower(t *testing.T) {
 		},
 	)
 
+	t.Run(
+		"Intermediate",
+		func(t *testing.T) {
+			expected := "on (32768)"
+			power := lifxlan.Power(32768)
+			if !power.On() {
+				t.Errorf("Power(%d).On() should return true.", power)
+			}
+			s := power.String()
+			if s != expected {
+				t.Errorf("Power(%d).String() expected %q, got %q", power, expected, s)
+			}
+		},
+	)
+
 	t.Run(
 		"RandomOn",
 		func(t *testing.T) {
@@ -53,11 +71,11 @@ func TestPower(t *testing.T) {
 			now := time.Now()
 			rander := rand.New(rand.NewSource(now.Unix() + int64(now.Nanosecond())))
 
-			expected := "on"
 			var n int
 			f := func() bool {
 				n++
 				power := lifxlan.Power(rander.Intn(math.MaxUint16-1) + 1)
+				expected := fmt.Sprintf("on (%d)", power)
 				pass := true
 				if !power.On() {
 					pass = false
@@ -158,3 +176,233 @@ func TestSetPower(t *testing.T) {
 		t.Error("SetPower message not received.")
 	}
 }
+
+func TestBoolToPower(t *testing.T) {
+	if p := lifxlan.BoolToPower(true); p != lifxlan.PowerOn {
+		t.Errorf("BoolToPower(true) expected %d, got %d", lifxlan.PowerOn, p)
+	}
+	if p := lifxlan.BoolToPower(false); p != lifxlan.PowerOff {
+		t.Errorf("BoolToPower(false) expected %d, got %d", lifxlan.PowerOff, p)
+	}
+}
+
+func TestTogglePower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label    string
+		current  lifxlan.Power
+		expected lifxlan.Power
+	}{
+		{
+			label:    "OnToOff",
+			current:  lifxlan.PowerOn,
+			expected: lifxlan.PowerOff,
+		},
+		{
+			label:    "OffToOn",
+			current:  lifxlan.PowerOff,
+			expected: lifxlan.PowerOn,
+		},
+		{
+			label:    "PartialToOff",
+			current:  lifxlan.Power(1),
+			expected: lifxlan.PowerOff,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.RawStatePowerPayload = &lifxlan.RawStatePowerPayload{
+					Level: c.current,
+				}
+
+				var set *lifxlan.Power
+				service.Handlers[lifxlan.SetPower] = func(
+					_ *mock.Service,
+					_ net.PacketConn,
+					_ net.Addr,
+					orig *lifxlan.Response,
+				) {
+					var raw lifxlan.RawSetPowerPayload
+					r := bytes.NewReader(orig.Payload)
+					if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+						t.Fatal(err)
+					}
+					set = &raw.Level
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				power, err := device.TogglePower(ctx, nil, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if power != c.expected {
+					t.Errorf("Power expected %v, got %v", c.expected, power)
+				}
+				if set == nil {
+					t.Fatal("SetPower message not received.")
+				}
+				if *set != c.expected {
+					t.Errorf("SetPower level expected %d, got %d", c.expected, *set)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"CancelledAfterGet",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			service.Handlers[lifxlan.GetPower] = func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				// Cancel ctx before the reply is sent,
+				// so TogglePower sees the cancellation after reading the power.
+				cancel()
+				mock.DefaultHandlerFunc(s, conn, addr, orig)
+			}
+			var called bool
+			service.Handlers[lifxlan.SetPower] = func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				_ *lifxlan.Response,
+			) {
+				called = true
+			}
+
+			if _, err := device.TogglePower(ctx, nil, true); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+			if called {
+				t.Error("SetPower message should not be sent after ctx is cancelled.")
+			}
+		},
+	)
+}
+
+func TestEnsurePower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label   string
+		current lifxlan.Power
+		want    lifxlan.Power
+		changed bool
+	}{
+		{
+			label:   "AlreadyOn",
+			current: lifxlan.PowerOn,
+			want:    lifxlan.PowerOn,
+			changed: false,
+		},
+		{
+			label:   "AlreadyOff",
+			current: lifxlan.PowerOff,
+			want:    lifxlan.PowerOff,
+			changed: false,
+		},
+		{
+			label:   "PartialIsOn",
+			current: lifxlan.Power(1),
+			want:    lifxlan.PowerOn,
+			changed: false,
+		},
+		{
+			label:   "OffToOn",
+			current: lifxlan.PowerOff,
+			want:    lifxlan.PowerOn,
+			changed: true,
+		},
+		{
+			label:   "OnToOff",
+			current: lifxlan.PowerOn,
+			want:    lifxlan.PowerOff,
+			changed: true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var lock sync.Mutex
+				var set *lifxlan.Power
+				service := &mock.Service{
+					TB:         t,
+					HandleAcks: true,
+					Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+						lifxlan.SetPower: func(
+							_ *mock.Service,
+							_ net.PacketConn,
+							_ net.Addr,
+							orig *lifxlan.Response,
+						) {
+							var raw lifxlan.RawSetPowerPayload
+							r := bytes.NewReader(orig.Payload)
+							if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+								t.Error(err)
+								return
+							}
+							lock.Lock()
+							defer lock.Unlock()
+							set = &raw.Level
+						},
+					},
+					RawStatePowerPayload: &lifxlan.RawStatePowerPayload{
+						Level: c.current,
+					},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				changed, err := lifxlan.EnsurePower(ctx, nil, device, c.want)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if changed != c.changed {
+					t.Errorf("Changed expected %v, got %v", c.changed, changed)
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if !c.changed {
+					if set != nil {
+						t.Errorf("SetPower message should not be sent, got level %d", *set)
+					}
+					return
+				}
+				if set == nil {
+					t.Fatal("SetPower message not received.")
+				}
+				if *set != c.want {
+					t.Errorf("SetPower level expected %d, got %d", c.want, *set)
+				}
+			},
+		)
+	}
+}
@@ -16,6 +16,7 @@ package lifxlan
 // https://github.com/LIFX/products/blob/master/products.json
 // and generated by
 // https://github.com/fishy/lifxlan/tree/master/cmd/gen-product-map
+// with its extra_products.json for products not published upstream yet.
 var ProductMap = map[uint64]Product{
 	ProductMapKey(1, 1): {
 		VendorName:  "LIFX",
@@ -1342,4 +1343,40 @@ var ProductMap = map[uint64]Product{
 			TemperatureRange:  TemperatureRange{1500, 9000},
 		},
 	},
+	ProductMapKey(1, 176): {
+		VendorName:  "LIFX",
+		VendorID:    1,
+		ProductName: "LIFX Ceiling",
+		ProductID:   176,
+		Features: Features{
+			HEV:               OptionalBoolPtr(false),
+			Color:             OptionalBoolPtr(true),
+			Chain:             OptionalBoolPtr(false),
+			Matrix:            OptionalBoolPtr(true),
+			Relays:            OptionalBoolPtr(false),
+			Buttons:           OptionalBoolPtr(false),
+			Infrared:          OptionalBoolPtr(false),
+			Multizone:         OptionalBoolPtr(false),
+			ExtendedMultizone: OptionalBoolPtr(false),
+			TemperatureRange:  TemperatureRange{1500, 9000},
+		},
+	},
+	ProductMapKey(1, 177): {
+		VendorName:  "LIFX",
+		VendorID:    1,
+		ProductName: "LIFX Ceiling",
+		ProductID:   177,
+		Features: Features{
+			HEV:               OptionalBoolPtr(false),
+			Color:             OptionalBoolPtr(true),
+			Chain:             OptionalBoolPtr(false),
+			Matrix:            OptionalBoolPtr(true),
+			Relays:            OptionalBoolPtr(false),
+			Buttons:           OptionalBoolPtr(false),
+			Infrared:          OptionalBoolPtr(false),
+			Multizone:         OptionalBoolPtr(false),
+			ExtendedMultizone: OptionalBoolPtr(false),
+			TemperatureRange:  TemperatureRange{1500, 9000},
+		},
+	},
 }
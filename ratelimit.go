@@ -0,0 +1,142 @@
+package lifxlan
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the max number of messages per second per device
+// recommended by LIFX.
+const DefaultRateLimit = 20
+
+// RateLimiter is a token bucket rate limiter.
+//
+// A RateLimiter is safe for concurrent use.
+// It should usually be used for a single device,
+// so controlling multiple devices won't be serialized by a single limiter.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a new RateLimiter that allows rate messages per
+// second, with bursts of up to burst messages.
+//
+// If rate <= 0, DefaultRateLimit will be used.
+// If burst < 1, 1 will be used.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		rate = DefaultRateLimit
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is cancelled.
+//
+// It returns ctx.Err() if ctx is cancelled before a token is available,
+// in which case no token is consumed.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wait := rl.take()
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take consumes a token if available and returns 0,
+// otherwise it returns how long to wait until the next token is available.
+func (rl *RateLimiter) take() time.Duration {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+	return time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
+// RateLimitedConn is a net.Conn with a RateLimiter attached.
+//
+// Device.Send and SendWithRetry will call Limiter.Wait before writing
+// every message to a RateLimitedConn,
+// so they block until a token is available or ctx is cancelled.
+// Write calls made directly on the conn are not rate limited.
+type RateLimitedConn struct {
+	net.Conn
+
+	Limiter *RateLimiter
+}
+
+// NewRateLimitedConn attaches limiter to conn.
+//
+// Example:
+//
+//     limiter := lifxlan.NewRateLimiter(lifxlan.DefaultRateLimit, 5)
+//     conn, err := device.Dial()
+//     if err != nil {
+//       // handle error
+//     }
+//     defer conn.Close()
+//     conn = lifxlan.NewRateLimitedConn(conn, limiter)
+//     // Use conn with device APIs
+func NewRateLimitedConn(conn net.Conn, limiter *RateLimiter) *RateLimitedConn {
+	return &RateLimitedConn{
+		Conn:    conn,
+		Limiter: limiter,
+	}
+}
+
+// waitForRateLimit calls Limiter.Wait if conn is a *RateLimitedConn,
+// or a *SyncConn wrapping a *RateLimitedConn.
+// Otherwise it calls the RateLimiter of the policy of the device with source,
+// if any.
+func waitForRateLimit(ctx context.Context, conn net.Conn, source uint32) error {
+	if sc, ok := conn.(*SyncConn); ok {
+		conn = sc.Conn
+	}
+	if rlc, ok := conn.(*RateLimitedConn); ok {
+		if rlc.Limiter != nil {
+			return rlc.Limiter.Wait(ctx)
+		}
+		return nil
+	}
+	if limiter := sourcePolicy(source).RateLimiter; limiter != nil {
+		return limiter.Wait(ctx)
+	}
+	return nil
+}
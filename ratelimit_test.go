@@ -0,0 +1,91 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	t.Run(
+		"Throttle",
+		func(t *testing.T) {
+			const rate = 50
+			const burst = 2
+			const n = 6
+			// The first burst messages go out immediately,
+			// the rest are throttled at rate.
+			const expected = time.Second * (n - burst) / rate
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			limiter := lifxlan.NewRateLimiter(rate, burst)
+			start := time.Now()
+			for i := 0; i < n; i++ {
+				if err := limiter.Wait(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < expected-time.Millisecond*5 {
+				t.Errorf("Expected %d waits to take at least %v, took %v", n, expected, elapsed)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			limiter := lifxlan.NewRateLimiter(1, 1)
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+			defer cancel()
+			if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Conn",
+		func(t *testing.T) {
+			const timeout = time.Millisecond * 500
+			const rate = 20
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			rlc := lifxlan.NewRateLimitedConn(conn, lifxlan.NewRateLimiter(rate, 1))
+
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				if err := device.SetPower(ctx, rlc, lifxlan.PowerOn, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected := time.Second * 2 / rate
+			if elapsed := time.Since(start); elapsed < expected-time.Millisecond*5 {
+				t.Errorf("Expected 3 SetPower calls to take at least %v, took %v", expected, elapsed)
+			}
+		},
+	)
+}
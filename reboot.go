@@ -0,0 +1,35 @@
+package lifxlan
+
+import (
+	"context"
+	"net"
+)
+
+func (d *device) Reboot(ctx context.Context, conn net.Conn) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	// The device reboots right away and never acks, so don't ask for one.
+	_, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		SetReboot,
+		nil, // payload
+	)
+	return err
+}
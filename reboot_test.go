@@ -0,0 +1,51 @@
+package lifxlan_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestReboot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	received := make(chan *lifxlan.Response, 1)
+	service.Handlers[lifxlan.SetReboot] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		received <- orig
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := device.Reboot(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("SetReboot message not received.")
+	case resp := <-received:
+		if resp.Flags&lifxlan.FlagAckRequired != 0 {
+			t.Errorf("Expected no ack required, got flags %v", resp.Flags)
+		}
+		if len(resp.Payload) != 0 {
+			t.Errorf("Expected empty payload, got %v", resp.Payload)
+		}
+	}
+}
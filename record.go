@@ -0,0 +1,101 @@
+package lifxlan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DeviceRecord is the JSON representation of a Device,
+// used to persist discovered devices and reconstruct them later without
+// going through discovery again.
+//
+// It captures the target, address and service of the device,
+// and the cached label and hardware version if they were fetched.
+type DeviceRecord struct {
+	Target          string           `json:"target"`
+	Addr            string           `json:"addr"`
+	Service         ServiceType      `json:"service"`
+	Label           string           `json:"label,omitempty"`
+	HardwareVersion *HardwareVersion `json:"hardware_version,omitempty"`
+}
+
+// RecordOf creates a DeviceRecord from d.
+func RecordOf(d Device) DeviceRecord {
+	record := DeviceRecord{
+		Target:  d.Target().String(),
+		Addr:    d.Addr().String(),
+		Service: ServiceUDP,
+		Label:   d.Label().String(),
+	}
+	for _, service := range d.Services() {
+		if service.Type.network() != "" {
+			record.Service = service.Type
+			break
+		}
+	}
+	if hv := *d.HardwareVersion(); hv != (HardwareVersion{}) {
+		record.HardwareVersion = &hv
+	}
+	return record
+}
+
+// Device reconstructs the Device from the record.
+//
+// The returned device can be dialed via the saved address immediately,
+// and has the cached label and hardware version restored.
+// The source of the device will be a new random one.
+func (r DeviceRecord) Device() (Device, error) {
+	target, err := ParseTarget(r.Target)
+	if err != nil {
+		return nil, fmt.Errorf("lifxlan.DeviceRecord.Device: %w", err)
+	}
+	if r.Service.network() == "" {
+		return nil, fmt.Errorf(
+			"lifxlan.DeviceRecord.Device: unknown device service type: %v",
+			r.Service,
+		)
+	}
+	normalized, err := normalizeAddr(r.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("lifxlan.DeviceRecord.Device: %w", err)
+	}
+	d := newDevice(normalized, r.Service, target)
+	d.label.Set(r.Label)
+	if r.HardwareVersion != nil {
+		d.version = *r.HardwareVersion
+	}
+	return d, nil
+}
+
+var _ json.Marshaler = (*device)(nil)
+
+func (d *device) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RecordOf(d))
+}
+
+// LoadDevices reads a JSON array of DeviceRecords from r and reconstructs the
+// devices.
+//
+// The input is usually generated by encoding a []Device to JSON, e.g.:
+//
+//     if err := json.NewEncoder(w).Encode(devices); err != nil {
+//       // handle error
+//     }
+//
+// It fails if any of the records cannot be reconstructed.
+func LoadDevices(r io.Reader) ([]Device, error) {
+	var records []DeviceRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("lifxlan.LoadDevices: %w", err)
+	}
+	devices := make([]Device, 0, len(records))
+	for i, record := range records {
+		d, err := record.Device()
+		if err != nil {
+			return nil, fmt.Errorf("lifxlan.LoadDevices: record #%d: %w", i, err)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
@@ -0,0 +1,115 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestDeviceJSONRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service := &mock.Service{
+		TB:         t,
+		Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+		HandleAcks: true,
+	}
+	service.Handlers[lifxlan.GetService] = stateServiceHandler(t)
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	discovered, err := lifxlan.DiscoverUnicast(ctx, device.Addr().String(), lifxlan.AllDevices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	discovered.Label().Set("foo")
+	*discovered.HardwareVersion() = lifxlan.HardwareVersion{
+		VendorID:  1,
+		ProductID: 22,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode([]lifxlan.Device{discovered}); err != nil {
+		t.Fatal(err)
+	}
+	devices, err := lifxlan.LoadDevices(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("Expected 1 device, got %v", devices)
+	}
+	d := devices[0]
+
+	if d.Target() != discovered.Target() {
+		t.Errorf("Target expected %v, got %v", discovered.Target(), d.Target())
+	}
+	if got, expected := d.Addr().String(), discovered.Addr().String(); got != expected {
+		t.Errorf("Addr expected %q, got %q", expected, got)
+	}
+	if got := d.Label().String(); got != "foo" {
+		t.Errorf("Label expected %q, got %q", "foo", got)
+	}
+	if got, expected := *d.HardwareVersion(), *discovered.HardwareVersion(); got != expected {
+		t.Errorf("HardwareVersion expected %+v, got %+v", expected, got)
+	}
+
+	conn, err := d.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if got, expected := conn.RemoteAddr().String(), device.Addr().String(); got != expected {
+		t.Errorf("Dialed address expected %q, got %q", expected, got)
+	}
+	if err := d.SetPower(ctx, conn, lifxlan.PowerOn, true); err != nil {
+		t.Errorf("SetPower on reconstructed device failed: %v", err)
+	}
+}
+
+func TestLoadDevicesInvalid(t *testing.T) {
+	for _, c := range []struct {
+		label string
+		input string
+	}{
+		{
+			label: "NotJSON",
+			input: "foo",
+		},
+		{
+			label: "Target",
+			input: `[{"target":"foo","addr":"127.0.0.1:56700","service":1}]`,
+		},
+		{
+			label: "Addr",
+			input: `[{"target":"d0:73:d5:01:23:45","addr":"127.0.0.1:foo","service":1}]`,
+		},
+		{
+			label: "Service",
+			input: `[{"target":"d0:73:d5:01:23:45","addr":"127.0.0.1:56700","service":0}]`,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if devices, err := lifxlan.LoadDevices(strings.NewReader(c.input)); err == nil {
+					t.Errorf("Expected error, got %v", devices)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,273 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// MaxButtons is the max number of buttons in a single StateButton message.
+const MaxButtons = 8
+
+// MaxButtonActions is the max number of actions per button.
+const MaxButtonActions = 5
+
+// ButtonActionTargetLength is the length of the raw target bytes of a
+// ButtonAction.
+const ButtonActionTargetLength = 16
+
+// MinReadBufferSize is the minimum read buffer size
+// (see lifxlan.WithReadBufferSize) for the responses of the APIs in this
+// package.
+//
+// It's the size of a StateButton message,
+// the biggest one in this package.
+const MinReadBufferSize = lifxlan.HeaderLength + 811
+
+// RawButtonConfigPayload defines the struct to be used for encoding and
+// decoding.
+//
+// It's the payload of both SetButtonConfig and StateButtonConfig messages.
+type RawButtonConfigPayload struct {
+	HapticDurationMs  uint16
+	BacklightOnColor  lifxlan.Color
+	BacklightOffColor lifxlan.Color
+}
+
+// ButtonConfig defines the settings shared by all the buttons on a switch.
+type ButtonConfig struct {
+	// How long the haptic feedback lasts when a button is pressed.
+	HapticDuration time.Duration
+
+	// The backlight color of the buttons when the relay is on and off.
+	BacklightOnColor  lifxlan.Color
+	BacklightOffColor lifxlan.Color
+}
+
+// Raw converts ButtonConfig into RawButtonConfigPayload.
+//
+// HapticDuration will be rounded down to milliseconds.
+func (cfg ButtonConfig) Raw() *RawButtonConfigPayload {
+	return &RawButtonConfigPayload{
+		HapticDurationMs:  uint16(cfg.HapticDuration / time.Millisecond),
+		BacklightOnColor:  cfg.BacklightOnColor,
+		BacklightOffColor: cfg.BacklightOffColor,
+	}
+}
+
+// ButtonConfig converts RawButtonConfigPayload into ButtonConfig.
+func (raw RawButtonConfigPayload) ButtonConfig() *ButtonConfig {
+	return &ButtonConfig{
+		HapticDuration:    time.Duration(raw.HapticDurationMs) * time.Millisecond,
+		BacklightOnColor:  raw.BacklightOnColor,
+		BacklightOffColor: raw.BacklightOffColor,
+	}
+}
+
+// ButtonAction defines what happens when a gesture is performed on a button.
+//
+// The meaning of Target depends on TargetType,
+// and it's kept as raw bytes as the protocol for it is not fully documented.
+type ButtonAction struct {
+	Gesture    uint16
+	TargetType uint16
+	Target     [ButtonActionTargetLength]byte
+}
+
+// RawButton defines the struct to be used for encoding and decoding.
+//
+// Only the first ActionsCount Actions are valid.
+type RawButton struct {
+	ActionsCount uint8
+	Actions      [MaxButtonActions]ButtonAction
+}
+
+// RawStateButtonPayload defines the struct to be used for encoding and
+// decoding.
+//
+// Only the first ButtonsCount Buttons are valid.
+type RawStateButtonPayload struct {
+	Count        uint8
+	Index        uint8
+	ButtonsCount uint8
+	Buttons      [MaxButtons]RawButton
+}
+
+// Button defines the actions configured on a single button.
+type Button struct {
+	Actions []ButtonAction
+}
+
+// ParseButtons converts RawStateButtonPayload into Buttons.
+func ParseButtons(raw *RawStateButtonPayload) []Button {
+	n := int(raw.ButtonsCount)
+	if n > MaxButtons {
+		n = MaxButtons
+	}
+	buttons := make([]Button, n)
+	for i := range buttons {
+		rb := raw.Buttons[i]
+		count := int(rb.ActionsCount)
+		if count > MaxButtonActions {
+			count = MaxButtonActions
+		}
+		buttons[i].Actions = make([]ButtonAction, count)
+		copy(buttons[i].Actions, rb.Actions[:count])
+	}
+	return buttons
+}
+
+func (rd *device) GetButtons(ctx context.Context, conn net.Conn) ([]Button, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := rd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetButton,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		rd.Source(),
+		seq,
+		StateButton,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateButtonPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return ParseButtons(&raw), nil
+}
+
+func (rd *device) GetButtonConfig(ctx context.Context, conn net.Conn) (*ButtonConfig, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := rd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetButtonConfig,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		rd.Source(),
+		seq,
+		StateButtonConfig,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawButtonConfigPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw.ButtonConfig(), nil
+}
+
+func (rd *device) SetButtonConfig(
+	ctx context.Context,
+	conn net.Conn,
+	cfg ButtonConfig,
+	ack bool,
+) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := rd.Send(
+		ctx,
+		conn,
+		flags,
+		SetButtonConfig,
+		cfg.Raw(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, rd.Source(), seq)
+	}
+	return nil
+}
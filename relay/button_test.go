@@ -0,0 +1,144 @@
+package relay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/relay"
+)
+
+func TestMinReadBufferSize(t *testing.T) {
+	expected := lifxlan.HeaderLength + binary.Size(relay.RawStateButtonPayload{})
+	if relay.MinReadBufferSize != expected {
+		t.Errorf("MinReadBufferSize expected %d, got %d", expected, relay.MinReadBufferSize)
+	}
+}
+
+func TestGetButtons(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	var lock sync.Mutex
+	service.Handlers[relay.GetRPower] = relaysHandler(
+		t,
+		&lock,
+		make([]lifxlan.Power, relay.NumRelays),
+	)
+
+	action := relay.ButtonAction{
+		Gesture:    1,
+		TargetType: 2,
+	}
+	copy(action.Target[:], []byte{0, 1, 0xde, 0xad})
+	raw := &relay.RawStateButtonPayload{
+		Count:        2,
+		ButtonsCount: 2,
+	}
+	raw.Buttons[0].ActionsCount = 1
+	raw.Buttons[0].Actions[0] = action
+	service.Handlers[relay.GetButton] = mock.ReplyHandler(relay.StateButton, raw)
+
+	rd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	buttons, err := rd.GetButtons(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []relay.Button{
+		{Actions: []relay.ButtonAction{action}},
+		{Actions: []relay.ButtonAction{}},
+	}
+	if !reflect.DeepEqual(buttons, expected) {
+		t.Errorf("Buttons expected %+v, got %+v", expected, buttons)
+	}
+}
+
+func TestButtonConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	var lock sync.Mutex
+	service.Handlers[relay.GetRPower] = relaysHandler(
+		t,
+		&lock,
+		make([]lifxlan.Power, relay.NumRelays),
+	)
+
+	expected := relay.ButtonConfig{
+		HapticDuration: time.Millisecond * 50,
+		BacklightOnColor: lifxlan.Color{
+			Hue:        0x5555,
+			Saturation: 0xffff,
+			Brightness: 0x8000,
+			Kelvin:     3500,
+		},
+		BacklightOffColor: lifxlan.Color{
+			Brightness: 0x1000,
+			Kelvin:     3500,
+		},
+	}
+
+	var set *relay.RawButtonConfigPayload
+	service.Handlers[relay.SetButtonConfig] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw relay.RawButtonConfigPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		set = &raw
+	}
+	service.Handlers[relay.GetButtonConfig] = mock.ReplyHandler(
+		relay.StateButtonConfig,
+		expected.Raw(),
+	)
+
+	rd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := rd.SetButtonConfig(ctx, nil, expected, true); err != nil {
+		t.Fatal(err)
+	}
+	if set == nil {
+		t.Fatal("SetButtonConfig message not received")
+	}
+	if !reflect.DeepEqual(*set, *expected.Raw()) {
+		t.Errorf("SetButtonConfig payload expected %+v, got %+v", expected.Raw(), set)
+	}
+
+	cfg, err := rd.GetButtonConfig(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cfg, expected) {
+		t.Errorf("ButtonConfig expected %+v, got %+v", expected, cfg)
+	}
+}
@@ -0,0 +1,95 @@
+package relay
+
+import (
+	"context"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Device is a wrapped lifxlan.Device that provides relay related APIs.
+type Device interface {
+	lifxlan.Device
+
+	// GetRPower returns the current power level of the relay at index.
+	//
+	// The level is the full 16-bit value reported by the device,
+	// the same encoding as the power level of lights
+	// (PowerOff for 0 and PowerOn for 65535).
+	//
+	// index must be in [0, NumRelays-1], otherwise an error will be returned.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetRPower(ctx context.Context, conn net.Conn, index uint8) (lifxlan.Power, error)
+
+	// SetRPower sets the power level of the relay at index.
+	//
+	// Relays only accept 0 or 65535 as the level,
+	// so any power that's On is sent as PowerOn.
+	//
+	// index must be in [0, NumRelays-1], otherwise an error will be returned.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetRPower(ctx context.Context, conn net.Conn, index uint8, power lifxlan.Power, ack bool) error
+
+	// AllRPower returns the current power levels of all NumRelays relays,
+	// in the order of relay index.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	AllRPower(ctx context.Context, conn net.Conn) ([]lifxlan.Power, error)
+
+	// GetButtons returns the actions configured on the buttons of the switch.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetButtons(ctx context.Context, conn net.Conn) ([]Button, error)
+
+	// GetButtonConfig returns the haptic and backlight settings of the buttons.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetButtonConfig(ctx context.Context, conn net.Conn) (*ButtonConfig, error)
+
+	// SetButtonConfig sets the haptic and backlight settings of the buttons.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetButtonConfig(ctx context.Context, conn net.Conn, cfg ButtonConfig, ack bool) error
+}
+
+type device struct {
+	lifxlan.Device
+}
+
+var _ Device = (*device)(nil)
+
+func (rd *device) String() string {
+	return lifxlan.FormatDevice(rd, "RelayDevice")
+}
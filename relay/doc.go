@@ -0,0 +1,7 @@
+// Package relay implements LIFX LAN Protocol for LIFX devices with relays,
+// e.g. LIFX Switch:
+//
+// https://lan.developer.lifx.com/docs/the-lifx-switch
+//
+// Please refer to its parent package for more background/context.
+package relay // import "go.yhsif.com/lifxlan/relay"
@@ -0,0 +1,35 @@
+package relay_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/relay"
+)
+
+// This example demonstrates how to toggle the first relay of a switch.
+func Example() {
+	// Need proper initialization on real code.
+	var (
+		device relay.Device
+		// Important to set timeout to context when requiring ack.
+		timeout time.Duration
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	power, err := device.GetRPower(ctx, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	target := lifxlan.PowerOn
+	if power.On() {
+		target = lifxlan.PowerOff
+	}
+	if err := device.SetRPower(ctx, nil, 0, target, true); err != nil {
+		log.Fatal(err)
+	}
+}
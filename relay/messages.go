@@ -0,0 +1,38 @@
+package relay
+
+import (
+	"encoding/binary"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Relay related MessageType values.
+const (
+	GetRPower   lifxlan.MessageType = 816
+	SetRPower   lifxlan.MessageType = 817
+	StateRPower lifxlan.MessageType = 818
+
+	GetButton         lifxlan.MessageType = 905
+	StateButton       lifxlan.MessageType = 907
+	GetButtonConfig   lifxlan.MessageType = 909
+	SetButtonConfig   lifxlan.MessageType = 910
+	StateButtonConfig lifxlan.MessageType = 911
+)
+
+func init() {
+	for msg, size := range map[lifxlan.MessageType]lifxlan.PayloadSize{
+		GetRPower:       {Name: "GetRPower", Size: binary.Size(RawGetRPowerPayload{})},
+		SetRPower:       {Name: "SetRPower", Size: binary.Size(RawRPowerPayload{})},
+		GetButton:       {Name: "GetButton", Size: 0},
+		GetButtonConfig: {Name: "GetButtonConfig", Size: 0},
+	} {
+		lifxlan.PayloadSizes[msg] = size
+	}
+	for msg, resp := range map[lifxlan.MessageType]lifxlan.MessageType{
+		GetRPower:       StateRPower,
+		GetButton:       StateButton,
+		GetButtonConfig: StateButtonConfig,
+	} {
+		lifxlan.ExpectedResponses[msg] = resp
+	}
+}
@@ -0,0 +1,202 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// NumRelays is the number of relays on a LIFX Switch,
+// addressed by relay index 0 to NumRelays-1.
+const NumRelays = 4
+
+// checkIndex returns an error prefixed by caller if index is out of range.
+func checkIndex(caller string, index uint8) error {
+	if index >= NumRelays {
+		return fmt.Errorf(
+			"lifxlan/relay.%s: relay index %d out of range [0, %d]",
+			caller,
+			index,
+			NumRelays-1,
+		)
+	}
+	return nil
+}
+
+// RawGetRPowerPayload defines the struct to be used for encoding and decoding.
+//
+// https://lan.developer.lifx.com/docs/querying-the-device-for-data#getrpower---packet-816
+type RawGetRPowerPayload struct {
+	RelayIndex uint8
+}
+
+// RawRPowerPayload defines the struct to be used for encoding and decoding.
+//
+// It's the payload of both SetRPower and StateRPower messages:
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setrpower---packet-817
+//
+// Level is a little endian uint16 the same as the power level of lights,
+// 0 for off and 65535 for on.
+type RawRPowerPayload struct {
+	RelayIndex uint8
+	Level      lifxlan.Power
+}
+
+// normalizePower maps any on power level to PowerOn,
+// as relays only accept 0 or 65535.
+func normalizePower(power lifxlan.Power) lifxlan.Power {
+	if power.On() {
+		return lifxlan.PowerOn
+	}
+	return lifxlan.PowerOff
+}
+
+func (rd *device) GetRPower(
+	ctx context.Context,
+	conn net.Conn,
+	index uint8,
+) (lifxlan.Power, error) {
+	if err := checkIndex("GetRPower", index); err != nil {
+		return 0, err
+	}
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return 0, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := rd.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetRPower,
+		&RawGetRPowerPayload{
+			RelayIndex: index,
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		rd.Source(),
+		seq,
+		StateRPower,
+		1, // count
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var raw RawRPowerPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return 0, err
+	}
+
+	return raw.Level, nil
+}
+
+func (rd *device) SetRPower(
+	ctx context.Context,
+	conn net.Conn,
+	index uint8,
+	power lifxlan.Power,
+	ack bool,
+) error {
+	if err := checkIndex("SetRPower", index); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := rd.Send(
+		ctx,
+		conn,
+		flags,
+		SetRPower,
+		&RawRPowerPayload{
+			RelayIndex: index,
+			Level:      normalizePower(power),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, rd.Source(), seq)
+	}
+	return nil
+}
+
+func (rd *device) AllRPower(ctx context.Context, conn net.Conn) ([]lifxlan.Power, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := rd.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	powers := make([]lifxlan.Power, NumRelays)
+	for i := range powers {
+		power, err := rd.GetRPower(ctx, conn, uint8(i))
+		if err != nil {
+			return nil, err
+		}
+		powers[i] = power
+	}
+	return powers, nil
+}
@@ -0,0 +1,251 @@
+package relay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/relay"
+)
+
+// relaysHandler returns a mock.HandlerFunc that answers GetRPower messages
+// with the power levels in relays.
+func relaysHandler(t *testing.T, lock *sync.Mutex, relays []lifxlan.Power) mock.HandlerFunc {
+	return func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var req relay.RawGetRPowerPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		if int(req.RelayIndex) >= len(relays) {
+			return
+		}
+		lock.Lock()
+		level := relays[req.RelayIndex]
+		lock.Unlock()
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, &relay.RawRPowerPayload{
+			RelayIndex: req.RelayIndex,
+			Level:      level,
+		}); err != nil {
+			t.Error(err)
+			return
+		}
+		s.Reply(conn, addr, orig, relay.StateRPower, buf.Bytes())
+	}
+}
+
+// setRelaysHandler returns a mock.HandlerFunc that stores the power levels
+// from SetRPower messages into relays.
+func setRelaysHandler(t *testing.T, lock *sync.Mutex, relays []lifxlan.Power) mock.HandlerFunc {
+	return func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw relay.RawRPowerPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		if int(raw.RelayIndex) >= len(relays) {
+			t.Errorf("Unexpected relay index %d", raw.RelayIndex)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		relays[raw.RelayIndex] = raw.Level
+	}
+}
+
+func wrapDevice(t *testing.T, device lifxlan.Device) relay.Device {
+	t.Helper()
+
+	const timeout = time.Millisecond * 200
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	rd, err := relay.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rd
+}
+
+func TestRPower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	var lock sync.Mutex
+	relays := []lifxlan.Power{
+		lifxlan.PowerOff,
+		lifxlan.PowerOn,
+		lifxlan.PowerOff,
+		lifxlan.PowerOff,
+	}
+	service.Handlers[relay.GetRPower] = relaysHandler(t, &lock, relays)
+	service.Handlers[relay.SetRPower] = setRelaysHandler(t, &lock, relays)
+
+	rd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	power, err := rd.GetRPower(ctx, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power != lifxlan.PowerOn {
+		t.Errorf("Relay 1 expected %v, got %v", lifxlan.PowerOn, power)
+	}
+
+	if err := rd.SetRPower(ctx, nil, 2, lifxlan.PowerOn, true); err != nil {
+		t.Fatal(err)
+	}
+	power, err = rd.GetRPower(ctx, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power != lifxlan.PowerOn {
+		t.Errorf("Relay 2 expected %v after SetRPower, got %v", lifxlan.PowerOn, power)
+	}
+
+	// Non-standard on levels are sent as PowerOn.
+	if err := rd.SetRPower(ctx, nil, 3, lifxlan.Power(1), true); err != nil {
+		t.Fatal(err)
+	}
+	lock.Lock()
+	level := relays[3]
+	lock.Unlock()
+	if level != lifxlan.PowerOn {
+		t.Errorf("Relay 3 level expected %d, got %d", lifxlan.PowerOn, level)
+	}
+}
+
+func TestRawRPowerPayload(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		data     []byte
+		expected lifxlan.Power
+	}{
+		{
+			label:    "Min",
+			data:     []byte{0x02, 0x00, 0x00},
+			expected: lifxlan.PowerOff,
+		},
+		{
+			label:    "Max",
+			data:     []byte{0x02, 0xff, 0xff},
+			expected: lifxlan.PowerOn,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var raw relay.RawRPowerPayload
+				if err := binary.Read(bytes.NewReader(c.data), binary.LittleEndian, &raw); err != nil {
+					t.Fatal(err)
+				}
+				if raw.RelayIndex != 2 {
+					t.Errorf("RelayIndex expected 2, got %d", raw.RelayIndex)
+				}
+				if raw.Level != c.expected {
+					t.Errorf("Level expected %d, got %d", c.expected, raw.Level)
+				}
+				if raw.Level.On() != c.expected.On() {
+					t.Errorf("On expected %v, got %v", c.expected.On(), raw.Level.On())
+				}
+
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, &raw); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), c.data) {
+					t.Errorf("Encoded payload expected %v, got %v", c.data, buf.Bytes())
+				}
+			},
+		)
+	}
+}
+
+func TestRPowerIndex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	var lock sync.Mutex
+	relays := make([]lifxlan.Power, relay.NumRelays)
+	service.Handlers[relay.GetRPower] = relaysHandler(t, &lock, relays)
+
+	rd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := rd.GetRPower(ctx, nil, relay.NumRelays); err == nil {
+		t.Error("Expected GetRPower with out of range index to fail")
+	}
+	if err := rd.SetRPower(ctx, nil, relay.NumRelays, lifxlan.PowerOn, true); err == nil {
+		t.Error("Expected SetRPower with out of range index to fail")
+	}
+}
+
+func TestAllRPower(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	var lock sync.Mutex
+	relays := []lifxlan.Power{
+		lifxlan.PowerOn,
+		lifxlan.PowerOff,
+		lifxlan.PowerOff,
+		lifxlan.PowerOn,
+	}
+	service.Handlers[relay.GetRPower] = relaysHandler(t, &lock, relays)
+
+	rd := wrapDevice(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	powers, err := rd.AllRPower(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(powers, relays) {
+		t.Errorf("AllRPower expected %v, got %v", relays, powers)
+	}
+}
@@ -0,0 +1,86 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Wrap tries to wrap a lifxlan.Device into a relay device.
+//
+// When force is false and d is already a relay device,
+// d will be casted and returned directly.
+// Otherwise, this function calls a relay device API (GetRPower on the first
+// relay),
+// and only returns a non-nil Device if it supports the API.
+//
+// If the device is not a relay device,
+// the function might block until ctx is cancelled.
+func Wrap(ctx context.Context, d lifxlan.Device, force bool) (Device, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if !force {
+		if t, ok := d.(Device); ok {
+			return t, nil
+		}
+	}
+
+	conn, err := d.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	const msg = GetRPower
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		msg,
+		&RawGetRPowerPayload{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, d.Source())
+	defer cancel()
+
+	for {
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, d.Source()) {
+				continue
+			}
+
+			switch resp.Message {
+			case StateRPower:
+				return &device{
+					Device: d,
+				}, nil
+
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				return nil, &lifxlan.UnhandledMessageError{
+					Type: raw.UnhandledType,
+				}
+			}
+		}
+	}
+}
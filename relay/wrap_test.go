@@ -0,0 +1,60 @@
+package relay_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/relay"
+)
+
+func TestWrap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	t.Run(
+		"Normal",
+		func(t *testing.T) {
+			var lock sync.Mutex
+			service.Handlers[relay.GetRPower] = relaysHandler(
+				t,
+				&lock,
+				[]lifxlan.Power{lifxlan.PowerOff},
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := relay.Wrap(ctx, device, false); err != nil {
+				t.Fatalf("Expected successful wrapping, got: %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"StateUnhandled",
+		func(t *testing.T) {
+			const msg = relay.GetRPower
+
+			service.Handlers[msg] = mock.StateUnhandledHandler(msg)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := relay.Wrap(ctx, device, false); err == nil {
+				t.Error("Expected Wrap to return error, got nil")
+			} else {
+				t.Logf("Got error: %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,240 @@
+package lifxlan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRedials is the default number of redials ReliableConn makes for a
+// single operation.
+const DefaultMaxRedials = 1
+
+// ErrReliableConnClosed is the error returned by ReliableConn after it's
+// closed.
+var ErrReliableConnClosed = errors.New("lifxlan.ReliableConn: connection closed")
+
+// ReliableConn is a net.Conn to a device that transparently redials the device
+// when the underlying connection goes stale
+// (e.g. after a Wi-Fi hiccup).
+//
+// This is different from retrying unacked messages (see SendWithRetry):
+// it recovers the transport itself when a read or write fails with a
+// non-timeout network error.
+//
+// Writes failed that way are retried on the new connection.
+// Reads cannot be retried as the response to the previous write would not
+// arrive on the new connection,
+// so a failed Read still redials for the next operation but returns the
+// original error.
+// Use Do to retry the whole request and response cycle instead:
+//
+//     rc, err := lifxlan.NewReliableConn(device)
+//     if err != nil {
+//       // handle error
+//     }
+//     defer rc.Close()
+//     err = rc.Do(ctx, func(conn net.Conn) error {
+//       return device.SetPower(ctx, conn, lifxlan.PowerOn, true)
+//     })
+//
+// ReliableConn is safe to be shared by multiple goroutines only the same way
+// as the connections returned by Device.Dial (e.g. with SyncConn).
+type ReliableConn struct {
+	// The maximum number of redials for a single operation.
+	//
+	// If MaxRedials <= 0, DefaultMaxRedials will be used.
+	MaxRedials int
+
+	dev Device
+
+	lock   sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+var _ net.Conn = (*ReliableConn)(nil)
+
+// NewReliableConn dials dev and wraps the connection into a ReliableConn.
+func NewReliableConn(dev Device) (*ReliableConn, error) {
+	conn, err := dev.Dial()
+	if err != nil {
+		return nil, err
+	}
+	return &ReliableConn{
+		dev:  dev,
+		conn: conn,
+	}, nil
+}
+
+func (rc *ReliableConn) maxRedials() int {
+	if rc.MaxRedials <= 0 {
+		return DefaultMaxRedials
+	}
+	return rc.MaxRedials
+}
+
+// isDeadConn returns true if err indicates that the connection is no longer
+// usable and should be redialed.
+//
+// Timeouts are expected on healthy connections when reading with deadlines,
+// so they are not considered dead.
+func isDeadConn(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && !opErr.Timeout()
+}
+
+func (rc *ReliableConn) current() net.Conn {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	return rc.conn
+}
+
+// redial replaces old with a newly dialed connection.
+//
+// If the connection was already replaced since old was used,
+// the current one is returned without dialing again.
+func (rc *ReliableConn) redial(old net.Conn) (net.Conn, error) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if rc.closed {
+		return nil, ErrReliableConnClosed
+	}
+	if rc.conn != old {
+		return rc.conn, nil
+	}
+	old.Close()
+	conn, err := rc.dev.Dial()
+	if err != nil {
+		return nil, err
+	}
+	debugf("ReliableConn: redialed %v", rc.dev)
+	rc.conn = conn
+	return conn, nil
+}
+
+// retry calls fn with the current connection,
+// and redials then calls fn again for up to MaxRedials times if it fails
+// because of a dead connection.
+func (rc *ReliableConn) retry(ctx context.Context, caller string, fn func(conn net.Conn) error) error {
+	conn := rc.current()
+	for redials := 0; ; redials++ {
+		err := fn(conn)
+		if err == nil || !isDeadConn(err) {
+			return err
+		}
+		if redials >= rc.maxRedials() {
+			return fmt.Errorf(
+				"lifxlan.ReliableConn.%s: giving up after %d redial(s): %w",
+				caller,
+				redials,
+				err,
+			)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		newConn, redialErr := rc.redial(conn)
+		if redialErr != nil {
+			return fmt.Errorf(
+				"lifxlan.ReliableConn.%s: redial after %v failed: %w",
+				caller,
+				err,
+				redialErr,
+			)
+		}
+		conn = newConn
+	}
+}
+
+// Do calls fn with the underlying connection,
+// and if fn fails because of a dead connection,
+// redials the device and calls fn again,
+// for up to MaxRedials times.
+//
+// If the redial fails,
+// the returned error wraps the error from Device.Dial.
+//
+// fn must not use the conn passed in after it returns.
+func (rc *ReliableConn) Do(ctx context.Context, fn func(conn net.Conn) error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return rc.retry(ctx, "Do", fn)
+}
+
+// Read implements net.Conn.
+//
+// If the read fails because of a dead connection,
+// the device is redialed for the next operation,
+// but the read itself is not retried.
+func (rc *ReliableConn) Read(b []byte) (int, error) {
+	conn := rc.current()
+	n, err := conn.Read(b)
+	if err != nil && isDeadConn(err) {
+		if _, redialErr := rc.redial(conn); redialErr != nil {
+			debugf("ReliableConn.Read: redial %v failed: %v", rc.dev, redialErr)
+		}
+	}
+	return n, err
+}
+
+// Write implements net.Conn.
+//
+// If the write fails because of a dead connection,
+// the device is redialed and the write is retried,
+// for up to MaxRedials times.
+func (rc *ReliableConn) Write(b []byte) (n int, err error) {
+	err = rc.retry(context.Background(), "Write", func(conn net.Conn) error {
+		var writeErr error
+		n, writeErr = conn.Write(b)
+		return writeErr
+	})
+	return
+}
+
+// Close implements net.Conn.
+//
+// It closes the current underlying connection,
+// and no more redials will happen after it's closed.
+func (rc *ReliableConn) Close() error {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	rc.closed = true
+	return rc.conn.Close()
+}
+
+// LocalAddr implements net.Conn.
+func (rc *ReliableConn) LocalAddr() net.Addr {
+	return rc.current().LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (rc *ReliableConn) RemoteAddr() net.Addr {
+	return rc.current().RemoteAddr()
+}
+
+// SetDeadline implements net.Conn.
+//
+// The deadline only applies to the current underlying connection.
+func (rc *ReliableConn) SetDeadline(t time.Time) error {
+	return rc.current().SetDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+//
+// The deadline only applies to the current underlying connection.
+func (rc *ReliableConn) SetReadDeadline(t time.Time) error {
+	return rc.current().SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn.
+//
+// The deadline only applies to the current underlying connection.
+func (rc *ReliableConn) SetWriteDeadline(t time.Time) error {
+	return rc.current().SetWriteDeadline(t)
+}
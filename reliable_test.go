@@ -0,0 +1,164 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+// dialRecorder wraps a lifxlan.Device to record the connections it dials,
+// and optionally fail the dials after the first one.
+type dialRecorder struct {
+	lifxlan.Device
+
+	lock  sync.Mutex
+	conns []net.Conn
+	fail  error
+}
+
+func (d *dialRecorder) Dial() (net.Conn, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.conns) > 0 && d.fail != nil {
+		return nil, d.fail
+	}
+	conn, err := d.Device.Dial()
+	if err != nil {
+		return nil, err
+	}
+	d.conns = append(d.conns, conn)
+	return conn, nil
+}
+
+func (d *dialRecorder) dials() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return len(d.conns)
+}
+
+// breakFirst closes the first connection dialed to simulate a dead socket.
+func (d *dialRecorder) breakFirst(t *testing.T) {
+	t.Helper()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(d.conns) == 0 {
+		t.Fatal("No connection dialed")
+	}
+	d.conns[0].Close()
+}
+
+func TestReliableConn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+
+	t.Run(
+		"Write",
+		func(t *testing.T) {
+			dev := &dialRecorder{Device: device}
+			rc, err := lifxlan.NewReliableConn(dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			dev.breakFirst(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.SetPower(ctx, rc, lifxlan.PowerOn, true); err != nil {
+				t.Fatal(err)
+			}
+			if got := dev.dials(); got != 2 {
+				t.Errorf("Expected 2 dials, got %d", got)
+			}
+		},
+	)
+
+	t.Run(
+		"Do",
+		func(t *testing.T) {
+			dev := &dialRecorder{Device: device}
+			rc, err := lifxlan.NewReliableConn(dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			dev.breakFirst(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			var calls int
+			if err := rc.Do(ctx, func(conn net.Conn) error {
+				calls++
+				return device.SetPower(ctx, conn, lifxlan.PowerOn, true)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if calls != 2 {
+				t.Errorf("Expected fn to be called 2 times, got %d", calls)
+			}
+			if got := dev.dials(); got != 2 {
+				t.Errorf("Expected 2 dials, got %d", got)
+			}
+		},
+	)
+
+	t.Run(
+		"RedialFailed",
+		func(t *testing.T) {
+			dialErr := errors.New("no route")
+			dev := &dialRecorder{
+				Device: device,
+				fail:   dialErr,
+			}
+			rc, err := lifxlan.NewReliableConn(dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			dev.breakFirst(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			err = rc.Do(ctx, func(conn net.Conn) error {
+				return device.SetPower(ctx, conn, lifxlan.PowerOn, true)
+			})
+			if !errors.Is(err, dialErr) {
+				t.Errorf("Expected error wrapping %v, got %v", dialErr, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Closed",
+		func(t *testing.T) {
+			dev := &dialRecorder{Device: device}
+			rc, err := lifxlan.NewReliableConn(dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc.Close()
+
+			if _, err := rc.Write([]byte{0}); !errors.Is(err, lifxlan.ErrReliableConnClosed) {
+				t.Errorf("Expected ErrReliableConnClosed, got %v", err)
+			}
+			if got := dev.dials(); got != 1 {
+				t.Errorf("Expected no redials after Close, got %d dials", got)
+			}
+		},
+	)
+}
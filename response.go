@@ -20,6 +20,19 @@ type Response struct {
 }
 
 // ParseResponse parses the response received from a lifxlan device.
+//
+// It returns an error if msg is shorter than HeaderLength,
+// the size field of the header doesn't match len(msg),
+// or the protocol number of the header is not Protocol,
+// so unrelated UDP packets (e.g. mDNS or SSDP broadcasts received by the
+// discovery socket) are rejected instead of partially parsed.
+//
+// StateUnhandled responses are returned as-is (after validating the payload
+// size),
+// WaitForAcks and WaitForResponses will turn them into
+// *UnhandledMessageError when the source and sequence match.
+//
+// See ParseHeader if you only need the header without any validations.
 func ParseResponse(msg []byte) (*Response, error) {
 	if len(msg) < int(HeaderLength) {
 		return nil, fmt.Errorf(
@@ -36,11 +49,18 @@ func ParseResponse(msg []byte) (*Response, error) {
 	}
 	if len(msg) != int(d.Size) {
 		return nil, fmt.Errorf(
-			"lifxlan.ParseResponse: response size mismatch: %d != %d",
+			"lifxlan.ParseResponse: response size mismatch: buffer has %d bytes, header size field is %d",
 			len(msg),
 			d.Size,
 		)
 	}
+	if protocol := d.Tagged & (1<<12 - 1); protocol != Protocol {
+		return nil, fmt.Errorf(
+			"lifxlan.ParseResponse: unexpected protocol number %d != %d",
+			protocol,
+			Protocol,
+		)
+	}
 
 	payload, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -56,39 +76,176 @@ func ParseResponse(msg []byte) (*Response, error) {
 	}
 
 	if resp.Message == StateUnhandled {
-		var raw RawStateUnhandledPayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return nil, err
+		if len(resp.Payload) < binary.Size(RawStateUnhandledPayload{}) {
+			return nil, fmt.Errorf(
+				"lifxlan.ParseResponse: StateUnhandled payload size not enough: %d",
+				len(resp.Payload),
+			)
 		}
-		return nil, raw
 	}
 	return resp, nil
 }
 
+type readBufferSizeKey struct{}
+
+// WithReadBufferSize returns a copy of ctx that carries size as the read buffer
+// size used by ReadNextResponse (and therefore WaitForAcks, WaitForResponses
+// and all the device getters), Discover and Watch.
+//
+// When it's not set or size <= 0, ResponseReadBufferSize will be used instead.
+// Responses bigger than the read buffer size will be truncated and fail to
+// parse,
+// see the doc of ResponseReadBufferSize for the minimum safe sizes.
+func WithReadBufferSize(ctx context.Context, size int) context.Context {
+	return context.WithValue(ctx, readBufferSizeKey{}, size)
+}
+
+// WithMinReadBufferSize is the same as WithReadBufferSize,
+// except that it returns ctx as-is if ReadBufferSize(ctx) is already at least
+// size.
+//
+// It's used by device API implementations to make sure that the read buffer
+// is big enough for the responses they expect.
+func WithMinReadBufferSize(ctx context.Context, size int) context.Context {
+	if ReadBufferSize(ctx) >= size {
+		return ctx
+	}
+	return WithReadBufferSize(ctx, size)
+}
+
+// ReadBufferSize returns the read buffer size set via WithReadBufferSize on ctx,
+// or ResponseReadBufferSize if it's not set.
+func ReadBufferSize(ctx context.Context) int {
+	if size, ok := ctx.Value(readBufferSizeKey{}).(int); ok && size > 0 {
+		return size
+	}
+	return ResponseReadBufferSize
+}
+
+// ParseResponses parses a buffer that contains one or more responses
+// concatenated together,
+// using the size field in the header of each response to split them.
+//
+// Each response is parsed by ParseResponse.
+// On error, the responses parsed before the bad one are also returned.
+func ParseResponses(msg []byte) ([]*Response, error) {
+	var responses []*Response
+	for {
+		if len(msg) < int(HeaderLength) {
+			return responses, fmt.Errorf(
+				"lifxlan.ParseResponses: response size not enough: %d < %d",
+				len(msg),
+				HeaderLength,
+			)
+		}
+		size := int(binary.LittleEndian.Uint16(msg))
+		if size < int(HeaderLength) || size > len(msg) {
+			return responses, fmt.Errorf(
+				"lifxlan.ParseResponses: invalid response size %d with %d bytes left",
+				size,
+				len(msg),
+			)
+		}
+		resp, err := ParseResponse(msg[:size])
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, resp)
+		msg = msg[size:]
+		if len(msg) == 0 {
+			return responses, nil
+		}
+	}
+}
+
 // ReadNextResponse returns the next received response.
 //
 // It handles read buffer, deadline, context cancellation check,
 // and response parsing.
+//
+// The size of the read buffer is ReadBufferSize(ctx).
+//
+// If a single read contains multiple responses,
+// only the first one is returned and the rest are dropped.
+// Use ReadNextResponses instead to handle all of them.
 func ReadNextResponse(ctx context.Context, conn net.Conn) (*Response, error) {
-	buf := make([]byte, ResponseReadBufferSize)
+	buf, err := readNext(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	n := len(buf)
+	if n >= 2 {
+		if size := int(binary.LittleEndian.Uint16(buf)); size >= int(HeaderLength) && size < n {
+			debugf(
+				"ReadNextResponse: dropped %d bytes after the first response from %v",
+				n-size,
+				conn.RemoteAddr(),
+			)
+			buf = buf[:size]
+		}
+	}
+	resp, err := ParseResponse(buf)
+	return resp, wrapTruncated(ctx, n, err)
+}
+
+// ReadNextResponses is the same as ReadNextResponse,
+// except that it returns all the responses contained in the next read,
+// for transports that coalesce multiple datagrams into a single read.
+//
+// The returned slice always has at least one response when the error is nil.
+func ReadNextResponses(ctx context.Context, conn net.Conn) ([]*Response, error) {
+	buf, err := readNext(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := ParseResponses(buf)
+	if err != nil && len(responses) > 0 {
+		// Still return the good ones.
+		debugf(
+			"ReadNextResponses: dropped the rest of the read from %v: %v",
+			conn.RemoteAddr(),
+			err,
+		)
+		return responses, nil
+	}
+	return responses, wrapTruncated(ctx, len(buf), err)
+}
+
+// readNext reads the next buffer from conn.
+func readNext(ctx context.Context, conn net.Conn) ([]byte, error) {
+	buf := make([]byte, ReadBufferSize(ctx))
 	for {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 
-		if err := conn.SetReadDeadline(GetReadDeadline()); err != nil {
+		if err := conn.SetReadDeadline(GetReadDeadlineContext(ctx)); err != nil {
 			return nil, err
 		}
 
+		stop := interruptReadOnDone(ctx, conn)
 		n, err := conn.Read(buf)
+		stop()
 		if err != nil {
 			if CheckTimeoutError(err) {
+				debugf("ReadNextResponse: read timeout from %v", conn.RemoteAddr())
 				continue
 			}
 			return nil, err
 		}
+		return buf[:n], nil
+	}
+}
 
-		return ParseResponse(buf[:n])
+// wrapTruncated wraps the parse error of a read of n bytes if the read
+// possibly got truncated by the read buffer.
+func wrapTruncated(ctx context.Context, n int, err error) error {
+	if size := ReadBufferSize(ctx); err != nil && n == size {
+		return fmt.Errorf(
+			"lifxlan.ReadNextResponse: response possibly truncated by read buffer size %d: %w",
+			size,
+			err,
+		)
 	}
+	return err
 }
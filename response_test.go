@@ -0,0 +1,264 @@
+package lifxlan_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestReadBufferSize(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		ctx      context.Context
+		expected int
+	}{
+		{
+			label:    "Default",
+			ctx:      context.Background(),
+			expected: lifxlan.ResponseReadBufferSize,
+		},
+		{
+			label:    "Set",
+			ctx:      lifxlan.WithReadBufferSize(context.Background(), 128),
+			expected: 128,
+		},
+		{
+			label:    "Zero",
+			ctx:      lifxlan.WithReadBufferSize(context.Background(), 0),
+			expected: lifxlan.ResponseReadBufferSize,
+		},
+		{
+			label: "MinRaise",
+			ctx: lifxlan.WithMinReadBufferSize(
+				lifxlan.WithReadBufferSize(context.Background(), 128),
+				1024,
+			),
+			expected: 1024,
+		},
+		{
+			label: "MinKeep",
+			ctx: lifxlan.WithMinReadBufferSize(
+				lifxlan.WithReadBufferSize(context.Background(), 128),
+				64,
+			),
+			expected: 128,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if actual := lifxlan.ReadBufferSize(c.ctx); actual != c.expected {
+					t.Errorf("ReadBufferSize expected %d, got %d", c.expected, actual)
+				}
+			},
+		)
+	}
+}
+
+func TestReadNextResponseTruncated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePowerPayload = &lifxlan.RawStatePowerPayload{}
+
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// StatePower has a 2-byte payload.
+	ctx = lifxlan.WithReadBufferSize(ctx, lifxlan.HeaderLength+1)
+
+	if _, err := device.GetPower(ctx, conn); err == nil {
+		t.Error("Expected error with truncated response")
+	} else if !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("Expected truncated error, got %v", err)
+	}
+}
+
+// concatMessages generates the messages with the given sequences and
+// concatenates them together.
+func concatMessages(
+	t *testing.T,
+	source uint32,
+	message lifxlan.MessageType,
+	payload []byte,
+	sequences ...uint8,
+) []byte {
+	t.Helper()
+	var buf []byte
+	for _, seq := range sequences {
+		msg, err := lifxlan.GenerateMessage(
+			lifxlan.NotTagged,
+			source,
+			lifxlan.AllDevices,
+			0, // flags
+			seq,
+			message,
+			payload,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf = append(buf, msg...)
+	}
+	return buf
+}
+
+func TestParseResponses(t *testing.T) {
+	const source = 1234
+
+	t.Run(
+		"Multiple",
+		func(t *testing.T) {
+			buf := concatMessages(t, source, lifxlan.StatePower, []byte{0xff, 0xff}, 1, 2, 3)
+			responses, err := lifxlan.ParseResponses(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != 3 {
+				t.Fatalf("Expected 3 responses, got %d", len(responses))
+			}
+			for i, resp := range responses {
+				if resp.Sequence != uint8(i+1) {
+					t.Errorf("responses[%d].Sequence expected %d, got %d", i, i+1, resp.Sequence)
+				}
+				if resp.Message != lifxlan.StatePower {
+					t.Errorf("responses[%d].Message expected %v, got %v", i, lifxlan.StatePower, resp.Message)
+				}
+				if len(resp.Payload) != 2 {
+					t.Errorf("responses[%d].Payload expected 2 bytes, got %v", i, resp.Payload)
+				}
+			}
+		},
+	)
+
+	for _, c := range []struct {
+		label    string
+		buf      []byte
+		expected int
+	}{
+		{
+			label:    "Empty",
+			buf:      nil,
+			expected: 0,
+		},
+		{
+			label:    "TrailingBytes",
+			buf:      append(concatMessages(t, source, lifxlan.Acknowledgement, nil, 1), 0, 0),
+			expected: 1,
+		},
+		{
+			label: "Truncated",
+			buf: func() []byte {
+				buf := concatMessages(t, source, lifxlan.StatePower, []byte{0, 0}, 1, 2)
+				return buf[:len(buf)-1]
+			}(),
+			expected: 1,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				responses, err := lifxlan.ParseResponses(c.buf)
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				if len(responses) != c.expected {
+					t.Errorf("Expected %d responses before the error, got %d", c.expected, len(responses))
+				}
+			},
+		)
+	}
+}
+
+func TestReadNextResponsesCoalesced(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 200
+		source  = 1234
+	)
+
+	// net.Pipe delivers a single Write as a single Read when the read buffer is
+	// big enough, which emulates coalesced datagrams.
+	coalesced := func(t *testing.T, buf []byte) net.Conn {
+		t.Helper()
+		client, server := net.Pipe()
+		t.Cleanup(func() {
+			client.Close()
+			server.Close()
+		})
+		go func() {
+			server.Write(buf)
+		}()
+		return client
+	}
+
+	t.Run(
+		"WaitForAcks",
+		func(t *testing.T) {
+			conn := coalesced(t, concatMessages(t, source, lifxlan.Acknowledgement, nil, 1, 2))
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := lifxlan.WaitForAcks(ctx, conn, source, 1, 2); err != nil {
+				t.Fatal(err)
+			}
+		},
+	)
+
+	t.Run(
+		"WaitForResponses",
+		func(t *testing.T) {
+			conn := coalesced(t, concatMessages(t, source, lifxlan.StatePower, []byte{0, 0}, 1, 1, 1))
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			responses, err := lifxlan.WaitForResponses(ctx, conn, source, 1, lifxlan.StatePower, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != 2 {
+				t.Errorf("Expected 2 responses, got %d", len(responses))
+			}
+		},
+	)
+
+	t.Run(
+		"ReadNextResponse",
+		func(t *testing.T) {
+			conn := coalesced(t, concatMessages(t, source, lifxlan.Acknowledgement, nil, 1, 2))
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			resp, err := lifxlan.ReadNextResponse(ctx, conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Sequence != 1 {
+				t.Errorf("Expected the first response, got sequence %d", resp.Sequence)
+			}
+		},
+	)
+}
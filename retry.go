@@ -0,0 +1,272 @@
+package lifxlan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// Default values used by RetryOptions when the fields are not set.
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = time.Millisecond * 100
+)
+
+// RetryOptions defines the options used by SendWithRetry.
+type RetryOptions struct {
+	// The max number of times the message will be sent,
+	// including the first attempt.
+	//
+	// If it's <= 0, DefaultRetryMaxAttempts will be used.
+	MaxAttempts int
+
+	// How long to wait for the ack of the first attempt before retrying.
+	// It doubles after every attempt, with a random jitter of up to 50% added.
+	//
+	// If it's <= 0, DefaultRetryInitialBackoff will be used.
+	InitialBackoff time.Duration
+
+	// If MaxBackoff > 0, the backoff will be capped at it (before jitter).
+	MaxBackoff time.Duration
+}
+
+// withDefaults returns a copy of opts with the fields not set filled from
+// defaults.
+func (opts RetryOptions) withDefaults(defaults RetryOptions) RetryOptions {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaults.InitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	return opts
+}
+
+func (opts RetryOptions) maxAttempts() int {
+	if opts.MaxAttempts <= 0 {
+		return DefaultRetryMaxAttempts
+	}
+	return opts.MaxAttempts
+}
+
+func (opts RetryOptions) initialBackoff() time.Duration {
+	if opts.InitialBackoff <= 0 {
+		return DefaultRetryInitialBackoff
+	}
+	return opts.InitialBackoff
+}
+
+func (opts RetryOptions) nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+		backoff = opts.MaxBackoff
+	}
+	return backoff
+}
+
+// jitter adds a random jitter of up to 50% to d.
+func jitter(d time.Duration) time.Duration {
+	if d < 2 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// SendWithRetry sends a message to the device,
+// and resends it when the ack is not received in time.
+//
+// If FlagAckRequired is not set in flags,
+// the message will only be sent once without waiting for acks.
+// Otherwise the message will be resent with exponential backoff,
+// up to opts.MaxAttempts times in total,
+// until the ack is received or ctx is cancelled.
+// All attempts use the same sequence number,
+// so the device can dedup the resent messages.
+//
+// The fields not set in opts are taken from the Retry of the Policy of dev
+// (see Device.SetPolicy).
+//
+// payload should be the already encoded payload (or nil).
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// If this function returns an error,
+// the error would be of type *SendWithRetryError.
+func SendWithRetry(
+	ctx context.Context,
+	conn net.Conn,
+	dev Device,
+	flags AckResFlag,
+	msg MessageType,
+	payload []byte,
+	opts RetryOptions,
+) error {
+	e := new(SendWithRetryError)
+
+	if ctx.Err() != nil {
+		e.Cause = ctx.Err()
+		return e
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			e.Cause = err
+			return e
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			e.Cause = ctx.Err()
+			return e
+		}
+	}
+
+	if err := validatePayloadSize("lifxlan.SendWithRetry", msg, payload); err != nil {
+		e.Cause = err
+		return e
+	}
+	seq := dev.NextSequence()
+	source := SourceFromContext(ctx, dev.Source())
+	data, err := GenerateMessage(
+		taggedFromContext(ctx, dev.Target()),
+		source,
+		dev.Target(),
+		flags,
+		seq,
+		msg,
+		payload,
+	)
+	if err != nil {
+		e.Cause = err
+		return e
+	}
+
+	opts = opts.withDefaults(sourcePolicy(dev.Source()).Retry)
+	max := opts.maxAttempts()
+	backoff := opts.initialBackoff()
+	for {
+		if err := waitForRateLimit(ctx, conn, dev.Source()); err != nil {
+			e.Cause = err
+			return e
+		}
+		e.Attempts++
+		n, err := conn.Write(data)
+		if err != nil {
+			e.Cause = err
+			return e
+		}
+		recordSent(source, seq)
+		if m := MetricsRecorder; m != nil {
+			m.IncSend(msg)
+		}
+		debugf(
+			"sent %v to %v: source=%d sequence=%d flags=%d attempt=%d",
+			msg,
+			dev.Target(),
+			source,
+			seq,
+			flags,
+			e.Attempts,
+		)
+		if n < len(data) {
+			e.Cause = fmt.Errorf("only wrote %d out of %d bytes", n, len(data))
+			return e
+		}
+
+		if flags&FlagAckRequired == 0 {
+			return nil
+		}
+
+		start := time.Now()
+		attemptCtx, cancel := context.WithTimeout(ctx, jitter(backoff))
+		err = WaitForAcks(attemptCtx, conn, dev.Source(), seq)
+		cancel()
+		if err == nil {
+			if m := MetricsRecorder; m != nil {
+				m.ObserveRTT(time.Since(start))
+			}
+			return nil
+		}
+		e.Cause = err
+		if ctx.Err() != nil {
+			return e
+		}
+		if e.Attempts >= max {
+			e.Offline = isAckTimeout(err)
+			return e
+		}
+		if m := MetricsRecorder; m != nil {
+			m.IncRetry()
+		}
+		backoff = opts.nextBackoff(backoff)
+	}
+}
+
+// SendWithRetryError defines the error returned by SendWithRetry.
+type SendWithRetryError struct {
+	// The number of attempts made.
+	Attempts int
+	// The error of the last attempt.
+	Cause error
+
+	// Offline is true when all the attempts timed out without any ack,
+	// while ctx was still not cancelled (see IsOffline).
+	Offline bool
+}
+
+var _ error = (*SendWithRetryError)(nil)
+
+func (e *SendWithRetryError) Error() string {
+	return fmt.Sprintf(
+		"lifxlan.SendWithRetry: failed after %d attempt(s): %v",
+		e.Attempts,
+		e.Cause,
+	)
+}
+
+// Unwrap returns the underlying error.
+func (e *SendWithRetryError) Unwrap() error {
+	return e.Cause
+}
+
+// isAckTimeout returns true if err from WaitForAcks is caused by the timeout
+// without receiving all the acks.
+func isAckTimeout(err error) bool {
+	var e *WaitForAcksError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return errors.Is(e.Cause, context.DeadlineExceeded) || CheckTimeoutError(e.Cause)
+}
+
+// IsOffline returns true if err means that the device is most likely offline
+// (e.g. powered off at the wall),
+// instead of a transient drop on the network.
+//
+// A single timeout cannot tell the two apart,
+// so it only recognizes the errors from SendWithRetry after all the attempts
+// timed out without any ack (see SendWithRetryError.Offline).
+// Errors caused by cancelling ctx,
+// or responses from the device (e.g. *UnhandledMessageError),
+// are not offline.
+//
+// It can be used to mark the device offline in a UI instead of reporting the
+// error, e.g.:
+//
+//     err := lifxlan.SendWithRetry(ctx, conn, dev, lifxlan.FlagAckRequired, msg, payload, opts)
+//     if lifxlan.IsOffline(err) {
+//       // mark dev offline
+//     }
+func IsOffline(err error) bool {
+	var e *SendWithRetryError
+	return errors.As(err, &e) && e.Offline
+}
@@ -0,0 +1,214 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestSendWithRetry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 500
+
+	opts := lifxlan.RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 20,
+	}
+
+	for _, c := range []struct {
+		label    string
+		flags    lifxlan.AckResFlag
+		drop     int
+		attempts int
+		err      bool
+	}{
+		{
+			label:    "NoAck",
+			flags:    0,
+			drop:     opts.MaxAttempts,
+			attempts: 1,
+		},
+		{
+			label:    "FirstAttempt",
+			flags:    lifxlan.FlagAckRequired,
+			attempts: 1,
+		},
+		{
+			label:    "Retried",
+			flags:    lifxlan.FlagAckRequired,
+			drop:     opts.MaxAttempts - 1,
+			attempts: opts.MaxAttempts,
+		},
+		{
+			label:    "Failed",
+			flags:    lifxlan.FlagAckRequired,
+			drop:     opts.MaxAttempts,
+			attempts: opts.MaxAttempts,
+			err:      true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				service, device := mock.StartService(t)
+				defer service.Stop()
+				service.AcksToDrop = c.drop
+
+				received := make(chan uint8, opts.MaxAttempts+1)
+				service.Handlers[lifxlan.SetPower] = func(
+					_ *mock.Service,
+					_ net.PacketConn,
+					_ net.Addr,
+					orig *lifxlan.Response,
+				) {
+					received <- orig.Sequence
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				err := lifxlan.SendWithRetry(
+					ctx,
+					nil, // conn
+					device,
+					c.flags,
+					lifxlan.SetPower,
+					[]byte{0xff, 0xff},
+					opts,
+				)
+				if c.err {
+					var e *lifxlan.SendWithRetryError
+					if !errors.As(err, &e) {
+						t.Fatalf("Expected *SendWithRetryError, got %v", err)
+					}
+					if e.Attempts != c.attempts {
+						t.Errorf("Attempts expected %d, got %d", c.attempts, e.Attempts)
+					}
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				if c.flags == 0 {
+					// Wait for the message to reach the mock service.
+					time.Sleep(time.Millisecond * 10)
+				}
+				seqs := make([]uint8, 0, len(received))
+				for n := len(received); n > 0; n-- {
+					seqs = append(seqs, <-received)
+				}
+				if len(seqs) != c.attempts {
+					t.Errorf("Expected %d messages received, got %d", c.attempts, len(seqs))
+				}
+				for _, seq := range seqs {
+					if seq != seqs[0] {
+						t.Errorf("Expected all attempts to use the same sequence, got %v", seqs)
+						break
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestIsOffline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 500
+
+	opts := lifxlan.RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 20,
+	}
+
+	t.Run(
+		"NeverResponding",
+		func(t *testing.T) {
+			service := &mock.Service{
+				TB: t,
+				// Never ack.
+				HandleAcks: false,
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			err := lifxlan.SendWithRetry(ctx, nil, device, lifxlan.FlagAckRequired, lifxlan.SetPower, []byte{0xff, 0xff}, opts)
+			if !lifxlan.IsOffline(err) {
+				t.Errorf("Expected offline error, got %v", err)
+			}
+			var e *lifxlan.SendWithRetryError
+			if !errors.As(err, &e) || e.Attempts != opts.MaxAttempts {
+				t.Errorf("Expected %d attempts, got %v", opts.MaxAttempts, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			service := &mock.Service{
+				TB:         t,
+				HandleAcks: false,
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			// The ctx times out before all the attempts are made.
+			ctx, cancel := context.WithTimeout(context.Background(), opts.InitialBackoff)
+			defer cancel()
+
+			err := lifxlan.SendWithRetry(ctx, nil, device, lifxlan.FlagAckRequired, lifxlan.SetPower, []byte{0xff, 0xff}, opts)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if lifxlan.IsOffline(err) {
+				t.Errorf("Expected not offline error, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Unhandled",
+		func(t *testing.T) {
+			service := &mock.Service{
+				TB: t,
+				Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+					lifxlan.SetPower: mock.StateUnhandledHandler(lifxlan.SetPower),
+				},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			err := lifxlan.SendWithRetry(ctx, nil, device, lifxlan.FlagAckRequired, lifxlan.SetPower, []byte{0xff, 0xff}, opts)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if lifxlan.IsOffline(err) {
+				t.Errorf("Expected not offline error, got %v", err)
+			}
+		},
+	)
+
+	if lifxlan.IsOffline(nil) {
+		t.Error("Expected nil error to be not offline")
+	}
+	if lifxlan.IsOffline(context.DeadlineExceeded) {
+		t.Error("Expected a plain timeout to be not offline")
+	}
+}
@@ -0,0 +1,7 @@
+// Package scene implements helpers to capture and restore the visible state
+// of LIFX devices,
+// to apply desired states to multiple devices together,
+// and to observe state changes made by other clients via polling.
+//
+// Please refer to its parent package for more background/context.
+package scene // import "go.yhsif.com/lifxlan/scene"
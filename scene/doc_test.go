@@ -0,0 +1,42 @@
+package scene_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/scene"
+)
+
+// This example demonstrates how to flash a light red then restore it.
+func Example() {
+	// Need proper initialization on real code.
+	var (
+		device light.Device
+		// Important to set timeout to context when requiring ack.
+		timeout time.Duration
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state, err := scene.Snapshot(ctx, nil, device)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	red := lifxlan.RGBToHSBK(255, 0, 0)
+	if err := device.SetColor(ctx, nil, &red, 0, true); err != nil {
+		log.Fatal(err)
+	}
+	if err := device.SetPower(ctx, nil, lifxlan.PowerOn, true); err != nil {
+		log.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	if err := state.Restore(ctx, nil); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// SceneStateVersion is the current version of the SceneState format.
+//
+// It will be bumped when the format changes in a way that older versions of
+// this package cannot read.
+// Files with a version newer than this are rejected by SceneState.UnmarshalJSON.
+const SceneStateVersion = 1
+
+// StateType is the type discriminator of a SceneState.
+type StateType string
+
+// StateType values.
+const (
+	StateTypeDevice    StateType = "device"
+	StateTypeLight     StateType = "light"
+	StateTypeMultiZone StateType = "multizone"
+	StateTypeTile      StateType = "tile"
+)
+
+func (t StateType) valid() bool {
+	switch t {
+	default:
+		return false
+	case StateTypeDevice, StateTypeLight, StateTypeMultiZone, StateTypeTile:
+		return true
+	}
+}
+
+// SceneState is the portable, versioned JSON representation of a DeviceState,
+// used to share captured states between installs.
+//
+// Unlike DeviceState,
+// it doesn't hold the Device itself but only its target,
+// so it needs to be converted back via DeviceState with a discovered device
+// before restoring.
+//
+// Example:
+//
+//     // Export
+//     var states []*scene.SceneState
+//     for _, s := range snapshots {
+//       states = append(states, s.Export())
+//     }
+//     if err := json.NewEncoder(w).Encode(states); err != nil {
+//       // handle error
+//     }
+//
+//     // Import
+//     var states []*scene.SceneState
+//     if err := json.NewDecoder(r).Decode(&states); err != nil {
+//       // handle error
+//     }
+//     for _, s := range states {
+//       state, err := s.DeviceState(devices[s.Target])
+//       // ...
+//     }
+type SceneState struct {
+	Version  int             `json:"version"`
+	Type     StateType       `json:"type"`
+	Target   lifxlan.Target  `json:"target"`
+	Power    lifxlan.Power   `json:"power"`
+	Color    *lifxlan.Color  `json:"color,omitempty"`
+	Zones    []lifxlan.Color `json:"zones,omitempty"`
+	Board    tile.ColorBoard `json:"board,omitempty"`
+	Infrared *uint16         `json:"infrared,omitempty"`
+}
+
+// sceneStateJSON is used to decode SceneState without recursion.
+type sceneStateJSON SceneState
+
+var _ json.Unmarshaler = (*SceneState)(nil)
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It returns an error if the version is missing or newer than
+// SceneStateVersion, or the type is unknown,
+// and leaves ss unchanged in those cases.
+func (ss *SceneState) UnmarshalJSON(data []byte) error {
+	var raw sceneStateJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Version < 1 || raw.Version > SceneStateVersion {
+		return fmt.Errorf(
+			"lifxlan/scene.SceneState.UnmarshalJSON: unsupported version %d, supported up to %d",
+			raw.Version,
+			SceneStateVersion,
+		)
+	}
+	if !raw.Type.valid() {
+		return fmt.Errorf(
+			"lifxlan/scene.SceneState.UnmarshalJSON: unknown type %q",
+			raw.Type,
+		)
+	}
+	*ss = SceneState(raw)
+	return nil
+}
+
+// Export converts the captured state into its portable form.
+//
+// The type is determined by the type of the device the state was captured
+// from, the same way as Snapshot.
+func (s *DeviceState) Export() *SceneState {
+	ss := &SceneState{
+		Version: SceneStateVersion,
+		Type:    StateTypeDevice,
+		Target:  s.Device.Target(),
+		Power:   s.Power,
+	}
+	switch s.Device.(type) {
+	case tile.Device:
+		ss.Type = StateTypeTile
+		ss.Board = s.Board
+	case multizone.Device:
+		ss.Type = StateTypeMultiZone
+		ss.Zones = s.Zones
+	case light.Device:
+		ss.Type = StateTypeLight
+		ss.Color = s.Color
+		ss.Infrared = s.Infrared
+	}
+	return ss
+}
+
+// DeviceState converts the portable state back into a DeviceState that can be
+// restored to dev.
+//
+// dev should already be wrapped into the device type matching the type of the
+// state (e.g. via auto.Wrap),
+// otherwise an error is returned and nothing will be restored.
+// Any device can restore a state of StateTypeDevice.
+// The target of dev is not required to match the target of the state,
+// so a state can also be imported to a different device of the same type.
+func (ss *SceneState) DeviceState(dev lifxlan.Device) (*DeviceState, error) {
+	if dev == nil {
+		return nil, fmt.Errorf(
+			"lifxlan/scene.SceneState.DeviceState: no device for %v",
+			ss.Target,
+		)
+	}
+
+	var ok bool
+	switch ss.Type {
+	case StateTypeDevice:
+		ok = true
+	case StateTypeLight:
+		_, ok = dev.(light.Device)
+	case StateTypeMultiZone:
+		_, ok = dev.(multizone.Device)
+	case StateTypeTile:
+		_, ok = dev.(tile.Device)
+	}
+	if !ok {
+		return nil, fmt.Errorf(
+			"lifxlan/scene.SceneState.DeviceState: %v cannot restore %q state",
+			dev,
+			ss.Type,
+		)
+	}
+
+	state := &DeviceState{
+		Device: dev,
+		Power:  ss.Power,
+	}
+	switch ss.Type {
+	case StateTypeLight:
+		state.Color = ss.Color
+		state.Infrared = ss.Infrared
+	case StateTypeMultiZone:
+		state.Zones = ss.Zones
+	case StateTypeTile:
+		state.Board = ss.Board
+	}
+	return state, nil
+}
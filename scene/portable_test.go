@@ -0,0 +1,168 @@
+package scene_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/scene"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestSceneStateTileRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	rawState := &tile.RawStateTileState64Payload{
+		Width: 8,
+	}
+	for i := range rawState.Colors {
+		rawState.Colors[i] = lifxlan.Color{
+			Hue:        uint16(i) * 1000,
+			Saturation: 0xffff,
+			Brightness: uint16(i),
+			Kelvin:     3500,
+		}
+	}
+	rec := new(recorder)
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			tile.SetTileState64: rec.handler,
+			lifxlan.SetPower:    rec.handler,
+		},
+		RawStatePowerPayload: &lifxlan.RawStatePowerPayload{
+			Level: lifxlan.PowerOn,
+		},
+		RawStatePayload:             &light.RawStatePayload{},
+		RawStateDeviceChainPayload:  rawChain,
+		RawStateTileState64Payloads: []*tile.RawStateTileState64Payload{rawState},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	td, err := tile.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured, err := scene.Snapshot(ctx, nil, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(captured.Export())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded scene.SceneState
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal %s: %v", data, err)
+	}
+	if decoded.Version != scene.SceneStateVersion {
+		t.Errorf("Version expected %d, got %d", scene.SceneStateVersion, decoded.Version)
+	}
+	if decoded.Type != scene.StateTypeTile {
+		t.Errorf("Type expected %q, got %q", scene.StateTypeTile, decoded.Type)
+	}
+	if decoded.Target != mock.Target {
+		t.Errorf("Target expected %v, got %v", mock.Target, decoded.Target)
+	}
+	if !reflect.DeepEqual(decoded.Board, captured.Board) {
+		t.Errorf("Board expected %v, got %v", captured.Board, decoded.Board)
+	}
+
+	if _, err := decoded.DeviceState(device); err == nil {
+		t.Error("Expected error importing tile state to a non-tile device")
+	}
+	state, err := decoded.DeviceState(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Power != lifxlan.PowerOn {
+		t.Errorf("Power expected %v, got %v", lifxlan.PowerOn, state.Power)
+	}
+	if err := state.Restore(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []lifxlan.MessageType{
+		tile.SetTileState64,
+		lifxlan.SetPower,
+	}
+	if got := rec.types(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Messages expected %v, got %v", expected, got)
+	}
+	var raw tile.RawSetTileState64Payload
+	rec.decode(t, 0, &raw)
+	if raw.Colors != rawState.Colors {
+		t.Errorf("Set64 colors expected %v, got %v", rawState.Colors, raw.Colors)
+	}
+}
+
+func TestSceneStateUnmarshalJSON(t *testing.T) {
+	for _, c := range []struct {
+		label string
+		json  string
+		ok    bool
+	}{
+		{
+			label: "Current",
+			json:  `{"version":1,"type":"device","target":1,"power":65535}`,
+			ok:    true,
+		},
+		{
+			label: "MissingVersion",
+			json:  `{"type":"device","target":1,"power":65535}`,
+		},
+		{
+			label: "FutureVersion",
+			json:  `{"version":2,"type":"device","target":1,"power":65535}`,
+		},
+		{
+			label: "UnknownType",
+			json:  `{"version":1,"type":"fan","target":1,"power":65535}`,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var ss scene.SceneState
+				err := json.Unmarshal([]byte(c.json), &ss)
+				if c.ok {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if ss.Power != lifxlan.PowerOn {
+						t.Errorf("Power expected %v, got %v", lifxlan.PowerOn, ss.Power)
+					}
+					return
+				}
+				if err == nil {
+					t.Errorf("Expected error, got %+v", ss)
+				}
+				if !reflect.DeepEqual(ss, scene.SceneState{}) {
+					t.Errorf("Expected SceneState unchanged, got %+v", ss)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,126 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// ErrTargetNotFound is the error returned by Scene.Apply for targets in the
+// scene but not in the devices.
+var ErrTargetNotFound = errors.New("lifxlan/scene.Scene.Apply: target not found")
+
+// TargetState is the desired state of a single device in a Scene.
+type TargetState struct {
+	Power lifxlan.Power
+
+	// If Color is non-nil,
+	// it will be set to the device before setting the power.
+	// It requires the device to be a light.Device.
+	Color *lifxlan.Color
+
+	// The transition used for both the color and the power.
+	//
+	// Power transition is only supported by light devices,
+	// other devices will switch the power immediately.
+	Transition time.Duration
+}
+
+// Scene maps targets to their desired states.
+type Scene map[lifxlan.Target]TargetState
+
+// Apply applies the states of the scene to devices in parallel with acks,
+// via lifxlan.ForEachDevice with concurrency.
+//
+// Devices should already be wrapped into light.Device (e.g. via light.Wrap or
+// auto.Wrap) if their states have colors.
+// Devices not in the scene are left untouched.
+//
+// The returned errors are in the same order as devices,
+// with nil for devices applied successfully or not in the scene,
+// followed by an error wrapping ErrTargetNotFound for each target in the scene
+// but not in devices, ordered by target.
+func (s Scene) Apply(ctx context.Context, devices []lifxlan.Device, concurrency int) []error {
+	seen := make(map[lifxlan.Target]bool, len(devices))
+	for _, d := range devices {
+		seen[d.Target()] = true
+	}
+
+	errs := lifxlan.ForEachDevice(
+		ctx,
+		devices,
+		concurrency,
+		func(ctx context.Context, d lifxlan.Device, conn net.Conn) error {
+			state, ok := s[d.Target()]
+			if !ok {
+				return nil
+			}
+			return state.Apply(ctx, conn, d)
+		},
+	)
+
+	var missing []lifxlan.Target
+	for target := range s {
+		if !seen[target] {
+			missing = append(missing, target)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i] < missing[j]
+	})
+	for _, target := range missing {
+		errs = append(errs, fmt.Errorf("%w: %v", ErrTargetNotFound, target))
+	}
+	return errs
+}
+
+// Apply applies the state to dev, with acks.
+//
+// The color is set first,
+// then the power,
+// so the device won't be on with the wrong color.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func (ts TargetState) Apply(ctx context.Context, conn net.Conn, dev lifxlan.Device) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	ld, isLight := dev.(light.Device)
+	if ts.Color != nil {
+		if !isLight {
+			return fmt.Errorf(
+				"lifxlan/scene.TargetState.Apply: %v is not a light device",
+				dev,
+			)
+		}
+		if err := ld.SetColor(ctx, conn, ts.Color, ts.Transition, true); err != nil {
+			return err
+		}
+	}
+
+	if isLight {
+		return ld.SetLightPower(ctx, conn, ts.Power, ts.Transition, true)
+	}
+	return dev.SetPower(ctx, conn, ts.Power, true)
+}
@@ -0,0 +1,154 @@
+package scene_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/scene"
+)
+
+func TestSceneApply(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	const (
+		target1 lifxlan.Target = 1
+		target2 lifxlan.Target = 2
+		target3 lifxlan.Target = 3
+		missing lifxlan.Target = 4
+	)
+
+	color := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0x8000,
+		Brightness: 0x8000,
+		Kelvin:     3500,
+	}
+
+	// startService starts a mock service with target recording the set
+	// messages received.
+	startService := func(target lifxlan.Target) (*mock.Service, *recorder, lifxlan.Device) {
+		rec := new(recorder)
+		service := &mock.Service{
+			TB:         t,
+			Target:     target,
+			HandleAcks: true,
+			Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+				light.SetColor:      rec.handler,
+				light.SetLightPower: rec.handler,
+				lifxlan.SetPower:    rec.handler,
+			},
+			RawStatePayload: &light.RawStatePayload{},
+		}
+		return service, rec, service.Start()
+	}
+
+	service1, rec1, device1 := startService(target1)
+	defer service1.Stop()
+	service2, rec2, device2 := startService(target2)
+	defer service2.Stop()
+	service3, rec3, device3 := startService(target3)
+	defer service3.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ld1, err := light.Wrap(ctx, device1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := []lifxlan.Device{
+		ld1,
+		device2,
+		// Not in the scene.
+		device3,
+	}
+
+	s := scene.Scene{
+		target1: {
+			Power:      lifxlan.PowerOn,
+			Color:      &color,
+			Transition: time.Second,
+		},
+		target2: {
+			Power: lifxlan.PowerOn,
+		},
+		missing: {
+			Power: lifxlan.PowerOn,
+		},
+	}
+	errs := s.Apply(ctx, devices, 2)
+	if len(errs) != len(devices)+1 {
+		t.Fatalf("Expected %d errors, got %v", len(devices)+1, errs)
+	}
+	for i, err := range errs[:len(devices)] {
+		if err != nil {
+			t.Errorf("Device %d: %v", i, err)
+		}
+	}
+	if err := errs[len(devices)]; !errors.Is(err, scene.ErrTargetNotFound) {
+		t.Errorf("Expected ErrTargetNotFound for missing target, got %v", err)
+	}
+
+	for _, c := range []struct {
+		label    string
+		rec      *recorder
+		expected []lifxlan.MessageType
+	}{
+		{
+			label:    "Light",
+			rec:      rec1,
+			expected: []lifxlan.MessageType{light.SetColor, light.SetLightPower},
+		},
+		{
+			label:    "Device",
+			rec:      rec2,
+			expected: []lifxlan.MessageType{lifxlan.SetPower},
+		},
+		{
+			label:    "NotInScene",
+			rec:      rec3,
+			expected: []lifxlan.MessageType{},
+		},
+	} {
+		if actual := c.rec.types(); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: messages expected %v, got %v", c.label, c.expected, actual)
+		}
+	}
+
+	var setColor light.RawSetColorPayload
+	rec1.decode(t, 0, &setColor)
+	if setColor.Color != color {
+		t.Errorf("SetColor expected %v, got %v", color, setColor.Color)
+	}
+	var setPower light.RawSetLightPowerPayload
+	rec1.decode(t, 1, &setPower)
+	if setPower.Level != lifxlan.PowerOn || setPower.Duration != 1000 {
+		t.Errorf("SetLightPower expected on with 1000ms, got %+v", setPower)
+	}
+
+	t.Run(
+		"NotLight",
+		func(t *testing.T) {
+			s := scene.Scene{
+				target2: {
+					Power: lifxlan.PowerOn,
+					Color: &color,
+				},
+			}
+			errs := s.Apply(ctx, devices[1:2], 0)
+			if len(errs) != 1 || errs[0] == nil {
+				t.Errorf("Expected error setting color on non-light device, got %v", errs)
+			}
+		},
+	)
+}
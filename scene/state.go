@@ -0,0 +1,181 @@
+package scene
+
+import (
+	"context"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// DeviceState is the captured visible state of a device.
+//
+// Only the fields relevant to the device type are set,
+// e.g. Zones is only set for multizone devices.
+type DeviceState struct {
+	// The device the state was captured from, and will be restored to.
+	Device lifxlan.Device
+
+	Power lifxlan.Power
+
+	// Color of light devices (that are not multizone or tile devices).
+	Color *lifxlan.Color
+
+	// Colors of the zones of multizone devices.
+	Zones []lifxlan.Color
+
+	// Colors of tile devices.
+	Board tile.ColorBoard
+
+	// Infrared brightness of light devices with infrared capability.
+	Infrared *uint16
+}
+
+// Snapshot captures the current visible state of dev.
+//
+// The state captured depends on the type of dev,
+// so it should already be wrapped into the most specific device type
+// (e.g. via auto.Wrap):
+//
+// - tile.Device: power and the colors of all the tiles
+//
+// - multizone.Device: power and the colors of all the zones
+//
+// - light.Device: power, color,
+// and infrared if the product has infrared capability
+//
+// - other devices: power only
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func Snapshot(ctx context.Context, conn net.Conn, dev lifxlan.Device) (*DeviceState, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	state := &DeviceState{
+		Device: dev,
+	}
+	power, err := dev.GetPower(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	state.Power = power
+
+	switch d := dev.(type) {
+	case tile.Device:
+		board, err := d.GetColors(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		state.Board = board
+
+	case multizone.Device:
+		var zones []lifxlan.Color
+		if d.SupportsExtendedColorZones() {
+			zones, err = d.GetExtendedColorZones(ctx, conn)
+		} else {
+			zones, err = d.GetColorZones(ctx, conn)
+		}
+		if err != nil {
+			return nil, err
+		}
+		state.Zones = zones
+
+	case light.Device:
+		color, err := d.GetColor(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		state.Color = color
+
+		if hasInfrared(d) {
+			infrared, err := d.GetInfrared(ctx, conn)
+			if err != nil {
+				return nil, err
+			}
+			state.Infrared = &infrared
+		}
+	}
+	return state, nil
+}
+
+// hasInfrared returns true if the cached hardware version and firmware of d
+// indicates infrared capability.
+func hasInfrared(d lifxlan.Device) bool {
+	parsed := d.HardwareVersion().Parse()
+	if parsed == nil {
+		return false
+	}
+	return parsed.FeaturesAt(*d.Firmware()).Infrared.Get()
+}
+
+// Restore restores the captured state back to the device, with acks.
+//
+// Colors (and infrared) are restored first,
+// then the power,
+// so the device won't be on with the wrong colors during the restoration.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+func (s *DeviceState) Restore(ctx context.Context, conn net.Conn) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := s.Device.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	switch d := s.Device.(type) {
+	case tile.Device:
+		if s.Board != nil {
+			if err := d.SetColors(ctx, conn, s.Board, 0, true); err != nil {
+				return err
+			}
+		}
+
+	case multizone.Device:
+		if err := d.SetZoneColors(ctx, conn, s.Zones, 0, true); err != nil {
+			return err
+		}
+
+	case light.Device:
+		if s.Color != nil {
+			if err := d.SetColor(ctx, conn, s.Color, 0, true); err != nil {
+				return err
+			}
+		}
+		if s.Infrared != nil {
+			if err := d.SetInfrared(ctx, conn, *s.Infrared, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.Device.SetPower(ctx, conn, s.Power, true)
+}
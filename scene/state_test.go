@@ -0,0 +1,289 @@
+package scene_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/multizone"
+	"go.yhsif.com/lifxlan/scene"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// recorder records the messages received by the mock service in order.
+type recorder struct {
+	lock     sync.Mutex
+	messages []*lifxlan.Response
+}
+
+func (rec *recorder) handler(
+	_ *mock.Service,
+	_ net.PacketConn,
+	_ net.Addr,
+	orig *lifxlan.Response,
+) {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	rec.messages = append(rec.messages, orig)
+}
+
+func (rec *recorder) types() []lifxlan.MessageType {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	types := make([]lifxlan.MessageType, len(rec.messages))
+	for i, msg := range rec.messages {
+		types[i] = msg.Message
+	}
+	return types
+}
+
+func (rec *recorder) decode(t *testing.T, i int, payload interface{}) {
+	t.Helper()
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	r := bytes.NewReader(rec.messages[i].Payload)
+	if err := binary.Read(r, binary.LittleEndian, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	color := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0x8000,
+		Kelvin:     3500,
+	}
+
+	t.Run(
+		"Light",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			service.RawStatePowerPayload = &lifxlan.RawStatePowerPayload{
+				Level: lifxlan.PowerOn,
+			}
+			service.RawStatePayload = &light.RawStatePayload{
+				Color: color,
+			}
+			// LIFX+ A19, with infrared.
+			service.RawStateVersionPayload = &lifxlan.RawStateVersionPayload{
+				Version: lifxlan.HardwareVersion{
+					VendorID:  1,
+					ProductID: 29,
+				},
+			}
+			const infrared = 0x4000
+			service.Handlers[light.GetInfrared] = mock.ReplyHandler(
+				light.StateInfrared,
+				&light.RawInfraredPayload{
+					Brightness: infrared,
+				},
+			)
+			rec := new(recorder)
+			service.Handlers[light.SetColor] = rec.handler
+			service.Handlers[light.SetInfrared] = rec.handler
+			service.Handlers[lifxlan.SetPower] = rec.handler
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.GetHardwareVersion(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			ld, err := light.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := scene.Snapshot(ctx, nil, ld)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.Power != lifxlan.PowerOn {
+				t.Errorf("Power expected %v, got %v", lifxlan.PowerOn, state.Power)
+			}
+			if state.Color == nil || *state.Color != color {
+				t.Errorf("Color expected %v, got %v", color, state.Color)
+			}
+			if state.Infrared == nil || *state.Infrared != infrared {
+				t.Errorf("Infrared expected %d, got %v", infrared, state.Infrared)
+			}
+
+			if err := state.Restore(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			expected := []lifxlan.MessageType{
+				light.SetColor,
+				light.SetInfrared,
+				lifxlan.SetPower,
+			}
+			if got := rec.types(); !reflect.DeepEqual(got, expected) {
+				t.Fatalf("Messages expected %v, got %v", expected, got)
+			}
+			var setColor light.RawSetColorPayload
+			rec.decode(t, 0, &setColor)
+			if setColor.Color != color {
+				t.Errorf("SetColor expected %v, got %v", color, setColor.Color)
+			}
+			var setPower lifxlan.RawSetPowerPayload
+			rec.decode(t, 2, &setPower)
+			if setPower.Level != lifxlan.PowerOn {
+				t.Errorf("SetPower expected %v, got %v", lifxlan.PowerOn, setPower.Level)
+			}
+		},
+	)
+
+	t.Run(
+		"Multizone",
+		func(t *testing.T) {
+			zones := []lifxlan.Color{color, {Kelvin: 3500}, color}
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			service.RawStatePowerPayload = &lifxlan.RawStatePowerPayload{
+				Level: lifxlan.PowerOff,
+			}
+			service.RawStatePayload = &light.RawStatePayload{}
+			service.Handlers[multizone.GetColorZones] = func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw multizone.RawGetColorZonesPayload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				payload := &multizone.RawStateMultiZonePayload{
+					ZonesCount: uint8(len(zones)),
+				}
+				copy(payload.Colors[:], zones)
+				mock.ReplyHandler(multizone.StateMultiZone, payload)(s, conn, addr, orig)
+			}
+			rec := new(recorder)
+			service.Handlers[multizone.SetColorZones] = rec.handler
+			service.Handlers[lifxlan.SetPower] = rec.handler
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			md, err := multizone.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := scene.Snapshot(ctx, nil, md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state.Zones, zones) {
+				t.Errorf("Zones expected %v, got %v", zones, state.Zones)
+			}
+			if state.Color != nil {
+				t.Errorf("Color expected nil for multizone, got %v", state.Color)
+			}
+
+			if err := state.Restore(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			got := rec.types()
+			if len(got) != len(zones)+1 || got[len(got)-1] != lifxlan.SetPower {
+				t.Fatalf("Expected %d SetColorZones followed by SetPower, got %v", len(zones), got)
+			}
+			for i := range zones {
+				var raw multizone.RawSetColorZonesPayload
+				rec.decode(t, i, &raw)
+				if raw.Color != zones[i] {
+					t.Errorf("Zone %d expected %v, got %v", i, zones[i], raw.Color)
+				}
+			}
+			var setPower lifxlan.RawSetPowerPayload
+			rec.decode(t, len(zones), &setPower)
+			if setPower.Level != lifxlan.PowerOff {
+				t.Errorf("SetPower expected %v, got %v", lifxlan.PowerOff, setPower.Level)
+			}
+		},
+	)
+
+	t.Run(
+		"Tile",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			service.RawStatePowerPayload = &lifxlan.RawStatePowerPayload{
+				Level: lifxlan.PowerOn,
+			}
+			service.RawStatePayload = &light.RawStatePayload{}
+			rawChain := &tile.RawStateDeviceChainPayload{
+				TotalCount: 1,
+			}
+			rawChain.TileDevices[0] = tile.RawTileDevice{
+				Width:  8,
+				Height: 8,
+			}
+			service.RawStateDeviceChainPayload = rawChain
+			rawState := &tile.RawStateTileState64Payload{
+				Width: 8,
+			}
+			for i := range rawState.Colors {
+				rawState.Colors[i] = color
+				rawState.Colors[i].Brightness = uint16(i)
+			}
+			service.RawStateTileState64Payloads = []*tile.RawStateTileState64Payload{
+				rawState,
+			}
+			rec := new(recorder)
+			service.Handlers[tile.SetTileState64] = rec.handler
+			service.Handlers[lifxlan.SetPower] = rec.handler
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			td, err := tile.Wrap(ctx, device, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := scene.Snapshot(ctx, nil, td)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.Board == nil {
+				t.Fatal("Expected Board to be captured")
+			}
+
+			if err := state.Restore(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			expected := []lifxlan.MessageType{
+				tile.SetTileState64,
+				lifxlan.SetPower,
+			}
+			if got := rec.types(); !reflect.DeepEqual(got, expected) {
+				t.Fatalf("Messages expected %v, got %v", expected, got)
+			}
+			var raw tile.RawSetTileState64Payload
+			rec.decode(t, 0, &raw)
+			if raw.Colors != rawState.Colors {
+				t.Errorf("Set64 colors expected %v, got %v", rawState.Colors, raw.Colors)
+			}
+		},
+	)
+}
@@ -0,0 +1,166 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// SubscribeTolerance is the tolerance used by Subscribe to compare the colors
+// of the states polled (see DeviceState.ApproxEqual).
+//
+// It's big enough to cover the rounding done by the devices,
+// but small enough that any visible change is still reported.
+const SubscribeTolerance = 256
+
+// ApproxEqual returns true if s and other have the same power,
+// and all their colors (and infrared) are within tolerance from each other
+// (see lifxlan.Color.ApproxEqual).
+//
+// The devices of s and other are not compared.
+func (s *DeviceState) ApproxEqual(other *DeviceState, tolerance uint16) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if s.Power != other.Power {
+		return false
+	}
+	if !approxEqualColorPtr(s.Color, other.Color, tolerance) {
+		return false
+	}
+	if (s.Infrared == nil) != (other.Infrared == nil) {
+		return false
+	}
+	if s.Infrared != nil {
+		diff := int(*s.Infrared) - int(*other.Infrared)
+		if diff < -int(tolerance) || diff > int(tolerance) {
+			return false
+		}
+	}
+	if len(s.Zones) != len(other.Zones) {
+		return false
+	}
+	for i := range s.Zones {
+		if !s.Zones[i].ApproxEqual(other.Zones[i], tolerance) {
+			return false
+		}
+	}
+	if len(s.Board) != len(other.Board) {
+		return false
+	}
+	for x := range s.Board {
+		if len(s.Board[x]) != len(other.Board[x]) {
+			return false
+		}
+		for y := range s.Board[x] {
+			if !approxEqualColorPtr(s.Board[x][y], other.Board[x][y], tolerance) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func approxEqualColorPtr(a, b *lifxlan.Color, tolerance uint16) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ApproxEqual(*b, tolerance)
+}
+
+// Subscribe polls the state of dev via Snapshot every interval,
+// and calls onChange with the previous and the new state when it changes,
+// so changes made by physical switches or other clients (e.g. the LIFX app)
+// can be observed without push notifications.
+//
+// The states are compared via DeviceState.ApproxEqual with
+// SubscribeTolerance,
+// so the rounding done by the devices doesn't trigger onChange.
+// old passed to onChange is the state passed as new to the previous onChange
+// call (or the initial state),
+// so slow changes accumulated over multiple polls are still reported.
+//
+// The same as Snapshot,
+// dev should already be wrapped into the most specific device type
+// (e.g. via auto.Wrap).
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// Every poll is limited to interval.
+// It returns the error if the initial poll fails,
+// later failed polls (e.g. dropped responses) are skipped and retried at the
+// next interval.
+// onChange is called synchronously,
+// and polling is paused until it returns.
+//
+// The function will only return upon error of the initial poll or when ctx is
+// cancelled,
+// in which case ctx.Err() will be returned.
+func Subscribe(
+	ctx context.Context,
+	conn net.Conn,
+	dev lifxlan.Device,
+	interval time.Duration,
+	onChange func(old, new *DeviceState),
+) error {
+	if interval <= 0 {
+		return errors.New("lifxlan/scene.Subscribe: interval must be positive")
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	poll := func() (*DeviceState, error) {
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		return Snapshot(ctx, conn, dev)
+	}
+
+	last, err := poll()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		state, err := poll()
+		if err != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !last.ApproxEqual(state, SubscribeTolerance) {
+			onChange(last, state)
+			last = state
+		}
+	}
+}
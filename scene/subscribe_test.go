@@ -0,0 +1,152 @@
+package scene_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/scene"
+)
+
+func TestDeviceStateApproxEqual(t *testing.T) {
+	color := &lifxlan.Color{Hue: 1000, Saturation: 65535, Brightness: 32768, Kelvin: 3500}
+	rounded := &lifxlan.Color{Hue: 1010, Saturation: 65535, Brightness: 32770, Kelvin: 3500}
+	changed := &lifxlan.Color{Hue: 20000, Saturation: 65535, Brightness: 32768, Kelvin: 3500}
+
+	for _, c := range []struct {
+		label    string
+		a, b     *scene.DeviceState
+		expected bool
+	}{
+		{
+			label:    "Rounded",
+			a:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: color},
+			b:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: rounded},
+			expected: true,
+		},
+		{
+			label:    "Power",
+			a:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: color},
+			b:        &scene.DeviceState{Power: lifxlan.PowerOff, Color: color},
+			expected: false,
+		},
+		{
+			label:    "Color",
+			a:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: color},
+			b:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: changed},
+			expected: false,
+		},
+		{
+			label:    "ColorMissing",
+			a:        &scene.DeviceState{Power: lifxlan.PowerOn, Color: color},
+			b:        &scene.DeviceState{Power: lifxlan.PowerOn},
+			expected: false,
+		},
+		{
+			label:    "Zones",
+			a:        &scene.DeviceState{Zones: []lifxlan.Color{*color, *color}},
+			b:        &scene.DeviceState{Zones: []lifxlan.Color{*rounded, *changed}},
+			expected: false,
+		},
+		{
+			label:    "ZonesRounded",
+			a:        &scene.DeviceState{Zones: []lifxlan.Color{*color, *color}},
+			b:        &scene.DeviceState{Zones: []lifxlan.Color{*rounded, *rounded}},
+			expected: true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if actual := c.a.ApproxEqual(c.b, scene.SubscribeTolerance); actual != c.expected {
+					t.Errorf("ApproxEqual expected %v, got %v", c.expected, actual)
+				}
+			},
+		)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout  = time.Millisecond * 200
+		interval = time.Millisecond * 10
+	)
+
+	color := lifxlan.Color{Hue: 1000, Saturation: 65535, Brightness: 32768, Kelvin: 3500}
+	server, device := mock.StartServer(t, mock.State{
+		Power: lifxlan.PowerOn,
+		Color: color,
+	})
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ld, err := light.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := scene.Subscribe(ctx, nil, ld, 0, nil); err == nil {
+		t.Error("Expected error for zero interval, got nil")
+	}
+
+	type change struct {
+		old, new *scene.DeviceState
+	}
+	changes := make(chan change, 10)
+	subCtx, subCancel := context.WithCancel(context.Background())
+	defer subCancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var subErr error
+	go func() {
+		defer wg.Done()
+		subErr = scene.Subscribe(subCtx, nil, ld, interval, func(old, new *scene.DeviceState) {
+			changes <- change{old: old, new: new}
+		})
+	}()
+
+	// Wait for the initial poll.
+	time.Sleep(interval * 3)
+
+	// Rounding only, should not fire.
+	state := server.State()
+	state.Color.Hue += 10
+	server.SetState(state)
+	time.Sleep(interval * 5)
+	select {
+	default:
+	case c := <-changes:
+		t.Errorf("Unexpected change from %+v to %+v", c.old, c.new)
+	}
+
+	state.Power = lifxlan.PowerOff
+	server.SetState(state)
+	select {
+	case <-ctx.Done():
+		t.Fatal("Did not get the power change")
+	case c := <-changes:
+		if c.old.Power != lifxlan.PowerOn || c.new.Power != lifxlan.PowerOff {
+			t.Errorf("Power change expected from on to off, got %v to %v", c.old.Power, c.new.Power)
+		}
+		if c.old.Device != ld || c.new.Device != ld {
+			t.Errorf("Expected the states of %v, got %v and %v", ld, c.old.Device, c.new.Device)
+		}
+	}
+
+	subCancel()
+	wg.Wait()
+	if !errors.Is(subErr, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", subErr)
+	}
+}
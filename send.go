@@ -1,9 +1,7 @@
 package lifxlan
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
 	"fmt"
 	"net"
 )
@@ -21,21 +19,28 @@ func (d *device) Send(
 	}
 
 	var msg []byte
-	seq = d.NextSequence()
-	buf := new(bytes.Buffer)
-	if payload != nil {
-		if err = binary.Write(buf, binary.LittleEndian, payload); err != nil {
-			return
-		}
+	seq, err = d.nextSequence(ctx)
+	if err != nil {
+		err = fmt.Errorf("lifxlan.Device.Send: all sequences are in flight: %w", err)
+		return
+	}
+	var data []byte
+	data, err = encodePayload(payload)
+	if err != nil {
+		return
+	}
+	if err = validatePayloadSize("lifxlan.Device.Send", message, data); err != nil {
+		return
 	}
+	source := SourceFromContext(ctx, d.Source())
 	msg, err = GenerateMessage(
-		NotTagged,
-		d.Source(),
+		taggedFromContext(ctx, d.Target()),
+		source,
 		d.Target(),
 		flags,
 		seq,
 		message,
-		buf.Bytes(),
+		data,
 	)
 	if err != nil {
 		return
@@ -46,11 +51,27 @@ func (d *device) Send(
 		return
 	}
 
+	if err = waitForRateLimit(ctx, conn, d.Source()); err != nil {
+		return
+	}
+
 	var n int
 	n, err = conn.Write(msg)
 	if err != nil {
 		return
 	}
+	recordSent(source, seq)
+	if m := MetricsRecorder; m != nil {
+		m.IncSend(message)
+	}
+	debugf(
+		"sent %v to %v: source=%d sequence=%d flags=%d",
+		message,
+		d.Target(),
+		source,
+		seq,
+		flags,
+	)
 	if n < len(msg) {
 		err = fmt.Errorf(
 			"lifxlan.Device.Send: only wrote %d out of %d bytes",
@@ -67,3 +88,65 @@ func (d *device) Send(
 
 	return
 }
+
+type sourceKey struct{}
+
+// WithSource returns a copy of ctx that carries source as the source override.
+//
+// When set, Device.Send uses it instead of Device.Source for the messages sent
+// with the returned ctx,
+// and WaitForAcks, WaitForResponses and all the device getters match the
+// responses against it,
+// which can be used to correlate the requests from a single operation in
+// network captures.
+//
+// A zero source means "use the default source of the device",
+// which can be used to clear an override set by a parent context.
+//
+// Pipeline doesn't support source overrides,
+// the messages added to a Pipeline always use Device.Source.
+func WithSource(ctx context.Context, source uint32) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the source override set via WithSource on ctx,
+// or fallback if it's not set or zero.
+//
+// Device API implementations with their own response reading loops should
+// match the responses against SourceFromContext(ctx, d.Source()).
+func SourceFromContext(ctx context.Context, fallback uint32) uint32 {
+	if source, ok := ctx.Value(sourceKey{}).(uint32); ok && source != 0 {
+		return source
+	}
+	return fallback
+}
+
+type taggedKey struct{}
+
+// WithTagged returns a copy of ctx that overrides the tagged bit in the header
+// of the messages sent by Device.Send and SendWithRetry with the returned ctx.
+//
+// By default Device.Send chooses the tagged bit via TaggedFor,
+// so only the messages to devices with AllDevices target are tagged.
+// This is only useful for experimenting with group addressing,
+// as devices ignore messages with a tagged bit that doesn't match the target
+// (see Tagged).
+//
+// Pipeline doesn't support tagged overrides,
+// the messages added to a Pipeline always use TaggedFor.
+func WithTagged(ctx context.Context, tagged bool) context.Context {
+	return context.WithValue(ctx, taggedKey{}, tagged)
+}
+
+// taggedFromContext returns the tagged bit to be used for target,
+// respecting the override set via WithTagged on ctx.
+func taggedFromContext(ctx context.Context, target Target) TaggedHeader {
+	tagged, ok := ctx.Value(taggedKey{}).(bool)
+	if !ok {
+		return TaggedFor(target)
+	}
+	if tagged {
+		return Tagged
+	}
+	return NotTagged
+}
@@ -0,0 +1,158 @@
+package lifxlan_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestWithSource(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const override = 12345
+
+	var lock sync.Mutex
+	var sources []uint32
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.SetPower: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				lock.Lock()
+				defer lock.Unlock()
+				sources = append(sources, orig.Source)
+			},
+		},
+		HandleAcks: true,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	if got := lifxlan.SourceFromContext(context.Background(), device.Source()); got != device.Source() {
+		t.Errorf("SourceFromContext expected fallback %d, got %d", device.Source(), got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	overridden := lifxlan.WithSource(ctx, override)
+	if got := lifxlan.SourceFromContext(overridden, device.Source()); got != override {
+		t.Errorf("SourceFromContext expected %d, got %d", override, got)
+	}
+	// The ack is matched against the overridden source.
+	if err := device.SetPower(overridden, nil, lifxlan.PowerOn, true); err != nil {
+		t.Fatal(err)
+	}
+	// Zero clears the override.
+	if err := device.SetPower(lifxlan.WithSource(overridden, 0), nil, lifxlan.PowerOn, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	expected := []uint32{override, device.Source()}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Sources expected %v, got %v", expected, sources)
+	}
+}
+
+func TestSendTagged(t *testing.T) {
+	const timeout = time.Millisecond * 200
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	for _, c := range []struct {
+		label    string
+		target   lifxlan.Target
+		override *bool
+		tagged   bool
+	}{
+		{
+			label:  "Unicast",
+			target: lifxlan.Target(1234),
+			tagged: false,
+		},
+		{
+			label:  "AllDevices",
+			target: lifxlan.AllDevices,
+			tagged: true,
+		},
+		{
+			label:    "OverrideTagged",
+			target:   lifxlan.Target(1234),
+			override: boolPtr(true),
+			tagged:   true,
+		},
+		{
+			label:    "OverrideNotTagged",
+			target:   lifxlan.AllDevices,
+			override: boolPtr(false),
+			tagged:   false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				device := lifxlan.NewDevice(pc.LocalAddr().String(), lifxlan.ServiceUDP, c.target)
+				conn, err := device.Dial()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				if c.override != nil {
+					ctx = lifxlan.WithTagged(ctx, *c.override)
+				}
+				if _, err := device.Send(ctx, conn, 0, lifxlan.GetPower, nil); err != nil {
+					t.Fatal(err)
+				}
+
+				buf := make([]byte, lifxlan.ResponseReadBufferSize)
+				if err := pc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+					t.Fatal(err)
+				}
+				n, _, err := pc.ReadFrom(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				header, err := lifxlan.ParseHeader(buf[:n])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if header.Tagged != c.tagged {
+					t.Errorf("Tagged expected %v, got %v", c.tagged, header.Tagged)
+				}
+				if !header.Addressable || header.Protocol != 1024 {
+					t.Errorf("Unexpected header %+v", header)
+				}
+				if header.Target != c.target {
+					t.Errorf("Target expected %v, got %v", c.target, header.Target)
+				}
+			},
+		)
+	}
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
@@ -0,0 +1,171 @@
+package lifxlan
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// SendAndWait sends a message to dev via Device.Send,
+// and waits for the replies requested by flags.
+//
+// When flags has both FlagAckRequired and FlagResRequired,
+// it waits for both the ack and one response of type wantType
+// (in either order) and returns the response,
+// so an optimistic write followed by a read can be done in a single call.
+// When flags only has FlagResRequired,
+// it only waits for the response.
+// When flags only has FlagAckRequired,
+// it only waits for the ack and returns nil response.
+// When flags has neither,
+// it returns nil response and nil error after the message is sent.
+//
+// If wantType is 0,
+// the expected response type of message from ExpectedResponse is used
+// instead,
+// and it returns an error without sending the message if FlagResRequired is
+// set but the expected response type of message is unknown.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// The same as WaitForResponses and WaitForAcks,
+// this function drops all received messages that don't match,
+// so there shouldn't be any other responses expected on the same connection
+// at the same time.
+//
+// If ctx doesn't have a deadline,
+// the default timeout of dev is applied (see Device.SetTimeout).
+func SendAndWait(
+	ctx context.Context,
+	conn net.Conn,
+	dev Device,
+	flags AckResFlag,
+	message MessageType,
+	payload interface{},
+	wantType MessageType,
+) (*Response, error) {
+	if wantType == 0 && flags&FlagResRequired != 0 {
+		resp, ok := ExpectedResponse(message)
+		if !ok {
+			return nil, fmt.Errorf(
+				"lifxlan.SendAndWait: unknown expected response type for message %d",
+				message,
+			)
+		}
+		wantType = resp
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := dev.Send(ctx, conn, flags, message, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	wantAck := flags&FlagAckRequired != 0
+	wantRes := flags&FlagResRequired != 0
+	if !wantAck && !wantRes {
+		return nil, nil
+	}
+	if !wantRes {
+		return nil, WaitForAcks(ctx, conn, dev.Source(), seq)
+	}
+	if !wantAck {
+		resps, err := WaitForResponses(
+			ctx,
+			conn,
+			dev.Source(),
+			seq,
+			wantType,
+			1, // count
+		)
+		if err != nil {
+			return nil, err
+		}
+		return resps[0], nil
+	}
+
+	ctx, cancel := ApplyPolicy(ctx, dev.Source())
+	defer cancel()
+
+	source := dev.Source()
+	// The source to match the responses against.
+	match := SourceFromContext(ctx, source)
+	var acked bool
+	var state *Response
+	for !acked || state == nil {
+		resps, err := ReadNextResponses(ctx, conn)
+		if err != nil {
+			if m := MetricsRecorder; m != nil && ctx.Err() != nil && !acked {
+				m.IncAckTimeout()
+			}
+			return nil, fmt.Errorf(
+				"lifxlan.SendAndWait: ack received: %v, %v response received: %v: %w",
+				acked,
+				wantType,
+				state != nil,
+				err,
+			)
+		}
+		for _, resp := range resps {
+			if resp.Source == match && resp.Sequence != seq {
+				if err := checkSourceCollision("SendAndWait", match, resp); err != nil {
+					return nil, fmt.Errorf("lifxlan.SendAndWait: %w", err)
+				}
+			}
+			if resp.Source != match || resp.Sequence != seq {
+				debugf(
+					"SendAndWait: dropped %v from %v: source=%d sequence=%d",
+					resp.Message,
+					resp.Target,
+					resp.Source,
+					resp.Sequence,
+				)
+				continue
+			}
+			switch resp.Message {
+			default:
+				debugf(
+					"SendAndWait: dropped unexpected %v from %v: sequence=%d",
+					resp.Message,
+					resp.Target,
+					resp.Sequence,
+				)
+			case StateUnhandled:
+				releaseSequence(source, seq)
+				return nil, fmt.Errorf("lifxlan.SendAndWait: %w", parseUnhandled(resp))
+			case Acknowledgement:
+				if !acked {
+					if m := MetricsRecorder; m != nil {
+						m.IncAck()
+					}
+				}
+				acked = true
+			case wantType:
+				if state == nil {
+					state = resp
+				}
+			}
+		}
+	}
+	releaseSequence(source, seq)
+	return state, nil
+}
@@ -0,0 +1,223 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestSendAndWait(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	statePower := func(t *testing.T) []byte {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, lifxlan.RawStatePowerPayload{
+			Level: lifxlan.PowerOn,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	for _, c := range []struct {
+		label    string
+		ackFirst bool
+	}{
+		{
+			label:    "StateFirst",
+			ackFirst: false,
+		},
+		{
+			label:    "AckFirst",
+			ackFirst: true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				payload := statePower(t)
+				service := &mock.Service{
+					TB: t,
+					Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+						lifxlan.SetPower: func(
+							s *mock.Service,
+							conn net.PacketConn,
+							addr net.Addr,
+							orig *lifxlan.Response,
+						) {
+							if c.ackFirst {
+								s.Reply(conn, addr, orig, lifxlan.Acknowledgement, nil)
+							}
+							s.Reply(conn, addr, orig, lifxlan.StatePower, payload)
+							if !c.ackFirst {
+								s.Reply(conn, addr, orig, lifxlan.Acknowledgement, nil)
+							}
+						},
+					},
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				resp, err := lifxlan.SendAndWait(
+					ctx,
+					nil,
+					device,
+					lifxlan.FlagAckRequired|lifxlan.FlagResRequired,
+					lifxlan.SetPower,
+					&lifxlan.RawSetPowerPayload{
+						Level: lifxlan.PowerOn,
+					},
+					lifxlan.StatePower,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.Message != lifxlan.StatePower {
+					t.Errorf("Response message expected %v, got %v", lifxlan.StatePower, resp.Message)
+				}
+				if !bytes.Equal(resp.Payload, payload) {
+					t.Errorf("Response payload expected %v, got %v", payload, resp.Payload)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"NoAck",
+		func(t *testing.T) {
+			payload := statePower(t)
+			service := &mock.Service{
+				TB: t,
+				Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+					lifxlan.SetPower: func(
+						s *mock.Service,
+						conn net.PacketConn,
+						addr net.Addr,
+						orig *lifxlan.Response,
+					) {
+						s.Reply(conn, addr, orig, lifxlan.StatePower, payload)
+					},
+				},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			_, err := lifxlan.SendAndWait(
+				ctx,
+				nil,
+				device,
+				lifxlan.FlagAckRequired|lifxlan.FlagResRequired,
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{
+					Level: lifxlan.PowerOn,
+				},
+				lifxlan.StatePower,
+			)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"AckOnly",
+		func(t *testing.T) {
+			service := &mock.Service{
+				TB:         t,
+				Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+				HandleAcks: true,
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			resp, err := lifxlan.SendAndWait(
+				ctx,
+				nil,
+				device,
+				lifxlan.FlagAckRequired,
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{
+					Level: lifxlan.PowerOn,
+				},
+				lifxlan.StatePower,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp != nil {
+				t.Errorf("Expected nil response, got %+v", resp)
+			}
+		},
+	)
+
+	t.Run(
+		"ExpectedResponse",
+		func(t *testing.T) {
+			service := &mock.Service{
+				TB:         t,
+				Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+				HandleAcks: true,
+				RawStatePowerPayload: &lifxlan.RawStatePowerPayload{
+					Level: lifxlan.PowerOn,
+				},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			resp, err := lifxlan.SendAndWait(
+				ctx,
+				nil,
+				device,
+				lifxlan.FlagResRequired,
+				lifxlan.GetPower,
+				nil, // payload
+				0,   // wantType
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Message != lifxlan.StatePower {
+				t.Errorf("Response message expected %d, got %d", lifxlan.StatePower, resp.Message)
+			}
+
+			if _, err := lifxlan.SendAndWait(
+				ctx,
+				nil,
+				device,
+				lifxlan.FlagResRequired,
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{
+					Level: lifxlan.PowerOn,
+				},
+				0, // wantType
+			); err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected error for unknown expected response, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,176 @@
+package lifxlan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InFlightSequenceTimeout is the max time a sequence is considered in flight
+// when sequence tracking is enabled on a Device (see SetSequenceTracking).
+//
+// Sequences are no longer in flight once their acks or responses are received
+// by WaitForAcks or WaitForResponses,
+// or after InFlightSequenceTimeout passed,
+// whichever comes first.
+//
+// It's intentionally defined as variable instead of constant,
+// so the user could adjust it if needed.
+var InFlightSequenceTimeout = time.Second
+
+// sequenceTracker tracks the in-flight sequences of a source.
+type sequenceTracker struct {
+	lock sync.Mutex
+	next uint8
+	// The expiration time of each in-flight sequence,
+	// zero value means it's not in flight.
+	inFlight [256]time.Time
+	// Closed and replaced whenever any sequence is released.
+	released chan struct{}
+}
+
+func newSequenceTracker(next uint8) *sequenceTracker {
+	return &sequenceTracker{
+		next:     next,
+		released: make(chan struct{}),
+	}
+}
+
+// acquire returns the next sequence not in flight and marks it in flight.
+//
+// When all the sequences are in flight,
+// it blocks until one of them is released or expired,
+// or ctx is cancelled, in which case ctx.Err() is returned.
+func (st *sequenceTracker) acquire(ctx context.Context) (uint8, error) {
+	for {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		st.lock.Lock()
+		now := time.Now()
+		earliest := now.Add(InFlightSequenceTimeout)
+		for i := 0; i < len(st.inFlight); i++ {
+			seq := st.next + uint8(i)
+			expire := st.inFlight[seq]
+			if expire.IsZero() || !now.Before(expire) {
+				st.inFlight[seq] = now.Add(InFlightSequenceTimeout)
+				st.next = seq + 1
+				st.lock.Unlock()
+				return seq, nil
+			}
+			if expire.Before(earliest) {
+				earliest = expire
+			}
+		}
+		released := st.released
+		st.lock.Unlock()
+
+		debugf("all sequences are in flight, waiting for %v", earliest.Sub(now))
+		timer := time.NewTimer(earliest.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-released:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// release marks seq as no longer in flight.
+func (st *sequenceTracker) release(seq uint8) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if st.inFlight[seq].IsZero() {
+		return
+	}
+	st.inFlight[seq] = time.Time{}
+	close(st.released)
+	st.released = make(chan struct{})
+}
+
+// sharedTracker is a sequenceTracker shared by all the devices with the same
+// source and sequence tracking enabled.
+type sharedTracker struct {
+	tracker *sequenceTracker
+	// The number of devices using tracker.
+	refs int
+}
+
+// sequenceTrackers maps sources to the trackers of the devices with sequence
+// tracking enabled.
+//
+// WaitForAcks and WaitForResponses only know the source,
+// so this is how they find the tracker of the device.
+// An entry is removed once the last device using it disables sequence
+// tracking.
+var (
+	sequenceTrackers = make(map[uint32]*sharedTracker)
+	trackersLock     sync.Mutex
+)
+
+// releaseSequence releases seq of source if sequence tracking is enabled for
+// it.
+func releaseSequence(source uint32, seq uint8) {
+	trackersLock.Lock()
+	st, ok := sequenceTrackers[source]
+	trackersLock.Unlock()
+	if ok {
+		st.tracker.release(seq)
+	}
+}
+
+func (d *device) SetSequenceTracking(enabled bool) {
+	d.trackerLock.Lock()
+	defer d.trackerLock.Unlock()
+
+	trackersLock.Lock()
+	defer trackersLock.Unlock()
+
+	if !enabled {
+		if d.tracker != nil {
+			if st := sequenceTrackers[d.source]; st != nil {
+				st.refs--
+				if st.refs <= 0 {
+					delete(sequenceTrackers, d.source)
+				}
+			}
+			d.tracker = nil
+		}
+		return
+	}
+	if d.tracker != nil {
+		return
+	}
+	// Share the same tracker if another device is using the same source.
+	st := sequenceTrackers[d.source]
+	if st == nil {
+		st = &sharedTracker{
+			tracker: newSequenceTracker(d.nextCounterSequence()),
+		}
+		sequenceTrackers[d.source] = st
+	}
+	st.refs++
+	d.tracker = st.tracker
+}
+
+// getTracker returns the sequence tracker of the device,
+// or nil if sequence tracking is disabled.
+func (d *device) getTracker() *sequenceTracker {
+	d.trackerLock.Lock()
+	defer d.trackerLock.Unlock()
+	return d.tracker
+}
+
+// nextSequence is the same as NextSequence,
+// except that when sequence tracking is enabled and all the sequences are in
+// flight,
+// it only blocks until ctx is cancelled.
+func (d *device) nextSequence(ctx context.Context) (uint8, error) {
+	if tracker := d.getTracker(); tracker != nil {
+		return tracker.acquire(ctx)
+	}
+	return d.NextSequence(), nil
+}
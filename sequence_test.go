@@ -0,0 +1,175 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestSequenceTracking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout   = time.Millisecond * 200
+		sequences = 256
+	)
+
+	defer func(orig time.Duration) {
+		lifxlan.InFlightSequenceTimeout = orig
+	}(lifxlan.InFlightSequenceTimeout)
+
+	// sendAll sends sequences messages concurrently without waiting for the
+	// acks, and returns the sequences used.
+	sendAll := func(
+		ctx context.Context,
+		t *testing.T,
+		device lifxlan.Device,
+		conn *lifxlan.SyncConn,
+	) map[uint8]bool {
+		t.Helper()
+
+		var lock sync.Mutex
+		seen := make(map[uint8]bool)
+		var wg sync.WaitGroup
+		for i := 0; i < sequences; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				seq, err := device.Send(
+					ctx,
+					conn,
+					0, // flags
+					lifxlan.SetPower,
+					&lifxlan.RawSetPowerPayload{},
+				)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				lock.Lock()
+				defer lock.Unlock()
+				if seen[seq] {
+					t.Errorf("Sequence %d used by multiple in-flight sends", seq)
+				}
+				seen[seq] = true
+			}()
+		}
+		wg.Wait()
+		return seen
+	}
+
+	t.Run(
+		"Release",
+		func(t *testing.T) {
+			lifxlan.InFlightSequenceTimeout = time.Minute
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			device.SetSequenceTracking(true)
+			defer device.SetSequenceTracking(false)
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := lifxlan.NewSyncConn(conn)
+			defer sc.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			seen := sendAll(ctx, t, device, sc)
+			if len(seen) != sequences {
+				t.Fatalf("Expected %d distinct sequences, got %d", sequences, len(seen))
+			}
+
+			shortCtx, shortCancel := context.WithTimeout(ctx, time.Millisecond*20)
+			defer shortCancel()
+			if _, err := device.Send(
+				shortCtx,
+				sc,
+				0, // flags
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{},
+			); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded with all sequences in flight, got %v", err)
+			}
+
+			// The mock service acks all the messages.
+			const seq = 42
+			if err := lifxlan.WaitForAcks(ctx, sc, device.Source(), seq); err != nil {
+				t.Fatal(err)
+			}
+			next, err := device.Send(
+				ctx,
+				sc,
+				0, // flags
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next != seq {
+				t.Errorf("Expected released sequence %d, got %d", seq, next)
+			}
+		},
+	)
+
+	t.Run(
+		"Expire",
+		func(t *testing.T) {
+			lifxlan.InFlightSequenceTimeout = time.Millisecond * 50
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			device.SetSequenceTracking(true)
+			defer device.SetSequenceTracking(false)
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := lifxlan.NewSyncConn(conn)
+			defer sc.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			sendAll(ctx, t, device, sc)
+			if _, err := device.Send(
+				ctx,
+				sc,
+				0, // flags
+				lifxlan.SetPower,
+				&lifxlan.RawSetPowerPayload{},
+			); err != nil {
+				t.Errorf("Expected nil error after in-flight sequences expired, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Disabled",
+		func(t *testing.T) {
+			lifxlan.InFlightSequenceTimeout = time.Minute
+
+			device := lifxlan.NewDevice("127.0.0.1:56700", lifxlan.ServiceUDP, lifxlan.AllDevices)
+			first := device.NextSequence()
+			for i := 1; i < sequences; i++ {
+				device.NextSequence()
+			}
+			if seq := device.NextSequence(); seq != first {
+				t.Errorf("Expected sequence to wrap around to %d, got %d", first, seq)
+			}
+		},
+	)
+}
@@ -0,0 +1,106 @@
+package lifxlan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// lightState is the MessageType of light.State.
+//
+// It's duplicated here as the light package imports this package.
+const lightState MessageType = 107
+
+// decodeState checks that the message type of resp is message,
+// and decodes its payload into raw.
+func (resp *Response) decodeState(caller string, message MessageType, raw interface{}) error {
+	if resp.Message != message {
+		return fmt.Errorf(
+			"%s: unexpected message type %d, want %d",
+			caller,
+			resp.Message,
+			message,
+		)
+	}
+	if err := CheckPayloadSize(caller, resp.Payload, binary.Size(raw)); err != nil {
+		return err
+	}
+	r := bytes.NewReader(resp.Payload)
+	if err := binary.Read(r, binary.LittleEndian, raw); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	return nil
+}
+
+// DecodePayload decodes the payload of resp into raw,
+// which should be a pointer to the Raw*Payload struct of its message type
+// (e.g. *RawStateServicePayload for StateService),
+// regardless of which package the message type is from.
+//
+// It returns an error if the payload is too short for raw.
+// Unlike the other accessors the message type of resp is not checked,
+// so check resp.Message first.
+func (resp *Response) DecodePayload(raw interface{}) error {
+	const caller = "lifxlan.Response.DecodePayload"
+	if err := CheckPayloadSize(caller, resp.Payload, binary.Size(raw)); err != nil {
+		return err
+	}
+	r := bytes.NewReader(resp.Payload)
+	if err := binary.Read(r, binary.LittleEndian, raw); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	return nil
+}
+
+// StatePower decodes the power level from a StatePower response.
+//
+// It returns an error if the message type of resp is not StatePower,
+// or the payload is too short.
+func (resp *Response) StatePower() (Power, error) {
+	var raw RawStatePowerPayload
+	if err := resp.decodeState("lifxlan.Response.StatePower", StatePower, &raw); err != nil {
+		return 0, err
+	}
+	return raw.Level, nil
+}
+
+// StateLabel decodes the label from a StateLabel response.
+//
+// It returns an error if the message type of resp is not StateLabel,
+// or the payload is too short.
+func (resp *Response) StateLabel() (string, error) {
+	var raw RawStateLabelPayload
+	if err := resp.decodeState("lifxlan.Response.StateLabel", StateLabel, &raw); err != nil {
+		return "", err
+	}
+	return raw.Label.String(), nil
+}
+
+// StateVersion decodes the hardware version from a StateVersion response.
+//
+// It returns an error if the message type of resp is not StateVersion,
+// or the payload is too short.
+func (resp *Response) StateVersion() (HardwareVersion, error) {
+	var raw RawStateVersionPayload
+	if err := resp.decodeState("lifxlan.Response.StateVersion", StateVersion, &raw); err != nil {
+		return HardwareVersion{}, err
+	}
+	return raw.Version, nil
+}
+
+// StateColor decodes the color from a light State (107) response,
+// which is the reply of light Get and SetColor messages.
+//
+// It returns an error if the message type of resp is not light State,
+// or the payload is too short.
+func (resp *Response) StateColor() (Color, error) {
+	// The color is the first field of the light.RawStatePayload.
+	var raw struct {
+		Color Color
+		_     [44]byte
+	}
+	if err := resp.decodeState("lifxlan.Response.StateColor", lightState, &raw); err != nil {
+		return Color{}, err
+	}
+	return raw.Color, nil
+}
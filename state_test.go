@@ -0,0 +1,142 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+)
+
+// makeResponse encodes payload as message then parses it back as a Response.
+func makeResponse(t *testing.T, message lifxlan.MessageType, payload interface{}) *lifxlan.Response {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := lifxlan.GenerateMessage(
+		lifxlan.NotTagged,
+		1, // source
+		lifxlan.AllDevices,
+		0, // flags
+		0, // sequence
+		message,
+		buf.Bytes(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := lifxlan.ParseResponse(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestResponseState(t *testing.T) {
+	color := lifxlan.Color{
+		Hue:        1,
+		Saturation: 2,
+		Brightness: 3,
+		Kelvin:     3500,
+	}
+	var label lifxlan.Label
+	label.Set("foo")
+	version := lifxlan.HardwareVersion{
+		VendorID:  1,
+		ProductID: 22,
+	}
+
+	power := makeResponse(t, lifxlan.StatePower, &lifxlan.RawStatePowerPayload{
+		Level: lifxlan.PowerOn,
+	})
+	labelResp := makeResponse(t, lifxlan.StateLabel, &lifxlan.RawStateLabelPayload{
+		Label: label,
+	})
+	versionResp := makeResponse(t, lifxlan.StateVersion, &lifxlan.RawStateVersionPayload{
+		Version: version,
+	})
+	state := makeResponse(t, light.State, &light.RawStatePayload{
+		Color: color,
+		Power: lifxlan.PowerOn,
+		Label: label,
+	})
+
+	t.Run(
+		"StatePower",
+		func(t *testing.T) {
+			got, err := power.StatePower()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != lifxlan.PowerOn {
+				t.Errorf("StatePower expected %v, got %v", lifxlan.PowerOn, got)
+			}
+			if _, err := labelResp.StatePower(); err == nil {
+				t.Error("Expected error on StateLabel response")
+			}
+		},
+	)
+
+	t.Run(
+		"StateLabel",
+		func(t *testing.T) {
+			got, err := labelResp.StateLabel()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "foo" {
+				t.Errorf("StateLabel expected %q, got %q", "foo", got)
+			}
+			if _, err := power.StateLabel(); err == nil {
+				t.Error("Expected error on StatePower response")
+			}
+		},
+	)
+
+	t.Run(
+		"StateVersion",
+		func(t *testing.T) {
+			got, err := versionResp.StateVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != version {
+				t.Errorf("StateVersion expected %+v, got %+v", version, got)
+			}
+			if _, err := state.StateVersion(); err == nil {
+				t.Error("Expected error on light State response")
+			}
+		},
+	)
+
+	t.Run(
+		"StateColor",
+		func(t *testing.T) {
+			got, err := state.StateColor()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != color {
+				t.Errorf("StateColor expected %v, got %v", color, got)
+			}
+			if _, err := versionResp.StateColor(); err == nil {
+				t.Error("Expected error on StateVersion response")
+			}
+		},
+	)
+
+	t.Run(
+		"ShortPayload",
+		func(t *testing.T) {
+			resp := *power
+			resp.Payload = resp.Payload[:1]
+			if _, err := resp.StatePower(); err == nil {
+				t.Error("Expected error on short payload")
+			}
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package lifxlan
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeStream reads LIFX frames from r one by one,
+// and calls fn with every parsed response,
+// until r returns io.EOF.
+//
+// Every frame is prefixed by the size field of its own header,
+// so the frames can simply be concatenated,
+// e.g. the UDP payloads extracted from a packet capture.
+// This makes it possible to reuse this package as an offline decoder for
+// debugging and monitoring tools,
+// with the payloads decoded via the Response accessors
+// (e.g. Response.StatePower or Response.DecodePayload).
+//
+// It returns nil error when r returns io.EOF on a frame boundary.
+// It returns an error if a frame is truncated,
+// or fails ParseResponse (e.g. the protocol number of the header is wrong),
+// as the frame boundaries after it can no longer be trusted.
+func DecodeStream(r io.Reader, fn func(*Response)) error {
+	var offset int64
+	for {
+		var size uint16
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("lifxlan.DecodeStream: frame at offset %d: %w", offset, err)
+		}
+		if size < HeaderLength {
+			return fmt.Errorf(
+				"lifxlan.DecodeStream: frame at offset %d: size field not enough: %d < %d",
+				offset,
+				size,
+				HeaderLength,
+			)
+		}
+
+		msg := make([]byte, size)
+		binary.LittleEndian.PutUint16(msg, size)
+		if _, err := io.ReadFull(r, msg[2:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("lifxlan.DecodeStream: frame at offset %d: %w", offset, err)
+		}
+
+		resp, err := ParseResponse(msg)
+		if err != nil {
+			return fmt.Errorf("lifxlan.DecodeStream: frame at offset %d: %w", offset, err)
+		}
+		fn(resp)
+		offset += int64(size)
+	}
+}
@@ -0,0 +1,149 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Captured responses from a device with target d0:73:d5:01:02:03,
+// to requests with source 0x12345678.
+var capturedFrames = []string{
+	// StateService: UDP on port 56700.
+	"2900001478563412d073d501020300000000000000000000000000000000000003000000017cdd0000",
+	// StatePower: on.
+	"2600001478563412d073d501020300000000000000000001000000000000000016000000ffff",
+	// StateLabel: "Kitchen".
+	"4400001478563412d073d5010203000000000000000000020000000000000000190000004b69746368656e00000000000000000000000000000000000000000000000000",
+	// Acknowledgement.
+	"2400001478563412d073d50102030000000000000000000300000000000000002d000000",
+}
+
+func capturedStream(t *testing.T) []byte {
+	t.Helper()
+	var stream []byte
+	for _, s := range capturedFrames {
+		frame, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = append(stream, frame...)
+	}
+	return stream
+}
+
+func TestDecodeStream(t *testing.T) {
+	const target = lifxlan.Target(0x030201d573d0)
+
+	t.Run(
+		"Captured",
+		func(t *testing.T) {
+			var resps []*lifxlan.Response
+			if err := lifxlan.DecodeStream(
+				bytes.NewReader(capturedStream(t)),
+				func(resp *lifxlan.Response) {
+					resps = append(resps, resp)
+				},
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []lifxlan.MessageType{
+				lifxlan.StateService,
+				lifxlan.StatePower,
+				lifxlan.StateLabel,
+				lifxlan.Acknowledgement,
+			}
+			if len(resps) != len(expected) {
+				t.Fatalf("Expected %d responses, got %d", len(expected), len(resps))
+			}
+			for i, resp := range resps {
+				if resp.Message != expected[i] {
+					t.Errorf("#%d: Message expected %d, got %d", i, expected[i], resp.Message)
+				}
+				if resp.Source != 0x12345678 {
+					t.Errorf("#%d: Source expected %#x, got %#x", i, 0x12345678, resp.Source)
+				}
+				if resp.Target != target {
+					t.Errorf("#%d: Target expected %v, got %v", i, target, resp.Target)
+				}
+				if resp.Sequence != uint8(i) {
+					t.Errorf("#%d: Sequence expected %d, got %d", i, i, resp.Sequence)
+				}
+			}
+
+			var service lifxlan.RawStateServicePayload
+			if err := resps[0].DecodePayload(&service); err != nil {
+				t.Fatal(err)
+			}
+			if service.Service != lifxlan.ServiceUDP || service.Port != 56700 {
+				t.Errorf("StateService expected UDP:56700, got %+v", service)
+			}
+			if power, err := resps[1].StatePower(); err != nil {
+				t.Error(err)
+			} else if power != lifxlan.PowerOn {
+				t.Errorf("StatePower expected %v, got %v", lifxlan.PowerOn, power)
+			}
+			if label, err := resps[2].StateLabel(); err != nil {
+				t.Error(err)
+			} else if label != "Kitchen" {
+				t.Errorf("StateLabel expected %q, got %q", "Kitchen", label)
+			}
+		},
+	)
+
+	t.Run(
+		"Empty",
+		func(t *testing.T) {
+			if err := lifxlan.DecodeStream(
+				strings.NewReader(""),
+				func(resp *lifxlan.Response) {
+					t.Errorf("Unexpected response %+v", resp)
+				},
+			); err != nil {
+				t.Error(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Truncated",
+		func(t *testing.T) {
+			stream := capturedStream(t)
+			var n int
+			err := lifxlan.DecodeStream(
+				bytes.NewReader(stream[:len(stream)-1]),
+				func(*lifxlan.Response) {
+					n++
+				},
+			)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+			}
+			if n != len(capturedFrames)-1 {
+				t.Errorf("Expected %d responses before the error, got %d", len(capturedFrames)-1, n)
+			}
+		},
+	)
+
+	t.Run(
+		"Garbage",
+		func(t *testing.T) {
+			err := lifxlan.DecodeStream(
+				strings.NewReader("M-SEARCH * HTTP/1.1\r\n\r\n"),
+				func(resp *lifxlan.Response) {
+					t.Errorf("Unexpected response %+v", resp)
+				},
+			)
+			if err == nil {
+				t.Error("Expected error, got nil")
+			}
+			t.Log(err)
+		},
+	)
+}
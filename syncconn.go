@@ -0,0 +1,94 @@
+package lifxlan
+
+import (
+	"context"
+	"net"
+)
+
+// SyncConn is a net.Conn wrapper that can be shared by multiple goroutines.
+//
+// WaitForAcks and WaitForResponses drop the messages not meant for them,
+// so two API calls running on the same connection at the same time could
+// consume each other's responses.
+// SyncConn uses an internal lock to make sure that only one request and
+// response cycle is running on the underlying connection at a time.
+//
+// Use Do to run the API calls:
+//
+//     conn, err := device.Dial()
+//     if err != nil {
+//       // handle error
+//     }
+//     sc := lifxlan.NewSyncConn(conn)
+//     defer sc.Close()
+//     // Safe to be used from multiple goroutines.
+//     err = sc.Do(ctx, func(conn net.Conn) error {
+//       return device.SetPower(ctx, conn, lifxlan.PowerOn, true)
+//     })
+//
+// Read and Write calls made directly on the SyncConn also hold the lock,
+// but only for the duration of that single call,
+// so they don't make a request and response cycle atomic.
+type SyncConn struct {
+	net.Conn
+
+	lock chan struct{}
+}
+
+var _ net.Conn = (*SyncConn)(nil)
+
+// NewSyncConn wraps conn into a SyncConn.
+func NewSyncConn(conn net.Conn) *SyncConn {
+	return &SyncConn{
+		Conn: conn,
+		lock: make(chan struct{}, 1),
+	}
+}
+
+// Do calls fn with the underlying connection while holding the lock.
+//
+// It blocks until the lock is acquired or ctx is cancelled,
+// in which case ctx.Err() is returned and fn is not called.
+// Otherwise it returns the error returned by fn.
+//
+// fn must not use the conn passed in after it returns,
+// and must not call Do, Read or Write on the same SyncConn,
+// otherwise it will deadlock.
+func (sc *SyncConn) Do(ctx context.Context, fn func(conn net.Conn) error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case sc.lock <- struct{}{}:
+	}
+	defer func() {
+		<-sc.lock
+	}()
+
+	return fn(sc.Conn)
+}
+
+// Read implements net.Conn.
+//
+// It holds the lock while reading from the underlying connection.
+func (sc *SyncConn) Read(b []byte) (int, error) {
+	sc.lock <- struct{}{}
+	defer func() {
+		<-sc.lock
+	}()
+	return sc.Conn.Read(b)
+}
+
+// Write implements net.Conn.
+//
+// It holds the lock while writing to the underlying connection.
+func (sc *SyncConn) Write(b []byte) (int, error) {
+	sc.lock <- struct{}{}
+	defer func() {
+		<-sc.lock
+	}()
+	return sc.Conn.Write(b)
+}
@@ -0,0 +1,115 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestSyncConn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	t.Run(
+		"Concurrent",
+		func(t *testing.T) {
+			const (
+				goroutines = 8
+				calls      = 10
+			)
+
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := lifxlan.NewSyncConn(conn)
+			defer sc.Close()
+
+			var wg sync.WaitGroup
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < calls; j++ {
+						ctx, cancel := context.WithTimeout(context.Background(), timeout)
+						err := sc.Do(ctx, func(conn net.Conn) error {
+							if err := device.SetPower(ctx, conn, lifxlan.PowerOn, true); err != nil {
+								return err
+							}
+							return device.SetPower(ctx, conn, lifxlan.PowerOff, true)
+						})
+						cancel()
+						if err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		},
+	)
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := lifxlan.NewSyncConn(conn)
+			defer sc.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			locked := make(chan struct{})
+			unlock := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				sc.Do(ctx, func(net.Conn) error {
+					close(locked)
+					<-unlock
+					return nil
+				})
+			}()
+			<-locked
+
+			shortCtx, shortCancel := context.WithTimeout(ctx, time.Millisecond*20)
+			defer shortCancel()
+			called := false
+			err = sc.Do(shortCtx, func(net.Conn) error {
+				called = true
+				return nil
+			})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded while locked, got %v", err)
+			}
+			if called {
+				t.Error("fn should not be called while locked")
+			}
+
+			close(unlock)
+			<-done
+			if err := sc.Do(ctx, func(net.Conn) error { return nil }); err != nil {
+				t.Errorf("Expected nil error after unlocked, got %v", err)
+			}
+		},
+	)
+}
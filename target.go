@@ -2,6 +2,7 @@ package lifxlan
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"net"
@@ -16,14 +17,27 @@ var _ flag.Getter = (*Target)(nil)
 // AllDevices is the special Target value means all devices.
 const AllDevices Target = 0
 
+// String returns the MAC address of the target in the canonical form,
+// e.g. "d0:73:d5:01:23:45".
 func (t Target) String() string {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, uint64(t))
-	strs := make([]string, 6)
-	for i, b := range buf[:6] {
-		strs[i] = fmt.Sprintf("%02x", b)
+	var buf [targetStringLength]byte
+	return string(t.appendString(buf[:0]))
+}
+
+// targetStringLength is the length of Target.String.
+const targetStringLength = 6*3 - 1
+
+// appendString appends the canonical form of t to buf.
+func (t Target) appendString(buf []byte) []byte {
+	const digits = "0123456789abcdef"
+	for i := 0; i < 6; i++ {
+		if i > 0 {
+			buf = append(buf, ':')
+		}
+		b := byte(t >> (8 * i))
+		buf = append(buf, digits[b>>4], digits[b&0xf])
 	}
-	return strings.Join(strs, ":")
+	return buf
 }
 
 // Set implements flag.Value interface.
@@ -57,19 +71,34 @@ func (t Target) Matches(other Target) bool {
 
 // ParseTarget parses s into a Target.
 //
-// s should be in the format of a MAC address, e.g. "01:23:45:67:89:ab",
+// s should be in the format of a 6-byte MAC address separated by colons or
+// hyphens, e.g. "d0:73:d5:01:23:45" or "d0-73-d5-01-23-45",
+// or 12 hex digits without separators, e.g. "d073d5012345",
 // or the special values for AllDevices: "00:00:00:00:00:00" and "".
+// It's case insensitive.
+//
+// The first byte of the MAC address is the lowest byte of the Target,
+// which matches how it's packed into the target field of the header,
+// and the reverse of Target.String.
 func ParseTarget(s string) (t Target, err error) {
 	// Special case.
 	if s == "" {
 		return AllDevices, nil
 	}
 
-	var mac net.HardwareAddr
-	mac, err = net.ParseMAC(s)
+	var mac []byte
+	if len(s) == 12 && !strings.ContainsAny(s, ":-.") {
+		mac, err = hex.DecodeString(s)
+	} else {
+		mac, err = net.ParseMAC(s)
+	}
 	if err != nil {
 		return
 	}
+	if len(mac) != 6 {
+		err = fmt.Errorf("lifxlan.ParseTarget: %q is not a 6-byte MAC address", s)
+		return
+	}
 	buf := make([]byte, 8)
 	copy(buf, mac)
 	t = Target(binary.LittleEndian.Uint64(buf))
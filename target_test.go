@@ -1,6 +1,7 @@
 package lifxlan_test
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"testing"
@@ -151,6 +152,93 @@ func TestParseTarget(t *testing.T) {
 	)
 }
 
+func TestParseTargetFormats(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		s        string
+		expected lifxlan.Target
+	}{
+		{
+			label:    "Colon",
+			s:        "d0:73:d5:12:34:56",
+			expected: lifxlan.Target(0x563412d573d0),
+		},
+		{
+			label:    "Hyphen",
+			s:        "d0-73-d5-12-34-56",
+			expected: lifxlan.Target(0x563412d573d0),
+		},
+		{
+			label:    "BareHex",
+			s:        "d073d5123456",
+			expected: lifxlan.Target(0x563412d573d0),
+		},
+		{
+			label:    "UpperCase",
+			s:        "D0:73:D5:AB:CD:EF",
+			expected: lifxlan.Target(0xefcdabd573d0),
+		},
+		{
+			label:    "BareHexUpperCase",
+			s:        "D073D5ABCDEF",
+			expected: lifxlan.Target(0xefcdabd573d0),
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				target, err := lifxlan.ParseTarget(c.s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if target != c.expected {
+					t.Errorf("ParseTarget(%q) expected %v, got %v", c.s, c.expected, target)
+				}
+				// Round trip.
+				again, err := lifxlan.ParseTarget(target.String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if again != target {
+					t.Errorf("ParseTarget(%q) expected %v, got %v", target.String(), target, again)
+				}
+				// The target field of the header.
+				msg := lifxlan.BuildHeader(0, target, 0, 0, lifxlan.GetService, 0)
+				header, err := lifxlan.ParseHeader(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if header.Target != target {
+					t.Errorf("Header target expected %v, got %v", target, header.Target)
+				}
+				expectedBytes := []byte{0xd0, 0x73, 0xd5}
+				if !bytes.Equal(msg[8:11], expectedBytes) {
+					t.Errorf("Header target bytes expected to start with % x, got % x", expectedBytes, msg[8:16])
+				}
+			},
+		)
+	}
+
+	for _, s := range []string{
+		"d0:73:d5:12:34",
+		"d0:73:d5:12:34:56:78:9a",
+		"d073d512345",
+		"d073d512345g",
+		"not a mac",
+	} {
+		s := s
+		t.Run(
+			"Invalid/"+s,
+			func(t *testing.T) {
+				if target, err := lifxlan.ParseTarget(s); err == nil {
+					t.Errorf("Expected error for ParseTarget(%q), got %v", s, target)
+				}
+			},
+		)
+	}
+}
+
 func ExampleTarget_Set() {
 	var target lifxlan.Target
 	flag.Var(
@@ -0,0 +1,105 @@
+package tile
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Animate drives an animation on td frame by frame,
+// until ctx is cancelled.
+//
+// frame is called fps times per second with the time elapsed since the start
+// of the animation,
+// and returns the colors of the frame,
+// one slice of ColorsPerTile colors per tile in the order of Tiles()
+// (the same as SetTileColors).
+// Tiles with nil colors or without a corresponding slice are left untouched for
+// that frame.
+// The colors of every frame are sent without acks,
+// one Set64 message per tile.
+//
+// If conn is a *lifxlan.RateLimitedConn the rate limiter is respected.
+// When sending a frame takes longer than the frame interval
+// (e.g. because of the rate limiter),
+// the missed frames are skipped instead of queued,
+// and the next frame is rendered with the up to date elapsed time,
+// so the animation stays in sync with the wall clock.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// It returns ctx.Err() after ctx is cancelled,
+// or the error if frame returns invalid colors or a frame failed to be sent.
+func Animate(
+	ctx context.Context,
+	conn net.Conn,
+	td Device,
+	fps int,
+	frame func(t time.Duration) [][]lifxlan.Color,
+) error {
+	if fps <= 0 {
+		return fmt.Errorf("lifxlan/tile.Animate: fps must be positive, got %d", fps)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	tiles := len(td.Tiles())
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	start := time.Now()
+	for {
+		colors := frame(time.Since(start))
+		if len(colors) > tiles {
+			return fmt.Errorf(
+				"lifxlan/tile.Animate: frame has %d tiles, device only has %d",
+				len(colors),
+				tiles,
+			)
+		}
+		indices := make([]int, 0, len(colors))
+		changed := make([][]lifxlan.Color, 0, len(colors))
+		for i, c := range colors {
+			if c == nil {
+				continue
+			}
+			if len(c) != ColorsPerTile {
+				return fmt.Errorf(
+					"lifxlan/tile.Animate: tile %d expected %d colors, got %d",
+					i,
+					ColorsPerTile,
+					len(c),
+				)
+			}
+			indices = append(indices, i)
+			changed = append(changed, c)
+		}
+		if err := setTiles(ctx, conn, "lifxlan/tile.Animate", td, indices, changed, 0, false); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package tile_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestAnimate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	var received int32
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			tile.SetTileState64: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				_ *lifxlan.Response,
+			) {
+				atomic.AddInt32(&received, 1)
+			},
+		},
+		RawStatePayload:            &light.RawStatePayload{},
+		RawStateDeviceChainPayload: rawChain,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	colors := make([]lifxlan.Color, tile.ColorsPerTile)
+	for i := range colors {
+		colors[i] = lifxlan.Color{
+			Hue:    uint16(i),
+			Kelvin: lifxlan.KelvinNeutral,
+		}
+	}
+
+	t.Run(
+		"Cancel",
+		func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var frames int32
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- tile.Animate(ctx, nil, td, 50, func(time.Duration) [][]lifxlan.Color {
+					atomic.AddInt32(&frames, 1)
+					return [][]lifxlan.Color{colors}
+				})
+			}()
+
+			time.Sleep(timeout / 2)
+			cancel()
+			select {
+			case err := <-errChan:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("Expected context.Canceled, got %v", err)
+				}
+			case <-time.After(timeout):
+				t.Fatal("Animate didn't stop after ctx is cancelled")
+			}
+			if atomic.LoadInt32(&frames) == 0 {
+				t.Error("Expected frame to be called")
+			}
+
+			// Wait for the last messages to be received by the mock.
+			time.Sleep(timeout / 10)
+			if atomic.LoadInt32(&received) == 0 {
+				t.Error("Expected Set64 messages to be received")
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := tile.Animate(ctx, nil, td, 0, nil); err == nil {
+				t.Error("Expected error with 0 fps")
+			}
+			err := tile.Animate(ctx, nil, td, 50, func(time.Duration) [][]lifxlan.Color {
+				return [][]lifxlan.Color{colors[:10]}
+			})
+			if err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected error with invalid colors, got %v", err)
+			}
+		},
+	)
+}
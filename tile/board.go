@@ -138,6 +138,10 @@ func (td *device) Height() int {
 	return td.board.Y
 }
 
+func (td *device) BoardSize() (width, height int) {
+	return td.Width(), td.Height()
+}
+
 func (td *device) OnTile(x, y int) bool {
 	if x < 0 || x >= td.Width() || y < 0 || y >= td.Height() {
 		return false
@@ -1,9 +1,13 @@
 package tile_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
 	"go.yhsif.com/lifxlan/tile"
 )
 
@@ -112,3 +116,77 @@ func TestParse(t *testing.T) {
 		},
 	)
 }
+
+func TestBoardSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	type position struct {
+		x, y float32
+	}
+	for _, c := range []struct {
+		label     string
+		positions []position
+		width     int
+		height    int
+	}{
+		{
+			label:     "2x2",
+			positions: []position{{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+			width:     16,
+			height:    16,
+		},
+		{
+			label:     "NonContiguous",
+			positions: []position{{0, 0}, {2, 0}},
+			width:     24,
+			height:    8,
+		},
+		{
+			label:     "Overlapping",
+			positions: []position{{0, 0}, {0.5, 0}},
+			width:     12,
+			height:    8,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				rawChain := &tile.RawStateDeviceChainPayload{
+					TotalCount: uint8(len(c.positions)),
+				}
+				for i, p := range c.positions {
+					rawChain.TileDevices[i] = tile.RawTileDevice{
+						UserX:  p.x,
+						UserY:  p.y,
+						Width:  8,
+						Height: 8,
+					}
+				}
+				service := &mock.Service{
+					TB:                         t,
+					RawStatePayload:            &light.RawStatePayload{},
+					RawStateDeviceChainPayload: rawChain,
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				td, err := tile.Wrap(ctx, device, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				width, height := td.BoardSize()
+				if width != c.width || height != c.height {
+					t.Errorf("BoardSize expected %dx%d, got %dx%d", c.width, c.height, width, height)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,189 @@
+package tile
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// Canvas is a drawing buffer addressed by board coordinates,
+// covering all the tiles of a tile device.
+//
+// It maps every board coordinate to the tile and the color index inside that
+// tile,
+// taking the user position and orientation of every tile into account,
+// the same way as SetColors.
+// Unlike ColorBoard,
+// it keeps one buffer per tile and tracks which tiles are changed since the
+// last Flush,
+// so only the changed tiles are sent to the device.
+//
+// The mapping is taken from the cached tiles of the device when the Canvas is
+// created,
+// create a new Canvas after calling GetDeviceChain or SetUserPosition.
+//
+// A Canvas is not safe for concurrent use.
+//
+// Example:
+//
+//     canvas := tile.NewCanvas(td)
+//     for x := 0; x < canvas.Width(); x++ {
+//       canvas.Set(x, canvas.Height()/2, red)
+//     }
+//     if err := canvas.Flush(ctx, conn, 0, true); err != nil {
+//       // handle error
+//     }
+type Canvas struct {
+	dev   Device
+	board BoardData
+
+	widths  []uint8
+	buffers [][]lifxlan.Color
+	dirty   []bool
+}
+
+var _ Board = (*Canvas)(nil)
+
+// NewCanvas creates a new Canvas for d,
+// with all the pixels initialized to black.
+func NewCanvas(d Device) *Canvas {
+	tiles := d.Tiles()
+	ptrs := make([]*Tile, len(tiles))
+	c := &Canvas{
+		dev:     d,
+		widths:  make([]uint8, len(tiles)),
+		buffers: make([][]lifxlan.Color, len(tiles)),
+		dirty:   make([]bool, len(tiles)),
+	}
+	for i := range tiles {
+		ptrs[i] = &tiles[i]
+		c.widths[i] = d.TileWidth(i)
+		c.buffers[i] = make([]lifxlan.Color, ColorsPerTile)
+		for j := range c.buffers[i] {
+			c.buffers[i][j] = lifxlan.ColorBlack
+		}
+	}
+	c.board = ParseBoard(ptrs)
+	return c
+}
+
+// Width returns the width of the board.
+func (c *Canvas) Width() int {
+	return c.board.X
+}
+
+// Height returns the height of the board.
+func (c *Canvas) Height() int {
+	return c.board.Y
+}
+
+// OnTile returns true if coordinate (x, y) is on a tile.
+func (c *Canvas) OnTile(x, y int) bool {
+	return c.Locate(x, y) != nil
+}
+
+// Locate returns the tile index and the coordinate inside that tile for board
+// coordinate (x, y).
+//
+// It returns nil if (x, y) is not on a tile.
+func (c *Canvas) Locate(x, y int) *IndexData {
+	if x < 0 || x >= c.Width() || y < 0 || y >= c.Height() {
+		return nil
+	}
+	return c.board.Data[x][y]
+}
+
+// Set sets the color of the pixel at board coordinate (x, y).
+//
+// It's a no-op if (x, y) is not on a tile.
+func (c *Canvas) Set(x, y int, color lifxlan.Color) {
+	data := c.Locate(x, y)
+	if data == nil {
+		return
+	}
+	c.buffers[data.Index][tileColorIndex(data, c.widths[data.Index])] = color
+	c.dirty[data.Index] = true
+}
+
+// Get returns the color of the pixel at board coordinate (x, y).
+//
+// It returns nil if (x, y) is not on a tile.
+func (c *Canvas) Get(x, y int) *lifxlan.Color {
+	data := c.Locate(x, y)
+	if data == nil {
+		return nil
+	}
+	color := c.buffers[data.Index][tileColorIndex(data, c.widths[data.Index])]
+	return &color
+}
+
+// Fill sets all the pixels to color.
+func (c *Canvas) Fill(color lifxlan.Color) {
+	for i, buf := range c.buffers {
+		for j := range buf {
+			buf[j] = color
+		}
+		c.dirty[i] = true
+	}
+}
+
+// TileColors returns a copy of the buffer of the i-th tile,
+// in the same order as the colors field of Set64 message,
+// so it can be passed to Device.SetTileColors directly.
+//
+// It returns nil if i is out of range.
+func (c *Canvas) TileColors(i int) []lifxlan.Color {
+	if i < 0 || i >= len(c.buffers) {
+		return nil
+	}
+	colors := make([]lifxlan.Color, len(c.buffers[i]))
+	copy(colors, c.buffers[i])
+	return colors
+}
+
+// Flush sends the tiles changed since the last successful Flush to the device
+// as Set64 messages,
+// one per tile.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// If ack is false,
+// this function returns nil error after the messages are sent successfully.
+// If ack is true,
+// this function will only return nil error after it received all ack(s) from
+// the device.
+//
+// On error,
+// the tiles stay marked as changed so they will be sent again by the next
+// Flush.
+func (c *Canvas) Flush(ctx context.Context, conn net.Conn, transition time.Duration, ack bool) error {
+	var indices []int
+	for i, dirty := range c.dirty {
+		if dirty {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/tile.Canvas.Flush: %w", err)
+	}
+
+	colors := make([][]lifxlan.Color, len(indices))
+	for j, i := range indices {
+		colors[j] = c.buffers[i]
+	}
+	if err := setTiles(ctx, conn, "lifxlan/tile.Canvas.Flush", c.dev, indices, colors, transition, ack); err != nil {
+		return err
+	}
+	for _, i := range indices {
+		c.dirty[i] = false
+	}
+	return nil
+}
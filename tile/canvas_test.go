@@ -0,0 +1,181 @@
+package tile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestCanvas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	// 2 tiles side by side:
+	//
+	//     +--+--+
+	//     |0 |1 |
+	//     +--+--+
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 2,
+	}
+	for i := 0; i < 2; i++ {
+		rawChain.TileDevices[i] = tile.RawTileDevice{
+			UserX:  float32(i),
+			Width:  8,
+			Height: 8,
+		}
+	}
+
+	var lock sync.Mutex
+	var received []tile.RawSetTileState64Payload
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			tile.SetTileState64: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw tile.RawSetTileState64Payload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw)
+			},
+		},
+		RawStatePayload:            &light.RawStatePayload{},
+		RawStateDeviceChainPayload: rawChain,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	td, err := tile.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canvas := tile.NewCanvas(td)
+	if canvas.Width() != 16 || canvas.Height() != 8 {
+		t.Fatalf("Size expected 16x8, got %dx%d", canvas.Width(), canvas.Height())
+	}
+
+	color := lifxlan.Color{
+		Hue:        0x1234,
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	// (9, 2) on the board is the 2nd column and the 6th row from the top of
+	// tile 1.
+	const (
+		x          = 9
+		y          = 2
+		tileIndex  = 1
+		colorIndex = 5*8 + 1
+	)
+	canvas.Set(x, y, color)
+	// Out of the board, should be ignored.
+	canvas.Set(-1, 0, color)
+	canvas.Set(16, 0, color)
+
+	if got := canvas.Get(x, y); got == nil || *got != color {
+		t.Errorf("Get(%d, %d) expected %v, got %v", x, y, color, got)
+	}
+	if got := canvas.Locate(x, y); got == nil || got.Index != tileIndex {
+		t.Errorf("Locate(%d, %d) expected to be on tile %d, got %v", x, y, tileIndex, got)
+	}
+	for i := 0; i < 2; i++ {
+		for j, c := range canvas.TileColors(i) {
+			expected := lifxlan.ColorBlack
+			if i == tileIndex && j == colorIndex {
+				expected = color
+			}
+			if c != expected {
+				t.Errorf("Tile %d color %d expected %v, got %v", i, j, expected, c)
+			}
+		}
+	}
+
+	if err := canvas.Flush(ctx, nil, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing changed, should not send anything.
+	if err := canvas.Flush(ctx, nil, 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 Set64 message, got %d", len(received))
+	}
+	raw := received[0]
+	if raw.TileIndex != tileIndex {
+		t.Errorf("TileIndex expected %d, got %d", tileIndex, raw.TileIndex)
+	}
+	if raw.Colors[colorIndex] != color {
+		t.Errorf("Color %d expected %v, got %v", colorIndex, color, raw.Colors[colorIndex])
+	}
+}
+
+func TestCanvasFlushTileSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	// A single 16x8 tile, too large for a Set64 message.
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  16,
+		Height: 8,
+	}
+	service := &mock.Service{
+		TB:                         t,
+		RawStatePayload:            &light.RawStatePayload{},
+		RawStateDeviceChainPayload: rawChain,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	td, err := tile.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canvas := tile.NewCanvas(td)
+	canvas.Fill(lifxlan.ColorBlack)
+	const expected = "lifxlan/tile.Canvas.Flush: "
+	err = canvas.Flush(ctx, nil, 0, false)
+	if err == nil || !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("Expected error prefixed by %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,229 @@
+package tile
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// MaxTilesInChain is the max number of tiles can be carried by a single
+// StateDeviceChain message.
+const MaxTilesInChain = 16
+
+// MinReadBufferSize is the minimum read buffer size
+// (see lifxlan.WithReadBufferSize) for the responses of the APIs in this
+// package.
+//
+// It's the size of a StateDeviceChain message,
+// the biggest one in this package.
+const MinReadBufferSize = lifxlan.HeaderLength + 882
+
+// RawStateDeviceChainPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statedevicechain---packet-702
+type RawStateDeviceChainPayload struct {
+	StartIndex  uint8
+	TileDevices [MaxTilesInChain]RawTileDevice
+	TotalCount  uint8
+}
+
+// DeviceChain defines the tiles reported by a tile device.
+type DeviceChain struct {
+	// The chain index of the first tile in Tiles.
+	StartIndex uint8
+
+	// Tiles[i] is the tile at chain index StartIndex+i.
+	Tiles []Tile
+}
+
+// ParseDeviceChain parses RawStateDeviceChainPayload into a DeviceChain.
+//
+// The first TotalCount elements of raw.TileDevices are the tiles starting from
+// chain index raw.StartIndex,
+// the rest of raw.TileDevices are ignored.
+func ParseDeviceChain(raw *RawStateDeviceChainPayload) (*DeviceChain, error) {
+	if raw.TotalCount == 0 {
+		return nil, errors.New("lifxlan/tile.ParseDeviceChain: no tiles found")
+	}
+	if raw.TotalCount > MaxTilesInChain {
+		return nil, fmt.Errorf(
+			"lifxlan/tile.ParseDeviceChain: total count %d > %d",
+			raw.TotalCount,
+			MaxTilesInChain,
+		)
+	}
+	if int(raw.StartIndex)+int(raw.TotalCount) > MaxTilesInChain {
+		return nil, fmt.Errorf(
+			"lifxlan/tile.ParseDeviceChain: start index %d + total count %d > %d",
+			raw.StartIndex,
+			raw.TotalCount,
+			MaxTilesInChain,
+		)
+	}
+
+	chain := &DeviceChain{
+		StartIndex: raw.StartIndex,
+		Tiles:      make([]Tile, raw.TotalCount),
+	}
+	for i := range chain.Tiles {
+		chain.Tiles[i] = *ParseTile(&raw.TileDevices[i])
+	}
+	return chain, nil
+}
+
+// setChain replaces the cached tiles of td with chain and re-parses the board.
+func (td *device) setChain(chain *DeviceChain) {
+	td.startIndex = chain.StartIndex
+	td.tiles = make([]*Tile, len(chain.Tiles))
+	for i := range chain.Tiles {
+		t := chain.Tiles[i]
+		td.tiles[i] = &t
+	}
+	td.parseBoard()
+}
+
+func (td *device) GetDeviceChain(
+	ctx context.Context,
+	conn net.Conn,
+) (*DeviceChain, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := td.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetDeviceChain,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		td.Source(),
+		seq,
+		StateDeviceChain,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateDeviceChainPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	chain, err := ParseDeviceChain(&raw)
+	if err != nil {
+		return nil, err
+	}
+	td.setChain(chain)
+	return chain, nil
+}
+
+// RawSetUserPositionPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#setuserposition---packet-703
+type RawSetUserPositionPayload struct {
+	TileIndex uint8
+	_         [2]byte // reserved
+	UserX     float32
+	UserY     float32
+}
+
+func (td *device) SetUserPosition(
+	ctx context.Context,
+	conn net.Conn,
+	tileIndex uint8,
+	x, y float32,
+	ack bool,
+) error {
+	if int(tileIndex) >= len(td.tiles) {
+		return fmt.Errorf(
+			"lifxlan/tile.SetUserPosition: tile index %d out of range [0, %d)",
+			tileIndex,
+			len(td.tiles),
+		)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := td.Send(
+		ctx,
+		conn,
+		flags,
+		SetUserPosition,
+		&RawSetUserPositionPayload{
+			TileIndex: td.startIndex + tileIndex,
+			UserX:     x,
+			UserY:     y,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		if err := lifxlan.WaitForAcks(ctx, conn, td.Source(), seq); err != nil {
+			return err
+		}
+	}
+
+	// Update the cached tiles so the board matches the new geometry.
+	t := *td.tiles[tileIndex]
+	t.UserX = x
+	t.UserY = y
+	td.tiles[tileIndex] = &t
+	td.parseBoard()
+	return nil
+}
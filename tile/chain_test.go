@@ -0,0 +1,321 @@
+package tile_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestMinReadBufferSize(t *testing.T) {
+	expected := lifxlan.HeaderLength + binary.Size(tile.RawStateDeviceChainPayload{})
+	if tile.MinReadBufferSize != expected {
+		t.Errorf("MinReadBufferSize expected %d, got %d", expected, tile.MinReadBufferSize)
+	}
+	for _, payload := range []interface{}{
+		tile.RawStateTileState64Payload{},
+		tile.RawStateTileEffectPayload{},
+	} {
+		if size := lifxlan.HeaderLength + binary.Size(payload); size > tile.MinReadBufferSize {
+			t.Errorf("%T size %d > MinReadBufferSize %d", payload, size, tile.MinReadBufferSize)
+		}
+	}
+}
+
+func TestParseDeviceChain(t *testing.T) {
+	version := lifxlan.HardwareVersion{
+		VendorID:        1,
+		ProductID:       55,
+		HardwareVersion: 1,
+	}
+
+	t.Run(
+		"Full",
+		func(t *testing.T) {
+			raw := &tile.RawStateDeviceChainPayload{
+				TotalCount: tile.MaxTilesInChain,
+			}
+			for i := range raw.TileDevices {
+				raw.TileDevices[i] = tile.RawTileDevice{
+					AccelMeasX:      int16(i),
+					AccelMeasY:      -1,
+					AccelMeasZ:      int16(-i),
+					UserX:           float32(i),
+					UserY:           0.5,
+					Width:           8,
+					Height:          8,
+					HardwareVersion: version,
+					Firmware: lifxlan.RawStateHostFirmwarePayload{
+						VersionMajor: 3,
+						VersionMinor: uint16(i),
+					},
+				}
+			}
+
+			chain, err := tile.ParseDeviceChain(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chain.StartIndex != 0 {
+				t.Errorf("StartIndex expected 0, got %d", chain.StartIndex)
+			}
+			if len(chain.Tiles) != tile.MaxTilesInChain {
+				t.Fatalf("Expected %d tiles, got %d", tile.MaxTilesInChain, len(chain.Tiles))
+			}
+			for i, ti := range chain.Tiles {
+				if ti.UserX != float32(i) || ti.UserY != 0.5 {
+					t.Errorf("#%d: User position expected (%d, 0.5), got (%v, %v)", i, i, ti.UserX, ti.UserY)
+				}
+				if ti.Width != 8 || ti.Height != 8 {
+					t.Errorf("#%d: Size expected 8x8, got %dx%d", i, ti.Width, ti.Height)
+				}
+				if ti.AccelMeasX != int16(i) || ti.AccelMeasY != -1 || ti.AccelMeasZ != int16(-i) {
+					t.Errorf(
+						"#%d: Accel expected (%d, -1, %d), got (%d, %d, %d)",
+						i,
+						i,
+						-i,
+						ti.AccelMeasX,
+						ti.AccelMeasY,
+						ti.AccelMeasZ,
+					)
+				}
+				if ti.HardwareVersion != version {
+					t.Errorf("#%d: HardwareVersion expected %+v, got %+v", i, version, ti.HardwareVersion)
+				}
+				if ti.Firmware.Major != 3 || ti.Firmware.Minor != uint16(i) {
+					t.Errorf("#%d: Firmware expected 3.%d, got %v", i, i, ti.Firmware)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"StartIndex",
+		func(t *testing.T) {
+			raw := &tile.RawStateDeviceChainPayload{
+				StartIndex: 2,
+				TotalCount: 3,
+			}
+			for i := range raw.TileDevices {
+				raw.TileDevices[i] = tile.RawTileDevice{
+					UserX:  float32(i),
+					Width:  8,
+					Height: 8,
+				}
+			}
+
+			chain, err := tile.ParseDeviceChain(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chain.StartIndex != 2 {
+				t.Errorf("StartIndex expected 2, got %d", chain.StartIndex)
+			}
+			if len(chain.Tiles) != 3 {
+				t.Fatalf("Expected 3 tiles, got %d", len(chain.Tiles))
+			}
+			for i, ti := range chain.Tiles {
+				if ti.UserX != float32(i) {
+					t.Errorf("#%d: UserX expected %d, got %v", i, i, ti.UserX)
+				}
+			}
+		},
+	)
+
+	for _, c := range []struct {
+		label string
+		raw   tile.RawStateDeviceChainPayload
+	}{
+		{
+			label: "Empty",
+			raw:   tile.RawStateDeviceChainPayload{},
+		},
+		{
+			label: "TooMany",
+			raw: tile.RawStateDeviceChainPayload{
+				TotalCount: tile.MaxTilesInChain + 1,
+			},
+		},
+		{
+			label: "Overflow",
+			raw: tile.RawStateDeviceChainPayload{
+				StartIndex: 1,
+				TotalCount: tile.MaxTilesInChain,
+			},
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if chain, err := tile.ParseDeviceChain(&c.raw); err == nil {
+					t.Errorf("Expected error, got %+v", chain)
+				}
+			},
+		)
+	}
+}
+
+func TestGetDeviceChain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(td.Tiles()) != 1 {
+		t.Fatalf("Expected 1 tile after Wrap, got %d", len(td.Tiles()))
+	}
+	if td.Width() != 8 {
+		t.Errorf("Board width expected 8, got %d", td.Width())
+	}
+
+	// A second tile is connected to the chain.
+	rawChain = &tile.RawStateDeviceChainPayload{
+		TotalCount: 2,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	rawChain.TileDevices[1] = tile.RawTileDevice{
+		UserX:  1,
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	chain, err := td.GetDeviceChain(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain.Tiles) != 2 {
+		t.Errorf("Expected 2 tiles, got %d", len(chain.Tiles))
+	}
+	if len(td.Tiles()) != 2 {
+		t.Errorf("Expected 2 cached tiles, got %d", len(td.Tiles()))
+	}
+	if td.Width() != 16 {
+		t.Errorf("Board width expected 16, got %d", td.Width())
+	}
+}
+
+func TestSetUserPosition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 2,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	rawChain.TileDevices[1] = tile.RawTileDevice{
+		UserX:  1,
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td.Width() != 16 || td.Height() != 8 {
+		t.Fatalf("Board size expected 16x8, got %dx%d", td.Width(), td.Height())
+	}
+
+	const x, y = float32(0), float32(1.5)
+
+	var payload []byte
+	service.Handlers[tile.SetUserPosition] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		payload = append([]byte(nil), orig.Payload...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := td.SetUserPosition(ctx, nil, 1, x, y, true); err != nil {
+		t.Fatal(err)
+	}
+
+	const expectedSize = 11
+	if len(payload) != expectedSize {
+		t.Fatalf("Payload size expected %d, got %d", expectedSize, len(payload))
+	}
+	if payload[0] != 1 {
+		t.Errorf("TileIndex expected 1, got %d", payload[0])
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(payload[3:7])); got != x {
+		t.Errorf("UserX expected %v, got %v", x, got)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(payload[7:11])); got != y {
+		t.Errorf("UserY expected %v, got %v", y, got)
+	}
+
+	tiles := td.Tiles()
+	if tiles[1].UserX != x || tiles[1].UserY != y {
+		t.Errorf(
+			"Cached user position expected (%v, %v), got (%v, %v)",
+			x,
+			y,
+			tiles[1].UserX,
+			tiles[1].UserY,
+		)
+	}
+	// 8*1.5 = 12, so the second tile now covers y in [12, 20).
+	if td.Width() != 8 || td.Height() != 20 {
+		t.Errorf("Board size expected 8x20, got %dx%d", td.Width(), td.Height())
+	}
+
+	if err := td.SetUserPosition(ctx, nil, 2, x, y, true); err == nil {
+		t.Error("Expected error with tile index 2, got nil")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"net"
 	"sync"
 	"time"
@@ -39,6 +40,10 @@ func (cb ColorBoard) GetColor(x, y int) *lifxlan.Color {
 	return row[y]
 }
 
+// ColorsPerTile is the number of colors carried by a single Set64 or State64
+// message, which covers a whole tile.
+const ColorsPerTile = 64
+
 // RawSetTileState64Payload defines the struct to be used for encoding and
 // decoding.
 //
@@ -51,7 +56,7 @@ type RawSetTileState64Payload struct {
 	Y         uint8
 	Width     uint8
 	Duration  lifxlan.TransitionTime
-	Colors    [64]lifxlan.Color
+	Colors    [ColorsPerTile]lifxlan.Color
 }
 
 func (td *device) SetColors(
@@ -61,6 +66,15 @@ func (td *device) SetColors(
 	transition time.Duration,
 	ack bool,
 ) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/tile.SetColors: %w", err)
+	}
+	for i := range td.tiles {
+		if err := td.checkTileSize("lifxlan/tile.SetColors", i); err != nil {
+			return err
+		}
+	}
+
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -101,12 +115,131 @@ func (td *device) SetColors(
 					// Not on tile
 					continue
 				}
-				colorIndex := data.X*int(td.TileWidth(data.Index)) + data.Y
+				colorIndex := tileColorIndex(data, td.TileWidth(data.Index))
 				payloads[data.Index].Colors[colorIndex] = td.SanitizeColor(*c)
 			}
 		}
 	}
 
+	return td.sendTiles(ctx, conn, payloads, ack)
+}
+
+// checkTileSize returns an error prefixed by caller if the size of the i-th
+// tile from the device chain cannot be written by a single Set64 message,
+// which carries ColorsPerTile colors with a stride of the tile width,
+// instead of garbling the tile (or the other tiles) with a wrong layout.
+func (td *device) checkTileSize(caller string, i int) error {
+	t := td.tiles[i]
+	width, height := int(t.Width), int(t.Height)
+	if width == 0 || height == 0 {
+		return fmt.Errorf(
+			"%s: tile %d has unknown size %dx%d, call GetDeviceChain first",
+			caller,
+			i,
+			width,
+			height,
+		)
+	}
+	if width*height > ColorsPerTile {
+		return fmt.Errorf(
+			"%s: tile %d size %dx%d doesn't fit in the %d colors of a Set64 message",
+			caller,
+			i,
+			width,
+			height,
+			ColorsPerTile,
+		)
+	}
+	return nil
+}
+
+// tileColorIndex returns the index in the colors field of Set64 and State64
+// messages for the tile coordinate of data,
+// on a tile with the given width.
+func tileColorIndex(data *IndexData, width uint8) int {
+	return data.X*int(width) + data.Y
+}
+
+// setTiles sets the colors of the tiles at indices in Tiles() of d,
+// one Set64 message per tile.
+//
+// When d is the device returned by Wrap,
+// the messages are sent together and their acks are waited together,
+// otherwise it falls back to calling SetTileColors one by one.
+//
+// transition must be already checked by lifxlan.CheckDuration.
+// caller is used to prefix the tile size errors.
+func setTiles(
+	ctx context.Context,
+	conn net.Conn,
+	caller string,
+	d Device,
+	indices []int,
+	colors [][]lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	td, ok := d.(*device)
+	if !ok {
+		for j, i := range indices {
+			if err := d.SetTileColors(ctx, conn, i, colors[j], transition, ack); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, i := range indices {
+		if err := td.checkTileSize(caller, i); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payloads := make([]*RawSetTileState64Payload, len(indices))
+	for j, i := range indices {
+		payloads[j] = td.tilePayload(i, colors[j], transition)
+	}
+	return td.sendTiles(ctx, conn, payloads, ack)
+}
+
+// tilePayload builds the Set64 payload to set the i-th tile to colors.
+func (td *device) tilePayload(i int, colors []lifxlan.Color, transition time.Duration) *RawSetTileState64Payload {
+	payload := &RawSetTileState64Payload{
+		TileIndex: td.startIndex + uint8(i),
+		Length:    1,
+		Width:     td.TileWidth(i),
+		Duration:  lifxlan.ConvertDuration(transition),
+	}
+	for k, color := range colors {
+		payload.Colors[k] = td.SanitizeColor(color)
+	}
+	return payload
+}
+
+// sendTiles sends all the Set64 payloads to conn together,
+// and waits for all the acks if ack is true.
+func (td *device) sendTiles(
+	ctx context.Context,
+	conn net.Conn,
+	payloads []*RawSetTileState64Payload,
+	ack bool,
+) error {
 	var flags lifxlan.AckResFlag
 	if ack {
 		flags |= lifxlan.FlagAckRequired
@@ -163,6 +296,78 @@ func (td *device) SetColors(
 	return nil
 }
 
+func (td *device) SetTileColors(
+	ctx context.Context,
+	conn net.Conn,
+	tileIndex int,
+	colors []lifxlan.Color,
+	transition time.Duration,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/tile.SetTileColors: %w", err)
+	}
+
+	if len(colors) != ColorsPerTile {
+		return fmt.Errorf(
+			"lifxlan/tile.SetTileColors: expected %d colors, got %d",
+			ColorsPerTile,
+			len(colors),
+		)
+	}
+	if tileIndex < 0 || tileIndex >= len(td.tiles) {
+		return fmt.Errorf(
+			"lifxlan/tile.SetTileColors: tile index %d out of range [0, %d)",
+			tileIndex,
+			len(td.tiles),
+		)
+	}
+	if err := td.checkTileSize("lifxlan/tile.SetTileColors", tileIndex); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload := td.tilePayload(tileIndex, colors, transition)
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := td.Send(
+		ctx,
+		conn,
+		flags,
+		SetTileState64,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, td.Source(), seq)
+	}
+	return nil
+}
+
 // RawGetTileState64Payload defines the struct to be used for encoding and
 // decoding.
 //
@@ -186,7 +391,7 @@ type RawStateTileState64Payload struct {
 	X         uint8
 	Y         uint8
 	Width     uint8
-	Colors    [64]lifxlan.Color
+	Colors    [ColorsPerTile]lifxlan.Color
 }
 
 func (td *device) GetColors(
@@ -227,29 +432,8 @@ func (td *device) GetColors(
 	}
 
 	// Read responses
-	received := make([]int, len(td.tiles))
 	cb := MakeColorBoard(td.Width(), td.Height())
-	for {
-		resp, err := lifxlan.ReadNextResponse(ctx, conn)
-		if err != nil {
-			return nil, err
-		}
-		if resp.Sequence != seq || resp.Source != td.Source() {
-			continue
-		}
-		if resp.Message != StateTileState64 {
-			continue
-		}
-
-		var raw RawStateTileState64Payload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return nil, err
-		}
-
-		// tile index
-		ti := raw.TileIndex - td.startIndex
-		received[ti] = 1
+	err = td.readTiles(ctx, conn, seq, 0, len(td.tiles), func(ti int, raw *RawStateTileState64Payload) {
 		tile := td.tiles[ti]
 		for x := 0; x < int(tile.Width); x++ {
 			for y := 0; y < int(tile.Height); y++ {
@@ -258,14 +442,113 @@ func (td *device) GetColors(
 				cb[c.X][c.Y] = &raw.Colors[x*int(tile.Width)+y]
 			}
 		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+// readTiles waits for the StateTileState64 responses to the GetTileState64
+// message with seq,
+// until every tile in [start, start+length) of Tiles() has been received once,
+// and calls fn for each of them with the index in Tiles().
+//
+// Duplicated responses and responses for tiles outside of the range are
+// dropped.
+func (td *device) readTiles(
+	ctx context.Context,
+	conn net.Conn,
+	seq uint8,
+	start, length int,
+	fn func(ti int, raw *RawStateTileState64Payload),
+) error {
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	received := make([]bool, length)
+	_, err := lifxlan.WaitForResponsesFunc(
+		ctx,
+		conn,
+		td.Source(),
+		seq,
+		StateTileState64,
+		length,
+		func(resp *lifxlan.Response) (bool, error) {
+			var raw RawStateTileState64Payload
+			r := bytes.NewReader(resp.Payload)
+			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+				return false, err
+			}
+
+			// tile index
+			ti := int(raw.TileIndex) - int(td.startIndex)
+			i := ti - start
+			if i < 0 || i >= length || received[i] {
+				return false, nil
+			}
+			received[i] = true
+			fn(ti, &raw)
+			return true, nil
+		},
+	)
+	return err
+}
+
+func (td *device) GetTileColors(
+	ctx context.Context,
+	conn net.Conn,
+	tileIndex uint8,
+	length uint8,
+) ([][]lifxlan.Color, error) {
+	if length == 0 || int(tileIndex)+int(length) > len(td.tiles) {
+		return nil, fmt.Errorf(
+			"lifxlan/tile.GetTileColors: tiles [%d, %d) out of range [0, %d)",
+			tileIndex,
+			int(tileIndex)+int(length),
+			len(td.tiles),
+		)
+	}
 
-		n := 0
-		for _, rec := range received {
-			n += rec
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return nil, err
 		}
-		if n >= len(td.tiles) {
-			// Got responses for all tiles.
-			return cb, nil
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
 	}
+
+	// Send
+	start := td.startIndex + tileIndex
+	seq, err := td.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetTileState64,
+		&RawGetTileState64Payload{
+			TileIndex: start,
+			Length:    length,
+			Width:     td.TileWidth(int(tileIndex)),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read responses
+	colors := make([][]lifxlan.Color, length)
+	err = td.readTiles(ctx, conn, seq, int(tileIndex), int(length), func(ti int, raw *RawStateTileState64Payload) {
+		colors[ti-int(tileIndex)] = append([]lifxlan.Color(nil), raw.Colors[:]...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return colors, nil
 }
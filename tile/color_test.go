@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net"
 	"reflect"
+	"sync"
 	"testing"
 	"testing/quick"
 	"time"
@@ -325,6 +326,53 @@ func TestColorsAPIs(t *testing.T) {
 					}
 				},
 			)
+
+			t.Run(
+				"OnlyDuplicated",
+				func(t *testing.T) {
+					service.RawStateTileState64Payloads = []*tile.RawStateTileState64Payload{
+						&stateColor1,
+						&stateColor1,
+					}
+
+					ctx, cancel := context.WithTimeout(context.Background(), timeout)
+					defer cancel()
+
+					if _, err := td.GetColors(ctx, nil); err == nil {
+						t.Error("Expected error when only duplicated tiles returned, got nil")
+					}
+				},
+			)
+
+			t.Run(
+				"DuplicatedAndUnexpected",
+				func(t *testing.T) {
+					unexpected := stateColor1
+					unexpected.TileIndex = 5
+					service.RawStateTileState64Payloads = []*tile.RawStateTileState64Payload{
+						&stateColor1,
+						&stateColor1,
+						&unexpected,
+						&stateColor2,
+					}
+
+					ctx, cancel := context.WithTimeout(context.Background(), timeout)
+					defer cancel()
+
+					cb, err := td.GetColors(ctx, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					for x := 0; x < 16; x++ {
+						for y := 0; y < 8; y++ {
+							if c := cb.GetColor(x, y); c == nil || *c != lifxlan.ColorBlack {
+								t.Errorf("Got color %+v at (%d, %d)", c, x, y)
+							}
+						}
+					}
+				},
+			)
 		},
 	)
 
@@ -408,3 +456,402 @@ func TestColorsAPIs(t *testing.T) {
 		},
 	)
 }
+
+func TestSetTileColors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const tiles = 5
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: tiles,
+	}
+	for i := 0; i < tiles; i++ {
+		rawChain.TileDevices[i] = tile.RawTileDevice{
+			UserX:  float32(i),
+			Width:  8,
+			Height: 8,
+		}
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	colors := make([]lifxlan.Color, tile.ColorsPerTile)
+	for i := range colors {
+		colors[i] = lifxlan.Color{
+			Hue:    uint16(i),
+			Kelvin: lifxlan.KelvinNeutral,
+		}
+	}
+
+	t.Run(
+		"Normal",
+		func(t *testing.T) {
+			const index = 3
+
+			var lock sync.Mutex
+			var received []tile.RawSetTileState64Payload
+			service.Handlers[tile.SetTileState64] = func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw tile.RawSetTileState64Payload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Fatal(err)
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, raw)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := td.SetTileColors(ctx, nil, index, colors, time.Second, true); err != nil {
+				t.Fatal(err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(received) != 1 {
+				t.Fatalf("Expected 1 Set64 message, got %d", len(received))
+			}
+			raw := received[0]
+			if raw.TileIndex != index {
+				t.Errorf("TileIndex expected %d, got %d", index, raw.TileIndex)
+			}
+			if raw.Length != 1 {
+				t.Errorf("Length expected 1, got %d", raw.Length)
+			}
+			if raw.Width != 8 {
+				t.Errorf("Width expected 8, got %d", raw.Width)
+			}
+			if !reflect.DeepEqual(raw.Colors[:], colors) {
+				t.Errorf("Colors expected %v, got %v", colors, raw.Colors)
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := td.SetTileColors(ctx, nil, 0, colors[:63], 0, false); err == nil {
+				t.Error("Expected error with 63 colors, got nil")
+			}
+			if err := td.SetTileColors(ctx, nil, tiles, colors, 0, false); err == nil {
+				t.Errorf("Expected error with tile index %d, got nil", tiles)
+			}
+			if err := td.SetTileColors(ctx, nil, -1, colors, 0, false); err == nil {
+				t.Error("Expected error with tile index -1, got nil")
+			}
+		},
+	)
+}
+
+func TestSetTileColorsSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	colors := make([]lifxlan.Color, tile.ColorsPerTile)
+	for _, c := range []struct {
+		label  string
+		width  uint8
+		height uint8
+		valid  bool
+	}{
+		{
+			label:  "8x8",
+			width:  8,
+			height: 8,
+			valid:  true,
+		},
+		{
+			label:  "5x6",
+			width:  5,
+			height: 6,
+			valid:  true,
+		},
+		{
+			label:  "16x8",
+			width:  16,
+			height: 8,
+		},
+		{
+			label:  "8x9",
+			width:  8,
+			height: 9,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				var lock sync.Mutex
+				var received int
+				rawChain := &tile.RawStateDeviceChainPayload{
+					TotalCount: 1,
+				}
+				rawChain.TileDevices[0] = tile.RawTileDevice{
+					Width:  c.width,
+					Height: c.height,
+				}
+				service := &mock.Service{
+					TB:         t,
+					HandleAcks: true,
+					Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+						tile.SetTileState64: func(
+							_ *mock.Service,
+							_ net.PacketConn,
+							_ net.Addr,
+							_ *lifxlan.Response,
+						) {
+							lock.Lock()
+							defer lock.Unlock()
+							received++
+						},
+					},
+					RawStatePayload:            &light.RawStatePayload{},
+					RawStateDeviceChainPayload: rawChain,
+				}
+				device := service.Start()
+				defer service.Stop()
+
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				td, err := tile.Wrap(ctx, device, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = td.SetTileColors(ctx, nil, 0, colors, 0, true)
+				if c.valid && err != nil {
+					t.Errorf("SetTileColors expected nil error, got %v", err)
+				}
+				if !c.valid && err == nil {
+					t.Error("SetTileColors expected error, got nil")
+				}
+				err = td.SetColors(ctx, nil, tile.MakeColorBoard(td.Width(), td.Height()), 0, true)
+				if c.valid && err != nil {
+					t.Errorf("SetColors expected nil error, got %v", err)
+				}
+				if !c.valid && err == nil {
+					t.Error("SetColors expected error, got nil")
+				}
+				t.Log(err)
+
+				lock.Lock()
+				defer lock.Unlock()
+				expected := 0
+				if c.valid {
+					expected = 2
+				}
+				if received != expected {
+					t.Errorf("Expected %d Set64 messages, got %d", expected, received)
+				}
+			},
+		)
+	}
+}
+
+func TestGetTileColors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const tiles = 3
+
+	state := mock.State{
+		Tiles: make([]mock.Tile, tiles),
+	}
+	for i := range state.Tiles {
+		state.Tiles[i].Device = tile.RawTileDevice{
+			UserX:  float32(i),
+			Width:  8,
+			Height: 8,
+		}
+		for j := range state.Tiles[i].Colors {
+			state.Tiles[i].Colors[j] = lifxlan.Color{
+				Hue:    uint16(i*tile.ColorsPerTile + j),
+				Kelvin: lifxlan.KelvinNeutral,
+			}
+		}
+	}
+	server, device := mock.StartServer(t, state)
+	defer server.Stop()
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(
+		"Range",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			colors, err := td.GetTileColors(ctx, nil, 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(colors) != 2 {
+				t.Fatalf("Expected 2 tiles, got %d", len(colors))
+			}
+			for i, got := range colors {
+				expected := state.Tiles[i+1].Colors[:]
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("Tile %d expected %v, got %v", i+1, expected, got)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Restore",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			snapshot, err := td.GetTileColors(ctx, nil, 0, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			black := make([]lifxlan.Color, tile.ColorsPerTile)
+			for i := range black {
+				black[i] = lifxlan.Color{Kelvin: lifxlan.KelvinNeutral}
+			}
+			if err := td.SetTileColors(ctx, nil, 0, black, 0, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := td.SetTileColors(ctx, nil, 0, snapshot[0], 0, true); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := td.GetTileColors(ctx, nil, 0, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(restored, snapshot) {
+				t.Errorf("Restored colors expected %v, got %v", snapshot, restored)
+			}
+		},
+	)
+
+	t.Run(
+		"OutOfRange",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			for _, c := range []struct {
+				index, length uint8
+			}{
+				{0, 0},
+				{2, 2},
+				{tiles, 1},
+			} {
+				if _, err := td.GetTileColors(ctx, nil, c.index, c.length); err == nil {
+					t.Errorf("GetTileColors(%d, %d) expected error, got nil", c.index, c.length)
+				}
+			}
+		},
+	)
+}
+
+func TestGetTileColorsDuplicated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const tiles = 3
+
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: tiles,
+	}
+	states := make([]tile.RawStateTileState64Payload, tiles)
+	for i := range states {
+		rawChain.TileDevices[i] = tile.RawTileDevice{
+			UserX:  float32(i),
+			Width:  8,
+			Height: 8,
+		}
+		states[i] = tile.RawStateTileState64Payload{
+			TileIndex: uint8(i),
+			Width:     8,
+		}
+		for j := range states[i].Colors {
+			states[i].Colors[j] = lifxlan.Color{
+				Hue:    uint16(i*tile.ColorsPerTile + j),
+				Kelvin: lifxlan.KelvinNeutral,
+			}
+		}
+	}
+	dup := states[1]
+	dup.Colors[0].Hue = 65535
+
+	service := &mock.Service{
+		TB:                         t,
+		Handlers:                   make(map[lifxlan.MessageType]mock.HandlerFunc),
+		HandleAcks:                 true,
+		RawStatePayload:            &light.RawStatePayload{},
+		RawStateDeviceChainPayload: rawChain,
+		RawStateTileState64Payloads: []*tile.RawStateTileState64Payload{
+			&states[1],
+			// Duplicated, should be dropped.
+			&dup,
+			// Outside of the requested range, should be dropped.
+			&states[0],
+			&states[2],
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	td, err := tile.Wrap(ctx, device, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	colors, err := td.GetTileColors(ctx, nil, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 2 {
+		t.Fatalf("Expected 2 tiles, got %d", len(colors))
+	}
+	for i, got := range colors {
+		expected := states[i+1].Colors[:]
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Tile %d expected %v, got %v", i+1, expected, got)
+		}
+	}
+}
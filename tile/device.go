@@ -2,7 +2,7 @@ package tile
 
 import (
 	"context"
-	"fmt"
+	"image"
 	"net"
 	"time"
 
@@ -17,8 +17,36 @@ type Device interface {
 	Board
 
 	// Tiles returns a copy of the tiles in this device.
+	//
+	// The tiles are cached by Wrap and refreshed by GetDeviceChain.
 	Tiles() []Tile
 
+	// GetDeviceChain queries the device for its chain of tiles,
+	// and updates the cached tiles (Tiles() and the Board) with the result.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetDeviceChain(ctx context.Context, conn net.Conn) (*DeviceChain, error)
+
+	// SetUserPosition sets the user position of the tileIndex-th tile in Tiles(),
+	// and updates the cached tiles (Tiles() and the Board) accordingly.
+	//
+	// x and y are in the unit of tile widths, relative to an arbitrary origin.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetUserPosition(ctx context.Context, conn net.Conn, tileIndex uint8, x, y float32, ack bool) error
+
 	// GetColors returns the current color board on this tile device.
 	//
 	// If conn is nil,
@@ -46,11 +74,140 @@ type Device interface {
 	// the device.
 	SetColors(ctx context.Context, conn net.Conn, cb ColorBoard, transition time.Duration, ack bool) error
 
+	// SetImage samples img across the whole board via ImageToColorBoard,
+	// and sets the tile device with the result via SetColors.
+	//
+	// The board already takes the user position and orientation of every tile
+	// into account.
+	// The image is stretched to cover the board by default,
+	// use WithImageScale on ctx to use ImageScaleLetterbox instead.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received all ack(s) from
+	// the device.
+	SetImage(ctx context.Context, conn net.Conn, img image.Image, transition time.Duration, ack bool) error
+
+	// SetTileColors sets the colors of a single tile in the chain,
+	// leaving the other tiles untouched.
+	//
+	// tileIndex is the index of the tile in Tiles(),
+	// and colors must be exactly ColorsPerTile colors,
+	// in the same order as the colors field of Set64 message.
+	//
+	// It returns an error if the size of the tile from the device chain is
+	// unknown or bigger than ColorsPerTile pixels,
+	// as it cannot be written by a single Set64 message.
+	// The same applies to all the other APIs setting tile colors.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetTileColors(ctx context.Context, conn net.Conn, tileIndex int, colors []lifxlan.Color, transition time.Duration, ack bool) error
+
+	// SetColorsStaggered sets the colors of the tiles one by one,
+	// waiting perTileDelay between the Set64 messages of two tiles,
+	// so the transitions of the tiles start at different times and form a
+	// cascading wipe.
+	//
+	// colors has one slice of ColorsPerTile colors per tile in the order of
+	// Tiles() (the same as SetTileColors).
+	// Tiles with nil colors or without a corresponding slice are left untouched
+	// and don't take a delay.
+	//
+	// The rate limiter of conn (if it's a *lifxlan.RateLimitedConn) or the
+	// Policy of the device is respected,
+	// which adds to the delays.
+	// If ctx is cancelled partway through,
+	// it returns ctx.Err() and the remaining tiles are not sent.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the APIs are sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received acks of all
+	// the messages from the device,
+	// the acks are waited after all the messages are sent so they don't affect
+	// the delays.
+	SetColorsStaggered(ctx context.Context, conn net.Conn, colors [][]lifxlan.Color, perTileDelay time.Duration, transition time.Duration, ack bool) error
+
+	// GetTileColors returns the current colors of length tiles in the chain,
+	// starting from tileIndex.
+	//
+	// tileIndex is the index of the tile in Tiles().
+	// Each of the returned slices contains exactly ColorsPerTile colors for one
+	// tile,
+	// in the same order as the colors field of State64 message,
+	// so they can be passed to SetTileColors directly to restore the tile later.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// This function will wait for length response messages.
+	// In case of one or more of the responses get dropped on the network,
+	// this function will wait until context is cancelled.
+	// So it's important to set an appropriate timeout on the context.
+	GetTileColors(ctx context.Context, conn net.Conn, tileIndex, length uint8) ([][]lifxlan.Color, error)
+
+	// GetTileEffect returns the firmware effect currently running on this tile
+	// device.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	GetTileEffect(ctx context.Context, conn net.Conn) (*TileEffectState, error)
+
+	// SetTileEffect starts (or stops, with TileEffectOff) a firmware effect on
+	// this tile device.
+	//
+	// TileEffectSky is only supported by LIFX Ceiling (see IsCeiling),
+	// and requires params.Sky to be set.
+	//
+	// If conn is nil,
+	// a new connection will be made and guaranteed to be closed before returning.
+	// You should pre-dial and pass in the conn if you plan to call APIs on this
+	// device repeatedly.
+	//
+	// If ack is false,
+	// this function returns nil error after the API is sent successfully.
+	// If ack is true,
+	// this function will only return nil error after it received ack from the
+	// device.
+	SetTileEffect(ctx context.Context, conn net.Conn, effect TileEffectType, params TileEffectParams, ack bool) error
+
 	// TileWidth returns the width of the i-th tile.
 	//
 	// If i is out of bound, it returns the width of the first tile (index 0)
 	// instead. If there's no known tiles, it returns 0.
 	TileWidth(i int) uint8
+
+	// BoardSize returns the pixel dimensions of the whole chain,
+	// which is the bounding box covering all the tiles based on their user
+	// positions and sizes (the same as Width() and Height() of the Board).
+	//
+	// Non-contiguous layouts include the gaps between the tiles,
+	// and overlapping tiles are only counted once.
+	BoardSize() (width, height int)
 }
 
 type device struct {
@@ -66,13 +223,7 @@ type device struct {
 var _ Device = (*device)(nil)
 
 func (td *device) String() string {
-	if label := td.Label().String(); label != lifxlan.EmptyLabel {
-		return fmt.Sprintf("%s(%v)", label, td.Target())
-	}
-	if parsed := td.HardwareVersion().Parse(); parsed != nil {
-		return fmt.Sprintf("%s(%v)", parsed.ProductName, td.Target())
-	}
-	return fmt.Sprintf("TileDevice(%v)", td.Target())
+	return lifxlan.FormatDevice(td, "TileDevice")
 }
 
 func (td *device) Tiles() []Tile {
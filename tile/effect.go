@@ -0,0 +1,371 @@
+package tile
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// TileEffectType defines the type of a tile firmware effect.
+//
+// https://lan.developer.lifx.com/docs/field-types#tileeffecttype
+type TileEffectType uint8
+
+// TileEffectType values.
+const (
+	TileEffectOff   TileEffectType = 0
+	TileEffectMorph TileEffectType = 2
+	TileEffectFlame TileEffectType = 3
+	TileEffectSky   TileEffectType = 5
+)
+
+func (e TileEffectType) String() string {
+	switch e {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint8(e))
+	case TileEffectOff:
+		return "Off"
+	case TileEffectMorph:
+		return "Morph"
+	case TileEffectFlame:
+		return "Flame"
+	case TileEffectSky:
+		return "Sky"
+	}
+}
+
+// SkyType defines the sub-mode of TileEffectSky.
+//
+// https://lan.developer.lifx.com/docs/field-types#tileeffectskytype
+type SkyType uint8
+
+// SkyType values.
+const (
+	SkySunrise SkyType = 0
+	SkySunset  SkyType = 1
+	SkyClouds  SkyType = 2
+)
+
+func (s SkyType) String() string {
+	switch s {
+	default:
+		return fmt.Sprintf("<UNKNOWN> (%d)", uint8(s))
+	case SkySunrise:
+		return "Sunrise"
+	case SkySunset:
+		return "Sunset"
+	case SkyClouds:
+		return "Clouds"
+	}
+}
+
+// MaxPaletteColors is the max number of colors in a tile effect palette.
+const MaxPaletteColors = lifxlan.MaxPaletteColors
+
+// TileEffectParametersLength is the length of the effect specific parameters
+// in tile effect messages.
+const TileEffectParametersLength = 32
+
+// RawTileEffectSettings defines the struct to be used for encoding and
+// decoding.
+//
+// It's the common part of SetTileEffect and StateTileEffect messages:
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#settileeffect---packet-719
+type RawTileEffectSettings struct {
+	InstanceID   uint32
+	Type         TileEffectType
+	Speed        lifxlan.TransitionTime
+	Duration     uint64  // nanoseconds
+	_            [8]byte // reserved
+	Parameters   [TileEffectParametersLength]byte
+	PaletteCount uint8
+	Palette      [MaxPaletteColors]lifxlan.Color
+}
+
+// RawSkyParameters defines the struct to be used for encoding and decoding
+// the Parameters of RawTileEffectSettings for TileEffectSky.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#settileeffect---packet-719
+type RawSkyParameters struct {
+	SkyType            SkyType
+	_                  [3]byte // reserved
+	CloudSaturationMin uint8
+	_                  [3]byte // reserved
+	CloudSaturationMax uint8
+	_                  [23]byte // reserved
+}
+
+// SkyParams defines the TileEffectSky specific parameters.
+type SkyParams struct {
+	Type SkyType
+
+	// The saturation range of the clouds, only used by SkyClouds.
+	CloudSaturationMin uint8
+	CloudSaturationMax uint8
+}
+
+// RawSetTileEffectPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/changing-a-device#settileeffect---packet-719
+type RawSetTileEffectPayload struct {
+	_ [2]byte // reserved
+
+	Settings RawTileEffectSettings
+}
+
+// RawGetTileEffectPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/querying-the-device-for-data#gettileeffect---packet-718
+type RawGetTileEffectPayload struct {
+	_ [2]byte // reserved
+}
+
+// RawStateTileEffectPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statetileeffect---packet-720
+type RawStateTileEffectPayload struct {
+	_ byte // reserved
+
+	Settings RawTileEffectSettings
+}
+
+// TileEffectParams defines the args to be used by SetTileEffect.
+type TileEffectParams struct {
+	// A user chosen id to identify this effect.
+	InstanceID uint32
+
+	// The speed of the effect, e.g. the duration of one cycle.
+	Speed time.Duration
+
+	// How long the effect should run for,
+	// 0 means forever.
+	Duration time.Duration
+
+	// Up to MaxPaletteColors colors to be used by the effect.
+	Palette lifxlan.Palette
+
+	// The parameters of TileEffectSky,
+	// required by TileEffectSky and ignored by other effects.
+	Sky *SkyParams
+}
+
+// TileEffectState defines the tile effect state returned by GetTileEffect.
+type TileEffectState struct {
+	InstanceID uint32
+	Type       TileEffectType
+	Speed      time.Duration
+	Duration   time.Duration
+	Palette    lifxlan.Palette
+
+	// Only set when Type is TileEffectSky.
+	Sky *SkyParams
+}
+
+// ParseTileEffectState parses RawTileEffectSettings into a TileEffectState.
+func ParseTileEffectState(raw *RawTileEffectSettings) *TileEffectState {
+	count := int(raw.PaletteCount)
+	if count > MaxPaletteColors {
+		count = MaxPaletteColors
+	}
+	palette := make(lifxlan.Palette, count)
+	copy(palette, raw.Palette[:count])
+	state := &TileEffectState{
+		InstanceID: raw.InstanceID,
+		Type:       raw.Type,
+		Speed:      raw.Speed.Duration(),
+		Duration:   time.Duration(raw.Duration),
+		Palette:    palette,
+	}
+	if raw.Type == TileEffectSky {
+		var sky RawSkyParameters
+		r := bytes.NewReader(raw.Parameters[:])
+		// It can't fail as the sizes match.
+		binary.Read(r, binary.LittleEndian, &sky)
+		state.Sky = &SkyParams{
+			Type:               sky.SkyType,
+			CloudSaturationMin: sky.CloudSaturationMin,
+			CloudSaturationMax: sky.CloudSaturationMax,
+		}
+	}
+	return state
+}
+
+// ceilingProductIDs are the product IDs of LIFX Ceiling under the LIFX vendor (1).
+var ceilingProductIDs = []uint32{176, 177}
+
+// IsCeiling returns true if the product of d is a LIFX Ceiling,
+// according to the cached HardwareVersion of d.
+//
+// It returns false if the HardwareVersion is not cached yet.
+func IsCeiling(d lifxlan.Device) bool {
+	version := d.HardwareVersion()
+	if version.VendorID != 1 {
+		return false
+	}
+	for _, pid := range ceilingProductIDs {
+		if version.ProductID == pid {
+			return true
+		}
+	}
+	return false
+}
+
+func (td *device) SetTileEffect(
+	ctx context.Context,
+	conn net.Conn,
+	effect TileEffectType,
+	params TileEffectParams,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(params.Speed); err != nil {
+		return fmt.Errorf("lifxlan/tile.SetTileEffect: %w", err)
+	}
+
+	if err := params.Palette.Validate(); err != nil {
+		return fmt.Errorf("lifxlan/tile.SetTileEffect: %w", err)
+	}
+
+	if effect == TileEffectSky {
+		if params.Sky == nil {
+			return errors.New("lifxlan/tile.SetTileEffect: Sky params required")
+		}
+		if !IsCeiling(td) {
+			return fmt.Errorf(
+				"lifxlan/tile.SetTileEffect: %v effect is only supported by LIFX Ceiling, got product %d",
+				effect,
+				td.HardwareVersion().ProductID,
+			)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	payload := &RawSetTileEffectPayload{
+		Settings: RawTileEffectSettings{
+			InstanceID: params.InstanceID,
+			Type:       effect,
+			Speed:      lifxlan.ConvertDuration(params.Speed),
+			Duration:   uint64(params.Duration),
+		},
+	}
+	if effect == TileEffectSky {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, &RawSkyParameters{
+			SkyType:            params.Sky.Type,
+			CloudSaturationMin: params.Sky.CloudSaturationMin,
+			CloudSaturationMax: params.Sky.CloudSaturationMax,
+		}); err != nil {
+			return err
+		}
+		copy(payload.Settings.Parameters[:], buf.Bytes())
+	}
+	// Unused palette slots are left as zero value colors.
+	palette := make(lifxlan.Palette, len(params.Palette))
+	for i, c := range params.Palette {
+		palette[i] = td.SanitizeColor(c)
+	}
+	payload.Settings.PaletteCount, payload.Settings.Palette = palette.Array()
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	seq, err := td.Send(
+		ctx,
+		conn,
+		flags,
+		SetTileEffect,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if ack {
+		return lifxlan.WaitForAcks(ctx, conn, td.Source(), seq)
+	}
+	return nil
+}
+
+func (td *device) GetTileEffect(
+	ctx context.Context,
+	conn net.Conn,
+) (*TileEffectState, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Send
+	seq, err := td.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetTileEffect,
+		&RawGetTileEffectPayload{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	resps, err := lifxlan.WaitForResponses(
+		ctx,
+		conn,
+		td.Source(),
+		seq,
+		StateTileEffect,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateTileEffectPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return ParseTileEffectState(&raw.Settings), nil
+}
@@ -0,0 +1,378 @@
+package tile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestTileEffect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var settings tile.RawTileEffectSettings
+	service.Handlers[tile.SetTileEffect] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		const expectedSize = 188
+		if len(orig.Payload) != expectedSize {
+			t.Errorf(
+				"SetTileEffect payload size expected %d, got %d",
+				expectedSize,
+				len(orig.Payload),
+			)
+		}
+		var raw tile.RawSetTileEffectPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Fatal(err)
+		}
+		settings = raw.Settings
+	}
+	service.Handlers[tile.GetTileEffect] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(
+			buf,
+			binary.LittleEndian,
+			&tile.RawStateTileEffectPayload{
+				Settings: settings,
+			},
+		); err != nil {
+			t.Fatal(err)
+		}
+		s.Reply(conn, addr, orig, tile.StateTileEffect, buf.Bytes())
+	}
+
+	palette := []lifxlan.Color{
+		{Hue: 0, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+		{Hue: 21845, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+		{Hue: 43690, Saturation: 65535, Brightness: 65535, Kelvin: 3500},
+	}
+
+	t.Run(
+		"TooManyPaletteColors",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := td.SetTileEffect(
+				ctx,
+				nil,
+				tile.TileEffectMorph,
+				tile.TileEffectParams{
+					Palette: make([]lifxlan.Color, tile.MaxPaletteColors+1),
+				},
+				false,
+			); err == nil {
+				t.Error("Expected error for too many palette colors, got nil")
+			}
+		},
+	)
+
+	for _, c := range []struct {
+		effect tile.TileEffectType
+		params tile.TileEffectParams
+	}{
+		{
+			effect: tile.TileEffectMorph,
+			params: tile.TileEffectParams{
+				InstanceID: 1234,
+				Speed:      time.Second * 3,
+				Palette:    palette,
+			},
+		},
+		{
+			effect: tile.TileEffectFlame,
+			params: tile.TileEffectParams{
+				InstanceID: 5678,
+				Speed:      time.Second,
+				Duration:   time.Minute,
+			},
+		},
+		{
+			effect: tile.TileEffectOff,
+		},
+	} {
+		c := c
+		t.Run(
+			c.effect.String(),
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				if err := td.SetTileEffect(ctx, nil, c.effect, c.params, true); err != nil {
+					t.Fatal(err)
+				}
+				for i := len(c.params.Palette); i < len(settings.Palette); i++ {
+					if settings.Palette[i] != (lifxlan.Color{}) {
+						t.Errorf(
+							"Palette[%d] expected zero value, got %+v",
+							i,
+							settings.Palette[i],
+						)
+					}
+				}
+
+				state, err := td.GetTileEffect(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := &tile.TileEffectState{
+					InstanceID: c.params.InstanceID,
+					Type:       c.effect,
+					Speed:      c.params.Speed,
+					Duration:   c.params.Duration,
+					Palette:    c.params.Palette,
+				}
+				if expected.Palette == nil {
+					expected.Palette = []lifxlan.Color{}
+				}
+				if !reflect.DeepEqual(state, expected) {
+					t.Errorf("GetTileEffect expected %+v, got %+v", expected, state)
+				}
+			},
+		)
+	}
+}
+
+func TestTileEffectSky(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+	// LIFX Ceiling
+	service.RawStateVersionPayload = &lifxlan.RawStateVersionPayload{
+		Version: lifxlan.HardwareVersion{
+			VendorID:  1,
+			ProductID: 176,
+		},
+	}
+
+	var lock sync.Mutex
+	var settings tile.RawTileEffectSettings
+	service.Handlers[tile.SetTileEffect] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw tile.RawSetTileEffectPayload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		settings = raw.Settings
+	}
+	service.Handlers[tile.GetTileEffect] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		lock.Lock()
+		payload := &tile.RawStateTileEffectPayload{
+			Settings: settings,
+		}
+		lock.Unlock()
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, payload); err != nil {
+			t.Error(err)
+			return
+		}
+		s.Reply(conn, addr, orig, tile.StateTileEffect, buf.Bytes())
+	}
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := tile.TileEffectParams{
+		InstanceID: 42,
+		Speed:      time.Second * 5,
+		Sky: &tile.SkyParams{
+			Type:               tile.SkyClouds,
+			CloudSaturationMin: 50,
+			CloudSaturationMax: 180,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The HardwareVersion is not cached yet.
+	if err := td.SetTileEffect(ctx, nil, tile.TileEffectSky, params, true); err == nil {
+		t.Error("Expected error for unknown product, got nil")
+	}
+
+	if err := td.GetHardwareVersion(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !tile.IsCeiling(td) {
+		t.Fatal("Expected IsCeiling to be true")
+	}
+
+	if err := td.SetTileEffect(ctx, nil, tile.TileEffectSky, tile.TileEffectParams{}, true); err == nil {
+		t.Error("Expected error without Sky params, got nil")
+	}
+
+	if err := td.SetTileEffect(ctx, nil, tile.TileEffectSky, params, true); err != nil {
+		t.Fatal(err)
+	}
+	lock.Lock()
+	parameters := settings.Parameters
+	lock.Unlock()
+	expectedParameters := [tile.TileEffectParametersLength]byte{
+		0: byte(tile.SkyClouds),
+		4: 50,
+		8: 180,
+	}
+	if parameters != expectedParameters {
+		t.Errorf("Parameters expected %v, got %v", expectedParameters, parameters)
+	}
+
+	state, err := td.GetTileEffect(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &tile.TileEffectState{
+		InstanceID: params.InstanceID,
+		Type:       tile.TileEffectSky,
+		Speed:      params.Speed,
+		Palette:    []lifxlan.Color{},
+		Sky:        params.Sky,
+	}
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("GetTileEffect expected %+v, got %+v", expected, state)
+	}
+}
+
+func TestIsCeiling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	for _, c := range []struct {
+		label    string
+		vendor   uint32
+		product  uint32
+		expected bool
+	}{
+		{
+			label:    "Ceiling",
+			vendor:   1,
+			product:  176,
+			expected: true,
+		},
+		{
+			label:    "CeilingIntl",
+			vendor:   1,
+			product:  177,
+			expected: true,
+		},
+		{
+			label:    "Tile",
+			vendor:   1,
+			product:  55,
+			expected: false,
+		},
+		{
+			label:    "OtherVendor",
+			vendor:   2,
+			product:  176,
+			expected: false,
+		},
+	} {
+		c := c
+		t.Run(c.label, func(t *testing.T) {
+			service := &mock.Service{
+				TB: t,
+				RawStateVersionPayload: &lifxlan.RawStateVersionPayload{
+					Version: lifxlan.HardwareVersion{
+						VendorID:  c.vendor,
+						ProductID: c.product,
+					},
+				},
+			}
+			device := service.Start()
+			defer service.Stop()
+
+			if tile.IsCeiling(device) {
+				t.Error("Expected IsCeiling to be false before HardwareVersion is cached")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := device.GetHardwareVersion(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			if actual := tile.IsCeiling(device); actual != c.expected {
+				t.Errorf("IsCeiling expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
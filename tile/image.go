@@ -0,0 +1,110 @@
+package tile
+
+import (
+	"context"
+	"image"
+	"math"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+// ImageScale defines how an image is scaled onto a Board.
+type ImageScale int
+
+// ImageScale values.
+const (
+	// ImageScaleStretch stretches the image to cover the whole board,
+	// ignoring its aspect ratio.
+	ImageScaleStretch ImageScale = iota
+
+	// ImageScaleLetterbox scales the image uniformly to fit inside the board,
+	// keeping its aspect ratio,
+	// and centers it on the board.
+	// The area of the board not covered by the image will be black.
+	ImageScaleLetterbox
+)
+
+type imageScaleKey struct{}
+
+// WithImageScale returns a copy of ctx that carries scale to be used by
+// Device.SetImage.
+func WithImageScale(ctx context.Context, scale ImageScale) context.Context {
+	return context.WithValue(ctx, imageScaleKey{}, scale)
+}
+
+// ImageScaleFromContext returns the ImageScale set via WithImageScale on ctx,
+// or ImageScaleStretch if it's not set.
+func ImageScaleFromContext(ctx context.Context) ImageScale {
+	if scale, ok := ctx.Value(imageScaleKey{}).(ImageScale); ok {
+		return scale
+	}
+	return ImageScaleStretch
+}
+
+// ImageToColorBoard samples img onto board,
+// and returns the ColorBoard with the same size of the board.
+//
+// The top left corner of img maps to the top left corner of the board.
+// Every coordinate on a tile takes the color of the nearest pixel,
+// converted via lifxlan.FromColor with lifxlan.KelvinNeutral.
+// The alpha channel is ignored,
+// so fully transparent pixels become black.
+//
+// The coordinates not on a tile,
+// or not covered by the image with ImageScaleLetterbox,
+// will be nil.
+func ImageToColorBoard(img image.Image, board Board, scale ImageScale) ColorBoard {
+	width, height := board.Width(), board.Height()
+	cb := MakeColorBoard(width, height)
+	bounds := img.Bounds()
+	imgWidth, imgHeight := float64(bounds.Dx()), float64(bounds.Dy())
+	if width == 0 || height == 0 || imgWidth == 0 || imgHeight == 0 {
+		return cb
+	}
+
+	// The area of the board covered by the image.
+	areaWidth, areaHeight := float64(width), float64(height)
+	var offsetX, offsetY float64
+	if scale == ImageScaleLetterbox {
+		ratio := math.Min(float64(width)/imgWidth, float64(height)/imgHeight)
+		areaWidth, areaHeight = imgWidth*ratio, imgHeight*ratio
+		offsetX = (float64(width) - areaWidth) / 2
+		offsetY = (float64(height) - areaHeight) / 2
+	}
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if !board.OnTile(x, y) {
+				continue
+			}
+			// The relative position of the center of the coordinate in the image.
+			// Board y grows upwards while image y grows downwards.
+			px := (float64(x) + 0.5 - offsetX) / areaWidth
+			py := (float64(height-1-y) + 0.5 - offsetY) / areaHeight
+			if px < 0 || px >= 1 || py < 0 || py >= 1 {
+				continue
+			}
+			cb[x][y] = lifxlan.FromColor(
+				img.At(
+					bounds.Min.X+int(px*imgWidth),
+					bounds.Min.Y+int(py*imgHeight),
+				),
+				lifxlan.KelvinNeutral,
+			)
+		}
+	}
+	return cb
+}
+
+func (td *device) SetImage(
+	ctx context.Context,
+	conn net.Conn,
+	img image.Image,
+	transition time.Duration,
+	ack bool,
+) error {
+	cb := ImageToColorBoard(img, td, ImageScaleFromContext(ctx))
+	return td.SetColors(ctx, conn, cb, transition, ack)
+}
@@ -0,0 +1,216 @@
+package tile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+// fullBoard is a tile.Board with every coordinate on a tile.
+type fullBoard struct {
+	width, height int
+}
+
+func (b fullBoard) Width() int {
+	return b.width
+}
+
+func (b fullBoard) Height() int {
+	return b.height
+}
+
+func (b fullBoard) OnTile(x, y int) bool {
+	return x >= 0 && x < b.width && y >= 0 && y < b.height
+}
+
+var (
+	imageRed = lifxlan.Color{
+		Hue:        0,
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	imageGreen = lifxlan.Color{
+		Hue:        21845,
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	imageBlue = lifxlan.Color{
+		Hue:        43690,
+		Saturation: 0xffff,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+	imageWhite = lifxlan.Color{
+		Hue:        0,
+		Saturation: 0,
+		Brightness: 0xffff,
+		Kelvin:     lifxlan.KelvinNeutral,
+	}
+)
+
+// makeImage creates an image with (0, 0) being the top left pixel.
+func makeImage(pixels [][]color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, len(pixels[0]), len(pixels)))
+	for y, row := range pixels {
+		for x, c := range row {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func checkBoardColor(t *testing.T, cb tile.ColorBoard, x, y int, expected *lifxlan.Color) {
+	t.Helper()
+	got := cb.GetColor(x, y)
+	if expected == nil {
+		if got != nil {
+			t.Errorf("(%d, %d) expected nil, got %v", x, y, *got)
+		}
+		return
+	}
+	if got == nil {
+		t.Errorf("(%d, %d) expected %v, got nil", x, y, *expected)
+		return
+	}
+	// Allow off-by-one hue from rounding.
+	diff := int(got.Hue) - int(expected.Hue)
+	if diff < -1 || diff > 1 ||
+		got.Saturation != expected.Saturation ||
+		got.Brightness != expected.Brightness ||
+		got.Kelvin != expected.Kelvin {
+		t.Errorf("(%d, %d) expected %v, got %v", x, y, *expected, *got)
+	}
+}
+
+func TestImageToColorBoard(t *testing.T) {
+	t.Run(
+		"Stretch",
+		func(t *testing.T) {
+			img := makeImage([][]color.Color{
+				{color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}},
+				{color.RGBA{0, 0, 0xff, 0xff}, color.White},
+			})
+			cb := tile.ImageToColorBoard(img, fullBoard{8, 8}, tile.ImageScaleStretch)
+			for _, c := range []struct {
+				x, y     int
+				expected *lifxlan.Color
+			}{
+				// Board y grows upwards, so the top of the image is at y = 7.
+				{0, 7, &imageRed},
+				{3, 4, &imageRed},
+				{4, 7, &imageGreen},
+				{7, 4, &imageGreen},
+				{0, 0, &imageBlue},
+				{3, 3, &imageBlue},
+				{4, 3, &imageWhite},
+				{7, 0, &imageWhite},
+			} {
+				checkBoardColor(t, cb, c.x, c.y, c.expected)
+			}
+		},
+	)
+
+	t.Run(
+		"Letterbox",
+		func(t *testing.T) {
+			img := makeImage([][]color.Color{
+				{color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}},
+			})
+			cb := tile.ImageToColorBoard(img, fullBoard{8, 8}, tile.ImageScaleLetterbox)
+			for x := 0; x < 8; x++ {
+				for y := 0; y < 8; y++ {
+					var expected *lifxlan.Color
+					switch {
+					case y < 2 || y >= 6:
+						// Margins
+					case x < 4:
+						expected = &imageRed
+					default:
+						expected = &imageGreen
+					}
+					checkBoardColor(t, cb, x, y, expected)
+				}
+			}
+		},
+	)
+}
+
+func TestSetImage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.RawStatePayload = &light.RawStatePayload{}
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: 1,
+	}
+	rawChain.TileDevices[0] = tile.RawTileDevice{
+		Width:  8,
+		Height: 8,
+	}
+	service.RawStateDeviceChainPayload = rawChain
+
+	var lock sync.Mutex
+	var received []tile.RawSetTileState64Payload
+	service.Handlers[tile.SetTileState64] = func(
+		_ *mock.Service,
+		_ net.PacketConn,
+		_ net.Addr,
+		orig *lifxlan.Response,
+	) {
+		var raw tile.RawSetTileState64Payload
+		r := bytes.NewReader(orig.Payload)
+		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+			t.Error(err)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, raw)
+	}
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	img := makeImage([][]color.Color{{color.White}})
+	if err := td.SetImage(ctx, nil, img, time.Second, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 Set64 message, got %d", len(received))
+	}
+	for i, c := range received[0].Colors {
+		if c != imageWhite {
+			t.Errorf("Color %d expected %v, got %v", i, imageWhite, c)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package tile
 
 import (
+	"encoding/binary"
+
 	"go.yhsif.com/lifxlan"
 )
 
@@ -8,7 +10,30 @@ import (
 const (
 	GetDeviceChain   lifxlan.MessageType = 701
 	StateDeviceChain lifxlan.MessageType = 702
+	SetUserPosition  lifxlan.MessageType = 703
 	GetTileState64   lifxlan.MessageType = 707
 	StateTileState64 lifxlan.MessageType = 711
 	SetTileState64   lifxlan.MessageType = 715
+	GetTileEffect    lifxlan.MessageType = 718
+	SetTileEffect    lifxlan.MessageType = 719
+	StateTileEffect  lifxlan.MessageType = 720
 )
+
+func init() {
+	for msg, size := range map[lifxlan.MessageType]lifxlan.PayloadSize{
+		GetDeviceChain:  {Name: "GetDeviceChain", Size: 0},
+		SetUserPosition: {Name: "SetUserPosition", Size: binary.Size(RawSetUserPositionPayload{})},
+		GetTileState64:  {Name: "GetTileState64", Size: binary.Size(RawGetTileState64Payload{})},
+		GetTileEffect:   {Name: "GetTileEffect", Size: binary.Size(RawGetTileEffectPayload{})},
+		SetTileEffect:   {Name: "SetTileEffect", Size: binary.Size(RawSetTileEffectPayload{})},
+	} {
+		lifxlan.PayloadSizes[msg] = size
+	}
+	for msg, resp := range map[lifxlan.MessageType]lifxlan.MessageType{
+		GetDeviceChain: StateDeviceChain,
+		GetTileState64: StateTileState64,
+		GetTileEffect:  StateTileEffect,
+	} {
+		lifxlan.ExpectedResponses[msg] = resp
+	}
+}
@@ -5,9 +5,7 @@ import (
 	"math"
 )
 
-// Rotation defines the rotation of a single tile.
-//
-// NOTE: Currently only RotationRightSideUp is fully supported.
+// Rotation defines the rotation (orientation) of a single tile.
 type Rotation int
 
 // Possible Rotation values.
@@ -40,9 +38,12 @@ func (r Rotation) String() string {
 }
 
 // ParseRotation parses accelerator measurements into Rotation
+//
+// When no axis is strictly larger than the other two (ambiguous readings),
+// or the readings are invalid, it returns RotationRightSideUp.
 func ParseRotation(x, y, z int16) Rotation {
-	abs := func(x int16) int16 {
-		return int16(math.Abs(float64(x)))
+	abs := func(x int16) int {
+		return int(math.Abs(float64(x)))
 	}
 
 	// Copied from:
@@ -55,6 +56,7 @@ func ParseRotation(x, y, z int16) Rotation {
 		// Invalid data, assume right-side up.
 		return RotationRightSideUp
 	}
+
 	if absX > absY && absX > absZ {
 		if x > 0 {
 			return RotationRotateRight
@@ -69,8 +71,13 @@ func ParseRotation(x, y, z int16) Rotation {
 		return RotationFaceUp
 	}
 
-	if y > 0 {
-		return RotationUpsideDown
+	if absY > absX && absY > absZ {
+		if y > 0 {
+			return RotationUpsideDown
+		}
+		return RotationRightSideUp
 	}
+
+	// Ambiguous, assume right-side up.
 	return RotationRightSideUp
 }
@@ -0,0 +1,107 @@
+package tile
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+)
+
+func (td *device) SetColorsStaggered(
+	ctx context.Context,
+	conn net.Conn,
+	colors [][]lifxlan.Color,
+	perTileDelay time.Duration,
+	transition time.Duration,
+	ack bool,
+) error {
+	if err := lifxlan.CheckDuration(transition); err != nil {
+		return fmt.Errorf("lifxlan/tile.SetColorsStaggered: %w", err)
+	}
+	if perTileDelay < 0 {
+		return fmt.Errorf(
+			"lifxlan/tile.SetColorsStaggered: negative per tile delay %v",
+			perTileDelay,
+		)
+	}
+	if len(colors) > len(td.tiles) {
+		return fmt.Errorf(
+			"lifxlan/tile.SetColorsStaggered: got colors of %d tiles, device only has %d",
+			len(colors),
+			len(td.tiles),
+		)
+	}
+	for i, c := range colors {
+		if c == nil {
+			continue
+		}
+		if len(c) != ColorsPerTile {
+			return fmt.Errorf(
+				"lifxlan/tile.SetColorsStaggered: tile %d expected %d colors, got %d",
+				i,
+				ColorsPerTile,
+				len(c),
+			)
+		}
+		if err := td.checkTileSize("lifxlan/tile.SetColorsStaggered", i); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := td.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	var flags lifxlan.AckResFlag
+	if ack {
+		flags |= lifxlan.FlagAckRequired
+	}
+
+	// Send
+	var seqs []uint8
+	for i, c := range colors {
+		if c == nil {
+			continue
+		}
+		if len(seqs) > 0 && perTileDelay > 0 {
+			timer := time.NewTimer(perTileDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		seq, err := td.Send(
+			ctx,
+			conn,
+			flags,
+			SetTileState64,
+			td.tilePayload(i, c, transition),
+		)
+		if err != nil {
+			return err
+		}
+		seqs = append(seqs, seq)
+	}
+
+	if ack && len(seqs) > 0 {
+		return lifxlan.WaitForAcks(ctx, conn, td.Source(), seqs...)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package tile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/light"
+	"go.yhsif.com/lifxlan/mock"
+	"go.yhsif.com/lifxlan/tile"
+)
+
+func TestSetColorsStaggered(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 500
+		delay   = time.Millisecond * 30
+		n       = 4
+	)
+
+	rawChain := &tile.RawStateDeviceChainPayload{
+		TotalCount: n,
+	}
+	for i := 0; i < n; i++ {
+		rawChain.TileDevices[i] = tile.RawTileDevice{
+			Width:  8,
+			Height: 8,
+		}
+	}
+
+	type packet struct {
+		index uint8
+		at    time.Time
+	}
+	var lock sync.Mutex
+	var received []packet
+	service := &mock.Service{
+		TB:         t,
+		HandleAcks: true,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			tile.SetTileState64: func(
+				_ *mock.Service,
+				_ net.PacketConn,
+				_ net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw tile.RawSetTileState64Payload
+				r := bytes.NewReader(orig.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				received = append(received, packet{
+					index: raw.TileIndex,
+					at:    time.Now(),
+				})
+			},
+		},
+		RawStatePayload:            &light.RawStatePayload{},
+		RawStateDeviceChainPayload: rawChain,
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	td, err := func() (tile.Device, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return tile.Wrap(ctx, device, false)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	colors := make([]lifxlan.Color, tile.ColorsPerTile)
+	for i := range colors {
+		colors[i] = lifxlan.Color{
+			Hue:    uint16(i),
+			Kelvin: lifxlan.KelvinNeutral,
+		}
+	}
+	reset := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		received = nil
+	}
+
+	t.Run(
+		"Spacing",
+		func(t *testing.T) {
+			reset()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			// Tile 2 is skipped.
+			board := [][]lifxlan.Color{colors, colors, nil, colors}
+			if err := td.SetColorsStaggered(ctx, nil, board, delay, time.Second, true); err != nil {
+				t.Fatal(err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			expected := []uint8{0, 1, 3}
+			if len(received) != len(expected) {
+				t.Fatalf("Expected %d packets, got %d", len(expected), len(received))
+			}
+			for i, p := range received {
+				if p.index != expected[i] {
+					t.Errorf("#%d: TileIndex expected %d, got %d", i, expected[i], p.index)
+				}
+				if i == 0 {
+					continue
+				}
+				// Allow some slack for the timer and the network.
+				if gap := p.at.Sub(received[i-1].at); gap < delay*8/10 {
+					t.Errorf("#%d: Expected gap of at least %v, got %v", i, delay, gap)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Cancel",
+		func(t *testing.T) {
+			reset()
+			ctx, cancel := context.WithTimeout(context.Background(), delay*3/2)
+			defer cancel()
+
+			board := [][]lifxlan.Color{colors, colors, colors, colors}
+			err := td.SetColorsStaggered(ctx, nil, board, delay, 0, false)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			// Give the mock some time to receive the packets already sent.
+			time.Sleep(delay)
+			lock.Lock()
+			defer lock.Unlock()
+			if len(received) != 2 {
+				t.Errorf("Expected 2 packets before cancellation, got %d", len(received))
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			for _, c := range []struct {
+				label string
+				board [][]lifxlan.Color
+				delay time.Duration
+			}{
+				{
+					label: "TooManyTiles",
+					board: make([][]lifxlan.Color, n+1),
+				},
+				{
+					label: "WrongLength",
+					board: [][]lifxlan.Color{colors[:1]},
+				},
+				{
+					label: "NegativeDelay",
+					board: [][]lifxlan.Color{colors},
+					delay: -time.Second,
+				},
+			} {
+				c := c
+				t.Run(
+					c.label,
+					func(t *testing.T) {
+						if err := td.SetColorsStaggered(ctx, nil, c.board, c.delay, 0, false); err == nil {
+							t.Error("Expected error, got nil")
+						} else {
+							t.Logf("Got error: %v", err)
+						}
+					},
+				)
+			}
+		},
+	)
+}
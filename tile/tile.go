@@ -36,30 +36,66 @@ type Tile struct {
 	Width    uint8
 	Height   uint8
 	Rotation Rotation
+
+	// The raw accelerometer measurements reported by the tile.
+	AccelMeasX int16
+	AccelMeasY int16
+	AccelMeasZ int16
+
+	HardwareVersion lifxlan.HardwareVersion
+	Firmware        lifxlan.FirmwareUpgrade
 }
 
 // ParseTile parses RawTileDevice into a Tile.
 func ParseTile(raw *RawTileDevice) *Tile {
-	return &Tile{
-		UserX:    raw.UserX,
-		UserY:    raw.UserY,
-		Width:    raw.Width,
-		Height:   raw.Height,
-		Rotation: ParseRotation(raw.AccelMeasX, raw.AccelMeasY, raw.AccelMeasZ),
+	t := &Tile{
+		UserX:  raw.UserX,
+		UserY:  raw.UserY,
+		Width:  raw.Width,
+		Height: raw.Height,
+
+		AccelMeasX: raw.AccelMeasX,
+		AccelMeasY: raw.AccelMeasY,
+		AccelMeasZ: raw.AccelMeasZ,
+
+		HardwareVersion: raw.HardwareVersion,
+		Firmware:        raw.Firmware.ToFirmware(),
 	}
+	t.Rotation = t.Orientation()
+	return t
+}
+
+// Orientation returns the rotation of the tile calculated from its raw
+// accelerometer measurements, via ParseRotation.
+//
+// Tiles returned by ParseTile already have their Rotation set to it.
+func (t Tile) Orientation() Rotation {
+	return ParseRotation(t.AccelMeasX, t.AccelMeasY, t.AccelMeasZ)
 }
 
 // Rotate rotates a given coordinate (x, y) based on tile's rotation and size.
 //
-// x, y must satisfy: (0 <= x < width) && (0 <= y < height)
+// x is the row and y is the column of the pixel on the tile,
+// as used by the colors in Set64 and State64 messages.
+// They must satisfy: (0 <= x < width) && (0 <= y < height)
 //
-// TODO: This function currently only handles RotationRightSideUp correctly.
+// The returned coordinate is the position of the pixel relative to the bottom
+// left corner of the tile,
+// after taking the physical rotation of the tile into consideration.
+// RotationFaceUp and RotationFaceDown are handled the same as
+// RotationRightSideUp.
 func (t Tile) Rotate(x, y int) (int, int) {
+	maxX := int(t.Width) - 1
+	maxY := int(t.Height) - 1
 	switch t.Rotation {
 	default:
+		return y, maxX - x
+	case RotationUpsideDown:
+		return maxY - y, x
+	case RotationRotateRight:
+		return maxX - x, maxY - y
+	case RotationRotateLeft:
 		return x, y
-	case RotationRightSideUp:
-		return y, int(t.Width) - 1 - x
 	}
 }
 
@@ -1,6 +1,7 @@
 package tile_test
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -237,4 +238,158 @@ func TestTileRotate(t *testing.T) {
 			)
 		},
 	)
+
+	// The expected coordinates of the first pixel (0, 0) and the last pixel of
+	// the first row (0, 3) on a 4x4 tile.
+	for _, tc := range []struct {
+		rotation tile.Rotation
+		first    tile.Coordinate
+		last     tile.Coordinate
+	}{
+		{
+			rotation: tile.RotationUpsideDown,
+			first:    c(3, 0),
+			last:     c(0, 0),
+		},
+		{
+			rotation: tile.RotationRotateRight,
+			first:    c(3, 3),
+			last:     c(3, 0),
+		},
+		{
+			rotation: tile.RotationRotateLeft,
+			first:    c(0, 0),
+			last:     c(0, 3),
+		},
+		{
+			rotation: tile.RotationFaceUp,
+			first:    c(0, 3),
+			last:     c(3, 3),
+		},
+		{
+			rotation: tile.RotationFaceDown,
+			first:    c(0, 3),
+			last:     c(3, 3),
+		},
+	} {
+		tc := tc
+		t.Run(
+			tc.rotation.String(),
+			func(t *testing.T) {
+				ti := tile.Tile{
+					Width:    4,
+					Height:   4,
+					Rotation: tc.rotation,
+				}
+
+				x, y := ti.Rotate(0, 0)
+				if x != tc.first.X || y != tc.first.Y {
+					t.Errorf("(0, 0): Expected %+v, got (%d, %d)", tc.first, x, y)
+				}
+				x, y = ti.Rotate(0, 3)
+				if x != tc.last.X || y != tc.last.Y {
+					t.Errorf("(0, 3): Expected %+v, got (%d, %d)", tc.last, x, y)
+				}
+			},
+		)
+	}
+}
+
+func TestTileOrientation(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		x, y, z  int16
+		expected tile.Rotation
+	}{
+		{
+			label:    "RightSideUp",
+			x:        0,
+			y:        -1000,
+			z:        50,
+			expected: tile.RotationRightSideUp,
+		},
+		{
+			label:    "UpsideDown",
+			x:        -20,
+			y:        1000,
+			z:        50,
+			expected: tile.RotationUpsideDown,
+		},
+		{
+			label:    "RotateRight",
+			x:        1000,
+			y:        10,
+			z:        -30,
+			expected: tile.RotationRotateRight,
+		},
+		{
+			label:    "RotateLeft",
+			x:        -1000,
+			y:        10,
+			z:        -30,
+			expected: tile.RotationRotateLeft,
+		},
+		{
+			label:    "FaceDown",
+			x:        10,
+			y:        -20,
+			z:        1000,
+			expected: tile.RotationFaceDown,
+		},
+		{
+			label:    "FaceUp",
+			x:        10,
+			y:        -20,
+			z:        -1000,
+			expected: tile.RotationFaceUp,
+		},
+		{
+			label:    "Invalid",
+			x:        -1,
+			y:        -1,
+			z:        -1,
+			expected: tile.RotationRightSideUp,
+		},
+		{
+			label:    "AllEqual",
+			x:        500,
+			y:        500,
+			z:        -500,
+			expected: tile.RotationRightSideUp,
+		},
+		{
+			label:    "TiedXY",
+			x:        700,
+			y:        700,
+			z:        0,
+			expected: tile.RotationRightSideUp,
+		},
+		{
+			label:    "MinInt16",
+			x:        math.MinInt16,
+			y:        0,
+			z:        0,
+			expected: tile.RotationRotateLeft,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ti := tile.ParseTile(&tile.RawTileDevice{
+					AccelMeasX: c.x,
+					AccelMeasY: c.y,
+					AccelMeasZ: c.z,
+					Width:      8,
+					Height:     8,
+				})
+				if actual := ti.Orientation(); actual != c.expected {
+					t.Errorf("Orientation expected %v, got %v", c.expected, actual)
+				}
+				if ti.Rotation != c.expected {
+					t.Errorf("Rotation expected %v, got %v", c.expected, ti.Rotation)
+				}
+			},
+		)
+	}
 }
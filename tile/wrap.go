@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
-	"errors"
 
 	"go.yhsif.com/lifxlan"
 	"go.yhsif.com/lifxlan/light"
@@ -61,54 +60,48 @@ func Wrap(ctx context.Context, d lifxlan.Device, force bool) (Device, error) {
 		return nil, err
 	}
 
+	ctx = lifxlan.WithMinReadBufferSize(ctx, MinReadBufferSize)
+	ctx, cancel := lifxlan.ApplyPolicy(ctx, d.Source())
+	defer cancel()
+
 	for {
-		resp, err := lifxlan.ReadNextResponse(ctx, conn)
+		resps, err := lifxlan.ReadNextResponses(ctx, conn)
 		if err != nil {
 			return nil, err
 		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-
-		switch resp.Message {
-		case StateDeviceChain:
-			var raw RawStateDeviceChainPayload
-			r := bytes.NewReader(resp.Payload)
-			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-				return nil, err
-			}
-			if raw.TotalCount == 0 {
-				return nil, errors.New("lifxlan/tile.Wrap: no tiles found")
-			}
-			*d.HardwareVersion() = raw.TileDevices[int(raw.StartIndex)].HardwareVersion
-			td := &device{
-				Device:     ld,
-				startIndex: raw.StartIndex,
-				tiles:      make([]*Tile, raw.TotalCount),
+		for _, resp := range resps {
+			if resp.Sequence != seq || resp.Source != lifxlan.SourceFromContext(ctx, d.Source()) {
+				continue
 			}
-			for i := range td.tiles {
-				td.tiles[i] = ParseTile(&raw.TileDevices[int(raw.StartIndex)+i])
-			}
-			td.parseBoard()
-			return td, nil
 
-		case lifxlan.StateUnhandled:
-			var raw lifxlan.RawStateUnhandledPayload
-			r := bytes.NewReader(resp.Payload)
-			if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-				return nil, err
+			switch resp.Message {
+			case StateDeviceChain:
+				var raw RawStateDeviceChainPayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				chain, err := ParseDeviceChain(&raw)
+				if err != nil {
+					return nil, err
+				}
+				*d.HardwareVersion() = chain.Tiles[0].HardwareVersion
+				td := &device{
+					Device: ld,
+				}
+				td.setChain(chain)
+				return td, nil
+
+			case lifxlan.StateUnhandled:
+				var raw lifxlan.RawStateUnhandledPayload
+				r := bytes.NewReader(resp.Payload)
+				if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+					return nil, err
+				}
+				return nil, &lifxlan.UnhandledMessageError{
+					Type: raw.UnhandledType,
+				}
 			}
-			return nil, raw
 		}
 	}
 }
-
-// RawStateDeviceChainPayload defines the struct to be used for encoding and
-// decoding.
-//
-// https://lan.developer.lifx.com/docs/information-messages#statedevicechain---packet-702
-type RawStateDeviceChainPayload struct {
-	StartIndex  uint8
-	TileDevices [16]RawTileDevice
-	TotalCount  uint8
-}
@@ -1,7 +1,9 @@
 package lifxlan
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -33,20 +35,42 @@ func (ts Timestamp) String() string {
 // Its unit is milliseconds.
 type TransitionTime uint32
 
-// ConvertDuration converts a time.Duration into TransitionTime.
+// MaxTransitionTime is the longest duration that can be represented by
+// TransitionTime.
 //
-// The max uint32 value can represent a transition time of more than 1,193
-// hours[1] (or, in other words, more than a month).
-// So although an overflow is technically possible,
-// we don't really do any special handlings here
-// (it's not a security risk and won't crash anything[2]).
-// If you feed in a duration that overflows TransitionTime,
-// you should feel bad (or great, it's totally up to you) about it.
-// Do you really want your light(s) to take more than a month to turn on/off?
+// It's more than 1,193 hours[1] (or, in other words, more than a month).
 //
 // [1] https://play.golang.com/p/LqfMpvhIctx
+const MaxTransitionTime = time.Duration(math.MaxUint32) * time.Millisecond
+
+// ErrDurationOutOfRange is the error wrapped by CheckDuration when the
+// duration cannot be represented by TransitionTime.
+var ErrDurationOutOfRange = errors.New("duration out of TransitionTime range")
+
+// CheckDuration returns an error wrapping ErrDurationOutOfRange if d is
+// negative or longer than MaxTransitionTime.
+//
+// All the device APIs taking a transition duration call it before sending
+// anything,
+// so an out of range duration fails loudly instead of silently wrapping
+// around in ConvertDuration.
+func CheckDuration(d time.Duration) error {
+	if d < 0 || d > MaxTransitionTime {
+		return fmt.Errorf(
+			"%w: %v not in [0, %v]",
+			ErrDurationOutOfRange,
+			d,
+			MaxTransitionTime,
+		)
+	}
+	return nil
+}
+
+// ConvertDuration converts a time.Duration into TransitionTime.
 //
-// [2] https://play.golang.com/p/edwqG4nNqkt
+// It doesn't do any range checks,
+// a duration overflowing TransitionTime wraps around.
+// Use CheckDuration first to validate d.
 func ConvertDuration(d time.Duration) TransitionTime {
 	return TransitionTime(d / time.Millisecond)
 }
@@ -1,6 +1,7 @@
 package lifxlan_test
 
 import (
+	"errors"
 	"math/rand"
 	"testing"
 	"testing/quick"
@@ -59,3 +60,46 @@ func TestTime(t *testing.T) {
 		)
 	}
 }
+
+func TestCheckDuration(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		duration time.Duration
+		valid    bool
+	}{
+		{
+			label:    "Zero",
+			duration: 0,
+			valid:    true,
+		},
+		{
+			label:    "Max",
+			duration: lifxlan.MaxTransitionTime,
+			valid:    true,
+		},
+		{
+			label:    "Negative",
+			duration: -time.Millisecond,
+			valid:    false,
+		},
+		{
+			label:    "60Days",
+			duration: time.Hour * 24 * 60,
+			valid:    false,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				err := lifxlan.CheckDuration(c.duration)
+				if c.valid && err != nil {
+					t.Errorf("CheckDuration(%v) expected nil error, got %v", c.duration, err)
+				}
+				if !c.valid && !errors.Is(err, lifxlan.ErrDurationOutOfRange) {
+					t.Errorf("CheckDuration(%v) expected ErrDurationOutOfRange, got %v", c.duration, err)
+				}
+			},
+		)
+	}
+}
@@ -1,6 +1,7 @@
 package lifxlan
 
 import (
+	"context"
 	"time"
 )
 
@@ -13,6 +14,7 @@ import (
 //
 // It's intentionally defined as variable instead of constant,
 // so the user could adjust it if needed.
+// It can also be overridden per call via WithReadTimeout.
 var UDPReadTimeout = time.Millisecond * 100
 
 // GetReadDeadline returns a value can be used in net.Conn.SetReadDeadline from
@@ -21,6 +23,98 @@ func GetReadDeadline() time.Time {
 	return time.Now().Add(UDPReadTimeout)
 }
 
+type readTimeoutKey struct{}
+
+// WithReadTimeout returns a copy of ctx that carries d as the read timeout.
+//
+// ReadNextResponse (and therefore WaitForAcks, WaitForResponses and all the
+// device getters),
+// Discover and Watch use the read timeout from the context when it's set,
+// so different calls in the same process can use different read timeouts.
+// When it's not set or d <= 0, UDPReadTimeout will be used instead.
+//
+// Please note that the read timeout only applies to a single read on the
+// connection.
+// Upon timeout the functions above check context cancellation and continue
+// reading,
+// so the overall time limit of a call is still controlled by the deadline of
+// ctx.
+// When ctx is cancelled or its deadline passed,
+// the ongoing read is interrupted right away instead of waiting out the read
+// timeout.
+func WithReadTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, readTimeoutKey{}, d)
+}
+
+// ReadTimeout returns the read timeout set via WithReadTimeout on ctx,
+// or UDPReadTimeout if it's not set.
+func ReadTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(readTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return d
+	}
+	return UDPReadTimeout
+}
+
+// GetReadDeadlineContext is the same as GetReadDeadline,
+// but uses ReadTimeout(ctx) instead of UDPReadTimeout,
+// and never returns a time later than the deadline of ctx.
+func GetReadDeadlineContext(ctx context.Context) time.Time {
+	deadline := time.Now().Add(ReadTimeout(ctx))
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+func (d *device) SetTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	policyLock.Lock()
+	defer policyLock.Unlock()
+	p := DefaultPolicy()
+	if sp, ok := devicePolicies[d.source]; ok {
+		p = sp.policy
+	}
+	p.Timeout = timeout
+	d.setPolicyLocked(p)
+}
+
+func (d *device) Timeout() time.Duration {
+	return sourcePolicy(d.source).Timeout
+}
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// interruptReadOnDone makes a blocking read on conn return as soon as ctx is
+// done (cancelled or past its deadline),
+// by moving the read deadline of conn to now.
+//
+// It's needed because the read deadline only covers ctx deadline but not
+// cancellation.
+// The returned stop function must be called after the read returns.
+func interruptReadOnDone(ctx context.Context, conn readDeadliner) (stop func()) {
+	done := ctx.Done()
+	if done == nil {
+		// ctx can never be cancelled.
+		return func() {}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-stopped:
+		case <-done:
+			// The next read will set a new deadline anyways.
+			conn.SetReadDeadline(time.Now())
+		}
+	}()
+	return func() {
+		close(stopped)
+	}
+}
+
 type timeouter interface {
 	Timeout() bool
 }
@@ -0,0 +1,239 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+// deadlineConn is a net.Conn that records the read timeouts set on it.
+type deadlineConn struct {
+	net.Conn
+
+	lock     sync.Mutex
+	timeouts []time.Duration
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.lock.Lock()
+	c.timeouts = append(c.timeouts, time.Until(t))
+	c.lock.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func TestReadTimeout(t *testing.T) {
+	for _, c := range []struct {
+		label    string
+		ctx      context.Context
+		expected time.Duration
+	}{
+		{
+			label:    "Default",
+			ctx:      context.Background(),
+			expected: lifxlan.UDPReadTimeout,
+		},
+		{
+			label:    "Set",
+			ctx:      lifxlan.WithReadTimeout(context.Background(), time.Second),
+			expected: time.Second,
+		},
+		{
+			label:    "Zero",
+			ctx:      lifxlan.WithReadTimeout(context.Background(), 0),
+			expected: lifxlan.UDPReadTimeout,
+		},
+		{
+			label: "Override",
+			ctx: lifxlan.WithReadTimeout(
+				lifxlan.WithReadTimeout(context.Background(), time.Second),
+				time.Millisecond,
+			),
+			expected: time.Millisecond,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				if actual := lifxlan.ReadTimeout(c.ctx); actual != c.expected {
+					t.Errorf("ReadTimeout expected %v, got %v", c.expected, actual)
+				}
+			},
+		)
+	}
+}
+
+func TestReadNextResponseReadTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout     = time.Millisecond * 200
+		readTimeout = time.Millisecond * 10
+	)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &deadlineConn{Conn: client}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ctx = lifxlan.WithReadTimeout(ctx, readTimeout)
+
+	_, err := lifxlan.ReadNextResponse(ctx, conn)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	if len(conn.timeouts) < 2 {
+		t.Fatalf("Expected multiple reads, got %d", len(conn.timeouts))
+	}
+	for _, d := range conn.timeouts {
+		if d > readTimeout {
+			t.Errorf("Expected read timeout <= %v, got %v", readTimeout, d)
+		}
+	}
+}
+
+func TestGetReadDeadlineContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	ctx = lifxlan.WithReadTimeout(ctx, time.Second)
+
+	deadline, _ := ctx.Deadline()
+	if actual := lifxlan.GetReadDeadlineContext(ctx); actual.After(deadline) {
+		t.Errorf("Expected read deadline no later than ctx deadline %v, got %v", deadline, actual)
+	}
+}
+
+func TestWaitForAcksCancelLatency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		// Much longer than the expected latency,
+		// so the test fails if it waits out the read timeout.
+		readTimeout = time.Second
+		delay       = time.Millisecond * 10
+		maxLatency  = time.Millisecond * 100
+	)
+
+	for _, c := range []struct {
+		label    string
+		ctx      func() (context.Context, context.CancelFunc)
+		expected error
+	}{
+		{
+			label: "Cancel",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(delay, cancel)
+				return ctx, cancel
+			},
+			expected: context.Canceled,
+		},
+		{
+			label: "Deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), delay)
+			},
+			expected: context.DeadlineExceeded,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				client, server := net.Pipe()
+				defer server.Close()
+				defer client.Close()
+
+				ctx, cancel := c.ctx()
+				defer cancel()
+				ctx = lifxlan.WithReadTimeout(ctx, readTimeout)
+
+				start := time.Now()
+				err := lifxlan.WaitForAcks(ctx, client, 1, 1)
+				if elapsed := time.Since(start); elapsed > delay+maxLatency {
+					t.Errorf("Expected WaitForAcks to return within %v, took %v", delay+maxLatency, elapsed)
+				}
+				if !errors.Is(err, c.expected) {
+					t.Errorf("Expected %v, got %v", c.expected, err)
+				}
+			},
+		)
+	}
+}
+
+func TestDeviceTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 200
+		delay   = time.Millisecond * 50
+	)
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	reply := mock.ReplyHandler(
+		lifxlan.StateWifiInfo,
+		&lifxlan.RawStateWifiInfoPayload{},
+	)
+	service.Handlers[lifxlan.GetWifiInfo] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		time.Sleep(delay)
+		reply(s, conn, addr, orig)
+	}
+
+	if d := device.Timeout(); d != 0 {
+		t.Errorf("Timeout expected 0 by default, got %v", d)
+	}
+	device.SetTimeout(delay / 5)
+	defer device.SetTimeout(0)
+	if d := device.Timeout(); d != delay/5 {
+		t.Errorf("Timeout expected %v, got %v", delay/5, d)
+	}
+
+	t.Run(
+		"NoDeadline",
+		func(t *testing.T) {
+			start := time.Now()
+			_, err := device.GetWifiInfo(context.Background(), nil)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed >= delay {
+				t.Errorf("Expected to return before %v, took %v", delay, elapsed)
+			}
+		},
+	)
+
+	t.Run(
+		"ExplicitDeadline",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := device.GetWifiInfo(ctx, nil); err != nil {
+				t.Errorf("Expected ctx deadline to override the device timeout, got %v", err)
+			}
+		},
+	)
+}
@@ -1,6 +1,8 @@
 package lifxlan
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 )
 
@@ -17,3 +19,36 @@ func (p RawStateUnhandledPayload) Error() string {
 		p.UnhandledType,
 	)
 }
+
+// UnhandledMessageError is the error returned when the device replied
+// StateUnhandled to the message sent,
+// which usually means the device (or its firmware) doesn't support it.
+//
+// WaitForAcks and WaitForResponses wrap it in their own error types,
+// so use errors.As to check for it.
+type UnhandledMessageError struct {
+	// The message type the device doesn't understand.
+	Type MessageType
+}
+
+var _ error = (*UnhandledMessageError)(nil)
+
+func (e *UnhandledMessageError) Error() string {
+	return fmt.Sprintf(
+		"lifxlan: unhandled message: %v",
+		e.Type,
+	)
+}
+
+// parseUnhandled parses the payload of a StateUnhandled response into
+// *UnhandledMessageError.
+func parseUnhandled(resp *Response) error {
+	var raw RawStateUnhandledPayload
+	r := bytes.NewReader(resp.Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return err
+	}
+	return &UnhandledMessageError{
+		Type: raw.UnhandledType,
+	}
+}
@@ -51,6 +51,49 @@ func (raw HardwareVersion) Parse() *Product {
 	return &parsed
 }
 
+// UnknownName is the name returned by HardwareVersion.ProductName and
+// HardwareVersion.VendorName when the IDs are not in ProductMap.
+const UnknownName = "unknown"
+
+// ProductName returns the name of the product from ProductMap,
+// or UnknownName if it's not in ProductMap.
+func (raw HardwareVersion) ProductName() string {
+	if parsed := raw.Parse(); parsed != nil {
+		return parsed.ProductName
+	}
+	return UnknownName
+}
+
+// VendorName returns the name of the vendor from ProductMap,
+// or UnknownName if there's no product with the vendor ID in ProductMap.
+//
+// The vendor name is still returned for unknown products from a known vendor
+// (e.g. products newer than ProductMap).
+func (raw HardwareVersion) VendorName() string {
+	if parsed := raw.Parse(); parsed != nil {
+		return parsed.VendorName
+	}
+	for _, p := range ProductMap {
+		if p.VendorID == raw.VendorID {
+			return p.VendorName
+		}
+	}
+	return UnknownName
+}
+
+// Capabilities returns the capabilities of the product from ProductMap,
+// without any firmware upgrades applied (the same as LookupProduct),
+// or the zero value if it's not in ProductMap.
+//
+// Use Parse().FeaturesAt(firmware).Capabilities() instead if you know the
+// firmware version of the device.
+func (raw HardwareVersion) Capabilities() ProductCapabilities {
+	if parsed := raw.Parse(); parsed != nil {
+		return parsed.Features.Capabilities()
+	}
+	return ProductCapabilities{}
+}
+
 func (raw HardwareVersion) String() string {
 	var sb strings.Builder
 	parsed := raw.Parse()
@@ -101,25 +144,24 @@ func (d *device) GetHardwareVersion(ctx context.Context, conn net.Conn) error {
 		return err
 	}
 
-	for {
-		resp, err := ReadNextResponse(ctx, conn)
-		if err != nil {
-			return err
-		}
-		if resp.Sequence != seq || resp.Source != d.Source() {
-			continue
-		}
-		if resp.Message != StateVersion {
-			continue
-		}
-
-		var raw RawStateVersionPayload
-		r := bytes.NewReader(resp.Payload)
-		if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
-			return err
-		}
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateVersion,
+		1, // count
+	)
+	if err != nil {
+		return err
+	}
 
-		d.version = raw.Version
-		return nil
+	var raw RawStateVersionPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return err
 	}
+
+	d.version = raw.Version
+	return nil
 }
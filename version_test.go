@@ -78,6 +78,62 @@ func TestVersion(t *testing.T) {
 	)
 }
 
+func TestHardwareVersionLookups(t *testing.T) {
+	t.Run(
+		"Known",
+		func(t *testing.T) {
+			raw := lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 70,
+			}
+			if name := raw.ProductName(); name != "LIFX Switch" {
+				t.Errorf("ProductName expected %q, got %q", "LIFX Switch", name)
+			}
+			if name := raw.VendorName(); name != "LIFX" {
+				t.Errorf("VendorName expected %q, got %q", "LIFX", name)
+			}
+			if caps := raw.Capabilities(); !caps.IsRelay() {
+				t.Errorf("Capabilities expected to be relay-only, got %+v", caps)
+			}
+		},
+	)
+
+	t.Run(
+		"UnknownProduct",
+		func(t *testing.T) {
+			raw := lifxlan.HardwareVersion{
+				VendorID:  1,
+				ProductID: 0,
+			}
+			if name := raw.ProductName(); name != lifxlan.UnknownName {
+				t.Errorf("ProductName expected %q, got %q", lifxlan.UnknownName, name)
+			}
+			if name := raw.VendorName(); name != "LIFX" {
+				t.Errorf("VendorName expected %q, got %q", "LIFX", name)
+			}
+			if caps := raw.Capabilities(); caps != (lifxlan.ProductCapabilities{}) {
+				t.Errorf("Capabilities expected zero value, got %+v", caps)
+			}
+		},
+	)
+
+	t.Run(
+		"UnknownVendor",
+		func(t *testing.T) {
+			raw := lifxlan.HardwareVersion{
+				VendorID:  0,
+				ProductID: 1,
+			}
+			if name := raw.ProductName(); name != lifxlan.UnknownName {
+				t.Errorf("ProductName expected %q, got %q", lifxlan.UnknownName, name)
+			}
+			if name := raw.VendorName(); name != lifxlan.UnknownName {
+				t.Errorf("VendorName expected %q, got %q", lifxlan.UnknownName, name)
+			}
+		},
+	)
+}
+
 func TestEmptyHardwareVersion(t *testing.T) {
 	var version lifxlan.HardwareVersion
 	s := version.String()
@@ -0,0 +1,149 @@
+package lifxlan
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// WaitForResponses helps device API implementations to wait for responses.
+//
+// It blocks until count responses of the given message type, source, and
+// sequence are received, in which case it returns them with nil error.
+// It also returns when the context is cancelled.
+//
+// This function drops all received messages that don't match the message type,
+// source and sequence.
+// Therefore, there shouldn't be more than one WaitForResponses (or WaitForAcks)
+// functions running for the same connection at the same time.
+// Use SyncConn if the connection is shared by multiple goroutines.
+//
+// If ctx doesn't have a deadline,
+// the default timeout of the device with the source is applied (see
+// Device.SetTimeout and ApplyPolicy).
+//
+// If this function returns an error,
+// the error would be of type *WaitForResponsesError,
+// and the responses received so far will also be returned.
+func WaitForResponses(
+	ctx context.Context,
+	conn net.Conn,
+	source uint32,
+	sequence uint8,
+	message MessageType,
+	count int,
+) ([]*Response, error) {
+	return WaitForResponsesFunc(ctx, conn, source, sequence, message, count, nil)
+}
+
+// WaitForResponsesFunc is the same as WaitForResponses,
+// except that only the responses accepted by accept are counted towards count
+// and returned,
+// e.g. to drop duplicated responses to a message with multiple responses.
+//
+// accept is called with every response matching the message type, source and
+// sequence, in the order they are received.
+// If accept returns an error,
+// this function returns it as the Cause of the *WaitForResponsesError.
+// If accept is nil, all the matching responses are accepted.
+func WaitForResponsesFunc(
+	ctx context.Context,
+	conn net.Conn,
+	source uint32,
+	sequence uint8,
+	message MessageType,
+	count int,
+	accept func(*Response) (bool, error),
+) ([]*Response, error) {
+	ctx, cancel := ApplyPolicy(ctx, source)
+	defer cancel()
+
+	responses := make([]*Response, 0, count)
+	e := &WaitForResponsesError{
+		Message: message,
+		Total:   count,
+	}
+
+	if ctx.Err() != nil {
+		e.Cause = ctx.Err()
+		return responses, e
+	}
+
+	// The source to match the responses against.
+	match := SourceFromContext(ctx, source)
+	for len(responses) < count {
+		resps, err := ReadNextResponses(ctx, conn)
+		if err != nil {
+			e.Received = len(responses)
+			e.Cause = err
+			return responses, e
+		}
+		for _, resp := range resps {
+			if resp.Sequence == sequence && resp.Source == match && resp.Message == StateUnhandled {
+				releaseSequence(source, sequence)
+				e.Received = len(responses)
+				e.Cause = parseUnhandled(resp)
+				return responses, e
+			}
+			if resp.Source == match && resp.Sequence != sequence {
+				if err := checkSourceCollision("WaitForResponses", match, resp); err != nil {
+					e.Received = len(responses)
+					e.Cause = err
+					return responses, e
+				}
+			}
+			if resp.Sequence != sequence || resp.Source != match || resp.Message != message {
+				debugf(
+					"WaitForResponses: dropped %v from %v: source=%d sequence=%d",
+					resp.Message,
+					resp.Target,
+					resp.Source,
+					resp.Sequence,
+				)
+				continue
+			}
+			if accept != nil {
+				ok, err := accept(resp)
+				if err != nil {
+					e.Received = len(responses)
+					e.Cause = err
+					return responses, e
+				}
+				if !ok {
+					continue
+				}
+			}
+			responses = append(responses, resp)
+			if len(responses) == count {
+				break
+			}
+		}
+	}
+	releaseSequence(source, sequence)
+	return responses, nil
+}
+
+// WaitForResponsesError defines the error returned by WaitForResponses.
+type WaitForResponsesError struct {
+	Message  MessageType
+	Received int
+	Total    int
+	Cause    error
+}
+
+var _ error = (*WaitForResponsesError)(nil)
+
+func (e *WaitForResponsesError) Error() string {
+	return fmt.Sprintf(
+		"lifxlan.WaitForResponses: %d of %d %v response(s) received: %v",
+		e.Received,
+		e.Total,
+		e.Message,
+		e.Cause,
+	)
+}
+
+// Unwrap returns the underlying error.
+func (e *WaitForResponsesError) Unwrap() error {
+	return e.Cause
+}
@@ -0,0 +1,525 @@
+package lifxlan_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestWaitForResponses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const replies = 3
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.Handlers[lifxlan.GetLabel] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		// Non-matching message type, should be dropped.
+		s.Reply(conn, addr, orig, lifxlan.StatePower, make([]byte, 2))
+		for i := 0; i < replies; i++ {
+			s.Reply(conn, addr, orig, lifxlan.StateLabel, make([]byte, lifxlan.LabelLength))
+		}
+	}
+
+	for _, c := range []struct {
+		label string
+		count int
+		err   bool
+	}{
+		{
+			label: "Enough",
+			count: replies,
+		},
+		{
+			label: "NotEnough",
+			count: replies + 1,
+			err:   true,
+		},
+	} {
+		c := c
+		t.Run(
+			c.label,
+			func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+
+				conn, err := device.Dial()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+
+				seq, err := device.Send(ctx, conn, 0, lifxlan.GetLabel, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resps, err := lifxlan.WaitForResponses(
+					ctx,
+					conn,
+					device.Source(),
+					seq,
+					lifxlan.StateLabel,
+					c.count,
+				)
+				if len(resps) != replies {
+					t.Errorf("Expected %d responses, got %d", replies, len(resps))
+				}
+				for _, resp := range resps {
+					if resp.Message != lifxlan.StateLabel {
+						t.Errorf("Expected message %v, got %v", lifxlan.StateLabel, resp.Message)
+					}
+				}
+				if !c.err {
+					if err != nil {
+						t.Errorf("Expected nil error, got %v", err)
+					}
+					return
+				}
+				var e *lifxlan.WaitForResponsesError
+				if !errors.As(err, &e) {
+					t.Fatalf("Expected *WaitForResponsesError, got %v", err)
+				}
+				if e.Received != replies || e.Total != c.count {
+					t.Errorf("Expected %d/%d received, got %d/%d", replies, c.count, e.Received, e.Total)
+				}
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Expected error to wrap context.DeadlineExceeded, got %v", err)
+				}
+			},
+		)
+	}
+}
+
+func TestWaitForResponsesFunc(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	labels := []string{"foo", "foo", "bar", "baz"}
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.GetLabel: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				for _, l := range labels {
+					var label lifxlan.Label
+					label.Set(l)
+					s.Reply(conn, addr, orig, lifxlan.StateLabel, label[:])
+				}
+			},
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	wait := func(
+		t *testing.T,
+		count int,
+		accept func(*lifxlan.Response) (bool, error),
+	) ([]*lifxlan.Response, error) {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		conn, err := device.Dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		seq, err := device.Send(ctx, conn, 0, lifxlan.GetLabel, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lifxlan.WaitForResponsesFunc(
+			ctx,
+			conn,
+			device.Source(),
+			seq,
+			lifxlan.StateLabel,
+			count,
+			accept,
+		)
+	}
+
+	t.Run(
+		"Dedup",
+		func(t *testing.T) {
+			seen := make(map[string]bool)
+			resps, err := wait(t, 2, func(resp *lifxlan.Response) (bool, error) {
+				label := string(resp.Payload)
+				if seen[label] {
+					return false, nil
+				}
+				seen[label] = true
+				return true, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, resp := range resps {
+				var label lifxlan.Label
+				copy(label[:], resp.Payload)
+				actual = append(actual, label.String())
+			}
+			expected := []string{"foo", "bar"}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected %q, got %q", expected, actual)
+			}
+		},
+	)
+
+	t.Run(
+		"Error",
+		func(t *testing.T) {
+			cause := errors.New("foo")
+			_, err := wait(t, 2, func(*lifxlan.Response) (bool, error) {
+				return false, cause
+			})
+			var e *lifxlan.WaitForResponsesError
+			if !errors.As(err, &e) {
+				t.Fatalf("Expected *WaitForResponsesError, got %v", err)
+			}
+			if !errors.Is(err, cause) {
+				t.Errorf("Expected error to wrap %v, got %v", cause, err)
+			}
+		},
+	)
+}
+
+func TestWaitForResponsesUnhandled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const msg = lifxlan.GetWifiInfo
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.Handlers[msg] = mock.StateUnhandledHandler(msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := device.GetWifiInfo(ctx, nil)
+	var e *lifxlan.UnhandledMessageError
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected *UnhandledMessageError, got %v", err)
+	}
+	if e.Type != msg {
+		t.Errorf("Unhandled type expected %v, got %v", msg, e.Type)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error before timeout, got %v", err)
+	}
+}
+
+func TestWaitForAcksUnhandled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const msg = lifxlan.SetPower
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.HandleAcks = false
+	service.Handlers[msg] = mock.StateUnhandledHandler(msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := device.SetPower(ctx, nil, lifxlan.PowerOn, true)
+	var e *lifxlan.UnhandledMessageError
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected *UnhandledMessageError, got %v", err)
+	}
+	if e.Type != msg {
+		t.Errorf("Unhandled type expected %v, got %v", msg, e.Type)
+	}
+}
+
+func TestWaitForAcksMissing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+	const n = 5
+
+	// Only ack the SetPower messages turning the device on.
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.SetPower: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				var raw lifxlan.RawSetPowerPayload
+				if err := raw.UnmarshalBinary(orig.Payload); err != nil {
+					t.Error(err)
+					return
+				}
+				if raw.Level.On() {
+					s.Reply(conn, addr, orig, lifxlan.Acknowledgement, nil)
+				}
+			},
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var sequences, received, missing []uint8
+	for i := 0; i < n; i++ {
+		power := lifxlan.BoolToPower(i%2 == 0)
+		seq, err := device.Send(
+			ctx,
+			conn,
+			lifxlan.FlagAckRequired,
+			lifxlan.SetPower,
+			&lifxlan.RawSetPowerPayload{Level: power},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sequences = append(sequences, seq)
+		if power.On() {
+			received = append(received, seq)
+		} else {
+			missing = append(missing, seq)
+		}
+	}
+
+	err = lifxlan.WaitForAcks(ctx, conn, device.Source(), sequences...)
+	var e *lifxlan.WaitForAcksError
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected *WaitForAcksError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if !reflect.DeepEqual(e.Total, sequences) {
+		t.Errorf("Total expected %v, got %v", sequences, e.Total)
+	}
+	if !reflect.DeepEqual(e.Missing, missing) {
+		t.Errorf("Missing expected %v, got %v", missing, e.Missing)
+	}
+	if len(e.Received) != len(received) {
+		t.Errorf("Received expected %v, got %v", received, e.Received)
+	}
+}
+
+func TestWaitForAcksWithin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout = time.Millisecond * 200
+		window  = time.Millisecond * 30
+	)
+
+	send := func(t *testing.T, ctx context.Context, device lifxlan.Device, conn net.Conn) uint8 {
+		t.Helper()
+		seq, err := device.Send(
+			ctx,
+			conn,
+			lifxlan.FlagAckRequired,
+			lifxlan.SetPower,
+			&lifxlan.RawSetPowerPayload{Level: lifxlan.PowerOn},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seq
+	}
+
+	t.Run(
+		"Acked",
+		func(t *testing.T) {
+			service, device := mock.StartService(t)
+			defer service.Stop()
+			conn, err := device.Dial()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			seq := send(t, ctx, device, conn)
+			acked, err := lifxlan.WaitForAcksWithin(ctx, conn, device.Source(), window, seq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !acked {
+				t.Error("Expected acked")
+			}
+		},
+	)
+
+	service := &mock.Service{
+		TB:         t,
+		Handlers:   make(map[lifxlan.MessageType]mock.HandlerFunc),
+		HandleAcks: false,
+	}
+	device := service.Start()
+	defer service.Stop()
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Run(
+		"NotAcked",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			seq := send(t, ctx, device, conn)
+			start := time.Now()
+			acked, err := lifxlan.WaitForAcksWithin(ctx, conn, device.Source(), window, seq)
+			if err != nil {
+				t.Fatalf("Expected nil error when not acked, got %v", err)
+			}
+			if acked {
+				t.Error("Expected not acked")
+			}
+			if elapsed := time.Since(start); elapsed >= timeout {
+				t.Errorf("Expected to return after the window %v, took %v", window, elapsed)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancelled",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), window/3)
+			defer cancel()
+			seq := send(t, ctx, device, conn)
+			acked, err := lifxlan.WaitForAcksWithin(ctx, conn, device.Source(), window, seq)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			if acked {
+				t.Error("Expected not acked")
+			}
+		},
+	)
+}
+
+func TestStaleResponses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	// stale returns copies of orig with a previous sequence and another source,
+	// as if they are late responses to other calls on the same connection.
+	stale := func(orig *lifxlan.Response) []*lifxlan.Response {
+		prevSeq := *orig
+		prevSeq.Sequence--
+		otherSource := *orig
+		otherSource.Source++
+		return []*lifxlan.Response{&prevSeq, &otherSource}
+	}
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.HandleAcks = false
+
+	var staleLabel, freshLabel lifxlan.RawStateLabelPayload
+	staleLabel.Label.Set("stale")
+	freshLabel.Label.Set("fresh")
+	service.Handlers[lifxlan.GetLabel] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		for _, resp := range stale(orig) {
+			s.Reply(conn, addr, resp, lifxlan.StateLabel, staleLabel.Label[:])
+		}
+		s.Reply(conn, addr, orig, lifxlan.StateLabel, freshLabel.Label[:])
+	}
+	// Only reply stale acks.
+	service.Handlers[lifxlan.SetPower] = func(
+		s *mock.Service,
+		conn net.PacketConn,
+		addr net.Addr,
+		orig *lifxlan.Response,
+	) {
+		for _, resp := range stale(orig) {
+			s.Reply(conn, addr, resp, lifxlan.Acknowledgement, nil)
+		}
+	}
+
+	conn, err := device.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Run(
+		"GetLabel",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.GetLabel(ctx, conn); err != nil {
+				t.Fatal(err)
+			}
+			if got := device.Label().String(); got != "fresh" {
+				t.Errorf("Expected label %q, got %q", "fresh", got)
+			}
+		},
+	)
+
+	t.Run(
+		"StaleAcksOnly",
+		func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := device.SetPower(ctx, conn, lifxlan.PowerOn, true); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded with only stale acks, got %v", err)
+			}
+		},
+	)
+}
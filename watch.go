@@ -0,0 +1,150 @@
+package lifxlan
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval used by Watch to rebroadcast
+// discovery messages.
+const DefaultWatchInterval = time.Second * 30
+
+// WatchOptions defines the options used by Watch.
+type WatchOptions struct {
+	// The host to broadcast discovery messages to.
+	//
+	// If it's empty, DefaultBroadcastHost will be used.
+	BroadcastHost string
+
+	// How often the discovery message will be rebroadcasted.
+	//
+	// If it's <= 0, DefaultWatchInterval will be used.
+	Interval time.Duration
+
+	// If StaleAfter > 0,
+	// a device that has not responded for longer than StaleAfter will be
+	// written into the channel again when it responds next time.
+	//
+	// If it's <= 0, every device will only be written into the channel once.
+	StaleAfter time.Duration
+}
+
+func (opts WatchOptions) interval() time.Duration {
+	if opts.Interval <= 0 {
+		return DefaultWatchInterval
+	}
+	return opts.Interval
+}
+
+// Watch keeps discovering lifx products in the lan,
+// by rebroadcasting discovery messages every opts.Interval.
+//
+// Discovered devices are deduped by their Target,
+// so every device is only written into devices channel once,
+// unless it's considered stale as defined by opts.StaleAfter.
+//
+// Unlike Discover,
+// responses that cannot be parsed are ignored instead of returned as errors,
+// as Watch is intended for long-running processes.
+//
+// The function is guaranteed to close the channel upon retuning,
+// so the caller could just range over the channel for reading.
+// Writing to the channel will not block Watch from returning after ctx is
+// cancelled.
+//
+// The function will only return upon error or when ctx is cancelled,
+// in which case ctx.Err() will be returned.
+func Watch(ctx context.Context, devices chan<- Device, opts WatchOptions) error {
+	defer close(devices)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	conn, err := net.ListenPacket("udp", ":"+DefaultBroadcastPort)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	host := opts.BroadcastHost
+	if host == "" {
+		host = DefaultBroadcastHost
+	}
+	broadcast, err := net.ResolveUDPAddr(
+		"udp",
+		net.JoinHostPort(host, DefaultBroadcastPort),
+	)
+	if err != nil {
+		return err
+	}
+
+	return watch(ctx, conn, broadcast, devices, opts)
+}
+
+// watch implements Watch with the given conn and broadcast address.
+//
+// It does not close devices channel.
+func watch(
+	ctx context.Context,
+	conn net.PacketConn,
+	broadcast net.Addr,
+	devices chan<- Device,
+	opts WatchOptions,
+) error {
+	msg, err := discoverMessage()
+	if err != nil {
+		return err
+	}
+
+	interval := opts.interval()
+	// The last time we heard from each device.
+	lastSeen := make(map[Target]time.Time)
+	var nextBroadcast time.Time
+
+	buf := make([]byte, ReadBufferSize(ctx))
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if now := time.Now(); !now.Before(nextBroadcast) {
+			if err := writeMessage(conn, msg, broadcast, "lifxlan.Watch"); err != nil {
+				return err
+			}
+			nextBroadcast = now.Add(interval)
+		}
+
+		if err := conn.SetReadDeadline(GetReadDeadlineContext(ctx)); err != nil {
+			return err
+		}
+		stop := interruptReadOnDone(ctx, conn)
+		n, addr, err := conn.ReadFrom(buf)
+		stop()
+		if err != nil {
+			if CheckTimeoutError(err) {
+				continue
+			}
+			return err
+		}
+
+		device, err := parseStateService(buf[:n], addr)
+		if err != nil || device == nil {
+			continue
+		}
+
+		now := time.Now()
+		last, seen := lastSeen[device.Target()]
+		lastSeen[device.Target()] = now
+		if seen && (opts.StaleAfter <= 0 || now.Sub(last) <= opts.StaleAfter) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case devices <- device:
+		}
+	}
+}
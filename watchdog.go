@@ -0,0 +1,98 @@
+package lifxlan
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Watchdog polls the uptime of dev via GetInfo every interval,
+// and calls onReboot with the previous and the new uptime when the new uptime
+// is less than the previous one,
+// which means the device restarted (e.g. lost power) between the polls.
+//
+// Uptimes overflowing time.Duration are capped at its max value (see
+// DeviceInfo), so they never trigger onReboot by wrapping around.
+//
+// If conn is nil,
+// a new connection will be made and guaranteed to be closed before returning.
+//
+// Every poll is limited to interval.
+// It returns the error if the initial poll fails,
+// later failed polls (e.g. while the device is rebooting) are skipped and
+// retried at the next interval.
+// onReboot is called synchronously,
+// and polling is paused until it returns.
+//
+// The function will only return upon error of the initial poll or when ctx is
+// cancelled,
+// in which case ctx.Err() will be returned.
+func Watchdog(
+	ctx context.Context,
+	conn net.Conn,
+	dev Device,
+	interval time.Duration,
+	onReboot func(prev, now time.Duration),
+) error {
+	if interval <= 0 {
+		return errors.New("lifxlan.Watchdog: interval must be positive")
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := dev.Dial()
+		if err != nil {
+			return err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	poll := func() (time.Duration, error) {
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		info, err := dev.GetInfo(ctx, conn)
+		if err != nil {
+			return 0, err
+		}
+		return info.Uptime, nil
+	}
+
+	last, err := poll()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		uptime, err := poll()
+		if err != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if uptime < last {
+			onReboot(last, uptime)
+		}
+		last = uptime
+	}
+}
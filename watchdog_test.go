@@ -0,0 +1,119 @@
+package lifxlan_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestWatchdog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const (
+		timeout  = time.Millisecond * 200
+		interval = time.Millisecond * 10
+	)
+
+	uptimes := []uint64{
+		uint64(time.Hour),
+		uint64(time.Hour + time.Second),
+		// Reboot.
+		uint64(time.Second * 5),
+		uint64(time.Second * 6),
+		// Both capped at the max time.Duration, so not a reboot.
+		math.MaxUint64,
+		math.MaxInt64 + 1,
+		// Reboot.
+		uint64(time.Second),
+	}
+	type reboot struct {
+		prev, now time.Duration
+	}
+	expected := []reboot{
+		{prev: time.Hour + time.Second, now: time.Second * 5},
+		{prev: math.MaxInt64, now: time.Second},
+	}
+
+	var lock sync.Mutex
+	var polled int
+	done := make(chan struct{})
+	service := &mock.Service{
+		TB: t,
+		Handlers: map[lifxlan.MessageType]mock.HandlerFunc{
+			lifxlan.GetInfo: func(
+				s *mock.Service,
+				conn net.PacketConn,
+				addr net.Addr,
+				orig *lifxlan.Response,
+			) {
+				lock.Lock()
+				defer lock.Unlock()
+				i := polled
+				if i >= len(uptimes) {
+					// Keep reporting the last uptime after the sequence.
+					i = len(uptimes) - 1
+				}
+				polled++
+				if polled == len(uptimes) {
+					close(done)
+				}
+				buf := new(bytes.Buffer)
+				if err := binary.Write(buf, binary.LittleEndian, &lifxlan.RawStateInfoPayload{
+					Uptime: uptimes[i],
+				}); err != nil {
+					t.Error(err)
+					return
+				}
+				s.Reply(conn, addr, orig, lifxlan.StateInfo, buf.Bytes())
+			},
+		},
+	}
+	device := service.Start()
+	defer service.Stop()
+
+	if err := lifxlan.Watchdog(context.Background(), nil, device, 0, nil); err == nil {
+		t.Error("Expected error for zero interval, got nil")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var reboots []reboot
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var watchErr error
+	go func() {
+		defer wg.Done()
+		watchErr = lifxlan.Watchdog(ctx, nil, device, interval, func(prev, now time.Duration) {
+			reboots = append(reboots, reboot{prev: prev, now: now})
+		})
+	}()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("Did not poll all the uptimes")
+	case <-done:
+	}
+	// Give the last poll some time to be handled.
+	time.Sleep(interval * 3)
+	cancel()
+	wg.Wait()
+
+	if !errors.Is(watchErr, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", watchErr)
+	}
+	if !reflect.DeepEqual(reboots, expected) {
+		t.Errorf("Reboots expected %+v, got %+v", expected, reboots)
+	}
+}
@@ -0,0 +1,144 @@
+package lifxlan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+)
+
+// RawStateWifiInfoPayload defines the struct to be used for encoding and
+// decoding.
+//
+// https://lan.developer.lifx.com/docs/information-messages#statewifiinfo---packet-17
+type RawStateWifiInfoPayload struct {
+	Signal float32
+	_      [10]byte // reserved
+}
+
+// NoSignalRSSI is the RSSI value reported when there's no wifi signal.
+const NoSignalRSSI = 200
+
+// WifiInfo defines the wifi info returned by GetWifiInfo.
+type WifiInfo struct {
+	// The raw signal value reported by the device.
+	Signal float32
+}
+
+// RSSI converts the raw signal value into RSSI according to:
+//
+// https://lan.developer.lifx.com/docs/information-messages#statewifiinfo---packet-17
+//
+// When Signal is less than 1.0 (older firmwares),
+// the returned value is in dBm (negative).
+// Otherwise the returned value is the signal-to-noise ratio (positive).
+//
+// NoSignalRSSI will be returned for non-positive signal values.
+func (w WifiInfo) RSSI() int {
+	return signalRSSI(w.Signal)
+}
+
+// signalRSSI converts the raw signal value reported by StateWifiInfo and
+// StateHostInfo into RSSI.
+func signalRSSI(signal float32) int {
+	if signal <= 0 {
+		return NoSignalRSSI
+	}
+	return int(math.Floor(10*math.Log10(float64(signal)) + 0.5))
+}
+
+// Values returned by WifiInfo.Quality.
+const (
+	WifiQualityNone      = "none"
+	WifiQualityPoor      = "poor"
+	WifiQualityFair      = "fair"
+	WifiQualityGood      = "good"
+	WifiQualityExcellent = "excellent"
+)
+
+// Quality returns a human readable signal quality derived from RSSI.
+func (w WifiInfo) Quality() string {
+	rssi := w.RSSI()
+	if rssi == NoSignalRSSI {
+		return WifiQualityNone
+	}
+	if rssi < 0 {
+		// dBm
+		switch {
+		case rssi <= -80:
+			return WifiQualityPoor
+		case rssi <= -70:
+			return WifiQualityFair
+		case rssi <= -60:
+			return WifiQualityGood
+		default:
+			return WifiQualityExcellent
+		}
+	}
+	// SNR
+	switch {
+	case rssi <= 3:
+		return WifiQualityNone
+	case rssi <= 6:
+		return WifiQualityPoor
+	case rssi <= 11:
+		return WifiQualityFair
+	case rssi <= 16:
+		return WifiQualityGood
+	default:
+		return WifiQualityExcellent
+	}
+}
+
+func (d *device) GetWifiInfo(ctx context.Context, conn net.Conn) (*WifiInfo, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn == nil {
+		newConn, err := d.Dial()
+		if err != nil {
+			return nil, err
+		}
+		defer newConn.Close()
+		conn = newConn
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	seq, err := d.Send(
+		ctx,
+		conn,
+		0, // flags
+		GetWifiInfo,
+		nil, // payload
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resps, err := WaitForResponses(
+		ctx,
+		conn,
+		d.Source(),
+		seq,
+		StateWifiInfo,
+		1, // count
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw RawStateWifiInfoPayload
+	r := bytes.NewReader(resps[0].Payload)
+	if err := binary.Read(r, binary.LittleEndian, &raw); err != nil {
+		return nil, err
+	}
+
+	return &WifiInfo{
+		Signal: raw.Signal,
+	}, nil
+}
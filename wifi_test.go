@@ -0,0 +1,133 @@
+package lifxlan_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.yhsif.com/lifxlan"
+	"go.yhsif.com/lifxlan/mock"
+)
+
+func TestWifiInfo(t *testing.T) {
+	for _, c := range []struct {
+		signal  float32
+		rssi    int
+		quality string
+	}{
+		{
+			signal:  0,
+			rssi:    lifxlan.NoSignalRSSI,
+			quality: lifxlan.WifiQualityNone,
+		},
+		// dBm
+		{
+			signal:  1e-9, // -90 dBm
+			rssi:    -90,
+			quality: lifxlan.WifiQualityPoor,
+		},
+		{
+			signal:  1e-8, // -80 dBm
+			rssi:    -80,
+			quality: lifxlan.WifiQualityPoor,
+		},
+		{
+			signal:  1.2e-8, // -79.2 dBm
+			rssi:    -79,
+			quality: lifxlan.WifiQualityFair,
+		},
+		{
+			signal:  1e-7, // -70 dBm
+			rssi:    -70,
+			quality: lifxlan.WifiQualityFair,
+		},
+		{
+			signal:  1e-6, // -60 dBm
+			rssi:    -60,
+			quality: lifxlan.WifiQualityGood,
+		},
+		{
+			signal:  1e-5, // -50 dBm
+			rssi:    -50,
+			quality: lifxlan.WifiQualityExcellent,
+		},
+		// SNR
+		{
+			signal:  1,
+			rssi:    0,
+			quality: lifxlan.WifiQualityNone,
+		},
+		{
+			signal:  3.2, // 5.05
+			rssi:    5,
+			quality: lifxlan.WifiQualityPoor,
+		},
+		{
+			signal:  5, // 6.99
+			rssi:    7,
+			quality: lifxlan.WifiQualityFair,
+		},
+		{
+			signal:  15.8, // 11.99
+			rssi:    12,
+			quality: lifxlan.WifiQualityGood,
+		},
+		{
+			signal:  40, // 16.02
+			rssi:    16,
+			quality: lifxlan.WifiQualityGood,
+		},
+		{
+			signal:  50, // 16.99
+			rssi:    17,
+			quality: lifxlan.WifiQualityExcellent,
+		},
+	} {
+		c := c
+		t.Run(
+			fmt.Sprintf("%v", c.signal),
+			func(t *testing.T) {
+				info := lifxlan.WifiInfo{
+					Signal: c.signal,
+				}
+				if rssi := info.RSSI(); rssi != c.rssi {
+					t.Errorf("RSSI expected %d, got %d", c.rssi, rssi)
+				}
+				if quality := info.Quality(); quality != c.quality {
+					t.Errorf("Quality expected %q, got %q", c.quality, quality)
+				}
+			},
+		)
+	}
+}
+
+func TestGetWifiInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const timeout = time.Millisecond * 200
+
+	const signal = 1e-6
+
+	service, device := mock.StartService(t)
+	defer service.Stop()
+	service.Handlers[lifxlan.GetWifiInfo] = mock.ReplyHandler(
+		lifxlan.StateWifiInfo,
+		&lifxlan.RawStateWifiInfoPayload{
+			Signal: signal,
+		},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	info, err := device.GetWifiInfo(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Signal != signal {
+		t.Errorf("Signal expected %v, got %v", signal, info.Signal)
+	}
+}